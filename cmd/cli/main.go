@@ -28,5 +28,12 @@ func main() {
 		}
 	}
 
+	if envNamespace, ok := os.LookupEnv("SERVERLEDGE_NAMESPACE"); ok {
+		cli.ServerConfig.Namespace = envNamespace
+	}
+	if envKey, ok := os.LookupEnv("SERVERLEDGE_API_KEY"); ok {
+		cli.ServerConfig.APIKey = envKey
+	}
+
 	cli.Init()
 }
@@ -4,11 +4,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 
 	"github.com/serverledge-faas/serverledge/internal/executor"
 )
 
 func main() {
+	port := executor.DEFAULT_EXECUTOR_PORT
+	if envPort, ok := os.LookupEnv(executor.PORT_ENV); ok {
+		var err error
+		if port, err = strconv.Atoi(envPort); err != nil {
+			log.Fatalf("Invalid %s: %s", executor.PORT_ENV, envPort)
+		}
+	}
+	host := os.Getenv(executor.HOST_ENV)
+
 	http.HandleFunc("/invoke", executor.InvokeHandler)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", executor.DEFAULT_EXECUTOR_PORT), nil))
+	log.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", host, port), nil))
 }
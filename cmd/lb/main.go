@@ -14,7 +14,9 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/lb"
 	"github.com/serverledge-faas/serverledge/internal/registration"
 )
@@ -54,6 +56,17 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// functions saved before namespaces were introduced are moved to the default one
+	if err := function.MigrateLegacyKeys(); err != nil {
+		log.Printf("Migration to namespaces failed: %v", err)
+	}
+
+	// function definitions and API keys are cached to balance invocations: drop them as soon as they change
+	if config.GetBool(config.CACHE_WATCH, true) {
+		function.WatchChanges(context.Background(), nil)
+		auth.WatchChanges(context.Background())
+	}
+
 	e := echo.New()
 	e.HideBanner = true
 	e.Use(middleware.Recover())
@@ -11,12 +11,15 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/serverledge-faas/serverledge/internal/api"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/metrics"
 	"github.com/serverledge-faas/serverledge/internal/node"
 	"github.com/serverledge-faas/serverledge/internal/registration"
 	"github.com/serverledge-faas/serverledge/internal/scheduling"
 	"github.com/serverledge-faas/serverledge/internal/telemetry"
+	"github.com/serverledge-faas/serverledge/internal/trigger"
 	"github.com/serverledge-faas/serverledge/internal/workflow"
 )
 
@@ -41,6 +44,18 @@ func main() {
 
 	metrics.Init()
 
+	// functions, workflows and async results saved before namespaces were introduced are moved to the default one
+	if err := function.MigrateLegacyKeys(); err != nil {
+		log.Printf("Migration to namespaces failed: %v", err)
+	}
+
+	// Keeps the cache (and the warm pools) consistent as functions, workflows and API keys change on other nodes
+	if config.GetBool(config.CACHE_WATCH, true) {
+		function.WatchChanges(context.Background(), node.ShutdownWarmContainersFor)
+		workflow.WatchChanges(context.Background())
+		auth.WatchChanges(context.Background())
+	}
+
 	if config.GetBool(config.TRACING_ENABLED, false) {
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 		defer stop()
@@ -71,6 +86,12 @@ func main() {
 
 	// Workflow offloading policy
 	workflow.CreateOffloadingPolicy()
+	// Resumes workflows suspended by Wait states
+	workflow.StartTimerService()
+	// Fires the schedules of the area, if this node is elected as their leader
+	trigger.StartScheduleService()
+	// Invokes functions and workflows upon the events of their triggers
+	trigger.StartEventService()
 
 	err = registration.StartMonitoring()
 	if err != nil {
@@ -0,0 +1,42 @@
+// A function for the wasi runtime, which increments its "input" parameter.
+//
+// Build it with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -o inc.wasm .
+//	tar cf inc.tar inc.wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	// as with the executor of the other runtimes, parameters and result are exchanged through files
+	var params map[string]interface{}
+	if paramsFile := os.Getenv("PARAMS_FILE"); paramsFile != "" {
+		content, err := os.ReadFile(paramsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot read the parameters: %v\n", err)
+			os.Exit(1)
+		}
+		if err := json.Unmarshal(content, &params); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid parameters: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	input, ok := params["input"].(float64)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "missing input")
+		os.Exit(1)
+	}
+	fmt.Printf("Invoked inc with input: %v\n", input)
+
+	result, _ := json.Marshal(int(input) + 1)
+	if err := os.WriteFile(os.Getenv("RESULT_FILE"), result, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot write the result: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -15,7 +15,12 @@ import java.util.ArrayList;
 import java.util.HashMap;
 import java.util.List;
 import java.util.Map;
+import java.util.concurrent.ExecutionException;
+import java.util.concurrent.ExecutorService;
 import java.util.concurrent.Executors;
+import java.util.concurrent.Future;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.TimeoutException;
 
 public class Executor {
 
@@ -28,6 +33,9 @@ public class Executor {
                                         .create();
 
 
+    // handlers run in threads of their own, so that the executor can reply when they exceed their timeout
+    private static final ExecutorService handlerThreads = Executors.newCachedThreadPool();
+
     private static volatile URLClassLoader sharedClassLoader = null;
     private static final Object LOADER_LOCK = new Object();
 
@@ -54,13 +62,20 @@ public class Executor {
             String handlerStr = (String) request.get("Handler");
             String handlerDir = (String) request.get("HandlerDir");
             Object params = request.get("Params");
+            // requests to the HTTP trigger pass the raw HTTP request (Method, Path, Query, Headers, Body) to the handler
+            if (request.get("HTTP") != null) {
+                params = request.get("HTTP");
+            }
             boolean returnOutput = request.containsKey("ReturnOutput") && (boolean) request.get("ReturnOutput");
+            long timeoutSeconds = request.get("TimeoutSeconds") instanceof Number ?
+                ((Number) request.get("TimeoutSeconds")).longValue() : 0;
 
             String envContext = System.getenv("CONTEXT");
             Map<String, Object> context = envContext != null ?
                 gson.fromJson(envContext, new TypeToken<Map<String, Object>>(){}.getType()) : new HashMap<>();
 
             Map<String, Object> response = new HashMap<>();
+            int statusCode = 200;
 
             PrintStream originalOut = System.out;
             PrintStream originalErr = System.err;
@@ -122,20 +137,36 @@ public class Executor {
 
                 if (method == null) throw new NoSuchMethodException("Method " + methodName + " not found in " + className);
 
-                Object result;
-                if (method.getParameterCount() == 2) {
-                    result = method.invoke(instance, params, context);
-                } else {
-                    result = method.invoke(instance, params);
+                final Method handlerMethod = method;
+                final Object handlerParams = params;
+                Future<Object> invocation = handlerThreads.submit(() -> handlerMethod.getParameterCount() == 2 ?
+                    handlerMethod.invoke(instance, handlerParams, context) : handlerMethod.invoke(instance, handlerParams));
+
+                try {
+                    Object result = timeoutSeconds > 0 ? invocation.get(timeoutSeconds, TimeUnit.SECONDS) : invocation.get();
+                    response.put("Result", gson.toJson(result));
+                    response.put("Success", true);
+                } catch (TimeoutException e) {
+                    // threads cannot be killed: the handler is interrupted, and the node discards the container
+                    invocation.cancel(true);
+                    originalErr.println("Handler interrupted after " + timeoutSeconds + " seconds");
+                    statusCode = 504;
+                    response.put("Success", false);
+                    response.put("Result", "");
+                } catch (ExecutionException e) {
+                    // the exception raised by method.invoke()
+                    if (e.getCause() instanceof Exception) {
+                        throw (Exception) e.getCause();
+                    }
+                    throw new RuntimeException(e.getCause());
                 }
-                
-                response.put("Result", gson.toJson(result));
-                response.put("Success", true);
 
             } catch (Exception e) {
                 e.printStackTrace();
                 response.put("Success", false);
-                response.put("Error", e.toString());
+                // exceptions raised by the handler are wrapped by method.invoke()
+                Throwable cause = e.getCause() != null ? e.getCause() : e;
+                response.put("Error", cause.getClass().getSimpleName());
                 response.put("Result", "");
             } finally {
                 if (returnOutput) {
@@ -155,7 +186,7 @@ public class Executor {
             String jsonResponse = gson.toJson(response);
             byte[] responseBytes = jsonResponse.getBytes(StandardCharsets.UTF_8);
             exchange.getResponseHeaders().set("Content-Type", "application/json");
-            exchange.sendResponseHeaders(200, responseBytes.length);
+            exchange.sendResponseHeaders(statusCode, responseBytes.length);
             OutputStream os = exchange.getResponseBody();
             os.write(responseBytes);
             os.close();
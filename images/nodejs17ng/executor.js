@@ -1,5 +1,83 @@
 let path = require('path');
 var http = require('http');
+const { Worker, isMainThread, parentPort } = require('worker_threads');
+
+if (!isMainThread) {
+	// handlers run in worker threads, which can be terminated if they exceed their timeout or get cancelled
+	parentPort.on('message', async (reqbody) => {
+		var resp = {}
+		try {
+			var handler = reqbody["Handler"]
+			var handler_dir = process.env.EXECUTOR_HANDLER_DIR || reqbody["HandlerDir"]
+			var params = reqbody["Params"]
+			// requests to the HTTP trigger pass the raw HTTP request (Method, Path, Query, Headers, Body) to the handler
+			if (reqbody["HTTP"]) {
+				params = reqbody["HTTP"]
+			}
+
+			var context = {}
+			if (process.env.CONTEXT !== "undefined") {
+				context = process.env.CONTEXT
+			}
+
+			let h = require(path.join(handler_dir, handler))
+
+			let result = await h(params, context)
+
+			resp["Result"] = JSON.stringify(result);
+			resp["Success"] = true
+		} catch (error) {
+			resp["Success"] = false
+			resp["Error"] = error.name
+		}
+		parentPort.postMessage(resp)
+	});
+	return;
+}
+
+// idle workers are reused, keeping the modules of the handlers loaded
+const idleWorkers = [];
+
+// invoke runs the handler in a worker and calls done with the response, or with null if the worker failed. It returns
+// a function that terminates the worker.
+function invoke(reqbody, done) {
+	const worker = idleWorkers.pop() || new Worker(__filename);
+	let completed = false;
+	const onError = (error) => {
+		console.log('Worker failed: ' + error);
+		complete(null);
+	};
+	const onExit = () => complete(null);
+	// only the listeners of this invocation are removed, as the worker relies on listeners of its own
+	const detach = () => {
+		worker.off('message', complete);
+		worker.off('error', onError);
+		worker.off('exit', onExit);
+	};
+	const complete = (resp) => {
+		if (completed) {
+			return;
+		}
+		completed = true;
+		detach();
+		if (resp !== null) {
+			idleWorkers.push(worker);
+		} else {
+			worker.terminate();
+		}
+		done(resp);
+	};
+	worker.on('message', complete);
+	worker.on('error', onError);
+	worker.on('exit', onExit);
+	worker.postMessage(reqbody);
+
+	return () => {
+		completed = true;
+		detach();
+		worker.terminate();
+	};
+}
 
 http.createServer(async (request, response) => {
 
@@ -16,46 +94,55 @@ http.createServer(async (request, response) => {
 		const data = Buffer.concat(buffers).toString();
 		const contentType = 'application/json';
 
+		let reqbody;
 		try {
-			const reqbody = JSON.parse(data);
-
-			var handler = reqbody["Handler"]
-			var handler_dir = reqbody["HandlerDir"]
-			var params = reqbody["Params"]
-			var return_output = reqbody["ReturnOutput"]
-
-			var context = {}
-			if (process.env.CONTEXT !== "undefined") {
-				context = process.env.CONTEXT
+			reqbody = JSON.parse(data);
+		} catch (error) {
+			let resp = {}
+			resp["Success"] = false
+			resp["Error"] = error.name
+			resp["Output"] = "Output capture not supported for this runtime yet."
+			response.writeHead(200, { 'Content-Type': contentType });
+			response.end(JSON.stringify(resp), 'utf-8');
+			return;
+		}
+		var return_output = reqbody["ReturnOutput"]
+		var timeout = reqbody["TimeoutSeconds"] || 0
+
+		let timer = null;
+		const kill = invoke(reqbody, (resp) => {
+			clearTimeout(timer);
+			if (resp === null) {
+				resp = { "Success": false, "Error": "WorkerError" }
 			}
-
-			let h = require(path.join(handler_dir, handler))
-
-			result = h(params, context)
-
-			resp = {}
-			resp["Result"] = JSON.stringify(result);
-			resp["Success"] = true
 			if (return_output === true) {
 				resp["Output"] = "Output capture not supported for this runtime yet."
 			} else {
 				resp["Output"] = ""
 			}
-
-
+			// as for the other runtimes, the failure is reported in the response, along with the error name
 			response.writeHead(200, { 'Content-Type': contentType });
 			response.end(JSON.stringify(resp), 'utf-8');
-		} catch (error) {
-			resp = {}
-			resp["Success"] = false
-			resp["Output"] = "Output capture not supported for this runtime yet."
-			response.writeHead(500, { 'Content-Type': contentType });
-			response.end(JSON.stringify(resp), 'utf-8');
+		});
+
+		if (timeout > 0) {
+			timer = setTimeout(() => {
+				kill();
+				console.log('Handler killed after ' + timeout + ' seconds');
+				response.writeHead(504, { 'Content-Type': contentType });
+				response.end(JSON.stringify({ "Success": false, "Result": "", "Output": "" }), 'utf-8');
+			}, timeout * 1000);
 		}
+
+		// the invocation has been cancelled if the client disconnects before the response
+		response.on('close', () => {
+			if (!response.writableFinished) {
+				clearTimeout(timer);
+				kill();
+				console.log('Handler killed: invocation cancelled');
+			}
+		});
 	}
 
-}).listen(8080);
+}).listen(process.env.EXECUTOR_PORT || 8080, process.env.EXECUTOR_HOST);
 console.log('Server running');
-
-
-
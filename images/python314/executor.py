@@ -6,9 +6,17 @@ import os
 import sys
 import importlib
 import json
+import threading
+import multiprocessing
+import select
+import socket
 
-hostName = "0.0.0.0"
-serverPort = 8080
+# the address can be changed through the environment, e.g., when the executor runs as a host process
+hostName = os.environ.get("EXECUTOR_HOST", "0.0.0.0")
+serverPort = int(os.environ.get("EXECUTOR_PORT", 8080))
+
+# interval (in seconds) between the checks of the timeout and of the connection of the client, while a handler runs
+POLL_INTERVAL = 0.1
 
 #executed_modules = {}
 added_dirs = {}
@@ -43,6 +51,160 @@ class CaptureOutput:
         return self._stderr_output
 
 
+class StreamOutput:
+    """Sends each line written to it as an event of the stream of the invocation"""
+
+    def __init__(self, send_event):
+        self._send_event = send_event
+        self._buffer = ''
+        self._output = ''
+
+    def write(self, data):
+        self._output += data
+        self._buffer += data
+        while '\n' in self._buffer:
+            line, self._buffer = self._buffer.split('\n', 1)
+            self._send_event("output", line)
+        return len(data)
+
+    def flush(self):
+        if self._buffer:
+            self._send_event("output", self._buffer)
+            self._buffer = ''
+
+    def get_output(self):
+        return self._output
+
+
+def run_worker(conn):
+    """Runs the handlers of the invocations sent by the executor, one at a time. As each invocation has a process of
+    its own, the output of the handler can be redirected without interfering with other invocations."""
+    while True:
+        try:
+            request = conn.recv()
+        except EOFError:
+            return
+        send_event = lambda name, data: conn.send(("event", name, data))
+        conn.send(("result", invoke_handler(request, send_event)))
+
+
+def invoke_handler(request, send_event):
+    """Calls the handler, returning the response to the invocation. If the invocation is streamed, each line of output
+    of the handler and each value passed to context["partial_result"] are sent as events."""
+    handler = request["Handler"]
+    handler_dir = os.environ.get("EXECUTOR_HANDLER_DIR", request["HandlerDir"])
+
+    try:
+        params = request["Params"]
+    except:
+        params = {}
+
+    # requests to the HTTP trigger pass the raw HTTP request (Method, Path, Query, Headers, Body) to the handler
+    if request.get("HTTP") is not None:
+        params = request["HTTP"]
+
+    if "context" in os.environ:
+        context = json.loads(os.environ["CONTEXT"])
+    else:
+        context = {}
+
+    if not handler_dir in added_dirs:
+        sys.path.insert(1, handler_dir)
+        added_dirs[handler_dir] = True
+
+    # Get module name
+    module,func_name = os.path.splitext(handler)
+    func_name = func_name[1:] # strip initial dot
+
+    return_output = bool(request["ReturnOutput"])
+    stream = bool(request.get("Stream"))
+
+    # partial results are sent only if the invocation is streamed
+    context = dict(context)
+    if stream:
+        context["partial_result"] = lambda value: send_event("partial", json.dumps(value))
+    else:
+        context["partial_result"] = lambda value: None
+
+    response = {}
+
+    try:
+        # Call function
+        loaded_mod = importlib.import_module(module)
+
+        if stream:
+            output = StreamOutput(send_event)
+            stdout, stderr = sys.stdout, sys.stderr
+            sys.stdout, sys.stderr = output, output
+            try:
+                result = getattr(loaded_mod, func_name)(params, context)
+            finally:
+                output.flush()
+                sys.stdout, sys.stderr = stdout, stderr
+            response["Output"] = output.get_output() if return_output else ""
+        elif not return_output:
+            result = getattr(loaded_mod, func_name)(params, context)
+            response["Output"] = ""
+        else:
+            with CaptureOutput() as capturer:
+                result = getattr(loaded_mod, func_name)(params, context)
+            response["Output"] = str(capturer.get_stdout()) + "\n" + str(capturer.get_stderr())
+
+        response["Result"] = json.dumps(result)
+        response["Success"] = True
+    except Exception as e:
+        print(e, file=sys.stderr)
+        response["Success"] = False
+        response["Error"] = type(e).__name__
+        response.setdefault("Output", "")
+
+    return response
+
+
+# workers are started as fresh interpreters, as forking the threads of the server is unsafe
+mp_context = multiprocessing.get_context("spawn")
+
+
+class Worker:
+    """A process running the handlers. Workers are reused by the next invocations, keeping the modules of the
+    handlers loaded, unless they are killed because the handler timed out or the invocation was cancelled."""
+
+    def __init__(self):
+        self.conn, child_conn = mp_context.Pipe()
+        self.process = mp_context.Process(target=run_worker, args=(child_conn,), daemon=True)
+        self.process.start()
+        child_conn.close()
+
+    def kill(self):
+        self.process.kill()
+        self.process.join()
+        self.conn.close()
+
+
+idle_workers = []
+workers_lock = threading.Lock()
+
+def acquire_worker():
+    with workers_lock:
+        while idle_workers:
+            worker = idle_workers.pop()
+            if worker.process.is_alive():
+                return worker
+            worker.kill()
+    return Worker()
+
+def release_worker(worker):
+    with workers_lock:
+        idle_workers.append(worker)
+
+
+class InvocationTimeout(Exception):
+    pass
+
+class InvocationCancelled(Exception):
+    pass
+
+
 class ThreadingSimpleServer(ThreadingMixIn, HTTPServer):
     pass
 
@@ -57,59 +219,103 @@ class Executor(BaseHTTPRequestHandler):
             self.end_headers()
             return
 
-        handler = request["Handler"]
-        handler_dir = request["HandlerDir"]
+        if request.get("Stream"):
+            self.invoke_streaming(request)
+            return
 
         try:
-            params = request["Params"]
-        except:
-            params = {}
-
-        if "context" in os.environ:
-            context = json.loads(os.environ["CONTEXT"])
-        else:
-            context = {}
+            response = self.run(request, None)
+        except InvocationTimeout:
+            print("Handler killed after %d seconds" % request["TimeoutSeconds"], file=sys.stderr)
+            self.write_response(504, {"Success": False, "Result": "", "Output": ""})
+            return
+        except InvocationCancelled:
+            # the invocation has been cancelled: there is no one waiting for the result
+            print("Handler killed: invocation cancelled", file=sys.stderr)
+            return
 
-        if not handler_dir in added_dirs:
-            sys.path.insert(1, handler_dir)
-            added_dirs[handler_dir] = True
+        self.write_response(200, response)
 
-        # Get module name
-        module,func_name = os.path.splitext(handler)
-        func_name = func_name[1:] # strip initial dot
-        loaded_mod = None
+    def write_response(self, status, response):
+        self.send_response(status)
+        self.send_header("Content-type", "application/json")
+        self.end_headers()
+        self.wfile.write(bytes(json.dumps(response), "utf-8"))
 
-        return_output = bool(request["ReturnOutput"])
+    def invoke_streaming(self, request):
+        """Streams each line of output of the handler, and each value passed to context["partial_result"], as
+        Server-Sent Events, followed by the result"""
+        self.send_response(200)
+        self.send_header("Content-type", "text/event-stream")
+        self.end_headers()
+        self.wfile.flush()
 
-        response = {}
+        def send_event(name, data):
+            event = "event: " + name + "\n" + "".join("data: " + line + "\n" for line in data.split("\n")) + "\n"
+            self.wfile.write(bytes(event, "utf-8"))
+            self.wfile.flush()
 
         try:
-            # Call function
-            if loaded_mod is None:
-                loaded_mod = importlib.import_module(module)
+            response = self.run(request, send_event)
+            send_event("result", json.dumps(response))
+        except InvocationTimeout:
+            print("Handler killed after %d seconds" % request["TimeoutSeconds"], file=sys.stderr)
+            send_event("error", json.dumps({"StatusCode": 504, "Message": "function execution timed out"}))
+        except InvocationCancelled:
+            print("Handler killed: invocation cancelled", file=sys.stderr)
+
+    def run(self, request, send_event):
+        """Runs the handler in a worker, passing the events it sends to send_event, and returns the response. The
+        worker is killed if the handler exceeds the timeout of the request, or if the client disconnects."""
+        timeout = request.get("TimeoutSeconds") or 0
+        deadline = time.monotonic() + timeout if timeout > 0 else None
+        worker = acquire_worker()
+        try:
+            worker.conn.send(request)
+            while True:
+                if worker.conn.poll(POLL_INTERVAL):
+                    message = worker.conn.recv()
+                    if message[0] == "result":
+                        release_worker(worker)
+                        return message[1]
+                    try:
+                        send_event(message[1], message[2])
+                    except (BrokenPipeError, ConnectionResetError):
+                        # the client disconnected while the events were sent
+                        worker.kill()
+                        raise InvocationCancelled()
+                elif deadline is not None and time.monotonic() > deadline:
+                    worker.kill()
+                    raise InvocationTimeout()
+                elif self.client_disconnected():
+                    worker.kill()
+                    raise InvocationCancelled()
+        except (EOFError, OSError) as e:
+            # the worker died, e.g., because it ran out of memory
+            worker.kill()
+            print("Worker failed: %s" % e, file=sys.stderr)
+            return {"Success": False, "Result": "", "Output": "", "Error": "WorkerError"}
+
+    def client_disconnected(self):
+        """Returns true if the client closed the connection, as a cancelled request does"""
+        readable, _, _ = select.select([self.connection], [], [], 0)
+        if not readable:
+            return False
+        try:
+            return self.connection.recv(1, socket.MSG_PEEK) == b""
+        except OSError:
+            return True
 
-            if not return_output:
-                result = getattr(loaded_mod, func_name)(params, context)
-                response["Output"] = ""
-            else:
-                with CaptureOutput() as capturer:
-                    result = getattr(loaded_mod, func_name)(params, context)
-                response["Output"] = str(capturer.get_stdout()) + "\n" + str(capturer.get_stderr())
-
-            response["Result"] = json.dumps(result)
-            response["Success"] = True
-        except Exception as e:
-            print(e, file=sys.stderr)
-            response["Success"] = False
 
-        self.send_response(200)
-        self.send_header("Content-type", "application/json")
-        self.end_headers()
-        self.wfile.write(bytes(json.dumps(response), "utf-8"))
 
+if __name__ == "__main__":
+    # handlers run in the workers: the threads of the server only need a small stack, which also keeps them within
+    # tight memory limits (e.g., of the process container factory)
+    threading.stack_size(512 * 1024)
 
+    # a worker is started in advance, for the first invocation
+    release_worker(Worker())
 
-if __name__ == "__main__":
     webServer = ThreadingSimpleServer((hostName, serverPort), Executor)
     print("Server started http://%s:%s" % (hostName, serverPort))
 
@@ -120,4 +326,3 @@ if __name__ == "__main__":
 
     webServer.server_close()
     print("Server stopped.")
-
@@ -12,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/serverledge-faas/serverledge/internal/async"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/client"
 	"github.com/serverledge-faas/serverledge/internal/container"
 	"github.com/serverledge-faas/serverledge/internal/function"
@@ -41,19 +43,39 @@ var workflowInvocationRequestPool = sync.Pool{
 
 // GetFunctions handles a request to list the function available in the system.
 func GetFunctions(c echo.Context) error {
-	list, err := function.GetAll()
+	list, err := function.GetAll(auth.GetIdentity(c).Namespace)
 	if err != nil {
 		return c.String(http.StatusServiceUnavailable, "")
 	}
 	return c.JSON(http.StatusOK, list)
 }
 
+// newRequestId returns the ID of a request for the function, arrived at the given time
+func newRequestId(funcName string, arrival time.Time) string {
+	return fmt.Sprintf("%s-%s%d", funcName, node.LocalNode.String()[len(node.LocalNode.String())-5:], arrival.Nanosecond())
+}
+
+// setMetricsHeaders sets the headers used by the Load Balancer (if there is one), to get fresh updates on the free
+// memory of each node after the execution of every function.
+func setMetricsHeaders(c echo.Context) {
+	c.Response().Header().Set("Serverledge-Node-Name", node.LocalNode.Key)
+	freeMem := node.LocalResources.AvailableMemory()
+	c.Response().Header().Set("Serverledge-Free-Mem", fmt.Sprintf("%d", freeMem))
+	c.Response().Header().Set("Serverledge-Timestamp", fmt.Sprintf("%d", time.Now().Unix()))
+	c.Response().Header().Set("Serverledge-Free-CPU", fmt.Sprintf("%f", node.LocalResources.AvailableCPUs()))
+	c.Response().Header().Set("Serverledge-Node-Arch", runtime.GOARCH) // used by the MAB to update correct arm
+	if reqID := c.Request().Header.Get("Serverledge-MAB-Request-ID"); reqID != "" {
+		c.Response().Header().Set("Serverledge-MAB-Request-ID", reqID)
+	}
+}
+
 // InvokeFunction handles a function invocation request.
 func InvokeFunction(c echo.Context) error {
-	funcName := c.Param("fun")
-	fun, ok := function.GetFunction(funcName)
+	funcRef := c.Param("fun") // the name, optionally followed by "@version" or "@alias"
+	identity := auth.GetIdentity(c)
+	fun, ok := function.Resolve(function.QualifiedName(identity.Namespace, funcRef))
 	if !ok {
-		log.Printf("Dropping request for unknown fun '%s'\n", funcName)
+		log.Printf("Dropping request for unknown fun '%s'\n", funcRef)
 		return c.String(http.StatusNotFound, "Function unknown")
 	}
 
@@ -69,54 +91,68 @@ func InvokeFunction(c echo.Context) error {
 	r.Fun = fun
 	r.Params = invocationRequest.Params
 	r.Arrival = time.Now()
+	r.Class = invocationRequest.QoSClass
 	r.MaxRespT = invocationRequest.QoSMaxRespT
 	r.CanDoOffloading = invocationRequest.CanDoOffloading
 	r.Async = invocationRequest.Async
 	r.ReturnOutput = invocationRequest.ReturnOutput
-
-	reqId := fmt.Sprintf("%s-%s%d", funcName, node.LocalNode.String()[len(node.LocalNode.String())-5:], r.Arrival.Nanosecond())
+	r.AsyncOptions = invocationRequest.AsyncOptions
+	r.HTTP = invocationRequest.HTTP
+	r.APIKey = identity.APIKey
+	r.Stream = nil
+
+	reqId := c.Request().Header.Get(function.RequestIdHeader) // offloaded requests keep their ID
+	if reqId == "" {
+		reqId = newRequestId(fun.Name, r.Arrival)
+	}
 	r.Ctx = context.WithValue(context.Background(), "ReqId", reqId)
 
 	// Tracing
 	if telemetry.DefaultTracer != nil {
 		ctx, span := telemetry.DefaultTracer.Start(r.Ctx, "invocation")
 		r.Ctx = ctx
-		span.SetAttributes(attribute.String("function", r.Fun.Name))
+		span.SetAttributes(attribute.String("function", r.Fun.VersionedName()))
 		defer span.End()
 	}
 
-	setMetricsHeaders := func() {
-		// These headers will be used by the Load Balancer (if there is one), to get fresh updates on the free
-		// memory of each node after the execution of every function.
-		c.Response().Header().Set("Serverledge-Node-Name", node.LocalNode.Key)
-		freeMem := node.LocalResources.AvailableMemory()
-		c.Response().Header().Set("Serverledge-Free-Mem", fmt.Sprintf("%d", freeMem))
-		c.Response().Header().Set("Serverledge-Timestamp", fmt.Sprintf("%d", time.Now().Unix()))
-		c.Response().Header().Set("Serverledge-Free-CPU", fmt.Sprintf("%f", node.LocalResources.AvailableCPUs()))
-		c.Response().Header().Set("Serverledge-Node-Arch", runtime.GOARCH) // used by the MAB to update correct arm
-		if reqID := c.Request().Header.Get("Serverledge-MAB-Request-ID"); reqID != "" {
-			c.Response().Header().Set("Serverledge-MAB-Request-ID", reqID)
-		}
-
-	}
-
+	stream := c.QueryParam("stream") == "true" // the output is streamed as Server-Sent Events
 	if r.Async {
+		if stream {
+			return c.String(http.StatusBadRequest, "Asynchronous invocations cannot be streamed")
+		}
+		if err := async.Validate(r.AsyncOptions); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
 		go scheduling.SubmitAsyncRequest(r)
-		setMetricsHeaders()
+		setMetricsHeaders(c)
 		return c.JSON(http.StatusOK, function.AsyncResponse{ReqId: r.Id()})
 	}
 
+	if stream {
+		return invokeStreaming(c, r)
+	}
+
 	executionReport, err := scheduling.SubmitRequest(r)
-	setMetricsHeaders()
+	setMetricsHeaders(c)
+
+	if err != nil {
+		return c.String(invocationErrorStatus(err))
+	}
+	return c.JSON(http.StatusOK, function.Response{Success: true, ExecutionReport: *executionReport})
+}
 
+// invocationErrorStatus returns the status code and the message of the response to a failed invocation
+func invocationErrorStatus(err error) (int, string) {
 	if errors.Is(err, node.OutOfResourcesErr) {
-		return c.String(http.StatusTooManyRequests, "")
-	} else if err != nil {
-		log.Printf("Invocation failed: %v\n", err)
-		return c.String(http.StatusInternalServerError, "Node has not enough resources")
-	} else {
-		return c.JSON(http.StatusOK, function.Response{Success: true, ExecutionReport: *executionReport})
+		return http.StatusTooManyRequests, ""
+	} else if errors.Is(err, container.ExecutionTimeoutErr) {
+		log.Printf("Invocation timed out: %v\n", err)
+		return http.StatusGatewayTimeout, "Function execution timed out"
+	} else if errors.Is(err, scheduling.CancelledErr) {
+		return http.StatusGone, "Invocation cancelled"
 	}
+	log.Printf("Invocation failed: %v\n", err)
+	return http.StatusInternalServerError, "Node has not enough resources"
 }
 
 // PollAsyncResult checks for the result of an asynchronous invocation.
@@ -134,8 +170,7 @@ func PollAsyncResult(c echo.Context) error {
 
 	ctx := context.Background()
 
-	key := fmt.Sprintf("async/%s", reqId)
-	res, err := etcdClient.Get(ctx, key)
+	res, err := etcdClient.Get(ctx, async.ResultKey(auth.GetIdentity(c).Namespace, reqId))
 	if err != nil {
 		log.Println(err)
 		return c.String(http.StatusInternalServerError, "Could not retrieve results")
@@ -149,6 +184,19 @@ func PollAsyncResult(c echo.Context) error {
 	}
 }
 
+// CancelInvocation handles a request to cancel a queued or running invocation.
+func CancelInvocation(c echo.Context) error {
+	reqId := c.Param("reqId")
+	err := scheduling.Cancel(reqId, auth.GetIdentity(c).Namespace)
+	if errors.Is(err, scheduling.UnknownRequestErr) {
+		return c.String(http.StatusNotFound, "Unknown or completed request")
+	} else if err != nil {
+		log.Printf("Cancellation of %s failed: %v\n", reqId, err)
+		return c.String(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(http.StatusOK, function.AsyncResponse{ReqId: reqId})
+}
+
 // CreateOrUpdateFunction handles a function creation/update request.
 func CreateOrUpdateFunction(c echo.Context) error {
 	var f function.Function
@@ -158,16 +206,21 @@ func CreateOrUpdateFunction(c echo.Context) error {
 		return err
 	}
 
+	if !function.IsValidName(f.Name) {
+		return c.String(http.StatusUnprocessableEntity, "Invalid function name")
+	}
+	f.Namespace = auth.GetIdentity(c).Namespace
+
 	if c.Path() != "/update" {
-		_, ok := function.GetFunction(f.Name) // TODO: we would need a system-wide lock here...
+		_, ok := function.GetFunction(f.QualifiedName()) // TODO: we would need a system-wide lock here...
 		if ok {
 			log.Printf("Dropping request for already existing function '%s'\n", f.Name)
 			return c.String(http.StatusConflict, "")
 		}
 
-		log.Printf("New request: creation of %s\n", f.Name)
+		log.Printf("New request: creation of %s\n", f.QualifiedName())
 	} else {
-		log.Printf("New request: creation/update of %s\n", f.Name)
+		log.Printf("New request: creation/update of %s\n", f.QualifiedName())
 	}
 
 	// Check that the selected runtime exists
@@ -225,6 +278,10 @@ func CreateOrUpdateFunction(c echo.Context) error {
 		return c.String(http.StatusUnprocessableEntity, "Invalid memory limit")
 	}
 
+	if f.TimeoutSeconds < 0 {
+		return c.String(http.StatusUnprocessableEntity, "Invalid timeout")
+	}
+
 	if f.MaxConcurrency <= 0 {
 		f.MaxConcurrency = 1
 	}
@@ -234,6 +291,8 @@ func CreateOrUpdateFunction(c echo.Context) error {
 		log.Printf("Failed creation: %v\n", err)
 		return c.JSON(http.StatusServiceUnavailable, "")
 	}
+	// warm containers of the latest version may run the old code (published versions are immutable)
+	node.ShutdownWarmContainersFor(&f)
 	response := struct{ Created string }{f.Name}
 	return c.JSON(http.StatusOK, response)
 }
@@ -247,21 +306,32 @@ func DeleteFunction(c echo.Context) error {
 		return err
 	}
 
-	_, ok := function.GetFunction(f.Name) // TODO: we would need a system-wide lock here...
+	f.Namespace = auth.GetIdentity(c).Namespace
+	_, ok := function.GetFunction(f.QualifiedName()) // TODO: we would need a system-wide lock here...
 	if !ok {
-		log.Printf("Dropping request for non existing function '%s'\n", f.Name)
+		log.Printf("Dropping request for non existing function '%s'\n", f.QualifiedName())
 		return c.String(http.StatusNotFound, "Unknown function")
 	}
 
-	log.Printf("New request: deleting %s\n", f.Name)
+	log.Printf("New request: deleting %s\n", f.QualifiedName())
+	versions, err := function.GetVersions(f.QualifiedName())
+	if err != nil {
+		log.Printf("Failed deletion: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
 	err = f.Delete()
 	if err != nil {
 		log.Printf("Failed deletion: %v\n", err)
 		return c.String(http.StatusServiceUnavailable, "")
 	}
 
-	// Delete local warm containers
+	// Delete local warm containers, of every version
 	node.ShutdownWarmContainersFor(&f)
+	for _, v := range versions {
+		published := f
+		published.Version = v
+		node.ShutdownWarmContainersFor(&published)
+	}
 
 	response := struct{ Deleted string }{f.Name}
 	return c.JSON(http.StatusOK, response)
@@ -308,6 +378,7 @@ func GetServerStatus(c echo.Context) error {
 		Coordinates:             coords,
 		LoadAvg:                 loadAvgValues,
 		LastUpdateTime:          time.Now().Unix(),
+		KeepAlive:               node.KeepAliveStatus(),
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -322,7 +393,7 @@ func PrewarmFunction(c echo.Context) error {
 		return err
 	}
 
-	fun, ok := function.GetFunction(req.Function)
+	fun, ok := function.Resolve(function.QualifiedName(auth.GetIdentity(c).Namespace, req.Function))
 	if !ok {
 		log.Printf("Dropping request for unknown fun '%s'\n", req.Function)
 		return c.String(http.StatusNotFound, "Function unknown")
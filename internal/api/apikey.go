@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/auth"
+	"github.com/serverledge-faas/serverledge/internal/client"
+	"github.com/serverledge-faas/serverledge/internal/function"
+)
+
+// CreateAPIKey handles a request to generate a new API key.
+func CreateAPIKey(c echo.Context) error {
+	var req client.APIKeyCreationRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	if req.Namespace == "" {
+		req.Namespace = function.DefaultNamespace
+	} else if !function.IsValidName(req.Namespace) {
+		return c.String(http.StatusUnprocessableEntity, "Invalid namespace")
+	}
+	role := auth.Role(req.Role)
+	if !role.IsValid() {
+		return c.String(http.StatusUnprocessableEntity, "Invalid role")
+	}
+
+	key, record, err := auth.NewAPIKey(req.Namespace, role)
+	if err != nil {
+		log.Printf("Failed API key creation: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	log.Printf("Created API key %s (%s in namespace %s)\n", record.Id, record.Role, record.Namespace)
+
+	response := struct {
+		Key string
+		auth.APIKey
+	}{key, *record}
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteAPIKey handles a request to revoke an API key.
+func DeleteAPIKey(c echo.Context) error {
+	var req client.APIKeyDeletionRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	err = auth.DeleteAPIKey(req.Id)
+	if err != nil {
+		log.Printf("Failed API key deletion: %v\n", err)
+		return c.String(http.StatusNotFound, "Unknown API key")
+	}
+
+	response := struct{ Deleted string }{req.Id}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetAPIKeys handles a request to list the API keys (only their identifiers, namespaces and roles).
+func GetAPIKeys(c echo.Context) error {
+	list, err := auth.GetAllAPIKeys()
+	if err != nil {
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	return c.JSON(http.StatusOK, list)
+}
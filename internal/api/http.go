@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/auth"
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/internal/scheduling"
+)
+
+// InvokeHTTP handles a request to the HTTP trigger of a function: the handler receives the raw HTTP request
+// (method, path, headers, query and body) and determines the HTTP response.
+func InvokeHTTP(c echo.Context) error {
+	funcRef := c.Param("fun") // the name, optionally followed by "@version" or "@alias"
+	identity := auth.GetIdentity(c)
+	fun, ok := function.Resolve(function.QualifiedName(identity.Namespace, funcRef))
+	if !ok {
+		log.Printf("Dropping request for unknown fun '%s'\n", funcRef)
+		return c.String(http.StatusNotFound, "Function unknown")
+	}
+
+	httpRequest, err := executor.NewHTTPRequest(c.Request(), "/"+c.Param("*"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Could not read the request")
+	}
+	// the credentials of the client are not passed to the function
+	httpRequest.Headers.Del(auth.APIKeyHeader)
+	httpRequest.Headers.Del(echo.HeaderAuthorization)
+
+	r := &function.Request{
+		Fun:             fun,
+		Arrival:         time.Now(),
+		CanDoOffloading: true,
+		APIKey:          identity.APIKey,
+		HTTP:            httpRequest,
+	}
+	r.Ctx = context.WithValue(context.Background(), "ReqId", newRequestId(fun.Name, r.Arrival))
+
+	executionReport, err := scheduling.SubmitRequest(r)
+	setMetricsHeaders(c)
+
+	if errors.Is(err, node.OutOfResourcesErr) {
+		return c.String(http.StatusTooManyRequests, "")
+	} else if errors.Is(err, container.ExecutionTimeoutErr) {
+		log.Printf("Invocation timed out: %v\n", err)
+		return c.String(http.StatusGatewayTimeout, "Function execution timed out")
+	} else if errors.Is(err, scheduling.CancelledErr) {
+		return c.String(http.StatusGone, "Invocation cancelled")
+	} else if err != nil {
+		log.Printf("Invocation failed: %v\n", err)
+		return c.String(http.StatusInternalServerError, "Function execution failed")
+	}
+
+	resp, err := executor.ParseHTTPResponse(executionReport.Result)
+	if err != nil {
+		log.Printf("Invalid HTTP response from %s: %v\n", fun, err)
+		return c.String(http.StatusBadGateway, "Invalid response from the function")
+	}
+	body, err := resp.BodyBytes()
+	if err != nil {
+		log.Printf("Invalid HTTP response from %s: %v\n", fun, err)
+		return c.String(http.StatusBadGateway, "Invalid response from the function")
+	}
+
+	for name, values := range resp.Headers {
+		c.Response().Header().Del(name)
+		for _, value := range values {
+			c.Response().Header().Add(name, value)
+		}
+	}
+	contentType := c.Response().Header().Get(echo.HeaderContentType)
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	return c.Blob(resp.StatusCode, contentType, body)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/auth"
+	"github.com/serverledge-faas/serverledge/internal/client"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/internal/trigger"
+)
+
+// CreateOrUpdateSchedule handles a request to create (or update) a schedule of a function or a workflow.
+func CreateOrUpdateSchedule(c echo.Context) error {
+	var s trigger.Schedule
+	err := json.NewDecoder(c.Request().Body).Decode(&s)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	identity := auth.GetIdentity(c)
+	s.Namespace = identity.Namespace
+	s.APIKey = identity.APIKey
+	if s.Area == "" {
+		s.Area = node.LocalNode.Area
+	}
+	if err = s.Validate(); err != nil {
+		return c.String(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	if !s.Exists(s.Namespace) {
+		return c.String(http.StatusNotFound, "Function or workflow unknown")
+	}
+
+	s.Created = time.Now()
+	existing, found := trigger.GetSchedule(s.Namespace, s.Name)
+	if c.Path() != "/schedule/update" {
+		if found {
+			log.Printf("Dropping request for already existing schedule '%s'\n", s.QualifiedName())
+			return c.String(http.StatusConflict, "")
+		}
+		log.Printf("New request: creation of schedule %s\n", s.QualifiedName())
+	} else {
+		if found {
+			s.Created = existing.Created
+		}
+		log.Printf("New request: creation/update of schedule %s\n", s.QualifiedName())
+	}
+
+	if err = s.Save(); err != nil {
+		log.Printf("Failed creation: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+
+	s.APIKey = ""
+	return c.JSON(http.StatusOK, s)
+}
+
+// DeleteSchedule handles a request to delete a schedule.
+func DeleteSchedule(c echo.Context) error {
+	var req client.ScheduleRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	err = trigger.DeleteSchedule(auth.GetIdentity(c).Namespace, req.Name)
+	if errors.Is(err, trigger.UnknownScheduleErr) {
+		return c.String(http.StatusNotFound, "Unknown schedule")
+	} else if err != nil {
+		log.Printf("Failed deletion: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+
+	response := struct{ Deleted string }{req.Name}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetSchedules handles a request to list the schedules in the namespace of the client.
+func GetSchedules(c echo.Context) error {
+	schedules, err := trigger.GetAllSchedules(auth.GetIdentity(c).Namespace)
+	if err != nil {
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	for i := range schedules {
+		schedules[i].APIKey = ""
+	}
+	return c.JSON(http.StatusOK, schedules)
+}
+
+// GetScheduleHistory handles a request to list the latest firings of a schedule.
+func GetScheduleHistory(c echo.Context) error {
+	namespace := auth.GetIdentity(c).Namespace
+	name := c.Param("name")
+	if _, found := trigger.GetSchedule(namespace, name); !found {
+		return c.String(http.StatusNotFound, "Unknown schedule")
+	}
+
+	history, err := trigger.GetHistory(namespace, name)
+	if err != nil {
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	return c.JSON(http.StatusOK, history)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/cache"
 	"github.com/serverledge-faas/serverledge/internal/config"
 	"github.com/serverledge-faas/serverledge/internal/node"
@@ -25,14 +26,26 @@ func StartAPIServer(e *echo.Echo) {
 	e.Use(middleware.Recover())
 
 	// Routes
-	e.POST("/invoke/:fun", InvokeFunction)
-	e.POST("/create", CreateOrUpdateFunction)
-	e.POST("/update", CreateOrUpdateFunction)
-	e.POST("/delete", DeleteFunction)
-	e.GET("/function", GetFunctions)
-	e.GET("/poll/:reqId", PollAsyncResult)
+	// Each route requires (at least) the privileges of a role, if authentication is enabled
+	invoker := auth.RequireRole(auth.Invoker)
+	deployer := auth.RequireRole(auth.Deployer)
+	admin := auth.RequireRole(auth.Admin)
+
+	e.POST("/invoke/:fun", InvokeFunction, invoker)
+	e.POST("/create", CreateOrUpdateFunction, deployer)
+	e.POST("/update", CreateOrUpdateFunction, deployer)
+	e.POST("/delete", DeleteFunction, deployer)
+	e.GET("/function", GetFunctions, invoker)
+	e.GET("/poll/:reqId", PollAsyncResult, invoker)
+	e.POST("/cancel/:reqId", CancelInvocation, invoker)
 	e.GET("/status", GetServerStatus)
-	e.POST("/prewarm", PrewarmFunction)
+	e.POST("/prewarm", PrewarmFunction, deployer)
+	e.POST("/publish", PublishFunction, deployer)
+	e.POST("/alias", SetFunctionAlias, deployer)
+	e.POST("/rollback", RollbackFunctionAlias, deployer)
+	e.GET("/versions/:fun", GetFunctionVersions, invoker)
+	e.Any("/http/:fun", InvokeHTTP, invoker)
+	e.Any("/http/:fun/*", InvokeHTTP, invoker)
 
 	if config.GetBool(config.METRICS_ENABLED, false) {
 		e.GET("/metrics", func(c echo.Context) error {
@@ -42,12 +55,30 @@ func StartAPIServer(e *echo.Echo) {
 	}
 
 	// Workflow routes
-	e.POST("/workflow/invoke/:workflow", InvokeWorkflow)
-	e.POST("/workflow/resume/:workflow", ResumeWorkflow)
-	e.POST("/workflow/create", CreateWorkflowFromASL)
-	e.POST("/workflow/import", CreateWorkflow)
-	e.POST("/workflow/delete", DeleteWorkflow)
-	e.GET("/workflow/list", GetWorkflows)
+	e.POST("/workflow/invoke/:workflow", InvokeWorkflow, invoker)
+	e.POST("/workflow/resume/:workflow", ResumeWorkflow, invoker)
+	e.POST("/workflow/create", CreateWorkflowFromASL, deployer)
+	e.POST("/workflow/import", CreateWorkflow, deployer)
+	e.POST("/workflow/delete", DeleteWorkflow, deployer)
+	e.GET("/workflow/list", GetWorkflows, invoker)
+
+	// Schedule routes
+	e.POST("/schedule/create", CreateOrUpdateSchedule, deployer)
+	e.POST("/schedule/update", CreateOrUpdateSchedule, deployer)
+	e.POST("/schedule/delete", DeleteSchedule, deployer)
+	e.GET("/schedule/list", GetSchedules, invoker)
+	e.GET("/schedule/history/:name", GetScheduleHistory, invoker)
+
+	// Event trigger routes
+	e.POST("/trigger/create", CreateOrUpdateTrigger, deployer)
+	e.POST("/trigger/update", CreateOrUpdateTrigger, deployer)
+	e.POST("/trigger/delete", DeleteTrigger, deployer)
+	e.GET("/trigger/list", GetTriggers, invoker)
+
+	// API keys routes
+	e.POST("/apikey/create", CreateAPIKey, admin)
+	e.POST("/apikey/delete", DeleteAPIKey, admin)
+	e.GET("/apikey/list", GetAPIKeys, admin)
 
 	// Start server
 	portNumber := config.GetInt(config.API_PORT, 1323)
@@ -117,6 +148,8 @@ func CreateSchedulingPolicy() scheduling.Policy {
 		return &scheduling.CloudEdgePolicy{}
 	} else if policyConf == "edgeonly" {
 		return &scheduling.EdgePolicy{}
+	} else if policyConf == "qosaware" {
+		return &scheduling.QoSAwarePolicy{}
 	} else { // default, localonly
 		return &scheduling.DefaultLocalPolicy{}
 	}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/scheduling"
+)
+
+// invokeStreaming serves an invocation whose output and partial results are streamed to the client as Server-Sent
+// Events, followed by the result. If the invocation fails before the first event, the response has the same status
+// code as without streaming; otherwise, the stream ends with an error event.
+func invokeStreaming(c echo.Context, r *function.Request) error {
+	var mu sync.Mutex // events are sent while the request is executed, possibly on a remote node
+	started := false
+	send := func(event executor.Event) {
+		if !started {
+			setMetricsHeaders(c)
+			c.Response().Header().Set(echo.HeaderContentType, executor.StreamContentType)
+			c.Response().Header().Set("Cache-Control", "no-cache")
+			c.Response().WriteHeader(http.StatusOK)
+			started = true
+		}
+		if err := executor.WriteEvent(c.Response(), event); err != nil {
+			log.Printf("Error while streaming %s: %v\n", r.Id(), err)
+		}
+	}
+	r.Stream = func(event executor.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		send(event)
+	}
+
+	executionReport, err := scheduling.SubmitRequest(r)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err != nil {
+		status, message := invocationErrorStatus(err)
+		if !started {
+			setMetricsHeaders(c)
+			return c.String(status, message)
+		}
+		data, _ := json.Marshal(executor.StreamError{StatusCode: status, Message: message})
+		send(executor.Event{Name: executor.ErrorEvent, Data: string(data)})
+		return nil
+	}
+
+	data, err := json.Marshal(function.Response{Success: true, ExecutionReport: *executionReport})
+	if err != nil {
+		return err
+	}
+	send(executor.Event{Name: executor.ResultEvent, Data: string(data)})
+	return nil
+}
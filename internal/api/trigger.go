@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/auth"
+	"github.com/serverledge-faas/serverledge/internal/client"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/internal/trigger"
+)
+
+// CreateOrUpdateTrigger handles a request to create (or update) an event trigger of a function or a workflow.
+func CreateOrUpdateTrigger(c echo.Context) error {
+	var t trigger.Trigger
+	err := json.NewDecoder(c.Request().Body).Decode(&t)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	identity := auth.GetIdentity(c)
+	t.Namespace = identity.Namespace
+	t.APIKey = identity.APIKey
+	if t.Area == "" {
+		t.Area = node.LocalNode.Area
+	}
+	if err = t.Validate(); err != nil {
+		return c.String(http.StatusUnprocessableEntity, err.Error())
+	}
+	if !t.Exists(t.Namespace) {
+		return c.String(http.StatusNotFound, "Function or workflow unknown")
+	}
+
+	t.Created = time.Now()
+	existing, found := trigger.GetTrigger(t.Namespace, t.Name)
+	if c.Path() != "/trigger/update" {
+		if found {
+			log.Printf("Dropping request for already existing trigger '%s'\n", t.QualifiedName())
+			return c.String(http.StatusConflict, "")
+		}
+		log.Printf("New request: creation of trigger %s\n", t.QualifiedName())
+	} else {
+		if found {
+			t.Created = existing.Created
+		}
+		log.Printf("New request: creation/update of trigger %s\n", t.QualifiedName())
+	}
+
+	if err = t.Save(); err != nil {
+		log.Printf("Failed creation: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+
+	t.APIKey = ""
+	return c.JSON(http.StatusOK, t)
+}
+
+// DeleteTrigger handles a request to delete a trigger.
+func DeleteTrigger(c echo.Context) error {
+	var req client.TriggerRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	err = trigger.DeleteTrigger(auth.GetIdentity(c).Namespace, req.Name)
+	if errors.Is(err, trigger.UnknownTriggerErr) {
+		return c.String(http.StatusNotFound, "Unknown trigger")
+	} else if err != nil {
+		log.Printf("Failed deletion: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+
+	response := struct{ Deleted string }{req.Name}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetTriggers handles a request to list the triggers in the namespace of the client.
+func GetTriggers(c echo.Context) error {
+	triggers, err := trigger.GetAllTriggers(auth.GetIdentity(c).Namespace)
+	if err != nil {
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	for i := range triggers {
+		triggers[i].APIKey = ""
+	}
+	return c.JSON(http.StatusOK, triggers)
+}
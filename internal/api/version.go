@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/auth"
+	"github.com/serverledge-faas/serverledge/internal/client"
+	"github.com/serverledge-faas/serverledge/internal/function"
+)
+
+// PublishFunction handles a request to publish the current definition of a function as a new version.
+func PublishFunction(c echo.Context) error {
+	var req client.PublishRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	f, ok := function.GetFunction(function.QualifiedName(auth.GetIdentity(c).Namespace, req.Function))
+	if !ok {
+		log.Printf("Dropping request for unknown fun '%s'\n", req.Function)
+		return c.String(http.StatusNotFound, "Function unknown")
+	}
+
+	version, err := f.Publish()
+	if err != nil {
+		log.Printf("Failed publication: %v\n", err)
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	log.Printf("Published version %d of %s\n", version, f.QualifiedName())
+
+	response := struct {
+		Function string
+		Version  int
+	}{f.Name, version}
+	return c.JSON(http.StatusOK, response)
+}
+
+// SetFunctionAlias handles a request to create an alias, or to shift its traffic to other versions.
+func SetFunctionAlias(c echo.Context) error {
+	var req client.AliasRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	if !function.IsValidAliasName(req.Alias) {
+		return c.String(http.StatusUnprocessableEntity, "Invalid alias name")
+	}
+	qualifiedName := function.QualifiedName(auth.GetIdentity(c).Namespace, req.Function)
+	if _, ok := function.GetFunction(qualifiedName); !ok {
+		log.Printf("Dropping request for unknown fun '%s'\n", req.Function)
+		return c.String(http.StatusNotFound, "Function unknown")
+	}
+
+	alias, err := function.SetAlias(qualifiedName, req.Alias, req.Routing)
+	if errors.Is(err, function.UnknownVersionErr) {
+		return c.String(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		log.Printf("Failed alias update: %v\n", err)
+		return c.String(http.StatusUnprocessableEntity, err.Error())
+	}
+	log.Printf("Alias %s of %s now routes to %v\n", alias.Name, qualifiedName, alias.Routing)
+
+	return c.JSON(http.StatusOK, alias)
+}
+
+// RollbackFunctionAlias handles a request to restore the previous routing of an alias.
+func RollbackFunctionAlias(c echo.Context) error {
+	var req client.RollbackRequest
+	err := json.NewDecoder(c.Request().Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		log.Printf("Could not parse request: %v\n", err)
+		return err
+	}
+
+	qualifiedName := function.QualifiedName(auth.GetIdentity(c).Namespace, req.Function)
+	alias, err := function.RollbackAlias(qualifiedName, req.Alias)
+	if err != nil {
+		log.Printf("Failed rollback: %v\n", err)
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	log.Printf("Alias %s of %s rolled back to %v\n", alias.Name, qualifiedName, alias.Routing)
+
+	return c.JSON(http.StatusOK, alias)
+}
+
+// GetFunctionVersions handles a request to list the published versions and the aliases of a function.
+func GetFunctionVersions(c echo.Context) error {
+	funcName := c.Param("fun")
+	qualifiedName := function.QualifiedName(auth.GetIdentity(c).Namespace, funcName)
+	if _, ok := function.GetFunction(qualifiedName); !ok {
+		return c.String(http.StatusNotFound, "Function unknown")
+	}
+
+	versions, err := function.GetVersions(qualifiedName)
+	if err != nil {
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+	aliases, err := function.GetAliases(qualifiedName)
+	if err != nil {
+		return c.String(http.StatusServiceUnavailable, "")
+	}
+
+	response := struct {
+		Function string
+		Versions []int
+		Aliases  []function.Alias
+	}{funcName, versions, aliases}
+	return c.JSON(http.StatusOK, response)
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/async"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/client"
 	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/node"
@@ -31,8 +33,13 @@ func CreateWorkflowFromASL(e echo.Context) error {
 		return err
 	}
 
+	if !function.IsValidName(creationRequest.Name) {
+		return e.JSON(http.StatusBadRequest, "invalid workflow name")
+	}
+	namespace := auth.GetIdentity(e).Namespace
+
 	// checking if the function already exists. If exists we return an error
-	_, found := workflow.Get(creationRequest.Name)
+	_, found := workflow.Get(function.QualifiedName(namespace, creationRequest.Name))
 	if found {
 		log.Printf("Dropping request for already existing workflow '%s'", creationRequest.Name)
 		return e.JSON(http.StatusConflict, "workflow already exists")
@@ -46,7 +53,7 @@ func CreateWorkflowFromASL(e echo.Context) error {
 		return e.JSON(http.StatusBadRequest, "workflow already exists")
 	}
 
-	comp, err := workflow.FromASL(creationRequest.Name, decodedSrc[:])
+	comp, err := workflow.FromASLInNamespace(namespace, creationRequest.Name, decodedSrc[:])
 	if err != nil {
 		log.Printf("Could not parse workflow from ASL: %v", err)
 		return e.JSON(http.StatusBadRequest, "ASL parsing failed")
@@ -76,6 +83,13 @@ func CreateWorkflow(e echo.Context) error {
 		log.Printf("Could not parse workflow request - error during unmarshal: %v", err)
 		return err
 	}
+	if !function.IsValidName(comp.Name) {
+		return e.JSON(http.StatusBadRequest, "invalid workflow name")
+	}
+	// functions are looked up in the namespace of the workflow
+	if err = comp.SetNamespace(auth.GetIdentity(e).Namespace); err != nil {
+		return e.JSON(http.StatusBadRequest, err.Error())
+	}
 	// checking if the function already exists. If exists we return an error
 	alreadyPresent := comp.Exists() // TODO: we would need a system-wide lock here...
 	if alreadyPresent {
@@ -108,7 +122,7 @@ func CreateWorkflow(e echo.Context) error {
 
 // GetWorkflows handles a request to list the function workflows available in the system.
 func GetWorkflows(c echo.Context) error {
-	list, err := workflow.GetAllWorkflows()
+	list, err := workflow.GetAllWorkflows(auth.GetIdentity(c).Namespace)
 	if err != nil {
 		return c.String(http.StatusServiceUnavailable, "")
 	}
@@ -125,7 +139,7 @@ func DeleteWorkflow(c echo.Context) error {
 		return err
 	}
 
-	wflow, ok := workflow.Get(comp.Name) // TODO: we would need a system-wide lock here...
+	wflow, ok := workflow.Get(function.QualifiedName(auth.GetIdentity(c).Namespace, comp.Name)) // TODO: we would need a system-wide lock here...
 	if !ok {
 		log.Printf("Dropping request for non existing function '%s'", comp.Name)
 		return c.JSON(http.StatusNotFound, "the request function workflow to delete does not exist")
@@ -145,7 +159,8 @@ func DeleteWorkflow(c echo.Context) error {
 // ResumeWorkflow handles a workflow invocation resume request (workflow offloading).
 func ResumeWorkflow(e echo.Context) error {
 	workflowName := e.Param("workflow")
-	wflow, ok := workflow.Get(workflowName)
+	identity := auth.GetIdentity(e)
+	wflow, ok := workflow.Get(function.QualifiedName(identity.Namespace, workflowName))
 	if !ok {
 		log.Printf("Dropping request for unknown workflow '%s'", workflowName)
 		return e.JSON(http.StatusNotFound, "function workflow '"+workflowName+"' does not exist")
@@ -169,9 +184,12 @@ func ResumeWorkflow(e echo.Context) error {
 	req.QoS = clientReq.QoS
 	req.CanDoOffloading = clientReq.CanDoOffloading
 	req.Async = clientReq.Async
+	req.AsyncOptions = clientReq.AsyncOptions
 	req.Resuming = true
+	req.APIKey = identity.APIKey
 	req.Id = clientReq.ReqId
 	req.ExecReport.Reports = map[string]*function.ExecutionReport{}
+	req.ExecReport.Retries = nil
 
 	if clientReq.Plan.ToExecute != nil {
 		req.Plan = &workflow.OffloadingPlan{ToExecute: clientReq.Plan.ToExecute}
@@ -187,7 +205,8 @@ func ResumeWorkflow(e echo.Context) error {
 // InvokeWorkflow handles a function workflow invocation request.
 func InvokeWorkflow(e echo.Context) error {
 	workflowName := e.Param("workflow")
-	wflow, ok := workflow.Get(workflowName)
+	identity := auth.GetIdentity(e)
+	wflow, ok := workflow.Get(function.QualifiedName(identity.Namespace, workflowName))
 	if !ok {
 		log.Printf("Dropping request for unknown workflow '%s'", workflowName)
 		return e.JSON(http.StatusNotFound, "function workflow '"+workflowName+"' does not exist")
@@ -211,36 +230,29 @@ func InvokeWorkflow(e echo.Context) error {
 	req.QoS = clientReq.QoS
 	req.CanDoOffloading = clientReq.CanDoOffloading
 	req.Async = clientReq.Async
+	req.AsyncOptions = clientReq.AsyncOptions
 	req.Plan = nil
 	req.Resuming = false
+	req.APIKey = identity.APIKey
 	req.Id = fmt.Sprintf("%v-%s%d", wflow.Name, node.LocalNode.String()[len(node.LocalNode.String())-5:], req.Arrival.Nanosecond())
 	req.ExecReport.Reports = map[string]*function.ExecutionReport{}
+	req.ExecReport.Retries = nil
 
 	return handleWorkflowInvocation(e, req)
 }
 
 func handleWorkflowInvocation(e echo.Context, req *workflow.Request) error {
 
+	// suspended workflows publish their results asynchronously, even if invoked synchronously
+	if err := async.Validate(req.AsyncOptions); err != nil {
+		workflowInvocationRequestPool.Put(req)
+		return e.String(http.StatusBadRequest, err.Error())
+	}
+
 	if req.Async {
 		go func() {
-			errInvoke := req.W.Invoke(req)
-
 			defer workflowInvocationRequestPool.Put(req)
-
-			if errInvoke != nil {
-				log.Printf("Invocation failed: %v", errInvoke)
-				workflow.PublishAsyncInvocationResponse(req.Id, workflow.InvocationResponse{Success: false})
-				return
-			}
-
-			log.Printf("Invocation succeeded. Publishing: %v", req.ExecReport)
-			req.ExecReport.ResponseTime = time.Now().Sub(req.Arrival).Seconds()
-			workflow.PublishAsyncInvocationResponse(req.Id, workflow.InvocationResponse{
-				Success:      true,
-				Result:       req.ExecReport.Result,
-				Reports:      req.ExecReport.Reports,
-				ResponseTime: req.ExecReport.ResponseTime,
-			})
+			workflow.InvokeAsync(req)
 		}()
 
 		return e.JSON(http.StatusOK, function.AsyncResponse{ReqId: req.Id})
@@ -256,6 +268,9 @@ func handleWorkflowInvocation(e echo.Context, req *workflow.Request) error {
 	} else if err != nil {
 		log.Printf("Invocation failed: %v", err)
 		return e.JSON(http.StatusInternalServerError, err.Error())
+	} else if req.Suspended && !req.Resuming {
+		// the workflow is waiting for a timer: the client polls for the result, as for async invocations
+		return e.JSON(http.StatusAccepted, function.AsyncResponse{ReqId: req.Id})
 	} else {
 		req.ExecReport.ResponseTime = time.Now().Sub(req.Arrival).Seconds()
 
@@ -263,7 +278,9 @@ func handleWorkflowInvocation(e echo.Context, req *workflow.Request) error {
 			Success:      true,
 			Result:       req.ExecReport.Result,
 			Reports:      req.ExecReport.Reports,
+			Retries:      req.ExecReport.Retries,
 			ResponseTime: req.ExecReport.ResponseTime,
+			Suspended:    req.Suspended,
 		})
 	}
 }
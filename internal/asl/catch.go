@@ -1,29 +1,92 @@
 package asl
 
 import (
+	"fmt"
+
+	"github.com/buger/jsonparser"
 	"github.com/serverledge-faas/serverledge/internal/types"
 	"golang.org/x/exp/slices"
 )
 
-// Catch is a field in Task, Parallel and Map states. When a state reports an error and either there is no Retrier, or retries have failed to resolve the error, the interpreter scans through the Catchers in array order, and when the Error Name appears in the value of a Catcher’s "ErrorEquals" field, transitions the machine to the state named in the value of the "Next" field. The reserved name "States.ALL" appearing in a Retrier’s "ErrorEquals" field is a wildcard and matches any Error Name.
-type Catch struct {
+// Catcher is an element of the Catch field of Task, Parallel and Map states. When a state reports an error and either there is no Retrier, or retries have failed to resolve the error, the interpreter scans through the Catchers in array order, and when the Error Name appears in the value of a Catcher’s "ErrorEquals" field, transitions the machine to the state named in the value of the "Next" field. The reserved name "States.ALL" appearing in a Catcher’s "ErrorEquals" field is a wildcard and matches any Error Name.
+type Catcher struct {
 	ErrorEquals []string
-	ResultPath  string
-	Next        string
+	// ResultPath is where the error output is placed in the input of the state, which is passed to Next. By default, the error output replaces the input
+	ResultPath Path
+	Next       string
 }
 
-func (c *Catch) Equals(cmp types.Comparable) bool {
-	c2 := cmp.(*Catch)
+func (c *Catcher) Equals(cmp types.Comparable) bool {
+	c2 := cmp.(*Catcher)
 
 	return slices.Equal(c.ErrorEquals, c2.ErrorEquals) &&
 		c.ResultPath == c2.ResultPath &&
 		c.Next == c2.Next
 }
 
+func (c *Catcher) Validate(stateNames []string, isLast bool) error {
+	if err := validateErrorEquals(c.ErrorEquals, isLast); err != nil {
+		return err
+	}
+	if c.Next == "" {
+		return fmt.Errorf("next field is mandatory for a catcher, but it is not defined")
+	}
+	if stateNames != nil && !slices.Contains(stateNames, c.Next) {
+		return fmt.Errorf("the next state %s of the catcher does not exist", c.Next)
+	}
+	return nil
+}
+
+func (c *Catcher) String() string {
+	return fmt.Sprintf("{ErrorEquals: %v, ResultPath: %s, Next: %s}", c.ErrorEquals, c.ResultPath, c.Next)
+}
+
 type CanCatch interface {
-	GetCatchOpt() Catch
+	GetCatchOpt() []Catcher
+}
+
+func NoCatch() []Catcher {
+	return []Catcher{}
+}
+
+// parseCatch parses the Catch field of a state, if any
+func parseCatch(jsonData []byte) ([]Catcher, error) {
+	catchers := NoCatch()
+	value, dataType, _, err := jsonparser.Get(jsonData, "Catch")
+	if dataType == jsonparser.NotExist {
+		return catchers, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dataType != jsonparser.Array {
+		return nil, fmt.Errorf("Catch is not an array")
+	}
+
+	var errCatcher error
+	_, err = jsonparser.ArrayEach(value, func(element []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if errCatcher != nil {
+			return
+		}
+		catcher := Catcher{}
+		catcher.ErrorEquals, errCatcher = JsonExtractStringArray(element, "ErrorEquals")
+		if errCatcher != nil {
+			return
+		}
+		catcher.ResultPath = JsonExtractRefPathOrDefault(element, "ResultPath", "")
+		catcher.Next = JsonExtractStringOrDefault(element, "Next", "")
+		catchers = append(catchers, catcher)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Catch: %v", err)
+	}
+	if errCatcher != nil {
+		return nil, fmt.Errorf("failed to parse Catch: %v", errCatcher)
+	}
+	return catchers, nil
 }
 
-func NoCatch() *Catch {
-	return &Catch{}
+// equalCatchers returns true if both arrays contain equal Catchers, in the same order
+func equalCatchers(c1, c2 []Catcher) bool {
+	return slices.EqualFunc(c1, c2, func(a, b Catcher) bool { return a.Equals(&b) })
 }
@@ -50,7 +50,7 @@ func (c *ChoiceState) Validate(stateNames []string) error {
 	if c.Default == "" {
 		log.Warn("Default choice not specified")
 	}
-	return nil
+	return ValidateDataProcessing(c.InputPath, PayloadTemplate{}, PayloadTemplate{}, "", c.OutputPath)
 }
 
 func NewEmptyChoice() *ChoiceState {
@@ -0,0 +1,29 @@
+package asl
+
+import (
+	"fmt"
+	"golang.org/x/exp/slices"
+)
+
+// Error names predefined by the ASL specification, which can be used in the ErrorEquals field of Retriers and Catchers
+const (
+	// ErrorAll is a wildcard that matches any error name, except ErrorRuntime
+	ErrorAll = "States.ALL"
+	// ErrorTimeout is raised when a Task runs longer than its timeout
+	ErrorTimeout = "States.Timeout"
+	// ErrorTaskFailed is raised when a Task fails. It matches any error name, except ErrorTimeout and ErrorRuntime
+	ErrorTaskFailed = "States.TaskFailed"
+	// ErrorRuntime is raised when the input of a Task cannot be processed. It cannot be retried nor caught
+	ErrorRuntime = "States.Runtime"
+)
+
+// validateErrorEquals checks that the ErrorEquals field is not empty and that ErrorAll only appears alone in the last element
+func validateErrorEquals(errorEquals []string, isLast bool) error {
+	if len(errorEquals) == 0 {
+		return fmt.Errorf("ErrorEquals cannot be empty")
+	}
+	if slices.Contains(errorEquals, ErrorAll) && (len(errorEquals) > 1 || !isLast) {
+		return fmt.Errorf("%s must appear alone in the last Retrier or Catcher", ErrorAll)
+	}
+	return nil
+}
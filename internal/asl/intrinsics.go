@@ -1,326 +1,782 @@
 package asl
 
-/*
-States.Format
-This Intrinsic Function takes one or more arguments. The Value of the first MUST be a string, which MAY include zero or more instances of the character sequence {}. There MUST be as many remaining arguments in the Intrinsic Function as there are occurrences of {}. The interpreter returns the first-argument string with each {} replaced by the Value of the positionally-corresponding argument in the Intrinsic Function.
-
-If necessary, the { and } characters can be escaped respectively as \\{ and \\}.
-
-If the argument is a Path, applying it to the input MUST yield a value that is a string, a boolean, a number, or null. In each case, the Value is the natural string representation; string values are not accompanied by enclosing " characters. The Value MUST NOT be a JSON array or object.
-
-For example, given the following Payload Template:
-
-{
-  "Parameters": {
-    "foo.$": "States.Format('Your name is {}, we are in the year {}', $.name, 2020)"
-  }
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"maps"
+	"math"
+	mathrand "math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxIntrinsicStringLength is the maximum length of the strings encoded, decoded or hashed by intrinsic functions
+const maxIntrinsicStringLength = 10000
+
+// maxArrayRangeLength is the maximum number of items of an array created by States.ArrayRange
+const maxArrayRangeLength = 1000
+
+// IntrinsicFunction is a call to one of the intrinsic functions of the ASL, which can be used as the value of the
+// dynamic fields of payload templates, e.g., "States.Format('Hello, {}', $.name)". The arguments can be string
+// literals (in single quotes, where the characters ', {, } and \ are escaped by a \), numbers, booleans, null, paths
+// on the input (or on the context object, if they start with "$$") and other intrinsic functions.
+type IntrinsicFunction struct {
+	Name string
+	args []intrinsicArgument
 }
-Suppose the input to the Payload Template is as follows:
 
-{
-  "name": "Foo",
-  "zebra": "stripe"
+// intrinsicArgument is an argument of an intrinsic function, which is evaluated before calling the function
+type intrinsicArgument interface {
+	evaluate(input interface{}, context interface{}) (interface{}, error)
 }
-After processing the Payload Template, the new payload becomes:
 
-{
-  "foo": "Your name is Foo, we are in the year 2020"
+// intrinsicLiteral is a constant argument: a string, a number, a boolean or null
+type intrinsicLiteral struct {
+	value interface{}
 }
-States.StringToJson
-This Intrinsic Function takes a single argument whose Value MUST be a string. The interpreter applies a JSON parser to the Value and returns its parsed JSON form.
 
-For example, given the following Payload Template:
+func (l *intrinsicLiteral) evaluate(input interface{}, context interface{}) (interface{}, error) {
+	return l.value, nil
+}
 
-{
-  "Parameters": {
-    "foo.$": "States.StringToJson($.someString)"
-  }
+// intrinsicPath is a path on the input or on the context object
+type intrinsicPath struct {
+	path      Path
+	onContext bool
 }
-Suppose the input to the Payload Template is as follows:
 
-{
-  "someString": "{\"number\": 20}",
-  "zebra": "stripe"
+func (p *intrinsicPath) evaluate(input interface{}, context interface{}) (interface{}, error) {
+	if p.onContext {
+		return p.path.Evaluate(context)
+	}
+	return p.path.Evaluate(input)
 }
-After processing the Payload Template, the new payload becomes:
 
-{
-  "foo": {
-    "number": 20
-  }
+func (f *IntrinsicFunction) evaluate(input interface{}, context interface{}) (interface{}, error) {
+	return f.Evaluate(input, context)
 }
-States.JsonToString
-This Intrinsic Function takes a single argument which MUST be a Path. The interpreter returns a string which is a JSON text representing the data identified by the Path.
 
-For example, given the following Payload Template:
+// formatTemplate is the first argument of States.Format given as a string literal. It is kept with its escape
+// sequences, so that escaped braces are not replaced.
+type formatTemplate string
 
-{
-  "Parameters": {
-    "foo.$": "States.JsonToString($.someJson)"
-  }
+// intrinsicDefinition defines the number of arguments of an intrinsic function and how it is evaluated.
+// A negative maxArgs means that the number of arguments is not limited.
+type intrinsicDefinition struct {
+	minArgs  int
+	maxArgs  int
+	evaluate func(args []interface{}) (interface{}, error)
 }
-Suppose the input to the Payload Template is as follows:
 
-{
-  "someJson": {
-    "name": "Foo",
-    "year": 2020
-  },
-  "zebra": "stripe"
+var intrinsicFunctions map[string]intrinsicDefinition
+
+func init() {
+	intrinsicFunctions = map[string]intrinsicDefinition{
+		"States.Format":         {1, -1, intrinsicFormat},
+		"States.StringToJson":   {1, 1, intrinsicStringToJson},
+		"States.JsonToString":   {1, 1, intrinsicJsonToString},
+		"States.Array":          {0, -1, intrinsicArray},
+		"States.ArrayPartition": {2, 2, intrinsicArrayPartition},
+		"States.ArrayContains":  {2, 2, intrinsicArrayContains},
+		"States.ArrayRange":     {3, 3, intrinsicArrayRange},
+		"States.ArrayGetItem":   {2, 2, intrinsicArrayGetItem},
+		"States.ArrayLength":    {1, 1, intrinsicArrayLength},
+		"States.ArrayUnique":    {1, 1, intrinsicArrayUnique},
+		"States.Base64Encode":   {1, 1, intrinsicBase64Encode},
+		"States.Base64Decode":   {1, 1, intrinsicBase64Decode},
+		"States.Hash":           {2, 2, intrinsicHash},
+		"States.JsonMerge":      {3, 3, intrinsicJsonMerge},
+		"States.MathRandom":     {2, 3, intrinsicMathRandom},
+		"States.MathAdd":        {2, 2, intrinsicMathAdd},
+		"States.StringSplit":    {2, 2, intrinsicStringSplit},
+		"States.UUID":           {0, 0, intrinsicUUID},
+	}
 }
-After processing the Payload Template, the new payload becomes:
 
-{
-  "foo": "{\"name\":\"Foo\",\"year\":2020}"
+// IntrinsicSyntaxError reports a malformed call of an intrinsic function, with the position (starting from 0) of
+// the character where the error was found
+type IntrinsicSyntaxError struct {
+	Expression string
+	Position   int
+	Message    string
 }
-States.Array
-This Intrinsic Function takes zero or more arguments. The interpreter returns a JSON array containing the Values of the arguments, in the order provided.
-
-For example, given the following Payload Template:
 
-{
-  "Parameters": {
-    "foo.$": "States.Array('Foo', 2020, $.someJson, null)"
-  }
+func (e *IntrinsicSyntaxError) Error() string {
+	return fmt.Sprintf("invalid intrinsic function %s: %s at position %d", e.Expression, e.Message, e.Position)
 }
-Suppose the input to the Payload Template is as follows:
 
-{
-  "someJson": {
-    "random": "abcdefg"
-  },
-  "zebra": "stripe"
+// ParseIntrinsicFunction parses a call of an intrinsic function, checking that the function exists and that it
+// has the right number of arguments. Malformed calls are reported with an *IntrinsicSyntaxError.
+func ParseIntrinsicFunction(expression string) (*IntrinsicFunction, error) {
+	p := &intrinsicParser{expression: expression}
+	function, err := p.parseFunction()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.isEnd() {
+		return nil, p.errorAt(p.pos, "unexpected %q after the end of the function", p.expression[p.pos:])
+	}
+	return function, nil
 }
-After processing the Payload Template, the new payload becomes:
 
-{
-  "foo": [
-    "Foo",
-    2020,
-    {
-      "random": "abcdefg"
-    },
-    null
-  ]
+// Evaluate evaluates the arguments on the input and on the context object, and then calls the function
+func (f *IntrinsicFunction) Evaluate(input interface{}, context interface{}) (interface{}, error) {
+	definition := intrinsicFunctions[f.Name]
+	values := make([]interface{}, len(f.args))
+	for i, arg := range f.args {
+		value, err := arg.evaluate(input, context)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %v", f.Name, i+1, err)
+		}
+		values[i] = value
+	}
+	result, err := definition.evaluate(values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.Name, err)
+	}
+	return result, nil
 }
-States.ArrayPartition
-Use the States.ArrayPartition intrinsic function to partition a large array. You can also use this intrinsic to slice the data and then send the payload in smaller chunks.
-
-This intrinsic function takes two arguments. The first argument is an array, while the second argument defines the chunk size. The interpreter chunks the input array into multiple arrays of the size specified by chunk size. The length of the last array chunk may be less than the length of the previous array chunks if the number of remaining items in the array is smaller than the chunk size.
-
-Input validation
-
-You must specify an array as the input value for the function's first argument.
-
-You must specify a non-zero, positive integer for the second argument representing the chunk size value.
-
-The input array can't exceed Step Functions' payload size limit of 256 KB.
-
-For example, given the following input array:
-
-{"inputArray": [1,2,3,4,5,6,7,8,9] }
-You could use the States.ArrayPartition function to divide the array into chunks of four values:
-
-"inputArray.$": "States.ArrayPartition($.inputArray,4)"
-Which would return the following array chunks:
-
-{"inputArray": [ [1,2,3,4], [5,6,7,8], [9]] }
-In the previous example, the States.ArrayPartition function outputs three arrays. The first two arrays each contain four values, as defined by the chunk size. A third array contains the remaining value and is smaller than the defined chunk size.
-
-States.ArrayContains
-Use the States.ArrayContains intrinsic function to determine if a specific value is present in an array. For example, you can use this function to detect if there was an error in a Map state iteration.
-
-This intrinsic function takes two arguments. The first argument is an array, while the second argument is the value to be searched for within the array.
-
-Input validation
-
-You must specify an array as the input value for function's first argument.
-You must specify a valid JSON object as the second argument.
-The input array can't exceed Step Functions' payload size limit of 256 KB.
-For example, given the following input array:
-
-{ "inputArray": [1,2,3,4,5,6,7,8,9], "lookingFor": 5 }
-You could use the States.ArrayContains function to find the lookingFor value within the inputArray:
-
-"contains.$": "States.ArrayContains($.inputArray, $.lookingFor)"
-Because the value stored in lookingFor is included in the inputArray, States.ArrayContains returns the following result:
-
-{"contains": true }
-States.ArrayRange
-Use the States.ArrayRange intrinsic function to create a new array containing a specific range of elements. The new array can contain up to 1000 elements.
-
-This function takes three arguments. The first argument is the first element of the new array, the second argument is the final element of the new array, and the third argument is the increment value between the elements in the new array.
-
-Input validation
-
-You must specify integer values for all of the arguments.
-
-You must specify a non-zero value for the third argument.
-
-The newly generated array can't contain more than 1000 items.
-
-For example, the following use of the States.ArrayRange function will create an array with a first value of 1, a final value of 9, and values in between the first and final values increase by two for each item:
-
-"array.$": "States.ArrayRange(1, 9, 2)"
-Which would return the following array:
-
-{"array": [1,3,5,7,9] }
-States.ArrayGetItem
-This intrinsic function returns a specified index's value. This function takes two arguments. The first argument is an array of values and the second argument is the array index of the value to return.
-
-For example, use the following inputArray and index values:
-
-{ "inputArray": [1,2,3,4,5,6,7,8,9], "index": 5 }
-From these values, you can use the States.ArrayGetItem function to return the value in the index position 5 within the array:
-
-"item.$": "States.ArrayGetItem($.inputArray, $.index)"
-In this example, States.ArrayGetItem would return the following result:
-
-{ "item": 6 }
-States.ArrayLength
-The States.ArrayLength intrinsic function returns the length of an array. It has one argument, the array to return the length of.
-
-For example, given the following input array:
-
-{ "inputArray": [1,2,3,4,5,6,7,8,9] }
-You can use States.ArrayLength to return the length of inputArray:
-
-"length.$": "States.ArrayLength($.inputArray)"
-In this example, States.ArrayLength would return the following JSON object that represents the array length:
-
-{ "length": 9 }
-States.ArrayUnique
-The States.ArrayUnique intrinsic function removes duplicate values from an array and returns an array containing only unique elements. This function takes an array, which can be unsorted, as its sole argument.
-
-For example, the following inputArray contains a series of duplicate values:
-
-{"inputArray": [1,2,3,3,3,3,3,3,4] }
-You could use the States.ArrayUnique function as and specify the array you want to remove duplicate values from:
-
-"array.$": "States.ArrayUnique($.inputArray)"
-The States.ArrayUnique function would return the following array containing only unique elements, removing all duplicate values:
-
-{"array": [1,2,3,4] }
-States.Base64Encode
-Use the States.Base64Encode intrinsic function to encode data based on MIME Base64 encoding scheme. You can use this function to pass data to other AWS services without using an AWS Lambda function.
 
-This function takes a data string of up to 10,000 characters to encode as its only argument.
-
-For example, consider the following input string:
-
-{"input": "Data to encode" }
-You can use the States.Base64Encode function to encode the input string as a MIME Base64 string:
-
-"base64.$": "States.Base64Encode($.input)"
-The States.Base64Encode function returns the following encoded data in response:
-
-{"base64": "RGF0YSB0byBlbmNvZGU=" }
-States.Base64Decode
-Use the States.Base64Decode intrinsic function to decode data based on MIME Base64 decoding scheme. You can use this function to pass data to other AWS services without using a Lambda function.
-
-This function takes a Base64 encoded data string of up to 10,000 characters to decode as its only argument.
+// intrinsicParser is a recursive descent parser of intrinsic functions
+type intrinsicParser struct {
+	expression string
+	pos        int
+}
 
-For example, given the following input:
+func (p *intrinsicParser) errorAt(position int, format string, args ...interface{}) error {
+	return &IntrinsicSyntaxError{Expression: p.expression, Position: position, Message: fmt.Sprintf(format, args...)}
+}
 
-{"base64": "RGF0YSB0byBlbmNvZGU=" }
-You can use the States.Base64Decode function to decode the base64 string to a human-readable string:
+func (p *intrinsicParser) isEnd() bool {
+	return p.pos >= len(p.expression)
+}
 
-"data.$": "States.Base64Decode($.base64)"
-The States.Base64Decode function would return the following decoded data in response:
+func (p *intrinsicParser) skipSpaces() {
+	for !p.isEnd() && (p.expression[p.pos] == ' ' || p.expression[p.pos] == '\t') {
+		p.pos++
+	}
+}
 
-{"data": "Decoded data" }
-States.Hash
-Use the States.Hash intrinsic function to calculate the hash value of a given input. You can use this function to pass data to other AWS services without using a Lambda function.
+// consume skips the next character, if it is the expected one
+func (p *intrinsicParser) consume(expected byte) bool {
+	if !p.isEnd() && p.expression[p.pos] == expected {
+		p.pos++
+		return true
+	}
+	return false
+}
 
-This function takes two arguments. The first argument is the data you want to calculate the hash value of. The second argument is the hashing algorithm to use to perform the hash calculation. The data you provide must be an object string containing 10,000 characters or less.
+func isIdentifierChar(c byte) bool {
+	return c == '.' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
 
-The hashing algorithm you specify can be any of the following algorithms:
+// parseFunction parses the name of the function and its arguments, in parentheses and separated by commas
+func (p *intrinsicParser) parseFunction() (*IntrinsicFunction, error) {
+	start := p.pos
+	for !p.isEnd() && isIdentifierChar(p.expression[p.pos]) {
+		p.pos++
+	}
+	name := p.expression[start:p.pos]
+	if !strings.HasPrefix(name, "States.") {
+		return nil, p.errorAt(start, "expected the name of an intrinsic function")
+	}
+	definition, found := intrinsicFunctions[name]
+	if !found {
+		return nil, p.errorAt(start, "unknown intrinsic function %s", name)
+	}
+
+	p.skipSpaces()
+	if !p.consume('(') {
+		return nil, p.errorAt(p.pos, "expected '(' after %s", name)
+	}
+	function := &IntrinsicFunction{Name: name, args: make([]intrinsicArgument, 0)}
+	p.skipSpaces()
+	if !p.consume(')') {
+		for {
+			arg, err := p.parseArgument(name == "States.Format" && len(function.args) == 0)
+			if err != nil {
+				return nil, err
+			}
+			function.args = append(function.args, arg)
+
+			p.skipSpaces()
+			if p.consume(')') {
+				break
+			}
+			if !p.consume(',') {
+				if p.isEnd() {
+					return nil, p.errorAt(p.pos, "missing ')' at the end of %s", name)
+				}
+				return nil, p.errorAt(p.pos, "expected ',' or ')' instead of %q", p.expression[p.pos])
+			}
+		}
+	}
+
+	nArgs := len(function.args)
+	if nArgs < definition.minArgs || (definition.maxArgs >= 0 && nArgs > definition.maxArgs) {
+		var expected string
+		switch {
+		case definition.maxArgs < 0:
+			expected = fmt.Sprintf("at least %d", definition.minArgs)
+		case definition.minArgs == definition.maxArgs:
+			expected = strconv.Itoa(definition.minArgs)
+		default:
+			expected = fmt.Sprintf("%d to %d", definition.minArgs, definition.maxArgs)
+		}
+		return nil, p.errorAt(start, "%s expects %s argument(s), but has %d", name, expected, nArgs)
+	}
+	return function, nil
+}
 
-MD5
-SHA-1
-SHA-256
-SHA-384
-SHA-512
-For example, you can use this function to calculate the hash value of the Data string using the specified Algorithm:
+// parseArgument parses a single argument. If isTemplate is true, a string literal is kept as a formatTemplate.
+func (p *intrinsicParser) parseArgument(isTemplate bool) (intrinsicArgument, error) {
+	p.skipSpaces()
+	if p.isEnd() {
+		return nil, p.errorAt(p.pos, "unexpected end of the expression")
+	}
+	c := p.expression[p.pos]
+	switch {
+	case c == '\'':
+		return p.parseString(isTemplate)
+	case c == '$':
+		return p.parsePath()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case strings.HasPrefix(p.expression[p.pos:], "States."):
+		return p.parseFunction()
+	}
+
+	start := p.pos
+	for !p.isEnd() && isIdentifierChar(p.expression[p.pos]) {
+		p.pos++
+	}
+	switch word := p.expression[start:p.pos]; word {
+	case "true":
+		return &intrinsicLiteral{value: true}, nil
+	case "false":
+		return &intrinsicLiteral{value: false}, nil
+	case "null":
+		return &intrinsicLiteral{value: nil}, nil
+	case "":
+		return nil, p.errorAt(start, "unexpected %q", c)
+	default:
+		return nil, p.errorAt(start, "unexpected %q: string literals must be enclosed in single quotes", word)
+	}
+}
 
-{ "Data": "input data", "Algorithm": "SHA-1" }
-You can use the States.Hash function to calculate the hash value:
+// parseString parses a string literal in single quotes
+func (p *intrinsicParser) parseString(isTemplate bool) (intrinsicArgument, error) {
+	start := p.pos
+	p.pos++ // opening quote
+	var value strings.Builder
+	for {
+		if p.isEnd() {
+			return nil, p.errorAt(start, "unterminated string literal")
+		}
+		c := p.expression[p.pos]
+		if c == '\'' {
+			p.pos++
+			break
+		}
+		if c == '\\' {
+			if p.pos+1 >= len(p.expression) {
+				return nil, p.errorAt(p.pos, "unterminated escape sequence")
+			}
+			escaped := p.expression[p.pos+1]
+			if !strings.ContainsRune(`'{}\`, rune(escaped)) {
+				return nil, p.errorAt(p.pos, "invalid escape sequence \\%c", escaped)
+			}
+			c = escaped
+			p.pos++
+		}
+		value.WriteByte(c)
+		p.pos++
+	}
+
+	if isTemplate {
+		// the quotes are removed, while the escape sequences are kept to find the placeholders
+		return &intrinsicLiteral{value: formatTemplate(p.expression[start+1 : p.pos-1])}, nil
+	}
+	return &intrinsicLiteral{value: value.String()}, nil
+}
 
-"output.$": "States.Hash($.Data, $.Algorithm)"
-The States.Hash function returns the following hash value in response:
+// parsePath parses a path, which ends at the first space, comma or parenthesis that is not in brackets
+func (p *intrinsicParser) parsePath() (intrinsicArgument, error) {
+	start := p.pos
+	inBrackets := false
+	var quote byte
+	for ; !p.isEnd(); p.pos++ {
+		c := p.expression[p.pos]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if inBrackets {
+			switch c {
+			case '\'', '"':
+				quote = c
+			case ']':
+				inBrackets = false
+			}
+			continue
+		}
+		if c == '[' {
+			inBrackets = true
+			continue
+		}
+		if c == ' ' || c == '\t' || c == ',' || c == ')' || c == '(' {
+			break
+		}
+	}
+
+	raw := p.expression[start:p.pos]
+	argument := &intrinsicPath{path: Path(raw)}
+	if strings.HasPrefix(raw, "$$") {
+		argument = &intrinsicPath{path: Path(raw[1:]), onContext: true}
+	}
+	if err := argument.path.check(false); err != nil {
+		return nil, p.errorAt(start, "%v", err)
+	}
+	return argument, nil
+}
 
-{"output": "aaff4a450a104cd177d28d18d7485e8cae074b7" }
-States.JsonMerge
-Use the States.JsonMerge intrinsic function to merge two JSON objects into a single object. This function takes three arguments. The first two arguments are the JSON objects that you want to merge.The third argument is a boolean value of false. This boolean value determines if the deep merging mode is enabled.
+// parseNumber parses an integer or a floating point number
+func (p *intrinsicParser) parseNumber() (intrinsicArgument, error) {
+	start := p.pos
+	p.pos++
+	for !p.isEnd() && strings.ContainsRune("0123456789.eE+-", rune(p.expression[p.pos])) {
+		p.pos++
+	}
+	raw := p.expression[start:p.pos]
+	if integer, err := strconv.Atoi(raw); err == nil {
+		return &intrinsicLiteral{value: integer}, nil
+	}
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, p.errorAt(start, "invalid number %s", raw)
+	}
+	return &intrinsicLiteral{value: number}, nil
+}
 
-Currently, Step Functions only supports the shallow merging mode; therefore, you must specify the boolean value as false. In the shallow mode, if the same key exists in both JSON objects, the latter object's key overrides the same key in the first object. Additionally, objects nested within a JSON object aren't merged when you use shallow merging.
+// toArray returns the value as an array, if it is a slice
+func toArray(value interface{}) ([]interface{}, bool) {
+	if array, ok := value.([]interface{}); ok {
+		return array, true
+	}
+	if value == nil || reflect.TypeOf(value).Kind() != reflect.Slice {
+		return nil, false
+	}
+	slice := reflect.ValueOf(value)
+	array := make([]interface{}, slice.Len())
+	for i := range array {
+		array[i] = slice.Index(i).Interface()
+	}
+	return array, true
+}
 
-For example, you can use the States.JsonMerge function to merge the following JSON arrays that share the key a.
+// toInteger returns the value as an int, if it is a number without a fractional part
+func toInteger(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float32:
+		return toInteger(float64(v))
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
 
-{ "json1": { "a": {"a1": 1, "a2": 2}, "b": 2, }, "json2": { "a": {"a3": 1, "a4": 2}, "c": 3 } }
-You can specify the json1 and json2 arrays as inputs in the States.JasonMerge function to merge them together:
+func arrayArgument(args []interface{}, i int) ([]interface{}, error) {
+	array, ok := toArray(args[i])
+	if !ok {
+		return nil, fmt.Errorf("argument %d must be an array, but is %v", i+1, args[i])
+	}
+	return array, nil
+}
 
-"output.$": "States.JsonMerge($.json1, $.json2, false)"
-The States.JsonMerge returns the following merged JSON object as result. In the merged JSON object output, the json2 object's key a replaces the json1 object's key a. Also, the nested object in json1 object's key a is discarded because shallow mode doesn't support merging nested objects.
+func integerArgument(args []interface{}, i int) (int, error) {
+	integer, ok := toInteger(args[i])
+	if !ok {
+		return 0, fmt.Errorf("argument %d must be an integer, but is %v", i+1, args[i])
+	}
+	return integer, nil
+}
 
-{ "output": { "a": {"a3": 1, "a4": 2}, "b": 2, "c": 3 } }
-States.MathRandom
-Use the States.MathRandom intrinsic function to return a random number between the specified start and end number. For example, you can use this function to distribute a specific task between two or more resources.
+func stringArgument(args []interface{}, i int) (string, error) {
+	str, ok := args[i].(string)
+	if !ok {
+		return "", fmt.Errorf("argument %d must be a string, but is %v", i+1, args[i])
+	}
+	return str, nil
+}
 
-This function takes three arguments. The first argument is the start number, the second argument is the end number, and the last argument controls the seed value. The seed value argument is optional.
+func objectArgument(args []interface{}, i int) (map[string]interface{}, error) {
+	object, ok := args[i].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("argument %d must be a JSON object, but is %v", i+1, args[i])
+	}
+	return object, nil
+}
 
-If you use this function with the same seed value, it returns an identical number.
+// jsonEquals compares two values by their JSON representation, so that numbers of different types are equal
+func jsonEquals(a interface{}, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
 
-Important
+// formatValue returns the natural string representation of a value, as replaced by States.Format
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case int, int32, int64:
+		return fmt.Sprintf("%d", v), nil
+	}
+	return "", fmt.Errorf("cannot format %v: only strings, numbers, booleans and null are allowed", value)
+}
 
-Because the States.MathRandom function doesn't return cryptographically secure random numbers, we recommend that you don't use it for security sensitive applications.
+func intrinsicFormat(args []interface{}) (interface{}, error) {
+	var template string
+	escapes := false
+	switch t := args[0].(type) {
+	case formatTemplate:
+		template = string(t)
+		escapes = true
+	case string:
+		template = t
+	default:
+		return nil, fmt.Errorf("argument 1 must be a string, but is %v", args[0])
+	}
+
+	var formatted strings.Builder
+	next := 1
+	for i := 0; i < len(template); i++ {
+		switch {
+		case escapes && template[i] == '\\' && i+1 < len(template):
+			i++
+			formatted.WriteByte(template[i])
+		case template[i] == '{' && i+1 < len(template) && template[i+1] == '}':
+			if next >= len(args) {
+				return nil, fmt.Errorf("the template has more placeholders than the %d argument(s)", len(args)-1)
+			}
+			value, err := formatValue(args[next])
+			if err != nil {
+				return nil, err
+			}
+			formatted.WriteString(value)
+			next++
+			i++
+		default:
+			formatted.WriteByte(template[i])
+		}
+	}
+	if next != len(args) {
+		return nil, fmt.Errorf("the template has %d placeholder(s), but %d argument(s) are given", next-1, len(args)-1)
+	}
+	return formatted.String(), nil
+}
 
-Input validation
+func intrinsicStringToJson(args []interface{}) (interface{}, error) {
+	str, err := stringArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(str), &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON %s: %v", str, err)
+	}
+	return value, nil
+}
 
-You must specify integer values for the start number and end number arguments.
-For example, to generate a random number from between one and 999, you can use the following input values:
+func intrinsicJsonToString(args []interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
 
-{ "start": 1, "end": 999 }
-To generate the random number, provide the start and end values to the States.MathRandom function:
+func intrinsicArray(args []interface{}) (interface{}, error) {
+	return append(make([]interface{}, 0, len(args)), args...), nil
+}
 
-"random.$": "States.MathRandom($.start, $.end)"
-The States.MathRandom function returns the following random number as a response:
+func intrinsicArrayPartition(args []interface{}) (interface{}, error) {
+	array, err := arrayArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	size, err := integerArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("the chunk size must be positive, but is %d", size)
+	}
+
+	chunks := make([]interface{}, 0, (len(array)+size-1)/size)
+	for start := 0; start < len(array); start += size {
+		end := min(start+size, len(array))
+		chunks = append(chunks, append(make([]interface{}, 0, end-start), array[start:end]...))
+	}
+	return chunks, nil
+}
 
-{"random": 456 }
-States.MathAdd
-Use the States.MathAdd intrinsic function to return the sum of two numbers. For example, you can use this function to increment values inside a loop without invoking a Lambda function.
+func intrinsicArrayContains(args []interface{}) (interface{}, error) {
+	array, err := arrayArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range array {
+		if jsonEquals(item, args[1]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-Input validation
+func intrinsicArrayRange(args []interface{}) (interface{}, error) {
+	bounds := make([]int, 3)
+	for i := range bounds {
+		value, err := integerArgument(args, i)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = value
+	}
+	start, end, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return nil, fmt.Errorf("the step must not be 0")
+	}
+
+	length := 0
+	if (step > 0 && end >= start) || (step < 0 && end <= start) {
+		length = (end-start)/step + 1
+	}
+	if length > maxArrayRangeLength {
+		return nil, fmt.Errorf("the array would contain %d items, but at most %d are allowed", length, maxArrayRangeLength)
+	}
+	array := make([]interface{}, length)
+	for i := range array {
+		array[i] = start + i*step
+	}
+	return array, nil
+}
 
-You must specify integer values for all the arguments.
-For example, you can use the following values to subtract one from 111:
+func intrinsicArrayGetItem(args []interface{}) (interface{}, error) {
+	array, err := arrayArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	index, err := integerArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(array) {
+		return nil, fmt.Errorf("index %d out of bounds for an array of %d items", index, len(array))
+	}
+	return array[index], nil
+}
 
-{ "value1": 111, "step": -1 }
-Then, use the States.MathAdd function defining value1 as the starting value, and step as the value to increment value1 by:
+func intrinsicArrayLength(args []interface{}) (interface{}, error) {
+	array, err := arrayArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return len(array), nil
+}
 
-"value1.$": "States.MathAdd($.value1, $.step)"
-The States.MathAdd function would return the following number in response:
+func intrinsicArrayUnique(args []interface{}) (interface{}, error) {
+	array, err := arrayArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	// the first occurrence of each item is kept, in the original order
+	unique := make([]interface{}, 0, len(array))
+	seen := make(map[string]bool, len(array))
+	for _, item := range array {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[string(encoded)] {
+			seen[string(encoded)] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique, nil
+}
 
-{"value1": 110 }
-States.StringSplit
-Use the States.StringSplit intrinsic function to split a string into an array of values. This function takes two arguments.The first argument is a string and the second argument is the delimiting character that the function will use to divide the string.
+func intrinsicBase64Encode(args []interface{}) (interface{}, error) {
+	str, err := stringArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(str) > maxIntrinsicStringLength {
+		return nil, fmt.Errorf("the string to encode is longer than %d characters", maxIntrinsicStringLength)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(str)), nil
+}
 
-For example, you can use States.StringSplit to divide the following inputString, which contains a series of comma separated values:
+func intrinsicBase64Decode(args []interface{}) (interface{}, error) {
+	str, err := stringArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(str) > maxIntrinsicStringLength {
+		return nil, fmt.Errorf("the string to decode is longer than %d characters", maxIntrinsicStringLength)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 string: %v", err)
+	}
+	return string(decoded), nil
+}
 
-{ "inputString": "1,2,3,4,5", "splitter": "," }
-Use the States.StringSplit function and define inputString as the first argument, and the delimiting character splitter as the second argument:
+func intrinsicHash(args []interface{}) (interface{}, error) {
+	data, err := stringArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxIntrinsicStringLength {
+		return nil, fmt.Errorf("the data to hash is longer than %d characters", maxIntrinsicStringLength)
+	}
+	algorithm, err := stringArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var h hash.Hash
+	switch algorithm {
+	case "MD5":
+		h = md5.New()
+	case "SHA-1":
+		h = sha1.New()
+	case "SHA-256":
+		h = sha256.New()
+	case "SHA-384":
+		h = sha512.New384()
+	case "SHA-512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %s: use MD5, SHA-1, SHA-256, SHA-384 or SHA-512", algorithm)
+	}
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
 
-"array.$": "States.StringSplit($.inputString, $.splitter)"
-The States.StringSplit function returns the following string array as result:
+func intrinsicJsonMerge(args []interface{}) (interface{}, error) {
+	first, err := objectArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	second, err := objectArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	deep, ok := args[2].(bool)
+	if !ok {
+		return nil, fmt.Errorf("argument 3 must be a boolean, but is %v", args[2])
+	}
+	if deep {
+		return nil, fmt.Errorf("deep merging is not supported")
+	}
+
+	merged := maps.Clone(first)
+	maps.Copy(merged, second)
+	return merged, nil
+}
 
-{"array": ["1","2","3","4","5"] }
-States.UUID
-Use the States.UUID intrinsic function to return a version 4 universally unique identifier (v4 UUID) generated using random numbers. For example, you can use this function to call other AWS services or resources that need a UUID parameter or insert items in a DynamoDB table.
+func intrinsicMathRandom(args []interface{}) (interface{}, error) {
+	start, err := integerArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := integerArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if start > end {
+		return nil, fmt.Errorf("the start number %d is greater than the end number %d", start, end)
+	}
+
+	random := mathrand.Intn
+	if len(args) == 3 {
+		seed, err := integerArgument(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		random = mathrand.New(mathrand.NewSource(int64(seed))).Intn
+	}
+	return start + random(end-start+1), nil
+}
 
-The States.UUID function is called with no arguments specified:
+func intrinsicMathAdd(args []interface{}) (interface{}, error) {
+	a, err := integerArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	b, err := integerArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return a + b, nil
+}
 
-"uuid.$": "States.UUID()"
-The function returns a randomly generated UUID, as in the following example:
+func intrinsicStringSplit(args []interface{}) (interface{}, error) {
+	str, err := stringArgument(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	delimiters, err := stringArgument(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	// each character of the second argument is a delimiter, and empty strings are discarded
+	fields := strings.FieldsFunc(str, func(r rune) bool {
+		return strings.ContainsRune(delimiters, r)
+	})
+	array := make([]interface{}, len(fields))
+	for i, field := range fields {
+		array[i] = field
+	}
+	return array, nil
+}
 
-{"uuid": "ca4c1140-dcc1-40cd-ad05-7b4aa23df4a8" }
-*/
+func intrinsicUUID(args []interface{}) (interface{}, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return nil, err
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC 4122 variant
+	encoded := hex.EncodeToString(uuid)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", encoded[0:8], encoded[8:12], encoded[12:16], encoded[16:20], encoded[20:]), nil
+}
@@ -1,6 +1,7 @@
 package asl
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/buger/jsonparser"
@@ -76,11 +77,15 @@ func JsonTryExtractRefPath(json []byte, key string) (Path, error) {
 	return path, nil
 }
 
+// JsonExtractRefPathOrDefault extracts a path with the specified key. If the value is null, returns NullPath
 func JsonExtractRefPathOrDefault(json []byte, key string, def Path) Path {
-	value, _, _, err := jsonparser.Get(json, key)
+	value, dataType, _, err := jsonparser.Get(json, key)
 	if err != nil {
 		return def
 	}
+	if dataType == jsonparser.Null {
+		return NullPath
+	}
 	path, errO := NewReferencePath(string(value))
 	if errO != nil {
 		return def
@@ -126,3 +131,34 @@ func JsonNumberOfKeys(json []byte) int {
 	})
 	return num
 }
+
+// JsonExtractStringArray extracts an array of strings with the specified key. If key does not exist, returns an empty array
+func JsonExtractStringArray(json []byte, key string) ([]string, error) {
+	strings := make([]string, 0)
+	value, dataType, _, err := jsonparser.Get(json, key)
+	if dataType == jsonparser.NotExist {
+		return strings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dataType != jsonparser.Array {
+		return nil, fmt.Errorf("%s is not an array", key)
+	}
+
+	var errElement error
+	_, err = jsonparser.ArrayEach(value, func(element []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if dataType != jsonparser.String {
+			errElement = fmt.Errorf("%s contains a value that is not a string: %s", key, element)
+			return
+		}
+		strings = append(strings, string(element))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if errElement != nil {
+		return nil, errElement
+	}
+	return strings, nil
+}
@@ -1,6 +1,10 @@
 package asl
 
-import "github.com/serverledge-faas/serverledge/internal/types"
+import (
+	"fmt"
+
+	"github.com/serverledge-faas/serverledge/internal/types"
+)
 
 type MapState struct {
 	Type      StateType
@@ -12,9 +16,9 @@ type MapState struct {
 	// ItemReader is an object that specifies where to read the items instead of from the effective input
 	ItemReader *ItemReaderConf // optional
 	// Parameters is like ItemSelector (but is deprecated)
-	Parameters string
-	// ItemSelector is an object that overrides each single element of the item array
-	ItemSelector string // optional
+	Parameters PayloadTemplate
+	// ItemSelector is a payload template that overrides each single element of the item array
+	ItemSelector PayloadTemplate // optional
 	// ItemBatcher is an object that specifies how to batch the items for the ItemProcessor
 	ItemBatcher string // optional
 	// ResultWriter is an object that specifies where to write the results instead of to the Map state's result
@@ -25,6 +29,12 @@ type MapState struct {
 	ToleratedFailurePercentage uint8
 	// ToleratedFailureCount is an integer that provides an upper bound on how many items may fail
 	ToleratedFailureCount uint8
+	// ResultSelector is a payload template applied to the array of results
+	ResultSelector PayloadTemplate // optional
+	// ResultPath is a Reference Path identifying where the array of results is placed in the raw input
+	ResultPath Path // optional
+	// OutputPath is a Path selecting the output of the state
+	OutputPath Path // optional
 	// Next is the name of the next state to execute
 	Next string
 	// End if true, we do not need a Next
@@ -32,8 +42,37 @@ type MapState struct {
 }
 
 func (m *MapState) Validate(stateNames []string) error {
-	//TODO implement me
-	panic("implement me")
+	if m.ItemProcessor == nil {
+		return fmt.Errorf("ItemProcessor field is mandatory for a map state, but it is not defined")
+	}
+	if m.End == false && m.Next == "" {
+		return fmt.Errorf("next field is mandatory for a non-terminal map state, but it is not defined")
+	}
+	if m.End == true && m.Next != "" {
+		return fmt.Errorf("next field should not be defined for a terminal map state, but it is")
+	}
+	if m.ItemReader != nil {
+		return fmt.Errorf("ItemReader is not supported")
+	}
+	if !m.Parameters.IsEmpty() && !m.ItemSelector.IsEmpty() {
+		return fmt.Errorf("Parameters and ItemSelector cannot be set at the same time")
+	}
+	if err := ValidateDataProcessing(m.InputPath, m.GetItemSelector(), m.ResultSelector, m.ResultPath, m.OutputPath); err != nil {
+		return err
+	}
+	if err := m.ItemsPath.check(true); err != nil {
+		return fmt.Errorf("invalid ItemsPath: %v", err)
+	}
+
+	// states of the ItemProcessor can only transition to other states of the ItemProcessor
+	err := m.ItemProcessor.Validate(m.ItemProcessor.GetAllStateNames())
+	if err != nil {
+		return fmt.Errorf("invalid ItemProcessor: %v", err)
+	}
+	if _, found := m.ItemProcessor.States[m.ItemProcessor.StartAt]; !found {
+		return fmt.Errorf("invalid ItemProcessor: StartAt state %s is not defined", m.ItemProcessor.StartAt)
+	}
+	return nil
 }
 
 func (m *MapState) IsEndState() bool {
@@ -47,8 +86,8 @@ func NewEmptyMap() *MapState {
 		ItemsPath:                  "",
 		ItemProcessor:              nil,
 		ItemReader:                 nil,
-		Parameters:                 "",
-		ItemSelector:               "",
+		Parameters:                 PayloadTemplate{},
+		ItemSelector:               PayloadTemplate{},
 		ItemBatcher:                "",
 		ResultWriter:               "",
 		MaxConcurrency:             0,
@@ -84,13 +123,87 @@ func (m *MapState) GetResources() []string {
 }
 
 func (m *MapState) Equals(cmp types.Comparable) bool {
-	m2 := cmp.(*MapState)
-	return m.Type == m2.Type
+	m2, ok := cmp.(*MapState)
+	if !ok {
+		return false
+	}
+	if (m.ItemProcessor == nil) != (m2.ItemProcessor == nil) {
+		return false
+	}
+	if m.ItemProcessor != nil && !m.ItemProcessor.Equals(m2.ItemProcessor) {
+		return false
+	}
+	return m.Type == m2.Type &&
+		m.InputPath == m2.InputPath &&
+		m.ItemsPath == m2.ItemsPath &&
+		m.Parameters == m2.Parameters &&
+		m.ItemSelector == m2.ItemSelector &&
+		m.ResultSelector == m2.ResultSelector &&
+		m.ResultPath == m2.ResultPath &&
+		m.OutputPath == m2.OutputPath &&
+		m.MaxConcurrency == m2.MaxConcurrency &&
+		m.Next == m2.Next &&
+		m.End == m2.End
 }
 
 func (m *MapState) ParseFrom(jsonData []byte) (State, error) {
-	//TODO implement me
-	panic("implement me")
+	m.Type = Map
+	m.Next = JsonExtractStringOrDefault(jsonData, "Next", "")
+	m.End = JsonExtractBool(jsonData, "End")
+	m.InputPath = JsonExtractRefPathOrDefault(jsonData, "InputPath", "")
+	m.ItemsPath = JsonExtractRefPathOrDefault(jsonData, "ItemsPath", "")
+	m.ResultPath = JsonExtractRefPathOrDefault(jsonData, "ResultPath", "")
+	m.OutputPath = JsonExtractRefPathOrDefault(jsonData, "OutputPath", "")
+	var err error
+	m.Parameters, err = parsePayloadTemplate(jsonData, "Parameters")
+	if err != nil {
+		return nil, err
+	}
+	m.ItemSelector, err = parsePayloadTemplate(jsonData, "ItemSelector")
+	if err != nil {
+		return nil, err
+	}
+	m.ResultSelector, err = parsePayloadTemplate(jsonData, "ResultSelector")
+	if err != nil {
+		return nil, err
+	}
+	m.ItemBatcher = JsonExtractStringOrDefault(jsonData, "ItemBatcher", "")
+	m.ResultWriter = JsonExtractStringOrDefault(jsonData, "ResultWriter", "")
+
+	maxConcurrency := JsonExtractIntOrDefault(jsonData, "MaxConcurrency", 0)
+	if maxConcurrency < 0 {
+		return nil, fmt.Errorf("MaxConcurrency must be a non-negative integer: %d", maxConcurrency)
+	}
+	m.MaxConcurrency = uint32(maxConcurrency)
+	m.ToleratedFailurePercentage = uint8(JsonExtractIntOrDefault(jsonData, "ToleratedFailurePercentage", 0))
+	m.ToleratedFailureCount = uint8(JsonExtractIntOrDefault(jsonData, "ToleratedFailureCount", 0))
+
+	if _, err := JsonExtract(jsonData, "ItemReader"); err == nil {
+		m.ItemReader = &ItemReaderConf{}
+	}
+
+	// Iterator is the deprecated name of ItemProcessor
+	processor, err := JsonExtract(jsonData, "ItemProcessor")
+	if err != nil {
+		processor, err = JsonExtract(jsonData, "Iterator")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ItemProcessor: %v", err)
+		}
+	}
+	m.ItemProcessor, err = ParseFrom("ItemProcessor", processor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ItemProcessor: %v", err)
+	}
+
+	return m, nil
+}
+
+// GetItemSelector returns the ItemSelector, or the deprecated Parameters if the ItemSelector is not set
+func (m *MapState) GetItemSelector() PayloadTemplate {
+	if m.ItemSelector.IsEmpty() {
+		return m.Parameters
+	}
+	return m.ItemSelector
 }
 
 func (m *MapState) GetNext() (string, bool) {
@@ -105,5 +218,18 @@ func (m *MapState) GetType() StateType {
 }
 
 func (m *MapState) String() string {
-	return "Map"
+	str := fmt.Sprint("{",
+		"\n\t\t\tType: ", m.Type,
+		"\n\t\t\tItemsPath: ", m.ItemsPath,
+		"\n\t\t\tMaxConcurrency: ", m.MaxConcurrency,
+		"\n\t\t\tItemProcessor: ", m.ItemProcessor,
+		"\n")
+	if m.Next != "" {
+		str += fmt.Sprintf("\t\t\tNext: %s\n", m.Next)
+	}
+	if m.End != false {
+		str += fmt.Sprintf("\t\t\tEnd: %v\n", m.End)
+	}
+	str += "\t\t}"
+	return str
 }
@@ -1,17 +1,52 @@
 package asl
 
-import "github.com/serverledge-faas/serverledge/internal/types"
+import (
+	"fmt"
+	"strings"
 
+	"github.com/buger/jsonparser"
+	"github.com/serverledge-faas/serverledge/internal/types"
+)
+
+// ParallelState executes all its Branches concurrently. Its output is an array with one element for each branch,
+// in the same order as the Branches.
 type ParallelState struct {
-	Type     StateType
-	Branches []*StateMachine
-	Next     string
-	End      bool
+	Type           StateType
+	Branches       []*StateMachine
+	InputPath      Path            // Optional
+	Parameters     PayloadTemplate // Optional
+	ResultSelector PayloadTemplate // Optional
+	ResultPath     Path            // Optional
+	OutputPath     Path            // Optional
+	Next           string
+	End            bool
 }
 
 func (p *ParallelState) Validate(stateNames []string) error {
-	//TODO implement me
-	panic("implement me")
+	if len(p.Branches) == 0 {
+		return fmt.Errorf("a parallel state must have at least one branch")
+	}
+	if p.End == false && p.Next == "" {
+		return fmt.Errorf("next field is mandatory for a non-terminal parallel state, but it is not defined")
+	}
+	if p.End == true && p.Next != "" {
+		return fmt.Errorf("next field should not be defined for a terminal parallel state, but it is")
+	}
+	if err := ValidateDataProcessing(p.InputPath, p.Parameters, p.ResultSelector, p.ResultPath, p.OutputPath); err != nil {
+		return err
+	}
+
+	for i, branch := range p.Branches {
+		// states within a branch can only transition to other states in the same branch
+		err := branch.Validate(branch.GetAllStateNames())
+		if err != nil {
+			return fmt.Errorf("invalid branch %d: %v", i, err)
+		}
+		if _, found := branch.States[branch.StartAt]; !found {
+			return fmt.Errorf("invalid branch %d: StartAt state %s is not defined", i, branch.StartAt)
+		}
+	}
+	return nil
 }
 
 func (p *ParallelState) IsEndState() bool {
@@ -27,8 +62,26 @@ func (p *ParallelState) GetResources() []string {
 }
 
 func (p *ParallelState) Equals(cmp types.Comparable) bool {
-	p2 := cmp.(*ParallelState)
-	return p.Type == p2.Type
+	p2, ok := cmp.(*ParallelState)
+	if !ok {
+		return false
+	}
+	if len(p.Branches) != len(p2.Branches) {
+		return false
+	}
+	for i := range p.Branches {
+		if !p.Branches[i].Equals(p2.Branches[i]) {
+			return false
+		}
+	}
+	return p.Type == p2.Type &&
+		p.InputPath == p2.InputPath &&
+		p.Parameters == p2.Parameters &&
+		p.ResultSelector == p2.ResultSelector &&
+		p.ResultPath == p2.ResultPath &&
+		p.OutputPath == p2.OutputPath &&
+		p.Next == p2.Next &&
+		p.End == p2.End
 }
 
 func NewEmptyParallel() *ParallelState {
@@ -41,8 +94,48 @@ func NewEmptyParallel() *ParallelState {
 }
 
 func (p *ParallelState) ParseFrom(jsonData []byte) (State, error) {
-	//TODO implement me
-	panic("implement me")
+	p.Type = Parallel
+	p.Next = JsonExtractStringOrDefault(jsonData, "Next", "")
+	p.End = JsonExtractBool(jsonData, "End")
+	p.InputPath = JsonExtractRefPathOrDefault(jsonData, "InputPath", "")
+	p.ResultPath = JsonExtractRefPathOrDefault(jsonData, "ResultPath", "")
+	p.OutputPath = JsonExtractRefPathOrDefault(jsonData, "OutputPath", "")
+	var err error
+	p.Parameters, err = parsePayloadTemplate(jsonData, "Parameters")
+	if err != nil {
+		return nil, err
+	}
+	p.ResultSelector, err = parsePayloadTemplate(jsonData, "ResultSelector")
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := JsonExtract(jsonData, "Branches")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Branches: %v", err)
+	}
+
+	var errBranch error
+	_, err = jsonparser.ArrayEach(branches, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if errBranch != nil {
+			return
+		}
+		// each branch is a complete state machine
+		branch, errParse := ParseFrom(fmt.Sprintf("branch%d", len(p.Branches)), value)
+		if errParse != nil {
+			errBranch = fmt.Errorf("failed to parse branch %d: %v", len(p.Branches), errParse)
+			return
+		}
+		p.Branches = append(p.Branches, branch)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Branches: %v", err)
+	}
+	if errBranch != nil {
+		return nil, errBranch
+	}
+
+	return p, nil
 }
 
 func (p *ParallelState) GetNext() (string, bool) {
@@ -56,7 +149,22 @@ func (p *ParallelState) GetType() StateType {
 	return Parallel
 }
 
-// FIXME: improve
 func (p *ParallelState) String() string {
-	return "Parallel"
+	branches := make([]string, 0, len(p.Branches))
+	for _, branch := range p.Branches {
+		branches = append(branches, branch.String())
+	}
+
+	str := fmt.Sprint("{",
+		"\n\t\t\tType: ", p.Type,
+		"\n\t\t\tBranches: [", strings.Join(branches, ", "), "]",
+		"\n")
+	if p.Next != "" {
+		str += fmt.Sprintf("\t\t\tNext: %s\n", p.Next)
+	}
+	if p.End != false {
+		str += fmt.Sprintf("\t\t\tEnd: %v\n", p.End)
+	}
+	str += "\t\t}"
+	return str
 }
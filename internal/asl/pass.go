@@ -1,16 +1,37 @@
 package asl
 
-import "github.com/serverledge-faas/serverledge/internal/types"
+import (
+	"encoding/json"
+	"fmt"
 
+	"github.com/buger/jsonparser"
+	"github.com/serverledge-faas/serverledge/internal/types"
+)
+
+// PassState passes its input to its output, without performing work. If Result is set, it is used as the result
+// of the state, which is placed at ResultPath as for Task states.
 type PassState struct {
-	Type StateType
-	Next string
-	End  bool
+	Type       StateType
+	Result     string          // Optional, any JSON value
+	InputPath  Path            // Optional
+	Parameters PayloadTemplate // Optional
+	ResultPath Path            // Optional
+	OutputPath Path            // Optional
+	Next       string
+	End        bool
 }
 
 func (p *PassState) Validate(stateNames []string) error {
-	//TODO implement me
-	panic("implement me")
+	if p.End == false && p.Next == "" {
+		return fmt.Errorf("next field is mandatory for a non-terminal pass state, but it is not defined")
+	}
+	if p.End == true && p.Next != "" {
+		return fmt.Errorf("next field should not be defined for a terminal pass state, but it is")
+	}
+	if p.Result != "" && !json.Valid([]byte(p.Result)) {
+		return fmt.Errorf("invalid Result: %s", p.Result)
+	}
+	return ValidateDataProcessing(p.InputPath, p.Parameters, PayloadTemplate{}, p.ResultPath, p.OutputPath)
 }
 
 func (p *PassState) IsEndState() bool {
@@ -18,8 +39,18 @@ func (p *PassState) IsEndState() bool {
 }
 
 func (p *PassState) Equals(cmp types.Comparable) bool {
-	p2 := cmp.(*PassState)
-	return p.Type == p2.Type && p.Next == p2.Next && p.End == p2.End
+	p2, ok := cmp.(*PassState)
+	if !ok {
+		return false
+	}
+	return p.Type == p2.Type &&
+		p.Result == p2.Result &&
+		p.InputPath == p2.InputPath &&
+		p.Parameters == p2.Parameters &&
+		p.ResultPath == p2.ResultPath &&
+		p.OutputPath == p2.OutputPath &&
+		p.Next == p2.Next &&
+		p.End == p2.End
 }
 
 func NewEmptyPass() *PassState {
@@ -31,8 +62,27 @@ func NewEmptyPass() *PassState {
 }
 
 func (p *PassState) ParseFrom(jsonData []byte) (State, error) {
-	//TODO implement me
-	panic("implement me")
+	p.Type = Pass
+	p.Next = JsonExtractStringOrDefault(jsonData, "Next", "")
+	p.End = JsonExtractBool(jsonData, "End")
+	// the raw JSON value is kept, as the Result can be of any type
+	result, dataType, _, err := jsonparser.Get(jsonData, "Result")
+	if err == nil {
+		if dataType == jsonparser.String {
+			// strings are returned without quotes
+			p.Result = `"` + string(result) + `"`
+		} else {
+			p.Result = string(result)
+		}
+	}
+	p.InputPath = JsonExtractRefPathOrDefault(jsonData, "InputPath", "")
+	p.ResultPath = JsonExtractRefPathOrDefault(jsonData, "ResultPath", "")
+	p.OutputPath = JsonExtractRefPathOrDefault(jsonData, "OutputPath", "")
+	p.Parameters, err = parsePayloadTemplate(jsonData, "Parameters")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
 func (p *PassState) GetNext() (string, bool) {
@@ -47,6 +97,30 @@ func (p *PassState) GetType() StateType {
 }
 
 func (p *PassState) String() string {
-	//TODO implement me
-	panic("implement me")
+	str := fmt.Sprint("{",
+		"\n\t\t\tType: ", p.Type,
+		"\n")
+	if p.Result != "" {
+		str += fmt.Sprintf("\t\t\tResult: %s\n", p.Result)
+	}
+	if p.InputPath != "" {
+		str += fmt.Sprintf("\t\t\tInputPath: %s\n", p.InputPath)
+	}
+	if !p.Parameters.IsEmpty() {
+		str += fmt.Sprintf("\t\t\tParameters: %s\n", p.Parameters)
+	}
+	if p.ResultPath != "" {
+		str += fmt.Sprintf("\t\t\tResultPath: %s\n", p.ResultPath)
+	}
+	if p.OutputPath != "" {
+		str += fmt.Sprintf("\t\t\tOutputPath: %s\n", p.OutputPath)
+	}
+	if p.Next != "" {
+		str += fmt.Sprintf("\t\t\tNext: %s\n", p.Next)
+	}
+	if p.End != false {
+		str += fmt.Sprintf("\t\t\tEnd: %v\n", p.End)
+	}
+	str += "\t\t}"
+	return str
 }
@@ -2,8 +2,13 @@ package asl
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
 // Path is a string beginning with "$", used to identify components in a JSON text, in JSONPath format.
@@ -16,7 +21,7 @@ func NewReferencePath(s string) (Path, error) {
 	if s == "" || s == "$" {
 		return Path(s), nil
 	}
-	if !strings.HasPrefix(s, "$.") {
+	if !strings.HasPrefix(s, "$") {
 		s = "$." + s
 	}
 	if strings.Contains(s, "@") || strings.Contains(s, ",") || strings.Contains(s, ":") || strings.Contains(s, "?") {
@@ -47,3 +52,229 @@ func RemoveDollar(s string) string {
 	}
 	return s
 }
+
+// NullPath is the value of an optional path explicitly set to null. For example, "ResultPath": null discards the
+// result of a state, while "InputPath": null and "OutputPath": null give an empty object.
+const NullPath Path = "null"
+
+// pathStep is a single step of a parsed path: an object field, an array index or a wildcard
+type pathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parse splits the path in steps. Supported steps are .field, ['field'], [index] (negative indexes count from the
+// end of the array), .* and [*].
+func (p Path) parse() ([]pathStep, error) {
+	s := string(p)
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("path %s does not start with '$'", p)
+	}
+	steps := make([]pathStep, 0)
+	i := 1
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			if i < len(s) && s[i] == '.' {
+				return nil, fmt.Errorf("recursive descent is not supported in path %s", p)
+			}
+			if i < len(s) && s[i] == '*' {
+				steps = append(steps, pathStep{wildcard: true})
+				i++
+				continue
+			}
+			end := i
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("empty field name in path %s", p)
+			}
+			steps = append(steps, pathStep{field: s[i:end]})
+			i = end
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in path %s", p)
+			}
+			selector := s[i+1 : i+end]
+			i += end + 1
+			switch {
+			case selector == "*":
+				steps = append(steps, pathStep{wildcard: true})
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				steps = append(steps, pathStep{field: selector[1 : len(selector)-1]})
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("unsupported selector [%s] in path %s", selector, p)
+				}
+				steps = append(steps, pathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' in path %s", s[i], p)
+		}
+	}
+	return steps, nil
+}
+
+// check returns an error if the path cannot be parsed. Reference paths identify a single value, so they cannot
+// contain wildcards.
+func (p Path) check(isReference bool) error {
+	if p == "" || p == NullPath {
+		return nil
+	}
+	steps, err := p.parse()
+	if err != nil {
+		return err
+	}
+	if isReference {
+		for _, step := range steps {
+			if step.wildcard {
+				return fmt.Errorf("reference path %s cannot contain wildcards", p)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the value selected by the path in the data. If the path contains wildcards, the selected values
+// are returned in an array. The empty path selects the whole data.
+func (p Path) Evaluate(data interface{}) (interface{}, error) {
+	if p == "" || p == "$" {
+		return data, nil
+	}
+	steps, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{data}
+	definite := true
+	for _, step := range steps {
+		selected := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			switch {
+			case step.wildcard:
+				definite = false
+				switch v := value.(type) {
+				case map[string]interface{}:
+					// fields are sorted, so that the result does not depend on the order of the map
+					fields := make([]string, 0, len(v))
+					for field := range v {
+						fields = append(fields, field)
+					}
+					sort.Strings(fields)
+					for _, field := range fields {
+						selected = append(selected, v[field])
+					}
+				case []interface{}:
+					selected = append(selected, v...)
+				}
+			case step.isIndex:
+				array, ok := value.([]interface{})
+				if !ok {
+					if definite {
+						return nil, fmt.Errorf("cannot evaluate path %s: [%d] is not applied to an array", p, step.index)
+					}
+					continue
+				}
+				index := step.index
+				if index < 0 {
+					index += len(array)
+				}
+				if index < 0 || index >= len(array) {
+					if definite {
+						return nil, fmt.Errorf("cannot evaluate path %s: index %d out of bounds", p, step.index)
+					}
+					continue
+				}
+				selected = append(selected, array[index])
+			default:
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					if definite {
+						return nil, fmt.Errorf("cannot evaluate path %s: %s is not a field of an object", p, step.field)
+					}
+					continue
+				}
+				fieldValue, found := object[step.field]
+				if !found {
+					if definite {
+						return nil, fmt.Errorf("cannot evaluate path %s: field %s not found", p, step.field)
+					}
+					continue
+				}
+				selected = append(selected, fieldValue)
+			}
+		}
+		values = selected
+	}
+
+	if definite {
+		return values[0], nil
+	}
+	return values, nil
+}
+
+// Set returns a copy of the data, where the value is placed at the reference path. Missing fields are created,
+// while the original data is not modified. The empty path replaces the whole data with the value.
+func (p Path) Set(data interface{}, value interface{}) (interface{}, error) {
+	if p == "" || p == "$" {
+		return value, nil
+	}
+	steps, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return setPath(p, data, steps, value)
+}
+
+func setPath(p Path, data interface{}, steps []pathStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+	step := steps[0]
+	switch {
+	case step.wildcard:
+		return nil, fmt.Errorf("cannot set a value at path %s: wildcards are not allowed", p)
+	case step.isIndex:
+		array, ok := data.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot set a value at path %s: [%d] is not applied to an array", p, step.index)
+		}
+		index := step.index
+		if index < 0 {
+			index += len(array)
+		}
+		if index < 0 || index >= len(array) {
+			return nil, fmt.Errorf("cannot set a value at path %s: index %d out of bounds", p, step.index)
+		}
+		nested, err := setPath(p, array[index], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		copied := slices.Clone(array)
+		copied[index] = nested
+		return copied, nil
+	default:
+		var object map[string]interface{}
+		switch v := data.(type) {
+		case map[string]interface{}:
+			object = maps.Clone(v)
+		case nil:
+			object = make(map[string]interface{})
+		default:
+			return nil, fmt.Errorf("cannot set a value at path %s: %s is not a field of an object", p, step.field)
+		}
+		nested, err := setPath(p, object[step.field], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		object[step.field] = nested
+		return object, nil
+	}
+}
@@ -1,5 +1,166 @@
 package asl
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// PayloadTemplate is a JSON object used by Parameters, ResultSelector and ItemSelector to build a new payload.
+// The fields whose name ends with ".$" are dynamic: their value is evaluated as a Path on the input (or on the
+// context object, if it starts with "$$") or as an intrinsic function, and the ".$" suffix is removed from the name.
+// For example, {"id.$": "$.order.id", "kind": "order"} applied to {"order": {"id": 1}} gives {"id": 1, "kind": "order"}.
 type PayloadTemplate struct {
 	json string
 }
+
+func NewPayloadTemplate(json string) PayloadTemplate {
+	return PayloadTemplate{json: json}
+}
+
+// parsePayloadTemplate extracts the payload template with the specified key. If key does not exist, returns an empty template
+func parsePayloadTemplate(jsonData []byte, key string) (PayloadTemplate, error) {
+	value, dataType, _, err := jsonparser.Get(jsonData, key)
+	if dataType == jsonparser.NotExist {
+		return PayloadTemplate{}, nil
+	}
+	if err != nil {
+		return PayloadTemplate{}, err
+	}
+	if dataType != jsonparser.Object {
+		return PayloadTemplate{}, fmt.Errorf("%s is not a JSON object", key)
+	}
+	return PayloadTemplate{json: string(value)}, nil
+}
+
+func (p PayloadTemplate) IsEmpty() bool {
+	return p.json == ""
+}
+
+func (p PayloadTemplate) String() string {
+	return p.json
+}
+
+// Validate checks that the template is a JSON object, and that dynamic fields contain valid paths
+func (p PayloadTemplate) Validate() error {
+	if p.IsEmpty() {
+		return nil
+	}
+	var template map[string]interface{}
+	if err := json.Unmarshal([]byte(p.json), &template); err != nil {
+		return fmt.Errorf("invalid payload template: %v", err)
+	}
+	return checkTemplate(template)
+}
+
+func checkTemplate(template interface{}) error {
+	switch t := template.(type) {
+	case map[string]interface{}:
+		for name, value := range t {
+			if !strings.HasSuffix(name, ".$") {
+				if err := checkTemplate(value); err != nil {
+					return err
+				}
+				continue
+			}
+			expression, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("the value of %s must be a path or an intrinsic function", name)
+			}
+			if strings.HasPrefix(expression, "$$") {
+				if err := Path(expression[1:]).check(false); err != nil {
+					return fmt.Errorf("invalid value of %s: %v", name, err)
+				}
+			} else if strings.HasPrefix(expression, "$") {
+				if err := Path(expression).check(false); err != nil {
+					return fmt.Errorf("invalid value of %s: %v", name, err)
+				}
+			} else if strings.HasPrefix(expression, "States.") {
+				if _, err := ParseIntrinsicFunction(expression); err != nil {
+					return fmt.Errorf("invalid value of %s: %v", name, err)
+				}
+			} else {
+				return fmt.Errorf("the value of %s must be a path or an intrinsic function: %s", name, expression)
+			}
+		}
+	case []interface{}:
+		for _, value := range t {
+			if err := checkTemplate(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Apply builds a new payload from the template, evaluating the dynamic fields on the input and on the context object
+func (p PayloadTemplate) Apply(input interface{}, context interface{}) (map[string]interface{}, error) {
+	var template map[string]interface{}
+	if err := json.Unmarshal([]byte(p.json), &template); err != nil {
+		return nil, fmt.Errorf("invalid payload template: %v", err)
+	}
+	payload, err := applyTemplate(template, input, context)
+	if err != nil {
+		return nil, err
+	}
+	return payload.(map[string]interface{}), nil
+}
+
+func applyTemplate(template interface{}, input interface{}, context interface{}) (interface{}, error) {
+	switch t := template.(type) {
+	case map[string]interface{}:
+		payload := make(map[string]interface{}, len(t))
+		for name, value := range t {
+			if !strings.HasSuffix(name, ".$") {
+				nested, err := applyTemplate(value, input, context)
+				if err != nil {
+					return nil, err
+				}
+				payload[name] = nested
+				continue
+			}
+			expression, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("the value of %s must be a path or an intrinsic function", name)
+			}
+			evaluated, err := evaluateExpression(expression, input, context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate %s: %v", name, err)
+			}
+			payload[strings.TrimSuffix(name, ".$")] = evaluated
+		}
+		return payload, nil
+	case []interface{}:
+		payload := make([]interface{}, len(t))
+		for i, value := range t {
+			nested, err := applyTemplate(value, input, context)
+			if err != nil {
+				return nil, err
+			}
+			payload[i] = nested
+		}
+		return payload, nil
+	default:
+		return template, nil
+	}
+}
+
+// evaluateExpression evaluates the value of a dynamic field
+func evaluateExpression(expression string, input interface{}, context interface{}) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(expression, "$$"):
+		return Path(expression[1:]).Evaluate(context)
+	case strings.HasPrefix(expression, "$"):
+		return Path(expression).Evaluate(input)
+	case strings.HasPrefix(expression, "States."):
+		function, err := ParseIntrinsicFunction(expression)
+		if err != nil {
+			return nil, err
+		}
+		return function.Evaluate(input, context)
+	default:
+		return nil, fmt.Errorf("%s is not a path or an intrinsic function", expression)
+	}
+}
@@ -1,30 +1,110 @@
 package asl
 
 import (
+	"fmt"
+
+	"github.com/buger/jsonparser"
 	"github.com/serverledge-faas/serverledge/internal/types"
 	"golang.org/x/exp/slices"
 )
 
-// Retry is a field in Task, Parallel and Map states which retries the state for a period or for a specified number of times
-type Retry struct {
+// Retrier is an element of the Retry field of Task, Parallel and Map states. When a state reports an error whose
+// name appears in ErrorEquals, the state is retried up to MaxAttempts times, waiting IntervalSeconds before the first
+// retry and multiplying the interval by BackoffRate after each retry. Retriers are evaluated in array order.
+type Retrier struct {
 	ErrorEquals     []string
-	IntervalSeconds int
-	BackoffRate     int
-	MaxAttempts     int
+	IntervalSeconds int     // default 1
+	BackoffRate     float64 // default 2.0
+	MaxAttempts     int     // default 3; 0 means never retry
+}
+
+func NewRetrier(errorEquals ...string) Retrier {
+	return Retrier{
+		ErrorEquals:     errorEquals,
+		IntervalSeconds: 1,
+		BackoffRate:     2.0,
+		MaxAttempts:     3,
+	}
 }
 
-func (r *Retry) Equals(cmp types.Comparable) bool {
-	r2 := cmp.(*Retry)
+func (r *Retrier) Equals(cmp types.Comparable) bool {
+	r2 := cmp.(*Retrier)
 	return slices.Equal(r.ErrorEquals, r2.ErrorEquals) &&
 		r.IntervalSeconds == r2.IntervalSeconds &&
 		r.BackoffRate == r2.BackoffRate &&
 		r.MaxAttempts == r2.MaxAttempts
 }
 
+func (r *Retrier) Validate(isLast bool) error {
+	if err := validateErrorEquals(r.ErrorEquals, isLast); err != nil {
+		return err
+	}
+	if r.IntervalSeconds < 1 {
+		return fmt.Errorf("IntervalSeconds must be a positive integer: %d", r.IntervalSeconds)
+	}
+	if r.MaxAttempts < 0 {
+		return fmt.Errorf("MaxAttempts must be a non-negative integer: %d", r.MaxAttempts)
+	}
+	if r.BackoffRate < 1.0 {
+		return fmt.Errorf("BackoffRate must be greater than or equal to 1.0: %f", r.BackoffRate)
+	}
+	return nil
+}
+
+func (r *Retrier) String() string {
+	return fmt.Sprintf("{ErrorEquals: %v, IntervalSeconds: %d, BackoffRate: %.1f, MaxAttempts: %d}",
+		r.ErrorEquals, r.IntervalSeconds, r.BackoffRate, r.MaxAttempts)
+}
+
 type CanRetry interface {
-	GetRetryOpt() Retry
+	GetRetryOpt() []Retrier
+}
+
+func NoRetry() []Retrier {
+	return []Retrier{}
+}
+
+// parseRetry parses the Retry field of a state, if any
+func parseRetry(jsonData []byte) ([]Retrier, error) {
+	retriers := NoRetry()
+	value, dataType, _, err := jsonparser.Get(jsonData, "Retry")
+	if dataType == jsonparser.NotExist {
+		return retriers, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dataType != jsonparser.Array {
+		return nil, fmt.Errorf("Retry is not an array")
+	}
+
+	var errRetrier error
+	_, err = jsonparser.ArrayEach(value, func(element []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if errRetrier != nil {
+			return
+		}
+		retrier := NewRetrier()
+		retrier.ErrorEquals, errRetrier = JsonExtractStringArray(element, "ErrorEquals")
+		if errRetrier != nil {
+			return
+		}
+		retrier.IntervalSeconds = JsonExtractIntOrDefault(element, "IntervalSeconds", retrier.IntervalSeconds)
+		retrier.MaxAttempts = JsonExtractIntOrDefault(element, "MaxAttempts", retrier.MaxAttempts)
+		if backoffRate, errFloat := jsonparser.GetFloat(element, "BackoffRate"); errFloat == nil {
+			retrier.BackoffRate = backoffRate
+		}
+		retriers = append(retriers, retrier)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Retry: %v", err)
+	}
+	if errRetrier != nil {
+		return nil, fmt.Errorf("failed to parse Retry: %v", errRetrier)
+	}
+	return retriers, nil
 }
 
-func NoRetry() *Retry {
-	return &Retry{}
+// equalRetriers returns true if both arrays contain equal Retriers, in the same order
+func equalRetriers(r1, r2 []Retrier) bool {
+	return slices.EqualFunc(r1, r2, func(a, b Retrier) bool { return a.Equals(&b) })
 }
@@ -57,8 +57,35 @@ type HasParameters interface {
 	GetParameters() Parameters
 }
 
-// ResultSelector is a reference path that indicates where to place the output relative to the raw input.
-type ResultSelector = string // TODO: what is that
+// ResultSelector is a JSON string payload template applied to the result of Task, Map and Parallel states, before
+// the result is placed at ResultPath
+type ResultSelector = string
+
+// ValidateDataProcessing checks the fields of the input and output processing of a state: InputPath and OutputPath
+// are paths, ResultPath is a reference path, and Parameters and ResultSelector are payload templates
+func ValidateDataProcessing(inputPath Path, parameters PayloadTemplate, resultSelector PayloadTemplate, resultPath Path, outputPath Path) error {
+	if err := inputPath.check(false); err != nil {
+		return fmt.Errorf("invalid InputPath: %v", err)
+	}
+	if err := parameters.Validate(); err != nil {
+		return fmt.Errorf("invalid Parameters: %v", err)
+	}
+	if err := resultSelector.Validate(); err != nil {
+		return fmt.Errorf("invalid ResultSelector: %v", err)
+	}
+	if err := resultPath.check(true); err != nil {
+		return fmt.Errorf("invalid ResultPath: %v", err)
+	}
+	if err := outputPath.check(false); err != nil {
+		return fmt.Errorf("invalid OutputPath: %v", err)
+	}
+	return nil
+}
+
+// ValidateReferencePath checks a Reference Path, which identifies a single value (e.g., the ItemsPath of a Map state)
+func ValidateReferencePath(path Path) error {
+	return path.check(true)
+}
 
 type HasResources interface {
 	// GetResources returns all function names present in the State. The implementation could return duplicate functions
@@ -114,7 +114,7 @@ func parseStates(statesData string) (map[string]State, error) {
 		parseable := emptyParsableFromType(StateType(stateType))
 		parsedState, err := parseable.ParseFrom(value)
 		if err != nil {
-			return fmt.Errorf("failed to parse state %s ...\n%v", value[:40], err)
+			return fmt.Errorf("failed to parse state %s: %v", key, err)
 		}
 		states[string(key)] = parsedState
 		return nil
@@ -13,7 +13,7 @@ type SucceedState struct {
 }
 
 func (s *SucceedState) Validate(stateNames []string) error {
-	return nil
+	return ValidateDataProcessing(s.InputPath, PayloadTemplate{}, PayloadTemplate{}, "", s.OutputPath)
 }
 
 func (s *SucceedState) IsEndState() bool {
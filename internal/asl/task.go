@@ -15,8 +15,8 @@ type TaskState struct {
 	OutputPath           Path            // Optional
 	ResultPath           Path            // Optional
 	ResultSelector       PayloadTemplate // Optional
-	Retry                *Retry          // Optional
-	Catch                *Catch          // Optional
+	Retry                []Retrier       // Optional
+	Catch                []Catcher       // Optional
 	TimeoutSeconds       uint32          // Optional, in seconds
 	TimeoutSecondsPath   Path            // Optional, in seconds
 	HeartbeatSeconds     uint32          // Optional, in seconds. Smaller than timeoutSeconds
@@ -32,15 +32,27 @@ func (t *TaskState) ParseFrom(jsonData []byte) (State, error) {
 
 	t.Next = JsonExtractStringOrDefault(jsonData, "Next", "")
 
-	t.Parameters.json = JsonExtractStringOrDefault(jsonData, "Parameters", "")
+	t.Parameters, err = parsePayloadTemplate(jsonData, "Parameters")
+	if err != nil {
+		return nil, err
+	}
 
 	t.InputPath = JsonExtractRefPathOrDefault(jsonData, "InputPath", "")
 	t.OutputPath = JsonExtractRefPathOrDefault(jsonData, "OutputPath", "")
 	t.ResultPath = JsonExtractRefPathOrDefault(jsonData, "ResultPath", "")
 
-	t.ResultSelector.json = JsonExtractStringOrDefault(jsonData, "ResultSelector", "")
-	t.Retry = JsonExtractObjectOrDefault(jsonData, "Retry", &Retry{}).(*Retry)
-	t.Catch = JsonExtractObjectOrDefault(jsonData, "Catch", &Catch{}).(*Catch)
+	t.ResultSelector, err = parsePayloadTemplate(jsonData, "ResultSelector")
+	if err != nil {
+		return nil, err
+	}
+	t.Retry, err = parseRetry(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	t.Catch, err = parseCatch(jsonData)
+	if err != nil {
+		return nil, err
+	}
 
 	t.TimeoutSeconds = uint32(JsonExtractIntOrDefault(jsonData, "TimeoutSeconds", 0))
 	t.TimeoutSecondsPath, err = JsonTryExtractRefPath(jsonData, "TimeoutSecondsPath")
@@ -77,6 +89,21 @@ func (t *TaskState) Validate(stateNames []string) error {
 		return fmt.Errorf("HeartbeatSecondsPath and HeartbeatSeconds cannot be set at the same time")
 	}
 
+	if err := ValidateDataProcessing(t.InputPath, t.Parameters, t.ResultSelector, t.ResultPath, t.OutputPath); err != nil {
+		return err
+	}
+
+	for i := range t.Retry {
+		if err := t.Retry[i].Validate(i == len(t.Retry)-1); err != nil {
+			return fmt.Errorf("invalid Retrier %d: %v", i, err)
+		}
+	}
+	for i := range t.Catch {
+		if err := t.Catch[i].Validate(stateNames, i == len(t.Catch)-1); err != nil {
+			return fmt.Errorf("invalid Catcher %d: %v", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -130,8 +157,8 @@ func NewEmptyTask() *TaskState {
 		OutputPath:           "",
 		ResultPath:           "",
 		ResultSelector:       PayloadTemplate{""},
-		Retry:                nil,
-		Catch:                nil,
+		Retry:                NoRetry(),
+		Catch:                NoCatch(),
 		TimeoutSeconds:       0,
 		TimeoutSecondsPath:   "",
 		HeartbeatSeconds:     0,
@@ -173,8 +200,8 @@ func (t *TaskState) Equals(cmp types.Comparable) bool {
 		t.OutputPath == t2.OutputPath &&
 		t.ResultPath == t2.ResultPath &&
 		t.ResultSelector.json == t2.ResultSelector.json &&
-		t.Retry.Equals(t2.Retry) &&
-		t.Catch.Equals(t2.Catch) &&
+		equalRetriers(t.Retry, t2.Retry) &&
+		equalCatchers(t.Catch, t2.Catch) &&
 		t.TimeoutSeconds == t2.TimeoutSeconds &&
 		t.TimeoutSecondsPath == t2.TimeoutSecondsPath &&
 		t.HeartbeatSeconds == t2.HeartbeatSeconds &&
@@ -207,12 +234,12 @@ func (t *TaskState) String() string {
 	}
 
 	if t.ResultSelector.json != "" {
-		str += fmt.Sprint("\t\t\tResultSelector: ", t.ResultSelector)
+		str += fmt.Sprintf("\t\t\tResultSelector: %s\n", t.ResultSelector.json)
 	}
-	if t.Retry != nil {
+	if len(t.Retry) > 0 {
 		str += fmt.Sprintf("\t\t\tRetry: %v\n", t.Retry)
 	}
-	if t.Catch != nil {
+	if len(t.Catch) > 0 {
 		str += fmt.Sprintf("\t\t\tCatch: %v\n", t.Catch)
 	}
 	if t.TimeoutSeconds != 0 {
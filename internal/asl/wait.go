@@ -1,21 +1,87 @@
 package asl
 
-import "github.com/serverledge-faas/serverledge/internal/types"
+import (
+	"fmt"
+	"time"
 
+	"github.com/serverledge-faas/serverledge/internal/types"
+)
+
+// WaitState delays the state machine for a specified time. Exactly one of Seconds, Timestamp, SecondsPath and
+// TimestampPath must be set.
 type WaitState struct {
 	Type StateType
-	Next string
-	End  bool
+	// Seconds is the number of seconds to wait
+	Seconds int
+	// Timestamp is the absolute time to wait until, in RFC3339 format (e.g. "2026-03-14T01:59:00Z")
+	Timestamp string
+	// SecondsPath is a Reference Path to the number of seconds to wait in the effective input
+	SecondsPath Path
+	// TimestampPath is a Reference Path to the timestamp to wait until in the effective input
+	TimestampPath Path
+	InputPath     Path // Optional
+	OutputPath    Path // Optional
+	Next          string
+	End           bool
 }
 
 func (w *WaitState) ParseFrom(jsonData []byte) (State, error) {
-	//TODO implement me
-	panic("implement me")
+	w.Type = Wait
+	w.Next = JsonExtractStringOrDefault(jsonData, "Next", "")
+	w.End = JsonExtractBool(jsonData, "End")
+	w.Timestamp = JsonExtractStringOrDefault(jsonData, "Timestamp", "")
+	w.SecondsPath = JsonExtractRefPathOrDefault(jsonData, "SecondsPath", "")
+	w.TimestampPath = JsonExtractRefPathOrDefault(jsonData, "TimestampPath", "")
+	w.InputPath = JsonExtractRefPathOrDefault(jsonData, "InputPath", "")
+	w.OutputPath = JsonExtractRefPathOrDefault(jsonData, "OutputPath", "")
+
+	if JsonHasKey(jsonData, "Seconds") {
+		w.Seconds = JsonExtractIntOrDefault(jsonData, "Seconds", -1)
+		if w.Seconds <= 0 {
+			return nil, fmt.Errorf("Seconds must be a positive integer")
+		}
+	}
+	return w, nil
 }
 
 func (w *WaitState) Validate(stateNames []string) error {
-	//TODO implement me
-	panic("implement me")
+	defined := 0
+	if w.Seconds > 0 {
+		defined++
+	}
+	if w.Timestamp != "" {
+		defined++
+		if _, err := time.Parse(time.RFC3339, w.Timestamp); err != nil {
+			return fmt.Errorf("invalid Timestamp %s: %v", w.Timestamp, err)
+		}
+	}
+	if w.SecondsPath != "" {
+		defined++
+		if err := w.SecondsPath.check(true); err != nil {
+			return fmt.Errorf("invalid SecondsPath: %v", err)
+		}
+	}
+	if w.TimestampPath != "" {
+		defined++
+		if err := w.TimestampPath.check(true); err != nil {
+			return fmt.Errorf("invalid TimestampPath: %v", err)
+		}
+	}
+	if defined != 1 {
+		return fmt.Errorf("exactly one of Seconds, Timestamp, SecondsPath and TimestampPath must be defined for a wait state, but %d are", defined)
+	}
+
+	if err := ValidateDataProcessing(w.InputPath, PayloadTemplate{}, PayloadTemplate{}, "", w.OutputPath); err != nil {
+		return err
+	}
+
+	if w.End == false && w.Next == "" {
+		return fmt.Errorf("next field is mandatory for a non-terminal wait state, but it is not defined")
+	}
+	if w.End == true && w.Next != "" {
+		return fmt.Errorf("next field should not be defined for a terminal wait state, but it is")
+	}
+	return nil
 }
 
 func NewEmptyWait() *WaitState {
@@ -40,11 +106,43 @@ func (w *WaitState) IsEndState() bool {
 }
 
 func (w *WaitState) Equals(cmp types.Comparable) bool {
-	w2 := cmp.(*WaitState)
-	return w.Type == w2.Type
+	w2, ok := cmp.(*WaitState)
+	if !ok {
+		return false
+	}
+	return w.Type == w2.Type &&
+		w.Seconds == w2.Seconds &&
+		w.Timestamp == w2.Timestamp &&
+		w.SecondsPath == w2.SecondsPath &&
+		w.TimestampPath == w2.TimestampPath &&
+		w.InputPath == w2.InputPath &&
+		w.OutputPath == w2.OutputPath &&
+		w.Next == w2.Next &&
+		w.End == w2.End
 }
 
 func (w *WaitState) String() string {
-	//TODO implement me
-	panic("implement me")
+	str := fmt.Sprint("{",
+		"\n\t\t\tType: ", w.Type,
+		"\n")
+	if w.Seconds > 0 {
+		str += fmt.Sprintf("\t\t\tSeconds: %d\n", w.Seconds)
+	}
+	if w.Timestamp != "" {
+		str += fmt.Sprintf("\t\t\tTimestamp: %s\n", w.Timestamp)
+	}
+	if w.SecondsPath != "" {
+		str += fmt.Sprintf("\t\t\tSecondsPath: %s\n", w.SecondsPath)
+	}
+	if w.TimestampPath != "" {
+		str += fmt.Sprintf("\t\t\tTimestampPath: %s\n", w.TimestampPath)
+	}
+	if w.Next != "" {
+		str += fmt.Sprintf("\t\t\tNext: %s\n", w.Next)
+	}
+	if w.End != false {
+		str += fmt.Sprintf("\t\t\tEnd: %v\n", w.End)
+	}
+	str += "\t\t}"
+	return str
 }
@@ -0,0 +1,79 @@
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultResultTTL is how long the results of asynchronous requests are kept in Etcd, if not configured otherwise
+const DefaultResultTTL = 30 * time.Minute
+
+// ResultKey returns the Etcd key of the result of an asynchronous request (of a function or a workflow)
+func ResultKey(namespace string, reqId string) string {
+	return fmt.Sprintf("async/%s/%s", namespace, reqId)
+}
+
+// ResultTTL returns how long the result of a request with the given options is kept
+func ResultTTL(options function.AsyncOptions) time.Duration {
+	if options.ResultTTL > 0 {
+		return time.Duration(options.ResultTTL) * time.Second
+	}
+	return time.Duration(config.GetInt(config.ASYNC_RESULT_TTL, int(DefaultResultTTL.Seconds()))) * time.Second
+}
+
+// Validate checks the options of an asynchronous request
+func Validate(options function.AsyncOptions) error {
+	if options.ResultTTL < 0 {
+		return fmt.Errorf("invalid ResultTTL: %d", options.ResultTTL)
+	}
+	if options.CallbackURL != "" {
+		if err := validateCallbackURL(options.CallbackURL); err != nil {
+			return err
+		}
+	} else if options.CallbackSecret != "" {
+		return fmt.Errorf("CallbackSecret requires a CallbackURL")
+	}
+	return nil
+}
+
+// Publish saves the response of an asynchronous request in Etcd, in the given namespace, and delivers it to the
+// callback URL of the request, if any
+func Publish(namespace string, reqId string, options function.AsyncOptions, response interface{}) {
+	etcdClient, err := utils.GetEtcdClient()
+	if err != nil {
+		log.Fatal("Client not available")
+		return
+	}
+
+	ctx := context.Background()
+
+	resp, err := etcdClient.Grant(ctx, int64(ResultTTL(options).Seconds()))
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Could not marshal response: %v\n", err)
+		return
+	}
+
+	_, err = etcdClient.Put(ctx, ResultKey(namespace, reqId), string(payload), clientv3.WithLease(resp.ID))
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	if options.CallbackURL != "" {
+		deliver(namespace, reqId, options, payload)
+	}
+}
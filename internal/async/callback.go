@@ -0,0 +1,257 @@
+package async
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of the TimestampHeader and of the body of a callback, as
+// "sha256=<hex digest>", if the request specified a CallbackSecret (see Sign)
+const SignatureHeader = "Serverledge-Signature"
+
+// TimestampHeader carries the time a callback has been sent at, in seconds since the Unix epoch. Receivers should
+// reject callbacks whose timestamp is too old, so that they cannot be replayed.
+const TimestampHeader = "Serverledge-Timestamp"
+
+const callbackTimeout = 10 * time.Second
+const maxCallbackBackoff = 5 * time.Minute
+
+const deadLettersEtcdDir = "/deadletter/"
+
+// callbackClient does not follow redirects, which could lead to hosts that are not allowed
+var callbackClient = &http.Client{
+	Timeout: callbackTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var NoSecretKeyErr = errors.New("no key to encrypt callback secrets: set " + config.CALLBACK_SECRET_KEY)
+
+// DeadLetter is saved in Etcd when the result of an asynchronous request cannot be delivered to its callback URL
+type DeadLetter struct {
+	ReqId       string
+	CallbackURL string
+	Attempts    int
+	LastError   string
+	FailedAt    time.Time
+	Response    json.RawMessage // the undelivered result
+}
+
+// DeadLetterKey returns the Etcd key of the dead letter of a request
+func DeadLetterKey(namespace string, reqId string) string {
+	return deadLettersEtcdDir + namespace + "/" + reqId
+}
+
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid CallbackURL: %s", callbackURL)
+	}
+	if !isAllowedHost(u.Hostname()) {
+		return fmt.Errorf("CallbackURL host not allowed: %s", u.Hostname())
+	}
+	return nil
+}
+
+// isAllowedHost returns true if the host matches an entry of CALLBACK_ALLOWED_HOSTS, where "*.<domain>" matches
+// the subdomains of the domain. Any host is allowed if the list is empty.
+func isAllowedHost(host string) bool {
+	allowed := config.GetStringSlice(config.CALLBACK_ALLOWED_HOSTS, nil)
+	if len(allowed) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+		if domain, found := strings.CutPrefix(entry, "*."); found {
+			if strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		} else if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the value of the SignatureHeader for the payload sent at the timestamp (the value of the
+// TimestampHeader): the HMAC-SHA256 of "<timestamp>.<payload>"
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// secretCipher returns the cipher of the callback secrets saved in Etcd, with a key derived from CALLBACK_SECRET_KEY
+func secretCipher() (cipher.AEAD, error) {
+	key := config.GetString(config.CALLBACK_SECRET_KEY, "")
+	if key == "" {
+		return nil, NoSecretKeyErr
+	}
+	digest := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealSecret encrypts a callback secret, to be saved in Etcd (e.g., while a workflow is suspended)
+func SealSecret(secret string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// OpenSecret decrypts a callback secret encrypted by SealSecret
+func OpenSecret(sealed string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid sealed secret")
+	}
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt the callback secret: %v", err)
+	}
+	return string(secret), nil
+}
+
+// permanentError is returned when retrying the delivery of a callback is pointless
+type permanentError struct {
+	statusCode int
+}
+
+func (e *permanentError) Error() string {
+	return fmt.Sprintf("callback rejected with status %d", e.statusCode)
+}
+
+// post sends the payload to the callback URL once
+func post(reqId string, options function.AsyncOptions, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, options.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return &permanentError{}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(function.RequestIdHeader, reqId)
+	if options.CallbackSecret != "" {
+		// each attempt is signed with the time it is sent at
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(options.CallbackSecret, timestamp, payload))
+	}
+
+	resp, err := callbackClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	} else if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout {
+		return fmt.Errorf("callback failed with status %d", resp.StatusCode)
+	}
+	return &permanentError{statusCode: resp.StatusCode}
+}
+
+// postWithRetries delivers the payload to the callback URL, retrying with exponential backoff after transient
+// failures. It returns the number of attempts and the last error, if the payload could not be delivered.
+func postWithRetries(reqId string, options function.AsyncOptions, payload []byte, maxAttempts int, backoff time.Duration) (int, error) {
+	var err error
+	attempts := 0
+	for attempts < max(maxAttempts, 1) {
+		if attempts > 0 {
+			time.Sleep(backoff)
+			backoff = min(2*backoff, maxCallbackBackoff)
+		}
+		attempts++
+		err = post(reqId, options, payload)
+		if err == nil {
+			return attempts, nil
+		} else if _, permanent := err.(*permanentError); permanent {
+			break
+		}
+		log.Printf("Delivery of the result of %s to %s failed (attempt %d): %v\n", reqId, options.CallbackURL, attempts, err)
+	}
+	return attempts, err
+}
+
+// deliver posts the result of a request to its callback URL, saving a dead letter in Etcd if delivery fails
+func deliver(namespace string, reqId string, options function.AsyncOptions, payload []byte) {
+	attempts, err := postWithRetries(reqId, options, payload,
+		config.GetInt(config.CALLBACK_ATTEMPTS, 5),
+		time.Duration(config.GetInt(config.CALLBACK_BACKOFF, 1))*time.Second)
+	if err == nil {
+		return
+	}
+
+	log.Printf("Could not deliver the result of %s to %s: %v\n", reqId, options.CallbackURL, err)
+	deadLetter := DeadLetter{
+		ReqId:       reqId,
+		CallbackURL: options.CallbackURL,
+		Attempts:    attempts,
+		LastError:   err.Error(),
+		FailedAt:    time.Now(),
+		Response:    payload,
+	}
+	if err = deadLetter.save(namespace, time.Duration(config.GetInt(config.CALLBACK_DEAD_LETTER_TTL, 7*24*3600))*time.Second); err != nil {
+		log.Printf("Could not save the dead letter of %s: %v\n", reqId, err)
+	}
+}
+
+func (d *DeadLetter) save(namespace string, ttl time.Duration) error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("could not marshal dead letter: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var opts []clientv3.OpOption
+	if ttl > 0 {
+		lease, err := cli.Grant(ctx, int64(ttl.Seconds()))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, clientv3.WithLease(lease.ID))
+	}
+	_, err = cli.Put(ctx, DeadLetterKey(namespace, d.ReqId), string(payload), opts...)
+	return err
+}
@@ -0,0 +1,134 @@
+package async
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(function.AsyncOptions{}))
+	assert.NoError(t, Validate(function.AsyncOptions{CallbackURL: "https://example.com/hook", CallbackSecret: "s", ResultTTL: 60}))
+	assert.Error(t, Validate(function.AsyncOptions{CallbackURL: "example.com/hook"}))
+	assert.Error(t, Validate(function.AsyncOptions{CallbackURL: "ftp://example.com/hook"}))
+	assert.Error(t, Validate(function.AsyncOptions{CallbackSecret: "s"}), "a secret without a URL is useless")
+	assert.Error(t, Validate(function.AsyncOptions{ResultTTL: -1}))
+}
+
+func TestValidateAllowedHosts(t *testing.T) {
+	viper.Set(config.CALLBACK_ALLOWED_HOSTS, []string{"hooks.example.com", "*.example.org"})
+	t.Cleanup(func() { viper.Set(config.CALLBACK_ALLOWED_HOSTS, nil) })
+
+	assert.NoError(t, Validate(function.AsyncOptions{CallbackURL: "https://hooks.example.com/result"}))
+	assert.NoError(t, Validate(function.AsyncOptions{CallbackURL: "https://HOOKS.example.com:8443/result"}))
+	assert.NoError(t, Validate(function.AsyncOptions{CallbackURL: "http://a.b.example.org/result"}))
+	assert.Error(t, Validate(function.AsyncOptions{CallbackURL: "https://example.org/result"}), "only subdomains match *.example.org")
+	assert.Error(t, Validate(function.AsyncOptions{CallbackURL: "https://other.example.com/result"}))
+	assert.Error(t, Validate(function.AsyncOptions{CallbackURL: "http://169.254.169.254/latest/meta-data"}))
+}
+
+func TestResultTTL(t *testing.T) {
+	assert.Equal(t, DefaultResultTTL, ResultTTL(function.AsyncOptions{}))
+	assert.Equal(t, 2*time.Minute, ResultTTL(function.AsyncOptions{ResultTTL: 120}))
+}
+
+func TestCallbackSignature(t *testing.T) {
+	payload := []byte(`{"Success":true}`)
+	var received []byte
+	var signature, timestamp, reqId string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		timestamp = r.Header.Get(TimestampHeader)
+		reqId = r.Header.Get(function.RequestIdHeader)
+	}))
+	defer server.Close()
+
+	attempts, err := postWithRetries("req-1", function.AsyncOptions{CallbackURL: server.URL, CallbackSecret: "secret"}, payload, 3, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, payload, received)
+	assert.Equal(t, "req-1", reqId)
+	assert.Equal(t, "sha256=279e2b1c2de9206a2f6c9ce3baebec75b5ab5cf6d2193be9c283d3f14e13cad9", Sign("secret", "1700000000", payload))
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().Unix(), sentAt, 5)
+	assert.Equal(t, Sign("secret", timestamp, payload), signature)
+	assert.NotEqual(t, Sign("other", timestamp, payload), signature)
+	assert.NotEqual(t, Sign("secret", "1700000000", payload), signature, "the signature covers the timestamp")
+
+	_, err = postWithRetries("req-2", function.AsyncOptions{CallbackURL: server.URL}, payload, 3, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Empty(t, signature, "callbacks are signed only if a secret is given")
+	assert.Empty(t, timestamp)
+}
+
+func TestCallbackRedirect(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	attempts, err := postWithRetries("req", function.AsyncOptions{CallbackURL: server.URL}, []byte("{}"), 3, time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.False(t, redirected, "redirects could lead to hosts that are not allowed")
+}
+
+func TestSealSecret(t *testing.T) {
+	viper.Set(config.CALLBACK_SECRET_KEY, "")
+	_, err := SealSecret("secret")
+	assert.ErrorIs(t, err, NoSecretKeyErr)
+
+	viper.Set(config.CALLBACK_SECRET_KEY, "cluster-key")
+	t.Cleanup(func() { viper.Set(config.CALLBACK_SECRET_KEY, "") })
+	sealed, err := SealSecret("secret")
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, "secret")
+	opened, err := OpenSecret(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", opened)
+
+	viper.Set(config.CALLBACK_SECRET_KEY, "other-key")
+	_, err = OpenSecret(sealed)
+	assert.Error(t, err, "secrets are opened only with the same key")
+}
+
+func TestCallbackRetries(t *testing.T) {
+	calls := 0
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+	options := function.AsyncOptions{CallbackURL: server.URL}
+
+	attempts, err := postWithRetries("req", options, []byte("{}"), 5, time.Millisecond)
+	assert.NoError(t, err, "transient failures are retried")
+	assert.Equal(t, 3, attempts)
+
+	calls = 0
+	attempts, err = postWithRetries("req", options, []byte("{}"), 2, time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts, "delivery stops after the max attempts")
+
+	calls = 0
+	status = http.StatusBadRequest
+	attempts, err = postWithRetries("req", options, []byte("{}"), 5, time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts, "rejected callbacks are not retried")
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Role determines the operations allowed to the owner of an API key
+type Role string
+
+const (
+	Admin    Role = "admin"    // any operation, in any namespace, including the management of API keys
+	Deployer Role = "deployer" // creation, update, deletion and invocation of functions and workflows in its namespace
+	Invoker  Role = "invoker"  // invocation of functions and workflows in its namespace
+)
+
+var roleLevel = map[Role]int{Invoker: 1, Deployer: 2, Admin: 3}
+
+// IsValid returns true if the role is one of the known roles
+func (r Role) IsValid() bool {
+	_, ok := roleLevel[r]
+	return ok
+}
+
+// Allows returns true if the role grants (at least) the privileges of the required role
+func (r Role) Allows(required Role) bool {
+	return roleLevel[r] >= roleLevel[required]
+}
+
+// APIKey is the record of an API key saved in etcd. The key itself is never saved: records are identified by its hash.
+type APIKey struct {
+	Id        string // SHA-256 of the key
+	Namespace string
+	Role      Role
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func getEtcdKey(id string) string {
+	return fmt.Sprintf("/apikey/%s", id)
+}
+
+// NewAPIKey generates a new API key for the namespace, and saves its record to etcd
+func NewAPIKey(namespace string, role Role) (string, *APIKey, error) {
+	if !role.IsValid() {
+		return "", nil, fmt.Errorf("invalid role: %s", role)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	key := hex.EncodeToString(secret)
+	record := &APIKey{Id: hashKey(key), Namespace: namespace, Role: role}
+
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not marshal API key: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = cli.Put(ctx, getEtcdKey(record.Id), string(payload)); err != nil {
+		return "", nil, fmt.Errorf("failed etcd Put: %v", err)
+	}
+
+	return key, record, nil
+}
+
+// GetAPIKey retrieves the record of an API key, from the local cache or from etcd. If it doesn't exist, returns false
+func GetAPIKey(key string) (*APIKey, bool) {
+	etcdKey := getEtcdKey(hashKey(key))
+
+	if cached, found := cache.GetCacheInstance().Get(etcdKey); found {
+		record := *cached.(*APIKey)
+		return &record, true
+	}
+
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	getResponse, err := cli.Get(ctx, etcdKey)
+	if err != nil || len(getResponse.Kvs) < 1 {
+		return nil, false
+	}
+
+	var record APIKey
+	if err = json.Unmarshal(getResponse.Kvs[0].Value, &record); err != nil {
+		return nil, false
+	}
+	cache.GetCacheInstance().Set(etcdKey, &record, cache.DefaultExp)
+
+	return &record, true
+}
+
+// DeleteAPIKey revokes the API key with the given id. Other nodes drop the key from their cache when they are notified
+// by WatchChanges, or, if they do not watch etcd, they may accept it until the cache entry expires.
+func DeleteAPIKey(id string) error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dresp, err := cli.Delete(ctx, getEtcdKey(id))
+	if err != nil {
+		return fmt.Errorf("failed Delete: %v", err)
+	}
+	if dresp.Deleted != 1 {
+		return fmt.Errorf("unknown API key: %s", id)
+	}
+
+	cache.GetCacheInstance().Delete(getEtcdKey(id))
+	return nil
+}
+
+// GetAllAPIKeys returns the records of all the API keys
+func GetAllAPIKeys() ([]APIKey, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := cli.Get(ctx, "/apikey/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKey, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var record APIKey
+		if err = json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		}
+		keys = append(keys, record)
+	}
+	return keys, nil
+}
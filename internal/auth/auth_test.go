@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func newRequest(key string, namespace string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/function", nil)
+	SetHeaders(req, namespace, key)
+	return req
+}
+
+func TestRoles(t *testing.T) {
+	assert.True(t, Admin.Allows(Deployer))
+	assert.True(t, Deployer.Allows(Invoker))
+	assert.True(t, Invoker.Allows(Invoker))
+	assert.False(t, Invoker.Allows(Deployer))
+	assert.False(t, Role("guest").IsValid())
+	assert.False(t, Role("guest").Allows(Invoker))
+}
+
+func TestAuthenticateDisabled(t *testing.T) {
+	viper.Set(config.API_AUTH_ENABLED, false)
+
+	identity, err := Authenticate(newRequest("", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, function.DefaultNamespace, identity.Namespace)
+	assert.Equal(t, Admin, identity.Role)
+
+	identity, err = Authenticate(newRequest("", "team1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "team1", identity.Namespace)
+
+	_, err = Authenticate(newRequest("", "a/b"))
+	assert.ErrorIs(t, err, InvalidNamespaceErr)
+}
+
+func TestAuthenticateAdminKey(t *testing.T) {
+	viper.Set(config.API_AUTH_ENABLED, true)
+	viper.Set(config.API_AUTH_ADMIN_KEY, "secret")
+	defer viper.Set(config.API_AUTH_ENABLED, false)
+
+	_, err := Authenticate(newRequest("", ""))
+	assert.ErrorIs(t, err, MissingKeyErr)
+
+	identity, err := Authenticate(newRequest("secret", "team1"))
+	assert.NoError(t, err)
+	assert.Equal(t, Admin, identity.Role)
+	assert.Equal(t, "team1", identity.Namespace, "admins can select any namespace")
+	assert.Equal(t, "secret", identity.APIKey)
+
+	req := newRequest("", "")
+	req.Header.Set("Authorization", "Bearer secret")
+	identity, err = Authenticate(req)
+	assert.NoError(t, err)
+	assert.Equal(t, function.DefaultNamespace, identity.Namespace)
+}
+
+func TestRequireRole(t *testing.T) {
+	viper.Set(config.API_AUTH_ENABLED, true)
+	viper.Set(config.API_AUTH_ADMIN_KEY, "secret")
+	defer viper.Set(config.API_AUTH_ENABLED, false)
+
+	e := echo.New()
+	handler := RequireRole(Deployer)(func(c echo.Context) error {
+		return c.String(http.StatusOK, GetIdentity(c).Namespace)
+	})
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler(e.NewContext(newRequest("", ""), rec)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	assert.NoError(t, handler(e.NewContext(newRequest("secret", "team1"), rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "team1", rec.Body.String())
+}
+
+func TestDeletedAPIKeyDroppedFromCache(t *testing.T) {
+	etcdKey := getEtcdKey(hashKey("revoked"))
+	cache.GetCacheInstance().Set(etcdKey, &APIKey{Id: hashKey("revoked"), Namespace: "team1", Role: Invoker},
+		cache.DefaultExp)
+	record, found := GetAPIKey("revoked")
+	assert.True(t, found)
+	assert.Equal(t, "team1", record.Namespace)
+
+	// the key is deleted by another node
+	onAPIKeyEvent(&clientv3.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(etcdKey)}})
+	_, found = cache.GetCacheInstance().Get(etcdKey)
+	assert.False(t, found)
+
+	cache.GetCacheInstance().Set(etcdKey, record, cache.DefaultExp)
+	cache.GetCacheInstance().Set("f", &function.Function{Name: "f"}, cache.DefaultExp)
+	resyncAPIKeys()
+	_, found = cache.GetCacheInstance().Get(etcdKey)
+	assert.False(t, found)
+	_, found = cache.GetCacheInstance().Get("f")
+	assert.True(t, found, "only API keys are dropped")
+	cache.GetCacheInstance().Delete("f")
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+)
+
+// Headers used by clients (and by nodes, when offloading) to authenticate and to select a namespace
+const (
+	APIKeyHeader    = "Serverledge-API-Key"
+	NamespaceHeader = "Serverledge-Namespace"
+)
+
+const identityContextKey = "identity"
+
+var MissingKeyErr = errors.New("missing API key")
+var InvalidKeyErr = errors.New("invalid API key")
+var InvalidNamespaceErr = errors.New("invalid namespace")
+var ForbiddenNamespaceErr = errors.New("the API key does not grant access to the namespace")
+
+// Identity describes the client of a request: the namespace it operates in and its privileges
+type Identity struct {
+	Namespace string
+	Role      Role
+	APIKey    string // the key used by the client, forwarded to the other nodes when offloading
+}
+
+// Authenticate returns the identity of the client of the request. If authentication is disabled, every client is
+// an admin and selects the namespace through the NamespaceHeader.
+func Authenticate(req *http.Request) (*Identity, error) {
+	key := req.Header.Get(APIKeyHeader)
+	if key == "" {
+		if bearer, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); found {
+			key = bearer
+		}
+	}
+	namespace := req.Header.Get(NamespaceHeader)
+	if namespace != "" && !function.IsValidName(namespace) {
+		return nil, InvalidNamespaceErr
+	}
+
+	var role Role
+	var keyNamespace string
+	if !config.GetBool(config.API_AUTH_ENABLED, false) {
+		role = Admin
+	} else if key == "" {
+		return nil, MissingKeyErr
+	} else if adminKey := config.GetString(config.API_AUTH_ADMIN_KEY, ""); adminKey != "" &&
+		subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+		role = Admin
+	} else {
+		record, found := GetAPIKey(key)
+		if !found {
+			return nil, InvalidKeyErr
+		}
+		role = record.Role
+		keyNamespace = record.Namespace
+	}
+
+	if role != Admin {
+		// other roles are confined to the namespace of their key
+		if namespace != "" && namespace != keyNamespace {
+			return nil, ForbiddenNamespaceErr
+		}
+		namespace = keyNamespace
+	}
+	if namespace == "" {
+		namespace = function.DefaultNamespace
+	}
+
+	return &Identity{Namespace: namespace, Role: role, APIKey: key}, nil
+}
+
+// RequireRole returns a middleware that authenticates the client and rejects the requests of the clients
+// without (at least) the privileges of the given role. The identity is then available through GetIdentity.
+func RequireRole(role Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			identity, err := Authenticate(c.Request())
+			if errors.Is(err, MissingKeyErr) || errors.Is(err, InvalidKeyErr) {
+				return c.String(http.StatusUnauthorized, err.Error())
+			} else if errors.Is(err, InvalidNamespaceErr) {
+				return c.String(http.StatusBadRequest, err.Error())
+			} else if err != nil {
+				return c.String(http.StatusForbidden, err.Error())
+			}
+
+			if !identity.Role.Allows(role) {
+				return c.String(http.StatusForbidden, "operation not allowed for role "+string(identity.Role))
+			}
+
+			c.Set(identityContextKey, identity)
+			return next(c)
+		}
+	}
+}
+
+// GetIdentity returns the identity of the client set by RequireRole, or an anonymous identity in the DefaultNamespace
+func GetIdentity(c echo.Context) *Identity {
+	if identity, ok := c.Get(identityContextKey).(*Identity); ok {
+		return identity
+	}
+	return &Identity{Namespace: function.DefaultNamespace}
+}
+
+// RequestNamespace returns the namespace selected by the request, without checking the privileges of the client
+func RequestNamespace(req *http.Request) string {
+	identity, err := Authenticate(req)
+	if err != nil {
+		return function.DefaultNamespace
+	}
+	return identity.Namespace
+}
+
+// SetHeaders sets the headers that forward the identity of a client on an outgoing request
+func SetHeaders(req *http.Request, namespace string, apiKey string) {
+	if namespace != "" {
+		req.Header.Set(NamespaceHeader, namespace)
+	}
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+}
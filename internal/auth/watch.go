@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchChanges drops the records of API keys from the local cache as soon as they are updated or deleted by any node,
+// so that revoked keys are rejected by every node. The watch runs in background until the context is cancelled.
+func WatchChanges(ctx context.Context) {
+	go utils.WatchPrefix(ctx, "/apikey/", onAPIKeyEvent, resyncAPIKeys)
+}
+
+func onAPIKeyEvent(ev *clientv3.Event) {
+	cache.GetCacheInstance().Delete(string(ev.Kv.Key))
+}
+
+// resyncAPIKeys drops all the cached API keys, after a watch interruption
+func resyncAPIKeys() {
+	cache.GetCacheInstance().DeleteMatching(func(_ string, x interface{}) bool {
+		_, isAPIKey := x.(*APIKey)
+		return isAPIKey
+	})
+}
@@ -171,6 +171,28 @@ func (c *cache) DeleteExpired() {
 	}
 }
 
+// DeleteMatching Delete all the items for which match returns true, and return
+// them (including the expired ones).
+func (c *cache) DeleteMatching(match func(k string, x interface{}) bool) map[string]interface{} {
+	deleted := make(map[string]interface{})
+	var evictedItems []keyAndValue
+	c.mu.Lock()
+	for k, v := range c.items {
+		if match(k, v.Object) {
+			deleted[k] = v.Object
+			ov, evicted := c.delete(k)
+			if evicted {
+				evictedItems = append(evictedItems, keyAndValue{k, ov})
+			}
+		}
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.onEvicted(v.key, v.value)
+	}
+	return deleted
+}
+
 type janitor struct {
 	Interval time.Duration
 	stop     chan bool
@@ -11,9 +11,12 @@ import (
 	"strings"
 
 	"github.com/labstack/gommon/log"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/client"
 	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/executor"
 	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/trigger"
 	"github.com/serverledge-faas/serverledge/internal/workflow"
 	"github.com/serverledge-faas/serverledge/utils"
 	"github.com/spf13/cobra"
@@ -39,6 +42,12 @@ var pollCmd = &cobra.Command{
 	Run:   poll,
 }
 
+var cancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancels a queued or running invocation",
+	Run:   cancel,
+}
+
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Registers a new function",
@@ -95,6 +104,98 @@ var compInvokeCmd = &cobra.Command{
 	Run:   invokeWorkflow,
 }
 
+// ========== VERSIONS ===========
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publishes the current definition of a function as a new version",
+	Run:   publish,
+}
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Points an alias of a function to a version, optionally sending a share of the traffic to a canary version",
+	Run:   promote,
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restores the routing of an alias before its last update",
+	Run:   rollback,
+}
+
+var listVersionsCmd = &cobra.Command{
+	Use:   "list-versions",
+	Short: "Lists the versions and the aliases of a function",
+	Run:   listVersions,
+}
+
+// ========== SCHEDULES ===========
+
+var scheduleCreateCmd = &cobra.Command{
+	Use:   "create-schedule",
+	Short: "Schedules the invocations of a function or a workflow with a cron expression",
+	Run:   createSchedule,
+}
+
+var scheduleDeleteCmd = &cobra.Command{
+	Use:   "delete-schedule",
+	Short: "Deletes a schedule",
+	Run:   deleteSchedule,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list-schedules",
+	Short: "Lists the schedules",
+	Run:   listSchedules,
+}
+
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "schedule-history",
+	Short: "Lists the latest invocations fired by a schedule",
+	Run:   getScheduleHistory,
+}
+
+// ========== EVENT TRIGGERS ===========
+
+var triggerCreateCmd = &cobra.Command{
+	Use:   "create-trigger",
+	Short: "Invokes a function or a workflow upon changes of Etcd keys, new files in a directory or MQTT messages",
+	Run:   createTrigger,
+}
+
+var triggerDeleteCmd = &cobra.Command{
+	Use:   "delete-trigger",
+	Short: "Deletes an event trigger",
+	Run:   deleteTrigger,
+}
+
+var triggerListCmd = &cobra.Command{
+	Use:   "list-triggers",
+	Short: "Lists the event triggers",
+	Run:   listTriggers,
+}
+
+// ========== API KEYS ===========
+
+var keyCreateCmd = &cobra.Command{
+	Use:   "create-key",
+	Short: "Generates a new API key (admin only)",
+	Run:   createAPIKey,
+}
+
+var keyDeleteCmd = &cobra.Command{
+	Use:   "delete-key",
+	Short: "Revokes an API key (admin only)",
+	Run:   deleteAPIKey,
+}
+
+var keyListCmd = &cobra.Command{
+	Use:   "list-keys",
+	Short: "Lists the API keys (admin only)",
+	Run:   listAPIKeys,
+}
+
 var compName, funcName, runtime, handler, customImage, src, jsonSrc string
 var qosClass int64
 var requestId string
@@ -105,17 +206,29 @@ var outputs []string
 var params []string
 var paramsFile string
 var asyncInvocation bool
+var asyncOptions function.AsyncOptions
 var verbose bool
 var returnOutput bool
+var streamOutput bool
 var update bool
 var maxConcurrency int16
+var timeoutSeconds int64
 var prewarmCount int64
 var forcePull bool
+var keyRole, keyId string
+var aliasName string
+var version, canaryVersion, canaryWeight int
+var scheduleName, cronExpr, timezone, missedFirePolicy, area string
+var triggerName, eventSource, eventPrefix, eventDirectory, filePattern string
+var mqttTopic, mqttReplyTopic string
+var mqttQoS int
 
 func Init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVarP(&ServerConfig.Host, "host", "H", ServerConfig.Host, "remote Serverledge host")
 	rootCmd.PersistentFlags().IntVarP(&ServerConfig.Port, "port", "P", ServerConfig.Port, "remote Serverledge port")
+	rootCmd.PersistentFlags().StringVarP(&ServerConfig.Namespace, "namespace", "n", ServerConfig.Namespace, "namespace of functions and workflows")
+	rootCmd.PersistentFlags().StringVarP(&ServerConfig.APIKey, "api-key", "", ServerConfig.APIKey, "API key used to authenticate")
 
 	rootCmd.AddCommand(invokeCmd)
 	invokeCmd.Flags().StringVarP(&funcName, "function", "f", "", "name of the function")
@@ -125,6 +238,8 @@ func Init() {
 	invokeCmd.Flags().StringVarP(&paramsFile, "params_file", "j", "", "File containing parameters (JSON)")
 	invokeCmd.Flags().BoolVarP(&asyncInvocation, "async", "a", false, "Asynchronous invocation")
 	invokeCmd.Flags().BoolVarP(&returnOutput, "ret_output", "o", false, "Capture function output (if supported by used runtime)")
+	invokeCmd.Flags().BoolVarP(&streamOutput, "stream", "", false, "Print the output and the partial results of the function while it runs (if supported by used runtime)")
+	addAsyncOptionsFlags(invokeCmd)
 
 	rootCmd.AddCommand(createCmd)
 	createCmd.Flags().BoolVarP(&update, "update", "u", false, "Overwrite any function with the same name")
@@ -134,6 +249,7 @@ func Init() {
 	createCmd.Flags().Int64VarP(&memory, "memory", "", 128, "memory (in MB) for the function")
 	createCmd.Flags().Int16VarP(&maxConcurrency, "max_concurrency", "C", 1, "max concurrency for the function (if supported by the runtime)")
 	createCmd.Flags().Float64VarP(&cpuDemand, "cpu", "", 0.0, "estimated CPU demand for the function (1.0 = 1 core)")
+	createCmd.Flags().Int64VarP(&timeoutSeconds, "timeout", "", 0, "max execution time (in seconds) for each invocation (0 = no limit)")
 	createCmd.Flags().StringVarP(&src, "src", "", "", "source for the function (single file, directory or TAR archive) (not necessary for runtime==custom)")
 	createCmd.Flags().StringVarP(&customImage, "custom_image", "", "", "custom container image (only if runtime == 'custom')")
 	createCmd.Flags().StringSliceVarP(&inputs, "input", "i", nil, "Input parameter: <name>:<type>")
@@ -154,6 +270,9 @@ func Init() {
 	rootCmd.AddCommand(pollCmd)
 	pollCmd.Flags().StringVarP(&requestId, "request", "", "", "ID of the async request")
 
+	rootCmd.AddCommand(cancelCmd)
+	cancelCmd.Flags().StringVarP(&requestId, "request", "", "", "ID of the request")
+
 	// Workflow
 
 	rootCmd.AddCommand(compInvokeCmd)
@@ -163,6 +282,7 @@ func Init() {
 	compInvokeCmd.Flags().StringSliceVarP(&params, "param", "p", nil, "Workflow parameter: <name>:<value>")
 	compInvokeCmd.Flags().StringVarP(&paramsFile, "params_file", "j", "", "File containing parameters (JSON) for workflow")
 	compInvokeCmd.Flags().BoolVarP(&asyncInvocation, "async", "a", false, "Asynchronous workflow invocation")
+	addAsyncOptionsFlags(compInvokeCmd)
 
 	rootCmd.AddCommand(compCreateCmd)
 	compCreateCmd.Flags().StringVarP(&compName, "workflow", "f", "", "name of the workflow")
@@ -173,12 +293,94 @@ func Init() {
 
 	rootCmd.AddCommand(compListCmd)
 
+	// Versions
+
+	rootCmd.AddCommand(publishCmd)
+	publishCmd.Flags().StringVarP(&funcName, "function", "f", "", "name of the function")
+
+	rootCmd.AddCommand(promoteCmd)
+	promoteCmd.Flags().StringVarP(&funcName, "function", "f", "", "name of the function")
+	promoteCmd.Flags().StringVarP(&aliasName, "alias", "", "", "name of the alias")
+	promoteCmd.Flags().IntVarP(&version, "version", "", 0, "version the alias points to")
+	promoteCmd.Flags().IntVarP(&canaryVersion, "canary", "", 0, "canary version receiving a share of the traffic (optional)")
+	promoteCmd.Flags().IntVarP(&canaryWeight, "canary_weight", "", 10, "percentage of the traffic sent to the canary version")
+
+	rootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().StringVarP(&funcName, "function", "f", "", "name of the function")
+	rollbackCmd.Flags().StringVarP(&aliasName, "alias", "", "", "name of the alias")
+
+	rootCmd.AddCommand(listVersionsCmd)
+	listVersionsCmd.Flags().StringVarP(&funcName, "function", "f", "", "name of the function")
+
+	// Schedules
+
+	rootCmd.AddCommand(scheduleCreateCmd)
+	scheduleCreateCmd.Flags().StringVarP(&scheduleName, "schedule", "s", "", "name of the schedule")
+	scheduleCreateCmd.Flags().StringVarP(&cronExpr, "cron", "", "", "cron expression (e.g., \"*/5 * * * *\")")
+	scheduleCreateCmd.Flags().StringVarP(&timezone, "timezone", "", "", "time zone of the cron expression (default: UTC)")
+	scheduleCreateCmd.Flags().StringVarP(&funcName, "function", "f", "", "function to invoke, optionally followed by @<version> or @<alias>")
+	scheduleCreateCmd.Flags().StringVarP(&compName, "workflow", "w", "", "workflow to invoke")
+	scheduleCreateCmd.Flags().StringSliceVarP(&params, "param", "p", nil, "Invocation parameter: <name>:<value>")
+	scheduleCreateCmd.Flags().StringVarP(&paramsFile, "params_file", "j", "", "File containing parameters (JSON)")
+	scheduleCreateCmd.Flags().StringVarP(&missedFirePolicy, "missed", "", "skip", "policy for missed invocations: skip, once or all")
+	scheduleCreateCmd.Flags().StringVarP(&area, "area", "", "", "area whose nodes fire the schedule (default: area of the contacted node)")
+	scheduleCreateCmd.Flags().BoolVarP(&update, "update", "u", false, "Overwrite any schedule with the same name")
+	addAsyncOptionsFlags(scheduleCreateCmd)
+
+	rootCmd.AddCommand(scheduleDeleteCmd)
+	scheduleDeleteCmd.Flags().StringVarP(&scheduleName, "schedule", "s", "", "name of the schedule")
+
+	rootCmd.AddCommand(scheduleListCmd)
+
+	rootCmd.AddCommand(scheduleHistoryCmd)
+	scheduleHistoryCmd.Flags().StringVarP(&scheduleName, "schedule", "s", "", "name of the schedule")
+
+	// Event triggers
+
+	rootCmd.AddCommand(triggerCreateCmd)
+	triggerCreateCmd.Flags().StringVarP(&triggerName, "trigger", "t", "", "name of the trigger")
+	triggerCreateCmd.Flags().StringVarP(&eventSource, "source", "", "", "source of the events: etcd, file or mqtt")
+	triggerCreateCmd.Flags().StringVarP(&eventPrefix, "prefix", "", "", "prefix of the Etcd keys watched, relative to the events of the namespace (etcd)")
+	triggerCreateCmd.Flags().StringVarP(&eventDirectory, "directory", "", "", "directory watched, relative to the root configured on the nodes (file)")
+	triggerCreateCmd.Flags().StringVarP(&filePattern, "pattern", "", "", "pattern of the names of the files, e.g. \"*.csv\" (file)")
+	triggerCreateCmd.Flags().StringVarP(&mqttTopic, "topic", "", "", "topic filter subscribed, relative to \"<namespace>/\", e.g. \"sensors/+/temperature\" (mqtt)")
+	triggerCreateCmd.Flags().IntVarP(&mqttQoS, "qos", "", 0, "QoS of the subscription and of the replies: 0, 1 or 2 (mqtt)")
+	triggerCreateCmd.Flags().StringVarP(&mqttReplyTopic, "reply-topic", "", "", "topic the results of the invocations are published to, relative to \"<namespace>/\" (mqtt)")
+	triggerCreateCmd.Flags().StringVarP(&funcName, "function", "f", "", "function to invoke, optionally followed by @<version> or @<alias>")
+	triggerCreateCmd.Flags().StringVarP(&compName, "workflow", "w", "", "workflow to invoke")
+	triggerCreateCmd.Flags().StringSliceVarP(&params, "param", "p", nil, "Additional invocation parameter: <name>:<value>")
+	triggerCreateCmd.Flags().StringVarP(&paramsFile, "params_file", "j", "", "File containing additional parameters (JSON)")
+	triggerCreateCmd.Flags().StringVarP(&area, "area", "", "", "area whose nodes deliver the events (default: area of the contacted node)")
+	triggerCreateCmd.Flags().BoolVarP(&update, "update", "u", false, "Overwrite any trigger with the same name")
+	addAsyncOptionsFlags(triggerCreateCmd)
+
+	rootCmd.AddCommand(triggerDeleteCmd)
+	triggerDeleteCmd.Flags().StringVarP(&triggerName, "trigger", "t", "", "name of the trigger")
+
+	rootCmd.AddCommand(triggerListCmd)
+
+	// API keys
+
+	rootCmd.AddCommand(keyCreateCmd)
+	keyCreateCmd.Flags().StringVarP(&keyRole, "role", "r", "invoker", "role of the key: admin, deployer or invoker")
+
+	rootCmd.AddCommand(keyDeleteCmd)
+	keyDeleteCmd.Flags().StringVarP(&keyId, "id", "", "", "ID of the key")
+
+	rootCmd.AddCommand(keyListCmd)
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+func addAsyncOptionsFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&asyncOptions.CallbackURL, "callback", "", "", "URL the result of an asynchronous invocation is POSTed to (optional)")
+	cmd.Flags().StringVarP(&asyncOptions.CallbackSecret, "callback_secret", "", "", "secret used to sign callbacks with HMAC-SHA256 (optional)")
+	cmd.Flags().Int64VarP(&asyncOptions.ResultTTL, "result_ttl", "", 0, "seconds the result of an asynchronous invocation is kept for polling (0 = server default)")
+}
+
 func showHelpAndExit(cmd *cobra.Command) {
 	err := cmd.Help()
 	if err != nil {
@@ -236,7 +438,8 @@ func invoke(cmd *cobra.Command, args []string) {
 		QoSMaxRespT:     qosMaxRespT,
 		CanDoOffloading: true,
 		ReturnOutput:    returnOutput,
-		Async:           asyncInvocation}
+		Async:           asyncInvocation,
+		AsyncOptions:    asyncOptions}
 	invocationBody, err := json.Marshal(request)
 	if err != nil {
 		showHelpAndExit(cmd)
@@ -244,14 +447,49 @@ func invoke(cmd *cobra.Command, args []string) {
 
 	// Send invocation request
 	url := fmt.Sprintf("http://%s:%d/invoke/%s", ServerConfig.Host, ServerConfig.Port, funcName)
-	resp, err := utils.PostJson(url, invocationBody)
+	if streamOutput {
+		url += "?stream=true"
+	}
+	resp, err := postJson(url, invocationBody)
 	if err != nil {
 		fmt.Printf("Invocation failed: %v\n", err)
 		os.Exit(2)
 	}
+	if streamOutput {
+		printStream(resp.Body)
+		return
+	}
 	utils.PrintJsonResponse(resp.Body)
 }
 
+// printStream prints the output and the partial results of a streamed invocation as they arrive, followed by the
+// result
+func printStream(body io.ReadCloser) {
+	defer func() {
+		_ = body.Close()
+	}()
+	err := executor.ReadEvents(body, func(event executor.Event) error {
+		switch event.Name {
+		case executor.OutputEvent:
+			fmt.Println(event.Data)
+		case executor.PartialEvent:
+			fmt.Printf("[partial] %s\n", event.Data)
+		case executor.ErrorEvent:
+			var streamErr executor.StreamError
+			_ = json.Unmarshal([]byte(event.Data), &streamErr)
+			fmt.Printf("Invocation failed: %d %s\n", streamErr.StatusCode, streamErr.Message)
+			os.Exit(2)
+		default:
+			utils.PrintJsonResponse(io.NopCloser(strings.NewReader(event.Data)))
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Invocation failed: %v\n", err)
+		os.Exit(2)
+	}
+}
+
 func buildSignature() (*function.Signature, error) {
 	sb := function.NewSignature()
 
@@ -307,7 +545,7 @@ func prewarm(cmd *cobra.Command, args []string) {
 	apiName := "prewarm"
 
 	url := fmt.Sprintf("http://%s:%d/%s", ServerConfig.Host, ServerConfig.Port, apiName)
-	resp, err := utils.PostJson(url, requestBody)
+	resp, err := postJson(url, requestBody)
 	if err != nil {
 		fmt.Printf("Prewarming request failed: %v\n", err)
 		os.Exit(2)
@@ -371,6 +609,7 @@ func create(cmd *cobra.Command, args []string) {
 		MaxConcurrency:  maxConcurrency,
 		MemoryMB:        memory,
 		CPUDemand:       cpuDemand,
+		TimeoutSeconds:  timeoutSeconds,
 		TarFunctionCode: encoded,
 		CustomImage:     customImage,
 		Signature:       sig,
@@ -386,7 +625,7 @@ func create(cmd *cobra.Command, args []string) {
 	}
 
 	url := fmt.Sprintf("http://%s:%d/%s", ServerConfig.Host, ServerConfig.Port, apiName)
-	resp, err := utils.PostJson(url, requestBody)
+	resp, err := postJson(url, requestBody)
 	if err != nil {
 		// TODO: check returned error code
 		fmt.Printf("Creation request failed: %v\n", err)
@@ -456,7 +695,7 @@ func deleteFunction(cmd *cobra.Command, args []string) {
 	}
 
 	url := fmt.Sprintf("http://%s:%d/delete", ServerConfig.Host, ServerConfig.Port)
-	resp, err := utils.PostJson(url, requestBody)
+	resp, err := postJson(url, requestBody)
 	if err != nil {
 		fmt.Printf("Deletion request failed: %v\n", err)
 		os.Exit(2)
@@ -466,7 +705,7 @@ func deleteFunction(cmd *cobra.Command, args []string) {
 
 func listFunctions(cmd *cobra.Command, args []string) {
 	url := fmt.Sprintf("http://%s:%d/function", ServerConfig.Host, ServerConfig.Port)
-	resp, err := http.Get(url)
+	resp, err := get(url)
 	if err != nil {
 		fmt.Printf("List request failed: %v\n", err)
 		os.Exit(2)
@@ -476,7 +715,7 @@ func listFunctions(cmd *cobra.Command, args []string) {
 
 func getStatus(cmd *cobra.Command, args []string) {
 	url := fmt.Sprintf("http://%s:%d/status", ServerConfig.Host, ServerConfig.Port)
-	resp, err := http.Get(url)
+	resp, err := get(url)
 	if err != nil {
 		fmt.Printf("Invocation failed: %v", err)
 		os.Exit(2)
@@ -490,7 +729,7 @@ func poll(cmd *cobra.Command, args []string) {
 	}
 
 	url := fmt.Sprintf("http://%s:%d/poll/%s", ServerConfig.Host, ServerConfig.Port, requestId)
-	resp, err := http.Get(url)
+	resp, err := get(url)
 	if err != nil {
 		fmt.Printf("Polling request failed: %v\n", err)
 		os.Exit(2)
@@ -499,6 +738,21 @@ func poll(cmd *cobra.Command, args []string) {
 	fmt.Println()
 }
 
+func cancel(cmd *cobra.Command, args []string) {
+	if len(requestId) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/cancel/%s", ServerConfig.Host, ServerConfig.Port, requestId)
+	resp, err := postJson(url, nil)
+	if err != nil {
+		fmt.Printf("Cancellation request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+	fmt.Println()
+}
+
 func invokeWorkflow(cmd *cobra.Command, args []string) {
 	if len(compName) < 1 {
 		fmt.Printf("Invalid workflow name.\n")
@@ -542,7 +796,8 @@ func invokeWorkflow(cmd *cobra.Command, args []string) {
 			Class:    qosClass,
 			MaxRespT: qosMaxRespT,
 		},
-		Async: asyncInvocation}
+		Async:        asyncInvocation,
+		AsyncOptions: asyncOptions}
 	invocationBody, err := json.Marshal(request)
 	if err != nil {
 		cmd.Help()
@@ -551,7 +806,11 @@ func invokeWorkflow(cmd *cobra.Command, args []string) {
 
 	// Send invocation request
 	url := fmt.Sprintf("http://%s:%d/workflow/invoke/%s", ServerConfig.Host, ServerConfig.Port, compName)
-	resp, err := utils.PostJson(url, invocationBody)
+	resp, err := postJson(url, invocationBody)
+	if resp != nil && resp.StatusCode == http.StatusAccepted {
+		// the workflow has been suspended by a Wait state: the response contains the id to poll for the result
+		err = nil
+	}
 	if err != nil {
 		fmt.Println(err)
 		if resp != nil {
@@ -585,7 +844,7 @@ func createWorkflow(cmd *cobra.Command, args []string) {
 	}
 
 	url := fmt.Sprintf("http://%s:%d/workflow/create", ServerConfig.Host, ServerConfig.Port)
-	resp, err := utils.PostJson(url, requestBody)
+	resp, err := postJson(url, requestBody)
 	if err != nil {
 		// TODO: check returned error code
 		fmt.Printf("Creation request failed: %v\n", err)
@@ -608,7 +867,7 @@ func deleteWorkflow(cmd *cobra.Command, args []string) {
 		os.Exit(2)
 	}
 	url := fmt.Sprintf("http://%s:%d/workflow/delete", ServerConfig.Host, ServerConfig.Port)
-	resp, err := utils.PostJson(url, requestBody)
+	resp, err := postJson(url, requestBody)
 	if err != nil {
 		fmt.Printf("Deletion request failed: %v\n", err)
 		os.Exit(2)
@@ -618,7 +877,7 @@ func deleteWorkflow(cmd *cobra.Command, args []string) {
 
 func listWorkflows(cmd *cobra.Command, args []string) {
 	url := fmt.Sprintf("http://%s:%d/workflow/list", ServerConfig.Host, ServerConfig.Port)
-	resp, err := http.Get(url)
+	resp, err := get(url)
 	if err != nil {
 		fmt.Printf("List request failed: %v\n", err)
 		os.Exit(2)
@@ -629,3 +888,349 @@ func listWorkflows(cmd *cobra.Command, args []string) {
 func IsWindows() bool {
 	return os.PathSeparator == '\\' && os.PathListSeparator == ';'
 }
+
+func publish(cmd *cobra.Command, args []string) {
+	if len(funcName) < 1 {
+		fmt.Println("Missing function name.")
+		showHelpAndExit(cmd)
+	}
+
+	request := client.PublishRequest{Function: funcName}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/publish", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Publication request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func promote(cmd *cobra.Command, args []string) {
+	if len(funcName) < 1 || len(aliasName) < 1 || version < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	routing := []function.VersionWeight{{Version: version, Weight: 100}}
+	if canaryVersion > 0 {
+		if canaryWeight < 0 || canaryWeight > 100 {
+			fmt.Println("The canary weight must be a percentage.")
+			showHelpAndExit(cmd)
+		}
+		routing = []function.VersionWeight{
+			{Version: version, Weight: 100 - canaryWeight},
+			{Version: canaryVersion, Weight: canaryWeight},
+		}
+	}
+
+	request := client.AliasRequest{Function: funcName, Alias: aliasName, Routing: routing}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/alias", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Alias request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func rollback(cmd *cobra.Command, args []string) {
+	if len(funcName) < 1 || len(aliasName) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	request := client.RollbackRequest{Function: funcName, Alias: aliasName}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/rollback", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Rollback request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func listVersions(cmd *cobra.Command, args []string) {
+	if len(funcName) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/versions/%s", ServerConfig.Host, ServerConfig.Port, funcName)
+	resp, err := get(url)
+	if err != nil {
+		fmt.Printf("List request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+// readParams returns the parameters given either via file ("--params_file") or via cli ("--param")
+func readParams(cmd *cobra.Command) map[string]interface{} {
+	paramsMap := make(map[string]interface{})
+	if len(params) > 0 && len(paramsFile) > 0 {
+		fmt.Println("Parameters must be specified using either --param OR --params_file")
+		os.Exit(1)
+	}
+	for _, rawParam := range params {
+		tokens := strings.Split(rawParam, ":")
+		if len(tokens) < 2 {
+			showHelpAndExit(cmd)
+		}
+		paramsMap[tokens[0]] = strings.Join(tokens[1:], ":")
+	}
+	if len(paramsFile) > 0 {
+		byteValue, err := os.ReadFile(paramsFile)
+		if err == nil {
+			err = json.Unmarshal(byteValue, &paramsMap)
+		}
+		if err != nil {
+			fmt.Printf("Could not parse JSON-encoded parameters from '%s'\n", paramsFile)
+			os.Exit(1)
+		}
+	}
+	return paramsMap
+}
+
+// newTarget returns the function or workflow to invoke, selected with "--function" or "--workflow"
+func newTarget(cmd *cobra.Command) trigger.Target {
+	if (len(funcName) < 1) == (len(compName) < 1) {
+		fmt.Println("Either --function or --workflow must be specified")
+		showHelpAndExit(cmd)
+	}
+	return trigger.Target{
+		Function:     funcName,
+		Workflow:     compName,
+		Params:       readParams(cmd),
+		AsyncOptions: asyncOptions,
+	}
+}
+
+func createSchedule(cmd *cobra.Command, args []string) {
+	if len(scheduleName) < 1 || len(cronExpr) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	request := trigger.Schedule{
+		Name:             scheduleName,
+		Cron:             cronExpr,
+		Timezone:         timezone,
+		MissedFirePolicy: missedFirePolicy,
+		Area:             area,
+		Target:           newTarget(cmd),
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/schedule/create", ServerConfig.Host, ServerConfig.Port)
+	if update {
+		url = fmt.Sprintf("http://%s:%d/schedule/update", ServerConfig.Host, ServerConfig.Port)
+	}
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Creation request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func deleteSchedule(cmd *cobra.Command, args []string) {
+	if len(scheduleName) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	requestBody, err := json.Marshal(client.ScheduleRequest{Name: scheduleName})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/schedule/delete", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Deletion request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func listSchedules(cmd *cobra.Command, args []string) {
+	url := fmt.Sprintf("http://%s:%d/schedule/list", ServerConfig.Host, ServerConfig.Port)
+	resp, err := get(url)
+	if err != nil {
+		fmt.Printf("List request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func getScheduleHistory(cmd *cobra.Command, args []string) {
+	if len(scheduleName) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/schedule/history/%s", ServerConfig.Host, ServerConfig.Port, scheduleName)
+	resp, err := get(url)
+	if err != nil {
+		fmt.Printf("History request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func createTrigger(cmd *cobra.Command, args []string) {
+	if len(triggerName) < 1 || len(eventSource) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	request := trigger.Trigger{
+		Name:       triggerName,
+		Source:     eventSource,
+		Prefix:     eventPrefix,
+		Directory:  eventDirectory,
+		Pattern:    filePattern,
+		Topic:      mqttTopic,
+		QoS:        mqttQoS,
+		ReplyTopic: mqttReplyTopic,
+		Area:       area,
+		Target:     newTarget(cmd),
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/trigger/create", ServerConfig.Host, ServerConfig.Port)
+	if update {
+		url = fmt.Sprintf("http://%s:%d/trigger/update", ServerConfig.Host, ServerConfig.Port)
+	}
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Creation request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func deleteTrigger(cmd *cobra.Command, args []string) {
+	if len(triggerName) < 1 {
+		showHelpAndExit(cmd)
+	}
+
+	requestBody, err := json.Marshal(client.TriggerRequest{Name: triggerName})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/trigger/delete", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Deletion request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func listTriggers(cmd *cobra.Command, args []string) {
+	url := fmt.Sprintf("http://%s:%d/trigger/list", ServerConfig.Host, ServerConfig.Port)
+	resp, err := get(url)
+	if err != nil {
+		fmt.Printf("List request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func createAPIKey(cmd *cobra.Command, args []string) {
+	// the key is created in the namespace selected with --namespace
+	request := client.APIKeyCreationRequest{Namespace: ServerConfig.Namespace, Role: keyRole}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/apikey/create", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Creation request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func deleteAPIKey(cmd *cobra.Command, args []string) {
+	if keyId == "" {
+		showHelpAndExit(cmd)
+	}
+	request := client.APIKeyDeletionRequest{Id: keyId}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	url := fmt.Sprintf("http://%s:%d/apikey/delete", ServerConfig.Host, ServerConfig.Port)
+	resp, err := postJson(url, requestBody)
+	if err != nil {
+		fmt.Printf("Deletion request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+func listAPIKeys(cmd *cobra.Command, args []string) {
+	url := fmt.Sprintf("http://%s:%d/apikey/list", ServerConfig.Host, ServerConfig.Port)
+	resp, err := get(url)
+	if err != nil {
+		fmt.Printf("List request failed: %v\n", err)
+		os.Exit(2)
+	}
+	utils.PrintJsonResponse(resp.Body)
+}
+
+// identityHeaders returns the headers with the namespace and the API key selected by the user
+func identityHeaders() map[string]string {
+	headers := make(map[string]string)
+	if ServerConfig.Namespace != "" {
+		headers[auth.NamespaceHeader] = ServerConfig.Namespace
+	}
+	if ServerConfig.APIKey != "" {
+		headers[auth.APIKeyHeader] = ServerConfig.APIKey
+	}
+	return headers
+}
+
+func postJson(url string, body []byte) (*http.Response, error) {
+	return utils.PostJsonWithHeaders(url, body, identityHeaders())
+}
+
+func get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range identityHeaders() {
+		req.Header.Set(k, v)
+	}
+	return http.DefaultClient.Do(req)
+}
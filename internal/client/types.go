@@ -1,6 +1,7 @@
 package client
 
 import (
+	"github.com/serverledge-faas/serverledge/internal/executor"
 	"github.com/serverledge-faas/serverledge/internal/function"
 )
 
@@ -12,6 +13,8 @@ type InvocationRequest struct {
 	CanDoOffloading bool
 	Async           bool
 	ReturnOutput    bool
+	function.AsyncOptions
+	HTTP *executor.HTTPRequest `json:",omitempty"` // raw HTTP request, for invocations through the HTTP trigger (forwarded when offloading)
 }
 
 type PrewarmingRequest struct {
@@ -26,9 +29,48 @@ type WorkflowInvocationRequest struct {
 	QoS             function.RequestQoS
 	CanDoOffloading bool
 	Async           bool
+	function.AsyncOptions
 }
 
 type WorkflowCreationRequest struct {
 	Name   string // Name of the new workflow
 	ASLSrc string // Specification source in Amazon State Language (encoded in Base64)
 }
+
+// APIKeyCreationRequest asks for a new API key (admin only)
+type APIKeyCreationRequest struct {
+	Namespace string
+	Role      string // "admin", "deployer" or "invoker"
+}
+
+type APIKeyDeletionRequest struct {
+	Id string // identifier of the key, as returned upon creation
+}
+
+// PublishRequest asks to save the current definition of a function as a new immutable version
+type PublishRequest struct {
+	Function string
+}
+
+// AliasRequest creates an alias of a function, or points an existing one to (possibly several) versions
+type AliasRequest struct {
+	Function string
+	Alias    string
+	Routing  []function.VersionWeight
+}
+
+// RollbackRequest restores the routing of an alias before its last update
+type RollbackRequest struct {
+	Function string
+	Alias    string
+}
+
+// ScheduleRequest identifies a schedule (e.g., to delete it)
+type ScheduleRequest struct {
+	Name string
+}
+
+// TriggerRequest identifies an event trigger (e.g., to delete it)
+type TriggerRequest struct {
+	Name string
+}
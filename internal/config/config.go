@@ -51,6 +51,14 @@ func GetBool(key string, defaultValue bool) bool {
 	}
 }
 
+func GetStringSlice(key string, defaultValue []string) []string {
+	if viper.IsSet(key) {
+		return viper.GetStringSlice(key)
+	} else {
+		return defaultValue
+	}
+}
+
 func GetStringMapFloat64(key string) map[string]float64 {
 	raw := viper.GetStringMap(key)
 	if raw == nil {
@@ -7,10 +7,39 @@ const ETCD_ADDRESS = "etcd.address"
 const API_PORT = "api.port"
 const API_IP = "api.ip"
 
+// Requires an API key for the requests to the API server (true/false)
+const API_AUTH_ENABLED = "api.auth.enabled"
+
+// Bootstrap API key with the admin role, used to create the other keys
+const API_AUTH_ADMIN_KEY = "api.auth.admin.key"
+
 // Forces runtime container images to be pulled the first time they are used,
 // even if they are locally available (true/false).
 const FACTORY_REFRESH_IMAGES = "factory.images.refresh"
 
+// Container factory used to run functions: "docker" (default), "containerd" or "process"
+const FACTORY_TYPE = "factory.type"
+
+// Path of the containerd socket, used by the containerd factory
+const FACTORY_CONTAINERD_ADDRESS = "factory.containerd.address"
+
+// Containerd namespace of the containers and images of the node
+const FACTORY_CONTAINERD_NAMESPACE = "factory.containerd.namespace"
+
+// Directory of the CNI plugins used to connect containerd containers
+const FACTORY_CONTAINERD_CNI_BIN_DIR = "factory.containerd.cni.bin"
+
+// Path of the CNI network configuration list for containerd containers. If not set,
+// containers are connected to a bridge network with factory.containerd.cni.subnet
+const FACTORY_CONTAINERD_CNI_CONF = "factory.containerd.cni.conf"
+
+// Subnet of the default bridge network of containerd containers
+const FACTORY_CONTAINERD_CNI_SUBNET = "factory.containerd.cni.subnet"
+
+// Directory of the runtime images sources (i.e., "images" in the repository), from which the process factory
+// runs the executors on the host
+const FACTORY_PROCESS_IMAGES_DIR = "factory.process.images.dir"
+
 // Amount of memory available for the container pool (in MB)
 const POOL_MEMORY_MB = "container.pool.memory"
 
@@ -23,6 +52,21 @@ const POOL_CLEANUP_PERIOD = "janitor.interval"
 // container expiration time
 const CONTAINER_EXPIRATION_TIME = "container.expiration"
 
+// policy to choose the idle containers to dismiss when memory is needed: "lru", "lfu" or "greedydual"
+const CONTAINER_EVICTION_POLICY = "container.eviction.policy"
+
+// keep-alive policy for idle containers: "fixed" (container.expiration) or "histogram" (adaptive, with prewarming)
+const KEEPALIVE_POLICY = "container.keepalive.policy"
+
+// width (in seconds) of the bins of the histogram of inter-arrival times
+const KEEPALIVE_HISTOGRAM_BIN = "container.keepalive.histogram.bin"
+
+// number of bins of the histogram of inter-arrival times (longer times are out of bounds)
+const KEEPALIVE_HISTOGRAM_BINS = "container.keepalive.histogram.bins"
+
+// inter-arrival times to record before predicting the windows from the histogram
+const KEEPALIVE_HISTOGRAM_MIN_SAMPLES = "container.keepalive.histogram.minsamples"
+
 // offloading cache validity time for EdgeOnlypolicy
 const OFFLOADING_CACHE_VALIDITY = "offloading.cache.validity"
 
@@ -35,6 +79,9 @@ const CACHE_CLEANUP = "cache.cleanup"
 // default expiration time assigned to a cache item (Seconds)
 const CACHE_ITEM_EXPIRATION = "cache.expiration"
 
+// watch etcd to invalidate cached functions and workflows as soon as they change (boolean)
+const CACHE_WATCH = "cache.watch"
+
 // default policy is to persist cache (boolean). Use false in localonly deployments
 const CACHE_PERSISTENCE = "cache.persistence"
 
@@ -93,7 +140,7 @@ const METRICS_PROMETHEUS_HOST = "metrics.prometheus.host"
 const METRICS_RETRIEVER_INTERVAL = "metrics.retriever.interval"
 
 // Scheduling policy to use
-// Possible values: "qosaware", "default", "cloudonly"
+// Possible values: "default", "cloudonly", "edgecloud", "edgeonly", "qosaware"
 const SCHEDULING_POLICY = "scheduler.policy"
 
 // If "edgeonly" policy is set, tries to execute the offloadable functions locally in the case
@@ -103,6 +150,13 @@ const SCHEDULING_FALLBACK_LOCAL = "scheduler.fallback.local"
 // Capacity of the queue (possibly) used by the scheduler
 const SCHEDULER_QUEUE_CAPACITY = "scheduler.queue.capacity"
 
+// Discipline of the scheduler queue: "fifo" (default), "priority" (higher QoS class first) or "edf"
+// (earliest deadline first, where the deadline is the arrival time plus the max response time)
+const SCHEDULER_QUEUE_DISCIPLINE = "scheduler.queue.discipline"
+
+// Drops the queued requests whose deadline has already passed, rather than executing them (true/false)
+const SCHEDULER_QUEUE_DROP_EXPIRED = "scheduler.queue.drop.expired"
+
 // Enables tracing
 const TRACING_ENABLED = "tracing.enabled"
 
@@ -141,3 +195,68 @@ const WORKFLOW_THRESHOLD_BASED_POLICY_THRESHOLD = "workflow.offloading.policy.th
 
 // Max number of tasks offloaded at once in the threshold-based offloading policy
 const WORKFLOW_THRESHOLD_BASED_POLICY_MAX_OFFLOADED = "workflow.offloading.policy.threshold.offloaded.max"
+
+// Interval (in seconds) between the scans for expired timers of suspended workflows
+const WORKFLOW_TIMER_SCAN_INTERVAL = "workflow.timer.scan.interval"
+
+// Seconds the results of asynchronous requests are kept for polling, unless requests specify a ResultTTL
+const ASYNC_RESULT_TTL = "async.result.ttl"
+
+// Attempts to deliver the result of an asynchronous request to its callback URL
+const CALLBACK_ATTEMPTS = "async.callback.attempts"
+
+// Seconds before retrying the delivery of a callback, doubled after every failed attempt
+const CALLBACK_BACKOFF = "async.callback.backoff"
+
+// Seconds the dead letters of undelivered callbacks are kept in Etcd (0 = forever)
+const CALLBACK_DEAD_LETTER_TTL = "async.callback.deadletter.ttl"
+
+// Hosts the results of asynchronous requests can be POSTed to (e.g., "hooks.example.com" or "*.example.com"); any
+// host is allowed if empty
+const CALLBACK_ALLOWED_HOSTS = "async.callback.allowed.hosts"
+
+// Key used to encrypt the callback secrets of the suspended workflows saved in Etcd (the same on every node)
+const CALLBACK_SECRET_KEY = "async.callback.secret.key"
+
+// Fires the schedules of the local area, if the node is elected as their leader (true/false)
+const TRIGGER_SCHEDULE_ENABLED = "trigger.schedule.enabled"
+
+// Interval (in seconds) between the checks for due schedules
+const TRIGGER_SCHEDULE_INTERVAL = "trigger.schedule.interval"
+
+// Seconds after which an occurrence of a schedule is considered missed, and handled according to its policy
+const TRIGGER_SCHEDULE_MISFIRE_THRESHOLD = "trigger.schedule.misfire.threshold"
+
+// Number of firings kept in the history of each schedule
+const TRIGGER_SCHEDULE_HISTORY_SIZE = "trigger.schedule.history.size"
+
+// TTL (in seconds) of the lease of the leaders of the triggers in each area
+const TRIGGER_LEADER_TTL = "trigger.leader.ttl"
+
+// Delivers the events of the triggers of the local area (true/false)
+const TRIGGER_EVENT_ENABLED = "trigger.event.enabled"
+
+// Root of the directories watched by file triggers (file triggers are disabled if empty)
+const TRIGGER_FILE_ROOT = "trigger.file.root"
+
+// Interval (in seconds) between the scans of the directories watched by file triggers
+const TRIGGER_FILE_INTERVAL = "trigger.file.interval"
+
+// Max size (in bytes) of the files whose content is passed to the functions invoked by file triggers
+const TRIGGER_FILE_MAX_SIZE = "trigger.file.maxsize"
+
+// Seconds the claims of the files delivered by file triggers are kept in Etcd, to deliver each file once
+const TRIGGER_FILE_CLAIM_TTL = "trigger.file.claim.ttl"
+
+// URL of the MQTT broker subscribed by MQTT triggers, e.g. tcp://broker:1883 (MQTT triggers are disabled if empty)
+const TRIGGER_MQTT_BROKER = "trigger.mqtt.broker"
+
+// Client ID used by the leader of the area to connect to the MQTT broker (default: serverledge-<area>)
+const TRIGGER_MQTT_CLIENT_ID = "trigger.mqtt.client.id"
+
+// Credentials used to connect to the MQTT broker
+const TRIGGER_MQTT_USERNAME = "trigger.mqtt.username"
+const TRIGGER_MQTT_PASSWORD = "trigger.mqtt.password"
+
+// Max seconds between the attempts to reconnect to the MQTT broker, doubled after every failed attempt
+const TRIGGER_MQTT_MAX_BACKOFF = "trigger.mqtt.backoff.max"
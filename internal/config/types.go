@@ -1,6 +1,8 @@
 package config
 
 type RemoteServerConf struct {
-	Host string
-	Port int
+	Host      string
+	Port      int
+	Namespace string // namespace selected by the client (optional)
+	APIKey    string // key used to authenticate the client (optional)
 }
@@ -2,13 +2,16 @@ package container
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/serverledge-faas/serverledge/internal/function"
@@ -16,8 +19,22 @@ import (
 	"github.com/serverledge-faas/serverledge/internal/executor"
 )
 
+// ExecutionTimeoutErr is returned when the function handler exceeds its timeout.
+var ExecutionTimeoutErr = errors.New("function execution timed out")
+
+// UnresponsiveExecutorErr is returned when the executor does not reply even
+// after the function timeout has expired (e.g., the handler cannot be killed).
+var UnresponsiveExecutorErr = fmt.Errorf("%w: executor did not reply", ExecutionTimeoutErr)
+
+// executorTimeoutGrace is the extra time given to the executor to kill a
+// timed-out handler and reply.
+const executorTimeoutGrace = 5 * time.Second
+
 // CreateContainer creates and starts a new container.
 func CreateContainer(f *function.Function, forceImagePull bool) (*Container, error) {
+	if f.Runtime == WASI_RUNTIME {
+		return createWasiContainer(f)
+	}
 	image, err := getImageForFunction(f)
 	if err != nil {
 		return nil, err
@@ -58,24 +75,11 @@ func newContainer(image, codeTar string, opts *ContainerOptions) (*Container, er
 	}
 
 	if len(codeTar) > 0 {
-		var r io.Reader
-		// Decoding codeTar
-		decodedCode, _ := base64.StdEncoding.DecodeString(codeTar)
-		// Check if decoded src is a url
-		u, err := url.ParseRequestURI(string(decodedCode))
-		if err == nil && u.Scheme != "" && u.Host != "" {
-			// codeTar is an URL; it has to be downloaded
-			resp, err := http.Get(string(decodedCode))
-			if err != nil {
-				log.Printf("Failed to download code %s", decodedCode)
-				return nil, err
-			}
-			defer resp.Body.Close()
-			r = resp.Body
-		} else {
-			// assuming decodedCode is base64 encoded tar
-			r = bytes.NewReader(decodedCode)
+		r, err := readFunctionCode(codeTar)
+		if err != nil {
+			return nil, err
 		}
+		defer r.Close()
 		err = cf.CopyToContainer(contID, r, "/app/")
 		if err != nil {
 			log.Printf("Failed code copy\n")
@@ -97,18 +101,57 @@ func newContainer(image, codeTar string, opts *ContainerOptions) (*Container, er
 	return container, nil
 }
 
+// readFunctionCode returns the tar archive with the code of a function, which is either encoded in base64 or
+// downloaded from the URL encoded in base64
+func readFunctionCode(codeTar string) (io.ReadCloser, error) {
+	// Decoding codeTar
+	decodedCode, _ := base64.StdEncoding.DecodeString(codeTar)
+	// Check if decoded src is a url
+	u, err := url.ParseRequestURI(string(decodedCode))
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		// codeTar is an URL; it has to be downloaded
+		resp, err := http.Get(string(decodedCode))
+		if err != nil {
+			log.Printf("Failed to download code %s", decodedCode)
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	// assuming decodedCode is base64 encoded tar
+	return io.NopCloser(bytes.NewReader(decodedCode)), nil
+}
+
 // Execute interacts with the Executor running in the container to invoke the
-// function through a HTTP request.
-func Execute(contID ContainerID, req *executor.InvocationRequest) (*executor.InvocationResult, time.Duration, error) {
+// function through a HTTP request. If the request specifies a timeout, the
+// Executor is expected to kill the handler and reply with ExecutionTimeoutErr;
+// if no reply arrives within a short grace period after the timeout,
+// UnresponsiveExecutorErr is returned and the container should not be reused.
+// If the request is streamed, onEvent receives the output and the partial results of the handler while it runs
+// (executors that do not support streaming reply with the result only).
+func Execute(ctx context.Context, contID ContainerID, req *executor.InvocationRequest, onEvent func(executor.Event)) (*executor.InvocationResult, time.Duration, error) {
+	if m, ok := getWasiModule(contID); ok {
+		// WASI modules are run by the node itself
+		result, err := m.invoke(ctx, req)
+		return result, 0, err
+	}
+
 	ipAddr, err := cf.GetIPAddress(contID)
 	if err != nil {
 		return nil, 0, fmt.Errorf("Failed to retrieve IP address for container: %v", err)
 	}
+	port, err := getExecutorPort(contID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to retrieve executor port for container: %v", err)
+	}
+
+	var timeout time.Duration
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds)*time.Second + executorTimeoutGrace
+	}
 
 	postBody, _ := json.Marshal(req)
-	postBodyB := bytes.NewBuffer(postBody) // this buffer will be erased after send
-	resp, waitDuration, err := sendPostRequestWithRetries(fmt.Sprintf("http://%s:%d/invoke", ipAddr,
-		executor.DEFAULT_EXECUTOR_PORT), postBodyB)
+	resp, waitDuration, err := sendPostRequestWithRetries(ctx, fmt.Sprintf("http://%s:%d/invoke", ipAddr, port),
+		postBody, timeout)
 	if err != nil {
 		if resp != nil {
 			buffer, err2 := io.ReadAll(resp.Body)
@@ -117,22 +160,32 @@ func Execute(contID ContainerID, req *executor.InvocationRequest) (*executor.Inv
 			}
 			return nil, waitDuration, fmt.Errorf("request to executor failed: %v - response: %s", err, buffer)
 		}
+		if errors.Is(err, UnresponsiveExecutorErr) {
+			return nil, waitDuration, err
+		}
 		return nil, waitDuration, fmt.Errorf("request to executor failed: %v", err)
 	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Printf("Error while closing response body\n")
+		}
+	}(resp.Body)
 
-	if resp.StatusCode != 200 {
+	if resp.StatusCode == http.StatusGatewayTimeout {
+		return nil, waitDuration, ExecutionTimeoutErr
+	} else if resp.StatusCode != 200 {
 		buffer, err2 := io.ReadAll(resp.Body)
 		if err2 != nil {
 			return nil, waitDuration, fmt.Errorf("function invocation  %v failed with status %s: - can't get response buffer %v", req, resp.Status, err2)
 		}
 		return nil, waitDuration, fmt.Errorf("function invocation %v failed with status %s: %s", req, resp.Status, buffer)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			log.Printf("Error while closing response body\n")
-		}
-	}(resp.Body)
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), executor.StreamContentType) {
+		response, err := readStream(resp.Body, onEvent)
+		return response, waitDuration, err
+	}
 
 	d := json.NewDecoder(resp.Body)
 	response := &executor.InvocationResult{}
@@ -144,19 +197,79 @@ func Execute(contID ContainerID, req *executor.InvocationRequest) (*executor.Inv
 	return response, waitDuration, nil
 }
 
+// readStream reads the events streamed by the executor, passing the output and the partial results to onEvent, and
+// returns the result that ends the stream
+func readStream(body io.Reader, onEvent func(executor.Event)) (*executor.InvocationResult, error) {
+	var response *executor.InvocationResult
+	err := executor.ReadEvents(body, func(event executor.Event) error {
+		switch event.Name {
+		case executor.ResultEvent:
+			response = &executor.InvocationResult{}
+			if err := json.Unmarshal([]byte(event.Data), response); err != nil {
+				return fmt.Errorf("Parsing executor response failed: %v", err)
+			}
+		case executor.ErrorEvent:
+			var streamErr executor.StreamError
+			_ = json.Unmarshal([]byte(event.Data), &streamErr)
+			if streamErr.StatusCode == http.StatusGatewayTimeout {
+				return ExecutionTimeoutErr
+			}
+			return fmt.Errorf("function invocation failed with status %d: %s", streamErr.StatusCode, streamErr.Message)
+		default:
+			if onEvent != nil {
+				onEvent(event)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, UnresponsiveExecutorErr
+	} else if err != nil {
+		return nil, err
+	} else if response == nil {
+		return nil, fmt.Errorf("executor stream ended with no result")
+	}
+	return response, nil
+}
+
+// executorPortGetter is implemented by factories whose executors do not listen on executor.DEFAULT_EXECUTOR_PORT
+// (e.g., the ProcessFactory, whose executors share the loopback address)
+type executorPortGetter interface {
+	GetExecutorPort(ContainerID) (int, error)
+}
+
+func getExecutorPort(contID ContainerID) (int, error) {
+	if f, ok := cf.(executorPortGetter); ok {
+		return f.GetExecutorPort(contID)
+	}
+	return executor.DEFAULT_EXECUTOR_PORT, nil
+}
+
 func GetMemoryMB(id ContainerID) (int64, error) {
+	if m, ok := getWasiModule(id); ok {
+		return m.memoryMB, nil
+	}
 	return cf.GetMemoryMB(id)
 }
 
 func Destroy(id ContainerID) error {
+	if _, ok := getWasiModule(id); ok {
+		return destroyWasiModule(id)
+	}
 	return cf.Destroy(id)
 }
 
 func GetLog(id ContainerID) (string, error) {
+	if m, ok := getWasiModule(id); ok {
+		return m.getLog(), nil
+	}
 	return cf.GetLog(id)
 }
 
-func sendPostRequestWithRetries(url string, body *bytes.Buffer) (*http.Response, time.Duration, error) {
+// sendPostRequestWithRetries posts the body to the executor, retrying while the
+// container is still starting. A non-zero timeout bounds the wait for the reply
+// once the request has been accepted.
+func sendPostRequestWithRetries(ctx context.Context, url string, body []byte, timeout time.Duration) (*http.Response, time.Duration, error) {
 	const TIMEOUT_MILLIS = 30000
 	const MAX_BACKOFF_MILLIS = 1000
 	var backoffMillis = 50
@@ -166,9 +279,13 @@ func sendPostRequestWithRetries(url string, body *bytes.Buffer) (*http.Response,
 	var err error
 
 	for totalWaitMillis < TIMEOUT_MILLIS {
-		resp, err := http.Post(url, "application/json", body)
+		var resp *http.Response
+		resp, err = postWithTimeout(ctx, url, body, timeout)
 		if err == nil {
 			return resp, time.Duration(totalWaitMillis * int(time.Millisecond)), err
+		} else if ctx.Err() != nil || errors.Is(err, UnresponsiveExecutorErr) {
+			// no point in retrying
+			return nil, time.Duration(totalWaitMillis * int(time.Millisecond)), err
 		} else if attempts > 3 {
 			// It is common to have a failure after a cold start, so
 			// we avoid logging failures on the first attempt(s)
@@ -187,6 +304,45 @@ func sendPostRequestWithRetries(url string, body *bytes.Buffer) (*http.Response,
 	return nil, time.Duration(totalWaitMillis * int(time.Millisecond)), err
 }
 
+func postWithTimeout(ctx context.Context, url string, body []byte, timeout time.Duration) (*http.Response, error) {
+	var reqCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		reqCtx, cancel = context.WithCancel(ctx)
+	}
+
+	httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		cancel()
+		if ctx.Err() == nil && errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+			return nil, UnresponsiveExecutorErr
+		}
+		return nil, err
+	}
+	// the context must stay alive until the response body has been read
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
 func minInt(a, b int) int {
 	if a <= b {
 		return a
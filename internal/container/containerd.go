@@ -0,0 +1,350 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/archive"
+	"github.com/containerd/containerd/v2/pkg/cio"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/pkg/netns"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	gocni "github.com/containerd/go-cni"
+	"github.com/distribution/reference"
+	"github.com/lithammer/shortuuid"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/serverledge-faas/serverledge/internal/config"
+)
+
+const defaultContainerdAddress = "/run/containerd/containerd.sock"
+const defaultContainerdNamespace = "serverledge"
+const defaultCNIBinDir = "/opt/cni/bin"
+const defaultCNISubnet = "10.62.0.0/16"
+
+// netnsDir is the directory where the network namespaces of the containers are mounted
+const netnsDir = "/var/run/netns"
+
+// defaultCNIConfList connects the containers to a bridge network, with the subnet given as parameter
+const defaultCNIConfList = `{
+	"cniVersion": "1.0.0",
+	"name": "serverledge",
+	"plugins": [
+		{
+			"type": "bridge",
+			"bridge": "serverledge0",
+			"isGateway": true,
+			"ipMasq": true,
+			"ipam": {
+				"type": "host-local",
+				"subnet": "%s",
+				"routes": [{"dst": "0.0.0.0/0"}]
+			}
+		}
+	]
+}`
+
+// ContainerdFactory runs functions in containerd containers, which are connected to the network through CNI
+// plugins. It does not need the Docker daemon.
+type ContainerdFactory struct {
+	client     *containerd.Client
+	cni        gocni.CNI
+	ctx        context.Context
+	logDir     string
+	mutex      sync.Mutex
+	containers map[ContainerID]*containerdContainer
+}
+
+// containerdContainer keeps the network of a container, which is not managed by containerd
+type containerdContainer struct {
+	netns     *netns.NetNS
+	ipAddress string
+}
+
+func InitContainerdFactory() *ContainerdFactory {
+	address := config.GetString(config.FACTORY_CONTAINERD_ADDRESS, defaultContainerdAddress)
+	namespace := config.GetString(config.FACTORY_CONTAINERD_NAMESPACE, defaultContainerdNamespace)
+	client, err := containerd.New(address, containerd.WithDefaultNamespace(namespace))
+	if err != nil {
+		panic(err)
+	}
+
+	cni, err := gocni.New(
+		gocni.WithPluginDir([]string{config.GetString(config.FACTORY_CONTAINERD_CNI_BIN_DIR, defaultCNIBinDir)}),
+		gocni.WithInterfacePrefix("eth"))
+	if err != nil {
+		panic(err)
+	}
+	networkConf := gocni.WithConfListBytes([]byte(fmt.Sprintf(defaultCNIConfList,
+		config.GetString(config.FACTORY_CONTAINERD_CNI_SUBNET, defaultCNISubnet))))
+	if confFile := config.GetString(config.FACTORY_CONTAINERD_CNI_CONF, ""); confFile != "" {
+		networkConf = gocni.WithConfListFile(confFile)
+	}
+	if err := cni.Load(gocni.WithLoNetwork, networkConf); err != nil {
+		panic(fmt.Errorf("could not load the CNI configuration: %v", err))
+	}
+
+	logDir, err := os.MkdirTemp("", "serverledge-containerd-logs-*")
+	if err != nil {
+		panic(err)
+	}
+
+	containerdFact := &ContainerdFactory{
+		client:     client,
+		cni:        cni,
+		ctx:        namespaces.WithNamespace(context.Background(), namespace),
+		logDir:     logDir,
+		containers: make(map[ContainerID]*containerdContainer),
+	}
+	cf = containerdFact
+	return containerdFact
+}
+
+// normalizeImageName returns the fully qualified reference of an image (e.g., "docker.io/library/alpine:latest"),
+// as required by containerd
+func normalizeImageName(image string) (string, error) {
+	ref, err := reference.ParseDockerRef(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image name %s: %v", image, err)
+	}
+	return ref.String(), nil
+}
+
+func (cf *ContainerdFactory) Create(image string, opts *ContainerOptions) (ContainerID, error) {
+	if !cf.HasImage(image) {
+		_ = cf.PullImage(image)
+		// error ignored, as we might still have a stale copy of the image
+	}
+	imageName, err := normalizeImageName(image)
+	if err != nil {
+		return "", err
+	}
+	img, err := cf.client.GetImage(cf.ctx, imageName)
+	if err != nil {
+		return "", fmt.Errorf("image %s is not available: %v", image, err)
+	}
+
+	ns, err := netns.NewNetNS(netnsDir)
+	if err != nil {
+		return "", fmt.Errorf("could not create the network namespace: %v", err)
+	}
+
+	specOpts := []oci.SpecOpts{
+		oci.WithImageConfigArgs(img, opts.Cmd), // as for Docker, Cmd replaces the arguments of the entrypoint
+		oci.WithEnv(opts.Env),
+		oci.WithMemoryLimit(uint64(opts.MemoryMB) * 1048576), // convert to bytes
+		oci.WithLinuxNamespace(specs.LinuxNamespace{Type: specs.NetworkNamespace, Path: ns.GetPath()}),
+	}
+	if opts.CPUQuota > 0.0 {
+		specOpts = append(specOpts, oci.WithCPUCFS((int64)(50000.0*opts.CPUQuota), 50000)) // 50ms period
+	}
+
+	id := shortuuid.New()
+	_, err = cf.client.NewContainer(cf.ctx, id,
+		containerd.WithImage(img),
+		containerd.WithNewSnapshot(id+"-snapshot", img),
+		containerd.WithNewSpec(specOpts...))
+	if err != nil {
+		log.Printf("Could not create the container: %v\n", err)
+		_ = ns.Remove()
+		return "", err
+	}
+
+	cf.mutex.Lock()
+	cf.containers[id] = &containerdContainer{netns: ns}
+	cf.mutex.Unlock()
+	return id, nil
+}
+
+// CopyToContainer extracts the tar archive in the root filesystem of the container, which must not be started yet
+func (cf *ContainerdFactory) CopyToContainer(contID ContainerID, content io.Reader, destPath string) error {
+	ctr, err := cf.client.LoadContainer(cf.ctx, contID)
+	if err != nil {
+		return err
+	}
+	info, err := ctr.Info(cf.ctx)
+	if err != nil {
+		return err
+	}
+	mounts, err := cf.client.SnapshotService(info.Snapshotter).Mounts(cf.ctx, info.SnapshotKey)
+	if err != nil {
+		return fmt.Errorf("could not mount the root filesystem of %s: %v", contID, err)
+	}
+
+	return mount.WithTempMount(cf.ctx, mounts, func(root string) error {
+		dest := filepath.Join(root, filepath.Clean("/"+destPath))
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return err
+		}
+		_, err := archive.Apply(cf.ctx, dest, content)
+		return err
+	})
+}
+
+// Start connects the container to the network and then starts its process
+func (cf *ContainerdFactory) Start(contID ContainerID) error {
+	ctr, err := cf.client.LoadContainer(cf.ctx, contID)
+	if err != nil {
+		return err
+	}
+	c, err := cf.getContainer(contID)
+	if err != nil {
+		return err
+	}
+
+	task, err := ctr.NewTask(cf.ctx, cio.LogFile(cf.logPath(contID)))
+	if err != nil {
+		return fmt.Errorf("could not create the task of %s: %v", contID, err)
+	}
+
+	result, err := cf.cni.Setup(cf.ctx, contID, c.netns.GetPath())
+	if err != nil {
+		_, _ = task.Delete(cf.ctx, containerd.WithProcessKill)
+		return fmt.Errorf("could not connect %s to the network: %v", contID, err)
+	}
+	// on failure, the container is disconnected and its task deleted, so that it can be started again or destroyed
+	cleanup := func() {
+		if err := cf.cni.Remove(cf.ctx, contID, c.netns.GetPath()); err != nil {
+			log.Printf("Could not disconnect %s from the network: %v\n", contID, err)
+		}
+		_, _ = task.Delete(cf.ctx, containerd.WithProcessKill)
+	}
+	iface, found := result.Interfaces["eth0"]
+	if !found || len(iface.IPConfigs) == 0 {
+		cleanup()
+		return fmt.Errorf("no IP address assigned to %s", contID)
+	}
+	cf.mutex.Lock()
+	c.ipAddress = iface.IPConfigs[0].IP.String()
+	cf.mutex.Unlock()
+
+	if err := task.Start(cf.ctx); err != nil {
+		cleanup()
+		cf.mutex.Lock()
+		c.ipAddress = ""
+		cf.mutex.Unlock()
+		return fmt.Errorf("could not start the task of %s: %v", contID, err)
+	}
+	return nil
+}
+
+func (cf *ContainerdFactory) Destroy(contID ContainerID) error {
+	ctr, err := cf.client.LoadContainer(cf.ctx, contID)
+	if err != nil {
+		return err
+	}
+	// the process is killed, if running
+	if task, err := ctr.Task(cf.ctx, nil); err == nil {
+		if _, err := task.Delete(cf.ctx, containerd.WithProcessKill); err != nil {
+			log.Printf("Could not delete the task of %s: %v\n", contID, err)
+		}
+	}
+
+	cf.mutex.Lock()
+	c, found := cf.containers[contID]
+	delete(cf.containers, contID)
+	cf.mutex.Unlock()
+	if found {
+		if err := cf.cni.Remove(cf.ctx, contID, c.netns.GetPath()); err != nil {
+			log.Printf("Could not disconnect %s from the network: %v\n", contID, err)
+		}
+		if err := c.netns.Remove(); err != nil {
+			log.Printf("Could not remove the network namespace of %s: %v\n", contID, err)
+		}
+	}
+	_ = os.Remove(cf.logPath(contID))
+
+	return ctr.Delete(cf.ctx, containerd.WithSnapshotCleanup)
+}
+
+func (cf *ContainerdFactory) HasImage(image string) bool {
+	imageName, err := normalizeImageName(image)
+	if err != nil {
+		return false
+	}
+	if _, err := cf.client.GetImage(cf.ctx, imageName); err != nil {
+		return false
+	}
+	// We have the image, but we may need to refresh it
+	if config.GetBool(config.FACTORY_REFRESH_IMAGES, false) {
+		if refreshed, ok := refreshedImages[image]; !ok || !refreshed {
+			return false
+		}
+	}
+	return true
+}
+
+func (cf *ContainerdFactory) PullImage(image string) error {
+	imageName, err := normalizeImageName(image)
+	if err != nil {
+		return err
+	}
+	_, err = cf.client.Pull(cf.ctx, imageName, containerd.WithPullUnpack)
+	if err != nil {
+		return fmt.Errorf("Could not pull image '%s': %v", image, err)
+	}
+	log.Printf("Pulled image: %s\n", image)
+	refreshedImages[image] = true
+	return nil
+}
+
+func (cf *ContainerdFactory) GetIPAddress(contID ContainerID) (string, error) {
+	c, err := cf.getContainer(contID)
+	if err != nil {
+		return "", err
+	}
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+	if c.ipAddress == "" {
+		return "", fmt.Errorf("container %s is not started", contID)
+	}
+	return c.ipAddress, nil
+}
+
+func (cf *ContainerdFactory) GetMemoryMB(contID ContainerID) (int64, error) {
+	ctr, err := cf.client.LoadContainer(cf.ctx, contID)
+	if err != nil {
+		return -1, err
+	}
+	spec, err := ctr.Spec(cf.ctx)
+	if err != nil {
+		return -1, err
+	}
+	if spec.Linux == nil || spec.Linux.Resources == nil || spec.Linux.Resources.Memory == nil || spec.Linux.Resources.Memory.Limit == nil {
+		return -1, fmt.Errorf("container %s has no memory limit", contID)
+	}
+	return *spec.Linux.Resources.Memory.Limit / 1048576, nil
+}
+
+func (cf *ContainerdFactory) GetLog(contID ContainerID) (string, error) {
+	logs, err := os.ReadFile(cf.logPath(contID))
+	if err != nil {
+		return "no logs", fmt.Errorf("can't read the logs: %v", err)
+	}
+	return string(logs), nil
+}
+
+// GetImageArchitectures retrieves the supported CPU architectures for a given container image (see getImageArchitectures).
+func (cf *ContainerdFactory) GetImageArchitectures(imageName string) ([]string, error) {
+	return getImageArchitectures(cf.ctx, imageName)
+}
+
+func (cf *ContainerdFactory) getContainer(contID ContainerID) (*containerdContainer, error) {
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+	c, found := cf.containers[contID]
+	if !found {
+		return nil, fmt.Errorf("unknown container %s", contID)
+	}
+	return c, nil
+}
+
+func (cf *ContainerdFactory) logPath(contID ContainerID) string {
+	return filepath.Join(cf.logDir, contID+".log")
+}
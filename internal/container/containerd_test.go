@@ -0,0 +1,65 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestContainerdFactory requires a running containerd daemon (listening on the socket given by
+// factory.containerd.address), the CNI plugins and root privileges.
+func TestContainerdFactory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping containerd test in short mode.")
+	}
+	if _, err := os.Stat(config.GetString(config.FACTORY_CONTAINERD_ADDRESS, defaultContainerdAddress)); err != nil {
+		t.Skip("containerd is not available")
+	}
+
+	dockerFactory := cf
+	defer func() { cf = dockerFactory }()
+	factory := InitContainerdFactory()
+
+	// the script copied in the container prints a message and sleeps
+	script := "echo hello from containerd; sleep 60\n"
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "run.sh", Mode: 0755, Size: int64(len(script))}))
+	_, err := tw.Write([]byte(script))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+
+	contID, err := factory.Create("busybox:latest", &ContainerOptions{
+		Cmd:      []string{"/bin/sh", "/app/run.sh"},
+		MemoryMB: 64,
+		CPUQuota: 0.5,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, factory.Destroy(contID))
+	}()
+
+	assert.NoError(t, factory.CopyToContainer(contID, &archive, "/app"))
+	assert.NoError(t, factory.Start(contID))
+
+	ip, err := factory.GetIPAddress(contID)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ip, "10.62."), "unexpected IP address %s", ip)
+
+	memory, err := factory.GetMemoryMB(contID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(64), memory)
+
+	assert.Eventually(t, func() bool {
+		logs, err := factory.GetLog(contID)
+		return err == nil && strings.Contains(logs, "hello from containerd")
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -2,22 +2,17 @@ package container
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
-	regName "github.com/google/go-containerregistry/pkg/name"
-	regRemote "github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/serverledge-faas/serverledge/internal/config"
-	"github.com/serverledge-faas/serverledge/utils"
 	//	"github.com/docker/docker/pkg/stdcopy"
 )
 
@@ -173,115 +168,7 @@ func (cf *DockerFactory) GetLog(contID ContainerID) (string, error) {
 	return string(logs[:]), nil
 }
 
-// GetImageArchitectures retrieves the supported CPU architectures for a given container image.
-// It first checks etcd for cached information. If not found, it queries the remote registry
-// and then caches the result in etcd. As for now, architectures of interest are: x86_64 (amd64) and arm64.
+// GetImageArchitectures retrieves the supported CPU architectures for a given container image (see getImageArchitectures).
 func (cf *DockerFactory) GetImageArchitectures(imageName string) ([]string, error) {
-	etcdCli, err := utils.GetEtcdClient()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get etcd client: %w", err)
-	}
-
-	const imageArchEtcdPrefix = "/serverledge/image_architectures/"
-	etcdKey := imageArchEtcdPrefix + imageName
-	ctx, cancel := context.WithTimeout(cf.ctx, 5*time.Second)
-	defer cancel()
-
-	// 1. Try to get architectures from etcd
-	resp, err := etcdCli.Get(ctx, etcdKey)
-	if err == nil && len(resp.Kvs) > 0 {
-		var architectures []string
-		if err := json.Unmarshal(resp.Kvs[0].Value, &architectures); err != nil {
-			log.Printf("Warning: failed to unmarshal architectures from etcd for image %s: %v. Re-fetching from registry.", imageName, err)
-		} else {
-			log.Printf("Architectures for image %s found in etcd: %v", imageName, architectures)
-			return architectures, nil
-		}
-	} else if err != nil {
-		// Log the error if it's not just "key not found"
-		if !strings.Contains(err.Error(), "key not found") { // etcd client returns error if key not found
-			log.Printf("Warning: failed to get architectures from etcd for image %s: %v. Re-fetching from registry.", imageName, err)
-		} else {
-			log.Printf("Architectures for image %s not found in etcd. Fetching from registry.", imageName)
-		}
-	} else {
-		log.Printf("Architectures for image %s not found in etcd. Fetching from registry.", imageName)
-	}
-
-	// 2. If not found in etcd (or unmarshal failed), query the remote registry
-	ref, err := regName.ParseReference(imageName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse image name %s: %w", imageName, err)
-	}
-
-	desc, err := regRemote.Get(ref)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get remote image descriptor for %s: %w", imageName, err)
-	}
-
-	var supportedArchitectures []string  // the list we will return
-	archSet := make(map[string]struct{}) // Use a set to avoid duplicates (it's just temporary, won't be returned)
-
-	if desc.MediaType.IsIndex() { // Multi-platform images have an index manifest for the multiple architectures
-		idx, err := desc.ImageIndex()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get image index for %s: %w", imageName, err)
-		}
-		manifests, err := idx.IndexManifest()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get index manifest for %s: %w", imageName, err)
-		}
-
-		for _, manifest := range manifests.Manifests {
-			if manifest.Platform != nil {
-				arch := manifest.Platform.Architecture
-				// We are interested in "amd64" (x86_64) and "arm64", for the moment we don't care if more architectures are supported
-				if arch == X86 || arch == ARM {
-					if _, found := archSet[arch]; !found {
-						supportedArchitectures = append(supportedArchitectures, arch)
-						archSet[arch] = struct{}{} // to avoid duplicates
-					}
-				}
-			}
-		}
-	} else if desc.MediaType.IsImage() { // Single-platform image
-		img, err := desc.Image()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get image for %s: %w", imageName, err)
-		}
-		cfg, err := img.ConfigFile()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get image config for %s: %w", imageName, err)
-		}
-		arch := cfg.Architecture
-		if arch == X86 || arch == ARM {
-			supportedArchitectures = append(supportedArchitectures, arch)
-		}
-	} else {
-		return nil, fmt.Errorf("unsupported media type for image %s: %s", imageName, desc.MediaType)
-	}
-
-	if len(supportedArchitectures) == 0 {
-		return nil, fmt.Errorf("no architecture supported by Serverledge found for image %s", imageName)
-
-	}
-
-	// 3. Cache the result in etcd for future lookup (e.g.: another function executed in the same custom runtime, or can
-	// be used by another node if/when the function is offloaded) without having to hit the registry again.
-	// TODO maybe TTL for cache?
-	archBytes, err := json.Marshal(supportedArchitectures)
-	if err != nil {
-		log.Printf("Warning: failed to marshal architectures for image %s: %v. Not caching in etcd.", imageName, err)
-		// Return what we found, even if not cached, is an etcd problem, not a problem retrieving architectures
-		return supportedArchitectures, nil
-	}
-
-	_, err = etcdCli.Put(ctx, etcdKey, string(archBytes))
-	if err != nil {
-		log.Printf("Warning: failed to put architectures to etcd for image %s: %v", imageName, err)
-	} else {
-		log.Printf("Architectures for image %s cached in etcd: %v", imageName, supportedArchitectures)
-	}
-
-	return supportedArchitectures, nil
+	return getImageArchitectures(cf.ctx, imageName)
 }
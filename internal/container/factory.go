@@ -1,7 +1,10 @@
 package container
 
 import (
+	"fmt"
 	"io"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
 )
 
 // A Factory to create and manage container.
@@ -32,6 +35,7 @@ type Container struct {
 	ID             ContainerID
 	RequestsCount  int16
 	ExpirationTime int64
+	Revision       int64 // revision of the function definition the container has been created from
 }
 
 // cf is the container factory for the node
@@ -40,3 +44,26 @@ var cf Factory
 func GetFactory() Factory {
 	return cf
 }
+
+// SetFactory replaces the container factory of the node (e.g., with a fake one for testing)
+func SetFactory(factory Factory) {
+	cf = factory
+}
+
+const DOCKER_FACTORY = "docker"
+const CONTAINERD_FACTORY = "containerd"
+const PROCESS_FACTORY = "process"
+
+// InitFactory initializes the container factory selected through config.FACTORY_TYPE (Docker, by default)
+func InitFactory() Factory {
+	switch factoryType := config.GetString(config.FACTORY_TYPE, DOCKER_FACTORY); factoryType {
+	case DOCKER_FACTORY:
+		return InitDockerContainerFactory()
+	case CONTAINERD_FACTORY:
+		return InitContainerdFactory()
+	case PROCESS_FACTORY:
+		return InitProcessFactory()
+	default:
+		panic(fmt.Sprintf("unknown container factory: %s", factoryType))
+	}
+}
@@ -0,0 +1,303 @@
+package container
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lithammer/shortuuid"
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/executor"
+)
+
+// processExecutors gives, for each runtime supported by the ProcessFactory, the command that starts its executor on
+// the host. The path of the executor is relative to the directory of the runtime images (factory.process.images.dir).
+var processExecutors = map[string][]string{
+	"python314":    {"python3", "python314/executor.py"},
+	"python-numpy": {"python3", "python314/executor.py"},
+	"python312ml":  {"python3", "python312ml/executor.py"},
+	"nodejs17ng":   {"node", "nodejs17ng/executor.js"},
+	"go125":        {"/bin/sh", "go125/entrypoint.sh"},
+}
+
+const processHost = "127.0.0.1"
+
+// ProcessFactory runs the executor of each "container" as a plain host process, listening on its own loopback port.
+// The function code is copied in a private directory, and the memory of the process is limited through rlimit
+// (where supported). There is no isolation between functions: it is meant for development and tests only, on hosts
+// where Docker is not available.
+type ProcessFactory struct {
+	imagesDir string
+	mutex     sync.Mutex
+	processes map[ContainerID]*hostProcess
+}
+
+type hostProcess struct {
+	cmd      *exec.Cmd
+	rootDir  string
+	memoryMB int64
+	port     int
+	logFile  *os.File
+}
+
+func InitProcessFactory() *ProcessFactory {
+	imagesDir, err := filepath.Abs(config.GetString(config.FACTORY_PROCESS_IMAGES_DIR, "images"))
+	if err != nil {
+		panic(err)
+	}
+
+	processFact := &ProcessFactory{
+		imagesDir: imagesDir,
+		processes: make(map[ContainerID]*hostProcess),
+	}
+	cf = processFact
+	return processFact
+}
+
+// executorCommand returns the command that starts the executor for the image of a runtime
+func (cf *ProcessFactory) executorCommand(image string) ([]string, error) {
+	for name, info := range RuntimeToInfo {
+		if info.Image != image {
+			continue
+		}
+		command, ok := processExecutors[name]
+		if !ok {
+			break
+		}
+		return []string{command[0], filepath.Join(cf.imagesDir, command[1])}, nil
+	}
+	return nil, fmt.Errorf("image %s is not supported by the process factory", image)
+}
+
+func (cf *ProcessFactory) Create(image string, opts *ContainerOptions) (ContainerID, error) {
+	command, err := cf.executorCommand(image)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(command[1]); err != nil {
+		return "", fmt.Errorf("executor not found: %v", err)
+	}
+
+	rootDir, err := os.MkdirTemp("", "serverledge-process-*")
+	if err != nil {
+		return "", err
+	}
+	logFile, err := os.Create(filepath.Join(rootDir, "executor.log"))
+	if err != nil {
+		_ = os.RemoveAll(rootDir)
+		return "", err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	setProcessAttributes(cmd)
+
+	id := shortuuid.New()
+	cf.mutex.Lock()
+	cf.processes[id] = &hostProcess{cmd: cmd, rootDir: rootDir, memoryMB: opts.MemoryMB, logFile: logFile}
+	cf.mutex.Unlock()
+	return id, nil
+}
+
+// CopyToContainer extracts the tar archive in the directory of the process, where destPath is the absolute path
+// that the content would have in a container
+func (cf *ProcessFactory) CopyToContainer(contID ContainerID, content io.Reader, destPath string) error {
+	p, err := cf.getProcess(contID)
+	if err != nil {
+		return err
+	}
+	return extractTar(content, filepath.Join(p.rootDir, filepath.Clean("/"+destPath)))
+}
+
+// Start starts the executor, which listens on a free loopback port and finds the function code in the directory of
+// the process (see executor.HANDLER_DIR_ENV)
+func (cf *ProcessFactory) Start(contID ContainerID) error {
+	p, err := cf.getProcess(contID)
+	if err != nil {
+		return err
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	appDir := filepath.Join(p.rootDir, "app")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		return err
+	}
+	p.cmd.Dir = appDir
+	p.cmd.Env = append(p.cmd.Env,
+		executor.HOST_ENV+"="+processHost,
+		executor.PORT_ENV+"="+strconv.Itoa(port),
+		executor.HANDLER_DIR_ENV+"="+appDir)
+	if err := p.cmd.Start(); err != nil {
+		return fmt.Errorf("could not start the executor: %v", err)
+	}
+	if err := limitMemory(p.cmd.Process.Pid, p.memoryMB); err != nil {
+		log.Printf("Could not limit the memory of %s: %v\n", contID, err)
+	}
+
+	cf.mutex.Lock()
+	p.port = port
+	cf.mutex.Unlock()
+	return nil
+}
+
+func (cf *ProcessFactory) Destroy(contID ContainerID) error {
+	cf.mutex.Lock()
+	p, found := cf.processes[contID]
+	delete(cf.processes, contID)
+	cf.mutex.Unlock()
+	if !found {
+		return fmt.Errorf("unknown container %s", contID)
+	}
+
+	if p.cmd.Process != nil {
+		if err := killProcess(p.cmd); err != nil {
+			log.Printf("Could not kill the executor of %s: %v\n", contID, err)
+		}
+		_ = p.cmd.Wait() // the exit error of the killed process is expected
+	}
+	_ = p.logFile.Close()
+	return os.RemoveAll(p.rootDir)
+}
+
+// HasImage returns true if the executor of the image can be run on the host
+func (cf *ProcessFactory) HasImage(image string) bool {
+	command, err := cf.executorCommand(image)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(command[1])
+	return err == nil
+}
+
+// PullImage does nothing, as the executors are not downloaded
+func (cf *ProcessFactory) PullImage(image string) error {
+	if !cf.HasImage(image) {
+		return fmt.Errorf("image %s is not supported by the process factory", image)
+	}
+	return nil
+}
+
+func (cf *ProcessFactory) GetIPAddress(contID ContainerID) (string, error) {
+	if _, err := cf.getProcess(contID); err != nil {
+		return "", err
+	}
+	return processHost, nil
+}
+
+// GetExecutorPort returns the loopback port of the executor of a started process
+func (cf *ProcessFactory) GetExecutorPort(contID ContainerID) (int, error) {
+	p, err := cf.getProcess(contID)
+	if err != nil {
+		return 0, err
+	}
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+	if p.port == 0 {
+		return 0, fmt.Errorf("container %s is not started", contID)
+	}
+	return p.port, nil
+}
+
+func (cf *ProcessFactory) GetMemoryMB(contID ContainerID) (int64, error) {
+	p, err := cf.getProcess(contID)
+	if err != nil {
+		return -1, err
+	}
+	return p.memoryMB, nil
+}
+
+func (cf *ProcessFactory) GetLog(contID ContainerID) (string, error) {
+	p, err := cf.getProcess(contID)
+	if err != nil {
+		return "no logs", err
+	}
+	logs, err := os.ReadFile(p.logFile.Name())
+	if err != nil {
+		return "no logs", fmt.Errorf("can't read the logs: %v", err)
+	}
+	return string(logs), nil
+}
+
+// GetImageArchitectures returns the architecture of the host, where the executors run
+func (cf *ProcessFactory) GetImageArchitectures(imageName string) ([]string, error) {
+	if !cf.HasImage(imageName) {
+		return nil, fmt.Errorf("image %s is not supported by the process factory", imageName)
+	}
+	return []string{runtime.GOARCH}, nil
+}
+
+func (cf *ProcessFactory) getProcess(contID ContainerID) (*hostProcess, error) {
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+	p, found := cf.processes[contID]
+	if !found {
+		return nil, fmt.Errorf("unknown container %s", contID)
+	}
+	return p, nil
+}
+
+// freePort returns a loopback port that is currently not in use
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", processHost+":0")
+	if err != nil {
+		return 0, fmt.Errorf("no free port available: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// extractTar extracts a tar archive in dest, rejecting entries outside of it
+func extractTar(content io.Reader, dest string) error {
+	tr := tar.NewReader(content)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, header.Name)
+		if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&os.ModePerm)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			errClose := f.Close()
+			if err != nil {
+				return err
+			} else if errClose != nil {
+				return errClose
+			}
+		default:
+			log.Printf("Skipping %s in archive: unsupported type\n", header.Name)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package container
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setProcessAttributes starts the executor in its own process group, so that the handlers it spawns are killed along
+// with it
+func setProcessAttributes(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// limitMemory limits the data segment of the process (i.e., its heap and private writable mappings)
+func limitMemory(pid int, memoryMB int64) error {
+	if memoryMB <= 0 {
+		return nil
+	}
+	limit := uint64(memoryMB) * 1048576 // convert to bytes
+	return unix.Prlimit(pid, unix.RLIMIT_DATA, &unix.Rlimit{Cur: limit, Max: limit}, nil)
+}
+
+func killProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
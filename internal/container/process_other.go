@@ -0,0 +1,18 @@
+//go:build !linux
+
+package container
+
+import (
+	"os/exec"
+)
+
+func setProcessAttributes(cmd *exec.Cmd) {}
+
+// limitMemory does nothing, as the limits of other processes can only be set on Linux
+func limitMemory(pid int, memoryMB int64) error {
+	return nil
+}
+
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
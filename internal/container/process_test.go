@@ -0,0 +1,226 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+// useProcessFactory replaces the container factory with a ProcessFactory for the duration of the test, which is
+// skipped if python3 is not available
+func useProcessFactory(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not available")
+	}
+
+	previousFactory := cf
+	t.Cleanup(func() { cf = previousFactory })
+	imagesDir, err := filepath.Abs("../../images")
+	assert.NoError(t, err)
+	cf = &ProcessFactory{imagesDir: imagesDir, processes: make(map[ContainerID]*hostProcess)}
+}
+
+// codeArchive returns the base64-encoded tar archive of a single file
+func codeArchive(t *testing.T, name string, src []byte) string {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(src))}))
+	_, err := tw.Write(src)
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	return base64.StdEncoding.EncodeToString(archive.Bytes())
+}
+
+// TestProcessFactory runs the Python executor as a host process, so it only requires python3.
+func TestProcessFactory(t *testing.T) {
+	useProcessFactory(t)
+
+	src, err := os.ReadFile("../../examples/inc.py")
+	assert.NoError(t, err)
+	code := codeArchive(t, "inc.py", src)
+
+	// two containers of the same function run side by side on different ports
+	var containers []*Container
+	for i := 0; i < 2; i++ {
+		cont, err := newContainer(RuntimeToInfo["python314"].Image, code, &ContainerOptions{MemoryMB: 128})
+		if !assert.NoError(t, err) {
+			return
+		}
+		containers = append(containers, cont)
+	}
+	defer func() {
+		for _, cont := range containers {
+			assert.NoError(t, Destroy(cont.ID))
+		}
+	}()
+
+	for i, cont := range containers {
+		result, _, err := Execute(context.Background(), cont.ID, &executor.InvocationRequest{
+			Params:       map[string]interface{}{"input": i},
+			Handler:      "inc.handler",
+			HandlerDir:   "/app",
+			ReturnOutput: true,
+		}, nil)
+		if assert.NoError(t, err) {
+			assert.True(t, result.Success)
+			assert.Equal(t, []string{"1", "2"}[i], result.Result)
+		}
+
+		memory, err := GetMemoryMB(cont.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(128), memory)
+	}
+
+	_, err = cf.Create("fmuschera/serverledge-java21", &ContainerOptions{})
+	assert.Error(t, err, "the Java runtime is not supported")
+}
+
+// sleeper prints the id of the invocation before and after sleeping, then creates the marker file (if any)
+const sleeper = `import time
+
+def handler(params, context):
+    print("start", params["id"])
+    time.sleep(params.get("sleep", 0))
+    print("end", params["id"])
+    if "marker" in params:
+        open(params["marker"], "w").close()
+    return params["id"]
+`
+
+func newSleeperContainer(t *testing.T) *Container {
+	useProcessFactory(t)
+	cont, err := newContainer(RuntimeToInfo["python314"].Image, codeArchive(t, "sleeper.py", []byte(sleeper)),
+		&ContainerOptions{MemoryMB: 256})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { assert.NoError(t, Destroy(cont.ID)) })
+	return cont
+}
+
+func sleeperRequest(id int, sleep float64) *executor.InvocationRequest {
+	return &executor.InvocationRequest{
+		Params:       map[string]interface{}{"id": id, "sleep": sleep},
+		Handler:      "sleeper.handler",
+		HandlerDir:   "/app",
+		ReturnOutput: true,
+	}
+}
+
+// TestPythonExecutorTimeout checks that the Python executor kills the handlers exceeding their timeout, and that the
+// container can still be used afterwards
+func TestPythonExecutorTimeout(t *testing.T) {
+	cont := newSleeperContainer(t)
+
+	req := sleeperRequest(1, 30)
+	req.TimeoutSeconds = 1
+	start := time.Now()
+	_, _, err := Execute(context.Background(), cont.ID, req, nil)
+	assert.ErrorIs(t, err, ExecutionTimeoutErr)
+	assert.NotErrorIs(t, err, UnresponsiveExecutorErr)
+	assert.Less(t, time.Since(start), executorTimeoutGrace)
+
+	req = sleeperRequest(2, 0)
+	req.TimeoutSeconds = 10
+	result, _, err := Execute(context.Background(), cont.ID, req, nil)
+	if assert.NoError(t, err) {
+		assert.True(t, result.Success)
+		assert.Equal(t, "2", result.Result)
+	}
+}
+
+// TestPythonExecutorCancel checks that the Python executor kills the handler when the invocation is cancelled
+func TestPythonExecutorCancel(t *testing.T) {
+	cont := newSleeperContainer(t)
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	req := sleeperRequest(1, 2)
+	req.Params["marker"] = marker
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, _, err := Execute(ctx, cont.ID, req, nil)
+	assert.Error(t, err)
+
+	time.Sleep(3 * time.Second)
+	assert.NoFileExists(t, marker, "the handler has not been killed")
+}
+
+// TestPythonExecutorConcurrentOutput checks that concurrent invocations in the same container capture only their own
+// output, both when it is returned and when it is streamed
+func TestPythonExecutorConcurrentOutput(t *testing.T) {
+	cont := newSleeperContainer(t)
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			result, _, err := Execute(context.Background(), cont.ID, sleeperRequest(id, 0.5), nil)
+			if assert.NoError(t, err) {
+				assert.True(t, result.Success)
+				assert.Equal(t, fmt.Sprintf("start %d\nend %d\n\n", id, id), result.Output)
+			}
+		}(i)
+		go func(id int) {
+			defer wg.Done()
+			req := sleeperRequest(n+id, 0.5)
+			req.Stream = true
+			var lines []string
+			var mu sync.Mutex
+			result, _, err := Execute(context.Background(), cont.ID, req, func(event executor.Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				assert.Equal(t, executor.OutputEvent, event.Name)
+				lines = append(lines, event.Data)
+			})
+			if assert.NoError(t, err) {
+				assert.True(t, result.Success)
+				assert.Equal(t, fmt.Sprintf("start %d\nend %d\n", n+id, n+id), result.Output)
+				assert.Equal(t, []string{fmt.Sprintf("start %d", n+id), fmt.Sprintf("end %d", n+id)}, lines)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestNodeExecutorTimeout checks that the Node.js executor terminates the handlers exceeding their timeout, even if
+// they never yield, and that the container can still be used afterwards
+func TestNodeExecutorTimeout(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not available")
+	}
+	useProcessFactory(t)
+	src := []byte(`module.exports = (params) => { while (params["loop"]) {} return params["input"] + 1 }`)
+	cont, err := newContainer(RuntimeToInfo["nodejs17ng"].Image, codeArchive(t, "loop.js", src), &ContainerOptions{MemoryMB: 256})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { assert.NoError(t, Destroy(cont.ID)) }()
+
+	req := &executor.InvocationRequest{Params: map[string]interface{}{"loop": true}, Handler: "loop.js", HandlerDir: "/app", TimeoutSeconds: 1}
+	_, _, err = Execute(context.Background(), cont.ID, req, nil)
+	assert.ErrorIs(t, err, ExecutionTimeoutErr)
+	assert.NotErrorIs(t, err, UnresponsiveExecutorErr)
+
+	// the second invocation reuses the worker of the first one
+	for i := 1; i <= 2; i++ {
+		req = &executor.InvocationRequest{Params: map[string]interface{}{"input": i}, Handler: "loop.js", HandlerDir: "/app", TimeoutSeconds: 1}
+		result, _, err := Execute(context.Background(), cont.ID, req, nil)
+		if assert.NoError(t, err) {
+			assert.True(t, result.Success)
+			assert.Equal(t, fmt.Sprint(i+1), result.Result)
+		}
+	}
+}
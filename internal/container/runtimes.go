@@ -6,6 +6,9 @@ type RuntimeInfo struct {
 	InvocationCmd        []string
 	ConcurrencySupported bool
 	Architectures        []string
+	// KillsHandler is true if the executor kills the handler when it exceeds its timeout or the invocation is
+	// cancelled, so that the container can be reused
+	KillsHandler bool
 }
 
 const CUSTOM_RUNTIME = "custom"
@@ -14,14 +17,18 @@ const ARM = "arm64"
 
 var refreshedImages = map[string]bool{}
 
+// The go125 runtimes do not use the Executor: the function binary serves the invocations itself (see the serverledge
+// package) and runs the handler in its own goroutine, which cannot be killed, so their containers are not reused
+// after timeouts and cancellations.
 var RuntimeToInfo = map[string]RuntimeInfo{
-	"python314":    {"fmuschera/serverledge-python314", []string{"python", "/entrypoint.py"}, true, []string{X86, ARM}},
-	"python-numpy": {"fmuschera/serverledge-python-numpy", []string{"python", "/entrypoint.py"}, true, []string{X86, ARM}},
-	"nodejs17ng":   {"fmuschera/serverledge-nodejs17ng", []string{}, false, []string{X86, ARM}},
-	"java21":       {"fmuschera/serverledge-java21", []string{}, false, []string{X86, ARM}},
-	"go125":        {"fmuschera/serverledge-go125", []string{"/entrypoint.sh"}, true, []string{X86, ARM}},
-	"go125-bench":  {"fmuschera/serverledge-go-bench", []string{"/entrypoint.sh"}, true, []string{X86, ARM}},
-	"python312ml":  {"fmuschera/serverledge-python312ml", []string{"python", "/entrypoint.py"}, true, []string{X86, ARM}},
+	"python314":    {"fmuschera/serverledge-python314", []string{"python", "/entrypoint.py"}, true, []string{X86, ARM}, true},
+	"python-numpy": {"fmuschera/serverledge-python-numpy", []string{"python", "/entrypoint.py"}, true, []string{X86, ARM}, false},
+	"nodejs17ng":   {"fmuschera/serverledge-nodejs17ng", []string{}, false, []string{X86, ARM}, true},
+	"java21":       {"fmuschera/serverledge-java21", []string{}, false, []string{X86, ARM}, false},
+	"go125":        {"fmuschera/serverledge-go125", []string{"/entrypoint.sh"}, true, []string{X86, ARM}, false},
+	"go125-bench":  {"fmuschera/serverledge-go-bench", []string{"/entrypoint.sh"}, true, []string{X86, ARM}, false},
+	"python312ml":  {"fmuschera/serverledge-python312ml", []string{"python", "/entrypoint.py"}, true, []string{X86, ARM}, true},
+	WASI_RUNTIME:   {"", nil, true, []string{X86, ARM}, true}, // modules are run by the node, with no container image
 }
 
 // CustomRuntimeToInfo Map to keep track of architectures compatible with each custom runtime image associated with a function registered
@@ -0,0 +1,244 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lithammer/shortuuid"
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// WASI_RUNTIME is the runtime of functions compiled to WebAssembly (WASI preview 1), which are executed within the
+// node process rather than in a container
+const WASI_RUNTIME = "wasi"
+
+// wasmPageSize is the size of a page of WebAssembly memory (64 KiB)
+const wasmPageSize = 65536
+
+// wasmMaxPages is the maximum number of pages of a 32-bit WebAssembly memory (4 GiB)
+const wasmMaxPages = 65536
+
+// wasiCompilationCache is shared by all the WASI modules, so that a module is compiled only once by the node
+var wasiCompilationCache = wazero.NewCompilationCache()
+
+// wasiModule is a warm instance of a WASI function: the module is compiled once, and a new instance of it is
+// created for each invocation, within the memory limit of the function. It is handled by the ContainerPool as any
+// other container.
+type wasiModule struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	codeDir  string
+	memoryMB int64
+	mutex    sync.Mutex
+	lastLog  string
+}
+
+var wasiModules = struct {
+	sync.Mutex
+	m map[ContainerID]*wasiModule
+}{m: make(map[ContainerID]*wasiModule)}
+
+// createWasiContainer compiles the module of the function. The TarFunctionCode must contain a .wasm module: the
+// one named by the handler of the function, or the only one in the archive.
+func createWasiContainer(f *function.Function) (*Container, error) {
+	if len(f.TarFunctionCode) == 0 {
+		return nil, fmt.Errorf("missing code of WASI function %s", f.Name)
+	}
+	code, err := readFunctionCode(f.TarFunctionCode)
+	if err != nil {
+		return nil, err
+	}
+	defer code.Close()
+
+	codeDir, err := os.MkdirTemp("", "serverledge-wasi-*")
+	if err != nil {
+		return nil, err
+	}
+	if err := extractTar(code, codeDir); err != nil {
+		_ = os.RemoveAll(codeDir)
+		return nil, fmt.Errorf("invalid code of WASI function %s: %v", f.Name, err)
+	}
+
+	m, err := newWasiModule(codeDir, f.Handler, f.MemoryMB)
+	if err != nil {
+		_ = os.RemoveAll(codeDir)
+		return nil, err
+	}
+
+	id := "wasi-" + shortuuid.New()
+	wasiModules.Lock()
+	wasiModules.m[id] = m
+	wasiModules.Unlock()
+	return &Container{ID: id}, nil
+}
+
+func newWasiModule(codeDir string, handler string, memoryMB int64) (*wasiModule, error) {
+	modulePath, err := findWasmModule(codeDir, handler)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := os.ReadFile(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := uint32(wasmMaxPages)
+	if memoryMB > 0 && memoryMB*1048576/wasmPageSize < wasmMaxPages {
+		pages = uint32(memoryMB * 1048576 / wasmPageSize)
+	}
+	ctx := context.Background()
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(pages).
+		WithCompilationCache(wasiCompilationCache).
+		WithCloseOnContextDone(true) // to stop handlers that exceed their timeout
+	r := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	wasi_snapshot_preview1.MustInstantiate(ctx, r)
+
+	compiled, err := r.CompileModule(ctx, binary)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("invalid WASI module %s: %v", filepath.Base(modulePath), err)
+	}
+	return &wasiModule{runtime: r, compiled: compiled, codeDir: codeDir, memoryMB: memoryMB}, nil
+}
+
+// findWasmModule returns the path of the module named by the handler or, if no handler is given, of the only
+// module in the directory
+func findWasmModule(codeDir string, handler string) (string, error) {
+	if handler != "" {
+		path := filepath.Join(codeDir, filepath.Clean("/"+handler))
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("WASI module %s not found", handler)
+		}
+		return path, nil
+	}
+	modules, err := filepath.Glob(filepath.Join(codeDir, "*.wasm"))
+	if err != nil {
+		return "", err
+	}
+	if len(modules) != 1 {
+		return "", fmt.Errorf("the handler must name the WASI module, as the code contains %d modules", len(modules))
+	}
+	return modules[0], nil
+}
+
+func getWasiModule(contID ContainerID) (*wasiModule, bool) {
+	wasiModules.Lock()
+	defer wasiModules.Unlock()
+	m, ok := wasiModules.m[contID]
+	return m, ok
+}
+
+// invoke runs a new instance of the module. As with the executors of the other runtimes, the parameters are read
+// from PARAMS_FILE and the result is written to RESULT_FILE; the code of the function is available in HandlerDir.
+func (m *wasiModule) invoke(ctx context.Context, req *executor.InvocationRequest) (*executor.InvocationResult, error) {
+	invocationDir, err := os.MkdirTemp("", "serverledge-wasi-invocation-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(invocationDir)
+
+	const paramsFile = "/params.json"
+	const resultFile = "/result.json"
+	handlerDir := req.HandlerDir
+	if handlerDir == "" || handlerDir == "/" {
+		handlerDir = "/app"
+	}
+	env := map[string]string{"RESULT_FILE": resultFile, "HANDLER": req.Handler, "HANDLER_DIR": handlerDir}
+	if input := req.HandlerInput(); input != nil {
+		params, _ := json.Marshal(input)
+		if err := os.WriteFile(filepath.Join(invocationDir, paramsFile), params, 0644); err != nil {
+			return nil, err
+		}
+		env["PARAMS_FILE"] = paramsFile
+	}
+
+	var output bytes.Buffer
+	moduleConfig := wazero.NewModuleConfig().
+		WithName(""). // anonymous, to allow concurrent instances
+		WithArgs("handler").
+		WithStdout(&output).
+		WithStderr(&output).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader).
+		WithFSConfig(wazero.NewFSConfig().
+			WithDirMount(invocationDir, "/").
+			WithReadOnlyDirMount(m.codeDir, handlerDir))
+	for name, value := range env {
+		moduleConfig = moduleConfig.WithEnv(name, value)
+	}
+
+	if req.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, time.Duration(req.TimeoutSeconds)*time.Second, ExecutionTimeoutErr)
+		defer cancel()
+	}
+	instance, err := m.runtime.InstantiateModule(ctx, m.compiled, moduleConfig)
+	if instance != nil {
+		_ = instance.Close(context.Background())
+	}
+	var exitErr *sys.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		// e.g., the module exceeded its memory limit
+		_, _ = fmt.Fprintf(&output, "\n%v\n", err)
+	}
+	m.setLog(output.String())
+	if errors.Is(context.Cause(ctx), ExecutionTimeoutErr) {
+		return nil, ExecutionTimeoutErr
+	}
+
+	result := &executor.InvocationResult{Success: err == nil || (exitErr != nil && exitErr.ExitCode() == 0)}
+	if req.ReturnOutput {
+		result.Output = output.String()
+	}
+	if !result.Success {
+		return result, nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(invocationDir, resultFile))
+	if err == nil {
+		result.Result = string(content)
+	}
+	return result, nil
+}
+
+func (m *wasiModule) setLog(log string) {
+	m.mutex.Lock()
+	m.lastLog = log
+	m.mutex.Unlock()
+}
+
+func (m *wasiModule) getLog() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.lastLog
+}
+
+func destroyWasiModule(contID ContainerID) error {
+	wasiModules.Lock()
+	m, ok := wasiModules.m[contID]
+	delete(wasiModules.m, contID)
+	wasiModules.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown container %s", contID)
+	}
+
+	err := m.runtime.Close(context.Background())
+	if errRemove := os.RemoveAll(m.codeDir); err == nil {
+		err = errRemove
+	}
+	return err
+}
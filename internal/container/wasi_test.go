@@ -0,0 +1,96 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildWasiExample compiles examples/wasi/inc and returns its code, as a base64-encoded tar archive
+func buildWasiExample(t *testing.T) string {
+	module := filepath.Join(t.TempDir(), "inc.wasm")
+	cmd := exec.Command("go", "build", "-o", module, ".")
+	cmd.Dir = "../../examples/wasi/inc"
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build the WASI module: %v\n%s", err, out)
+	}
+
+	binary, err := os.ReadFile(module)
+	assert.NoError(t, err)
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "inc.wasm", Mode: 0644, Size: int64(len(binary))}))
+	_, err = tw.Write(binary)
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	return base64.StdEncoding.EncodeToString(archive.Bytes())
+}
+
+func TestWasiRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping WASI test in short mode.")
+	}
+	f := &function.Function{
+		Name:            "inc",
+		Runtime:         WASI_RUNTIME,
+		MemoryMB:        64,
+		TarFunctionCode: buildWasiExample(t),
+	}
+
+	cont, err := CreateContainer(f, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, Destroy(cont.ID))
+	}()
+
+	memory, err := GetMemoryMB(cont.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(64), memory)
+
+	// the same warm instance serves several invocations
+	for i := 0; i < 3; i++ {
+		result, _, err := Execute(context.Background(), cont.ID, &executor.InvocationRequest{
+			Params:       map[string]interface{}{"input": i},
+			HandlerDir:   "/app",
+			ReturnOutput: true,
+		}, nil)
+		if assert.NoError(t, err) {
+			assert.True(t, result.Success)
+			assert.Equal(t, []string{"1", "2", "3"}[i], result.Result)
+			assert.Contains(t, result.Output, "Invoked inc")
+		}
+	}
+
+	// a failure of the handler is reported in the result
+	result, _, err := Execute(context.Background(), cont.ID, &executor.InvocationRequest{HandlerDir: "/app"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	logs, err := GetLog(cont.ID)
+	assert.NoError(t, err)
+	assert.Contains(t, logs, "missing input")
+
+	// the memory limit is enforced
+	f.MemoryMB = 1
+	small, err := CreateContainer(f, false)
+	if err == nil {
+		defer Destroy(small.ID)
+		result, _, err = Execute(context.Background(), small.ID, &executor.InvocationRequest{
+			Params:     map[string]interface{}{"input": 1},
+			HandlerDir: "/app",
+		}, nil)
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+	}
+}
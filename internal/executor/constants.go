@@ -1,3 +1,20 @@
 package executor
 
+import "time"
+
 const DEFAULT_EXECUTOR_PORT = 8080
+
+// killWaitDelay bounds how long the executor waits for the output of a killed handler
+const killWaitDelay = 2 * time.Second
+
+// partialsPollInterval is the interval between the reads of the partial results of streamed invocations
+const partialsPollInterval = 100 * time.Millisecond
+
+// maxLineLength is the max length of the lines of output streamed as events: longer lines are split
+const maxLineLength = 64 * 1024
+
+// Environment variables that override the address of the executor and the directory of the function code (i.e.,
+// the HandlerDir of requests), when the executor does not run in a container
+const HOST_ENV = "EXECUTOR_HOST"
+const PORT_ENV = "EXECUTOR_PORT"
+const HANDLER_DIR_ENV = "EXECUTOR_HANDLER_DIR"
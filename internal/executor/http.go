@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+)
+
+// HTTPRequest is a raw HTTP request received through the HTTP trigger, which is passed to the handler in place of
+// the parameters. The body is base64-encoded if it is not valid UTF-8.
+type HTTPRequest struct {
+	Method          string
+	Path            string // the part of the path following /http/<function>
+	Query           url.Values
+	Headers         http.Header
+	Body            string
+	IsBase64Encoded bool
+}
+
+// HTTPResponse is returned by the handlers invoked through the HTTP trigger. Handlers returning any other value
+// reply with status 200 and the value as body (as plain text for strings, JSON otherwise).
+type HTTPResponse struct {
+	StatusCode      int
+	Headers         HTTPHeaders
+	Body            string
+	IsBase64Encoded bool // the body is base64-encoded binary data
+}
+
+// HTTPHeaders are the headers of an HTTPResponse. Each header is mapped to a list of values, so that it can be
+// repeated (e.g., Set-Cookie), or to a single value.
+type HTTPHeaders map[string][]string
+
+func (h *HTTPHeaders) UnmarshalJSON(data []byte) error {
+	var headers map[string]json.RawMessage
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return err
+	}
+	*h = make(HTTPHeaders, len(headers))
+	for name, raw := range headers {
+		var value string
+		if json.Unmarshal(raw, &value) == nil {
+			(*h)[name] = []string{value}
+			continue
+		}
+		var values []string
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return fmt.Errorf("header %s is neither a string nor a list of strings", name)
+		}
+		(*h)[name] = values
+	}
+	return nil
+}
+
+// NewHTTPRequest reads the request, to be passed to a handler invoked through the HTTP trigger
+func NewHTTPRequest(req *http.Request, path string) (*HTTPRequest, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &HTTPRequest{
+		Method:  req.Method,
+		Path:    path,
+		Query:   req.URL.Query(),
+		Headers: req.Header.Clone(),
+	}
+	if utf8.Valid(body) {
+		r.Body = string(body)
+	} else {
+		r.Body = base64.StdEncoding.EncodeToString(body)
+		r.IsBase64Encoded = true
+	}
+	return r, nil
+}
+
+// ParseHTTPResponse returns the response determined by the result of a handler invoked through the HTTP trigger
+func ParseHTTPResponse(result string) (*HTTPResponse, error) {
+	if result == "" {
+		return &HTTPResponse{StatusCode: http.StatusOK}, nil
+	}
+
+	var object map[string]json.RawMessage
+	if json.Unmarshal([]byte(result), &object) == nil {
+		if _, ok := object["StatusCode"]; ok {
+			var resp HTTPResponse
+			if err := json.Unmarshal([]byte(result), &resp); err != nil {
+				return nil, err
+			}
+			if resp.StatusCode == 0 {
+				resp.StatusCode = http.StatusOK
+			} else if resp.StatusCode < 100 || resp.StatusCode > 599 {
+				return nil, fmt.Errorf("invalid status code %d", resp.StatusCode)
+			}
+			return &resp, nil
+		}
+	}
+
+	var text string
+	if json.Unmarshal([]byte(result), &text) == nil {
+		return &HTTPResponse{
+			StatusCode: http.StatusOK,
+			Headers:    HTTPHeaders{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:       text,
+		}, nil
+	}
+	return &HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    HTTPHeaders{"Content-Type": {"application/json"}},
+		Body:       result,
+	}, nil
+}
+
+// BodyBytes returns the body of the response, decoded if necessary
+func (r *HTTPResponse) BodyBytes() ([]byte, error) {
+	if r.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPRequest(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/http/f/items/1?q=a&q=b", bytes.NewReader([]byte("hello")))
+	req.Header.Set("X-Custom", "v")
+	r, err := NewHTTPRequest(req, "/items/1")
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", r.Method)
+	assert.Equal(t, "/items/1", r.Path)
+	assert.Equal(t, []string{"a", "b"}, r.Query["q"])
+	assert.Equal(t, "v", r.Headers.Get("X-Custom"))
+	assert.Equal(t, "hello", r.Body)
+	assert.False(t, r.IsBase64Encoded)
+
+	binary := []byte{0xff, 0x00, 0xfe}
+	r, err = NewHTTPRequest(httptest.NewRequest("POST", "/http/f", bytes.NewReader(binary)), "/")
+	assert.NoError(t, err)
+	assert.True(t, r.IsBase64Encoded, "non UTF-8 bodies are base64-encoded")
+	assert.Equal(t, "/wD+", r.Body)
+}
+
+func TestParseHTTPResponse(t *testing.T) {
+	resp, err := ParseHTTPResponse(`{"StatusCode": 201, "Headers": {"Location": "/items/2"}, "Body": "created"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, []string{"/items/2"}, resp.Headers["Location"])
+	assert.Equal(t, "created", resp.Body)
+
+	resp, err = ParseHTTPResponse(`{"StatusCode": 0, "Body": "/wD+", "IsBase64Encoded": true}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	body, err := resp.BodyBytes()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, body)
+
+	resp, err = ParseHTTPResponse(`"plain"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "plain", resp.Body)
+	assert.Contains(t, resp.Headers["Content-Type"][0], "text/plain")
+
+	resp, err = ParseHTTPResponse(`{"a": 1}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, resp.Body, "other values are returned as JSON")
+	assert.Equal(t, []string{"application/json"}, resp.Headers["Content-Type"])
+
+	resp, err = ParseHTTPResponse("")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	_, err = ParseHTTPResponse(`{"StatusCode": "ok"}`)
+	assert.Error(t, err)
+	for _, invalid := range []string{`{"StatusCode": 1000}`, `{"StatusCode": 99}`, `{"StatusCode": -200}`} {
+		_, err = ParseHTTPResponse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseHTTPResponseHeaders(t *testing.T) {
+	resp, err := ParseHTTPResponse(`{"StatusCode": 200, "Headers": {"Set-Cookie": ["a=1", "b=2"], "Location": "/"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a=1", "b=2"}, resp.Headers["Set-Cookie"], "headers can be repeated")
+	assert.Equal(t, []string{"/"}, resp.Headers["Location"])
+
+	_, err = ParseHTTPResponse(`{"StatusCode": 200, "Headers": {"X-Count": 1}}`)
+	assert.Error(t, err)
+}
+
+func TestHandlerInput(t *testing.T) {
+	params := map[string]interface{}{"n": 1.0}
+	assert.Nil(t, (&InvocationRequest{}).HandlerInput())
+	assert.Equal(t, params, (&InvocationRequest{Params: params}).HandlerInput())
+
+	httpReq := &HTTPRequest{Method: "GET", Path: "/"}
+	input, err := json.Marshal((&InvocationRequest{Params: params, HTTP: httpReq}).HandlerInput())
+	assert.NoError(t, err)
+	assert.Contains(t, string(input), `"Method":"GET"`, "the HTTP request replaces the params")
+}
@@ -1,17 +1,28 @@
 package executor
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
-const resultFile = "/tmp/_executor_result.json"
-const paramsFile = "/tmp/_executor.params"
+// Each invocation gets its own directory for parameters and result, so
+// that concurrent invocations within the same container do not interfere.
+const invocationDirPattern = "_executor-"
+const resultFileName = "result.json"
+const paramsFileName = "params.json"
+const partialsFileName = "partials.json"
 
 func readExecutionResult(resultFile string) string {
 	content, err := os.ReadFile(resultFile)
@@ -33,28 +44,6 @@ func InvokeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set environment variables
-	err = os.Setenv("RESULT_FILE", resultFile)
-	err = errors.Join(err, os.Setenv("HANDLER", req.Handler))
-	err = errors.Join(err, os.Setenv("HANDLER_DIR", req.HandlerDir))
-	params := req.Params
-	if params == nil {
-		err = errors.Join(err, os.Setenv("PARAMS_FILE", ""))
-	} else {
-		paramsB, _ := json.Marshal(req.Params)
-		fileError := os.WriteFile(paramsFile, paramsB, 0644)
-		if fileError != nil {
-			log.Printf("Could not write parameters to %s\n", paramsFile)
-			http.Error(w, fileError.Error(), http.StatusInternalServerError)
-			return
-		}
-		err = errors.Join(err, os.Setenv("PARAMS_FILE", paramsFile))
-	}
-	if err != nil {
-		log.Printf("Error while setting environment variables: %s\n", err)
-	}
-
-	// Exec handler process
 	cmd := req.Command
 	if cmd == nil || len(cmd) < 1 {
 		// this request is either invalid or uses a custom runtime
@@ -62,17 +51,80 @@ func InvokeHandler(w http.ResponseWriter, r *http.Request) {
 		customCmd, ok := os.LookupEnv("CUSTOM_CMD")
 		if !ok {
 			log.Printf("Invalid request!\n")
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			http.Error(w, "missing command", http.StatusBadRequest)
 			return
 		}
 
 		cmd = strings.Split(customCmd, " ")
 	}
 
+	if handlerDir, ok := os.LookupEnv(HANDLER_DIR_ENV); ok {
+		req.HandlerDir = handlerDir
+	}
+
+	invocationDir, err := os.MkdirTemp("", invocationDirPattern)
+	if err != nil {
+		log.Printf("Could not create invocation directory: %v\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(invocationDir); err != nil {
+			log.Printf("Could not remove %s: %v\n", invocationDir, err)
+		}
+	}()
+
+	resultFile := filepath.Join(invocationDir, resultFileName)
+	partialsFile := filepath.Join(invocationDir, partialsFileName)
+	paramsFile := ""
+	if input := req.HandlerInput(); input != nil {
+		paramsFile = filepath.Join(invocationDir, paramsFileName)
+		paramsB, _ := json.Marshal(input)
+		fileError := os.WriteFile(paramsFile, paramsB, 0644)
+		if fileError != nil {
+			log.Printf("Could not write parameters to %s\n", paramsFile)
+			http.Error(w, fileError.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The handler process is killed if the client goes away or the function timeout expires
+	ctx := r.Context()
+	if req.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
 	var resp *InvocationResult
-	execCmd := exec.Command(cmd[0], cmd[1:]...)
+	execCmd := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	// environment variables are set for the handler process only
+	execCmd.Env = append(os.Environ(),
+		"RESULT_FILE="+resultFile,
+		"HANDLER="+req.Handler,
+		"HANDLER_DIR="+req.HandlerDir,
+		"PARAMS_FILE="+paramsFile,
+		"PARTIAL_RESULTS_FILE="+partialsFile)
+	// do not wait forever for children that inherited the output pipes
+	execCmd.WaitDelay = killWaitDelay
+	if req.Stream {
+		invokeStreaming(ctx, w, execCmd, req, resultFile, partialsFile)
+		return
+	}
 	out, err := execCmd.CombinedOutput()
-	if err != nil {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("Handler killed after %d seconds\n", req.TimeoutSeconds)
+		resp = &InvocationResult{Success: false}
+		if req.ReturnOutput {
+			resp.Output = string(out)
+		}
+		writeResult(w, http.StatusGatewayTimeout, resp)
+		return
+	} else if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// the invocation has been cancelled: there is no one waiting for the result
+		log.Printf("Handler killed: invocation cancelled\n")
+		return
+	} else if err != nil {
 		log.Printf("cmd.Run() failed with %s\n", err)
 		if req.ReturnOutput {
 			resp = &InvocationResult{Success: false, Output: string(out)}
@@ -83,17 +135,154 @@ func InvokeHandler(w http.ResponseWriter, r *http.Request) {
 		result := readExecutionResult(resultFile)
 
 		if req.ReturnOutput {
-			resp = &InvocationResult{true, result, string(out)}
+			resp = &InvocationResult{Success: true, Result: result, Output: string(out)}
 		} else {
-			resp = &InvocationResult{true, result, ""}
+			resp = &InvocationResult{Success: true, Result: result, Output: ""}
 		}
 	}
 
+	writeResult(w, http.StatusOK, resp)
+}
+
+func writeResult(w http.ResponseWriter, statusCode int, resp *InvocationResult) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 	respBody, _ := json.Marshal(resp)
-	_, err = w.Write(respBody)
+	_, err := w.Write(respBody)
 	if err != nil {
 		log.Printf("Error while writing response to HTTP %s\n", err)
 		return
 	}
 }
+
+// invokeStreaming runs the handler, streaming each line of its output and each partial result (i.e., each line the
+// handler appends to the file at PARTIAL_RESULTS_FILE) as an Event, followed by the result of the invocation
+func invokeStreaming(ctx context.Context, w http.ResponseWriter, execCmd *exec.Cmd, req *InvocationRequest, resultFile string, partialsFile string) {
+	var mu sync.Mutex // events are sent by both the readers of the output and of the partial results
+	send := func(name string, data string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := WriteEvent(w, Event{Name: name, Data: data}); err != nil {
+			log.Printf("Error while writing event: %v\n", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", StreamContentType)
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	outputReader, outputWriter := io.Pipe()
+	execCmd.Stdout = outputWriter
+	execCmd.Stderr = outputWriter
+	var output strings.Builder
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		readLines(outputReader, func(line string) {
+			if req.ReturnOutput {
+				output.WriteString(line + "\n")
+			}
+			send(OutputEvent, line)
+		})
+	}()
+
+	partials := &partialsReader{path: partialsFile}
+	stopPartials := make(chan struct{})
+	partialsDone := make(chan struct{})
+	go func() {
+		defer close(partialsDone)
+		ticker := time.NewTicker(partialsPollInterval)
+		defer ticker.Stop()
+		for {
+			final := false
+			select {
+			case <-stopPartials:
+				final = true
+			case <-ticker.C:
+			}
+			for _, partial := range partials.read(final) {
+				send(PartialEvent, partial)
+			}
+			if final {
+				return
+			}
+		}
+	}()
+
+	err := execCmd.Run()
+	_ = outputWriter.Close()
+	<-outputDone
+	close(stopPartials)
+	<-partialsDone
+
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("Handler killed after %d seconds\n", req.TimeoutSeconds)
+		data, _ := json.Marshal(StreamError{StatusCode: http.StatusGatewayTimeout, Message: "function execution timed out"})
+		send(ErrorEvent, string(data))
+		return
+	} else if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		log.Printf("Handler killed: invocation cancelled\n")
+		return
+	}
+
+	resp := &InvocationResult{Success: err == nil, Output: output.String()}
+	if err != nil {
+		log.Printf("cmd.Run() failed with %s\n", err)
+	} else {
+		resp.Result = readExecutionResult(resultFile)
+	}
+	data, _ := json.Marshal(resp)
+	send(ResultEvent, string(data))
+}
+
+// readLines calls handle for each line read (without the line terminator). Lines longer than maxLineLength are split.
+func readLines(r io.Reader, handle func(line string)) {
+	reader := bufio.NewReaderSize(r, maxLineLength)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if len(line) > 0 {
+			handle(strings.TrimRight(string(line), "\r\n"))
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			break
+		}
+	}
+	// the writer must not block, even if reading failed
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// partialsReader reads the partial results appended to a file by the handler, one per line
+type partialsReader struct {
+	path   string
+	offset int64
+}
+
+// read returns the partial results appended since the last read: only the complete lines, unless final
+func (p *partialsReader) read(final bool) []string {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil // no partial results yet
+	}
+	defer f.Close()
+	if _, err = f.Seek(p.offset, io.SeekStart); err != nil {
+		return nil
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	if !final {
+		content = content[:bytes.LastIndexByte(content, '\n')+1]
+	}
+	p.offset += int64(len(content))
+
+	var partials []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSuffix(line, "\r"); line != "" {
+			partials = append(partials, line)
+		}
+	}
+	return partials
+}
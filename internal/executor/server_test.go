@@ -0,0 +1,173 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func invoke(t *testing.T, url string, req *InvocationRequest) (*http.Response, *InvocationResult) {
+	body, err := json.Marshal(req)
+	assert.NoError(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	result := &InvocationResult{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(result))
+	return resp, result
+}
+
+func TestInvokeHandlerTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	server := httptest.NewServer(http.HandlerFunc(InvokeHandler))
+	defer server.Close()
+
+	start := time.Now()
+	resp, result := invoke(t, server.URL, &InvocationRequest{
+		Command:        []string{"/bin/sh", "-c", "sleep 30"},
+		TimeoutSeconds: 1,
+	})
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.False(t, result.Success)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestInvokeHandlerWithinTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	server := httptest.NewServer(http.HandlerFunc(InvokeHandler))
+	defer server.Close()
+
+	resp, result := invoke(t, server.URL, &InvocationRequest{
+		Command:        []string{"/bin/sh", "-c", "echo done"},
+		ReturnOutput:   true,
+		TimeoutSeconds: 10,
+	})
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, result.Success)
+	assert.Equal(t, "done\n", result.Output)
+}
+
+// TestInvokeHandlerConcurrentIsolation checks that concurrent invocations
+// never observe each other's parameters or results.
+func TestInvokeHandlerConcurrentIsolation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	server := httptest.NewServer(http.HandlerFunc(InvokeHandler))
+	defer server.Close()
+
+	const n = 50
+	// the random sleep interleaves the handlers between reading the params
+	// and writing the result
+	cmd := []string{"/bin/sh", "-c", `sleep 0.0$(( $$ % 10 )); cat "$PARAMS_FILE" > "$RESULT_FILE"; echo "$HANDLER"`}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			handler := fmt.Sprintf("handler-%d", i)
+			resp, result := invoke(t, server.URL, &InvocationRequest{
+				Command:      cmd,
+				Params:       map[string]interface{}{"id": float64(i)},
+				Handler:      handler,
+				ReturnOutput: true,
+			})
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.True(t, result.Success)
+
+			var params map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(result.Result), &params))
+			assert.Equal(t, float64(i), params["id"])
+			assert.Equal(t, handler+"\n", result.Output)
+		}(i)
+	}
+	wg.Wait()
+
+	leftovers, err := filepath.Glob(filepath.Join(os.TempDir(), invocationDirPattern+"*"))
+	assert.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func invokeStream(t *testing.T, url string, req *InvocationRequest) (*http.Response, []Event) {
+	req.Stream = true
+	body, err := json.Marshal(req)
+	assert.NoError(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var events []Event
+	assert.NoError(t, ReadEvents(resp.Body, func(event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+	return resp, events
+}
+
+func TestInvokeHandlerStreaming(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	server := httptest.NewServer(http.HandlerFunc(InvokeHandler))
+	defer server.Close()
+
+	// the second line of output is written after the partial result has been read
+	cmd := []string{"/bin/sh", "-c", `echo first; echo '{"epoch": 1}' >> "$PARTIAL_RESULTS_FILE"; sleep 0.5; ` +
+		`echo second >&2; printf '{"epoch": 2}' >> "$PARTIAL_RESULTS_FILE"; echo '"done"' > "$RESULT_FILE"`}
+	resp, events := invokeStream(t, server.URL, &InvocationRequest{Command: cmd, ReturnOutput: true})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, StreamContentType, resp.Header.Get("Content-Type"))
+
+	if assert.Len(t, events, 5) {
+		assert.Equal(t, []Event{
+			{Name: OutputEvent, Data: "first"},
+			{Name: PartialEvent, Data: `{"epoch": 1}`},
+			{Name: OutputEvent, Data: "second"},
+			{Name: PartialEvent, Data: `{"epoch": 2}`},
+		}, events[:4])
+		assert.Equal(t, ResultEvent, events[4].Name)
+		var result InvocationResult
+		assert.NoError(t, json.Unmarshal([]byte(events[4].Data), &result))
+		assert.True(t, result.Success)
+		assert.Equal(t, "\"done\"\n", result.Result)
+		assert.Equal(t, "first\nsecond\n", result.Output)
+	}
+}
+
+func TestInvokeHandlerStreamingTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	server := httptest.NewServer(http.HandlerFunc(InvokeHandler))
+	defer server.Close()
+
+	_, events := invokeStream(t, server.URL, &InvocationRequest{
+		Command:        []string{"/bin/sh", "-c", "echo started; sleep 30"},
+		TimeoutSeconds: 1,
+	})
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, Event{Name: OutputEvent, Data: "started"}, events[0])
+		assert.Equal(t, ErrorEvent, events[1].Name)
+		var streamErr StreamError
+		assert.NoError(t, json.Unmarshal([]byte(events[1].Data), &streamErr))
+		assert.Equal(t, http.StatusGatewayTimeout, streamErr.StatusCode)
+	}
+}
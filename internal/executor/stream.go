@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StreamContentType is the content type of the responses of streamed invocations (Server-Sent Events)
+const StreamContentType = "text/event-stream"
+
+// Events of the streams of invocations (see InvocationRequest.Stream)
+const (
+	OutputEvent  = "output"  // a line of the output of the handler
+	PartialEvent = "partial" // a partial result of the handler
+	ResultEvent  = "result"  // the result of the invocation, which ends the stream
+	ErrorEvent   = "error"   // a StreamError, which ends the stream of a failed invocation
+)
+
+// Event is an event of a stream, in the Server-Sent Events format
+type Event struct {
+	Name string
+	Data string
+}
+
+// StreamError is the data of the ErrorEvent, with the status code that the response to the invocation would have
+// without streaming
+type StreamError struct {
+	StatusCode int
+	Message    string
+}
+
+// WriteEvent writes an event to the stream, flushing it if w is an http.Flusher. Multi-line data are split into
+// several "data" fields, as prescribed by the format.
+func WriteEvent(w io.Writer, event Event) error {
+	var buf bytes.Buffer
+	buf.WriteString("event: " + event.Name + "\n")
+	for _, line := range strings.Split(event.Data, "\n") {
+		buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buf.WriteString("\n")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// ReadEvents reads the events of a stream, calling handle for each of them, until the end of the stream. It stops at
+// the first error, returned by either r or handle.
+func ReadEvents(r io.Reader, handle func(Event) error) error {
+	reader := bufio.NewReader(r)
+	var event Event
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// a blank line dispatches the event
+			if event.Name != "" || data != nil {
+				if event.Name == "" {
+					event.Name = "message"
+				}
+				event.Data = strings.Join(data, "\n")
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+			event, data = Event{}, nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package executor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsRoundTrip(t *testing.T) {
+	sent := []Event{
+		{Name: OutputEvent, Data: "epoch 1"},
+		{Name: PartialEvent, Data: `{"loss": 0.5}`},
+		{Name: OutputEvent, Data: ""},
+		{Name: ResultEvent, Data: "first line\nsecond line"},
+	}
+	var buf bytes.Buffer
+	for _, event := range sent {
+		assert.NoError(t, WriteEvent(&buf, event))
+	}
+	assert.Equal(t, "event: output\ndata: epoch 1\n\n", strings.SplitAfter(buf.String(), "\n\n")[0])
+
+	var received []Event
+	assert.NoError(t, ReadEvents(&buf, func(event Event) error {
+		received = append(received, event)
+		return nil
+	}))
+	assert.Equal(t, sent, received)
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := ": comment\r\ndata:no space\r\n\r\nevent: result\ndata: {}\n\nevent: truncated\ndata: lost"
+	var received []Event
+	assert.NoError(t, ReadEvents(strings.NewReader(stream), func(event Event) error {
+		received = append(received, event)
+		return nil
+	}))
+	assert.Equal(t, []Event{{Name: "message", Data: "no space"}, {Name: ResultEvent, Data: "{}"}}, received,
+		"events with no name are messages, and incomplete events are dropped")
+}
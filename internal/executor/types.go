@@ -2,15 +2,43 @@ package executor
 
 // InvocationRequest is a struct used by the executor to effectively run the function
 type InvocationRequest struct {
-	Command      []string
-	Params       map[string]interface{}
-	Handler      string
-	HandlerDir   string
-	ReturnOutput bool
+	Command        []string
+	Params         map[string]interface{}
+	Handler        string
+	HandlerDir     string
+	ReturnOutput   bool
+	TimeoutSeconds int64        // the handler process is killed after this many seconds (0 = no limit)
+	HTTP           *HTTPRequest `json:",omitempty"` // set for invocations through the HTTP trigger, and passed to the handler in place of Params
+	Stream         bool         `json:",omitempty"` // the output and the partial results of the handler are streamed as Events
+}
+
+// HandlerInput returns the input of the handler: the raw HTTP request for invocations through the HTTP trigger,
+// the parameters otherwise (nil if there are none)
+func (r *InvocationRequest) HandlerInput() interface{} {
+	if r.HTTP != nil {
+		return r.HTTP
+	} else if r.Params != nil {
+		return r.Params
+	}
+	return nil
 }
 
 type InvocationResult struct {
 	Success bool
 	Result  string
 	Output  string
+	Error   string // name of the error raised by the handler, if reported by the runtime (optional)
+}
+
+// HandlerError is returned when the function handler fails. Name is the name of the error raised by the handler,
+// if reported by the runtime (e.g., the class of the exception in Python)
+type HandlerError struct {
+	Name string
+}
+
+func (e *HandlerError) Error() string {
+	if e.Name == "" {
+		return "the function handler failed"
+	}
+	return "the function handler failed with " + e.Name
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
+	"strings"
 
 	"time"
 
@@ -13,9 +14,14 @@ import (
 	"golang.org/x/net/context"
 )
 
+// DefaultNamespace is the namespace of the functions and workflows created without specifying one
+const DefaultNamespace = "default"
+
 // Function describes a serverless function.
 type Function struct {
 	Name            string
+	Namespace       string   // namespace (i.e., tenant) of the function; DefaultNamespace if empty
+	Version         int      // published version of the function; LatestVersion for its current definition
 	Runtime         string   // example: python314
 	MemoryMB        int64    // MB
 	CPUDemand       float64  // 1.0 -> 1 core
@@ -24,33 +30,73 @@ type Function struct {
 	TarFunctionCode string   // input is .tar
 	CustomImage     string   // used if custom runtime is chosen
 	SupportedArchs  []string // list of supported architectures by the runtime
+	TimeoutSeconds  int64    // max execution time for each invocation (0 = no limit)
 	Signature       *Signature
+	Revision        int64 `json:"-"` // etcd revision of the definition, which tells updated definitions apart
 }
 
 func (f *Function) getEtcdKey() string {
-	return getEtcdKey(f.Name)
+	return getEtcdKey(f.GetNamespace(), f.Name)
+}
+
+func getEtcdKey(namespace string, funcName string) string {
+	return fmt.Sprintf("/function/%s/%s", namespace, funcName)
+}
+
+// GetNamespace returns the namespace of the function
+func (f *Function) GetNamespace() string {
+	if f.Namespace == "" {
+		return DefaultNamespace
+	}
+	return f.Namespace
+}
+
+// QualifiedName returns the name that identifies the function across namespaces (see QualifiedName)
+func (f *Function) QualifiedName() string {
+	return QualifiedName(f.GetNamespace(), f.Name)
+}
+
+// QualifiedName returns "namespace/name", or just the name for the DefaultNamespace
+func QualifiedName(namespace string, name string) string {
+	if namespace == "" || namespace == DefaultNamespace {
+		return name
+	}
+	return namespace + "/" + name
 }
 
-func getEtcdKey(funcName string) string {
-	return fmt.Sprintf("/function/%s", funcName)
+// SplitQualifiedName returns the namespace and the name of a (possibly) qualified name
+func SplitQualifiedName(qualifiedName string) (namespace string, name string) {
+	namespace, name, found := strings.Cut(qualifiedName, "/")
+	if !found {
+		return DefaultNamespace, qualifiedName
+	}
+	return namespace, name
+}
+
+// IsValidName returns true if the name can be used for a function, a workflow or a namespace
+func IsValidName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/@ ")
 }
 
 func (f *Function) SupportsArch(arch string) bool {
 	return slices.Contains(f.SupportedArchs, arch)
 }
 
-// GetFunction retrieves a Function given its name. If it doesn't exist, returns false
-func GetFunction(name string) (*Function, bool) {
+// GetFunction retrieves a Function given its qualified name (see QualifiedName). If it doesn't exist, returns false
+func GetFunction(qualifiedName string) (*Function, bool) {
+	namespace, name := SplitQualifiedName(qualifiedName)
+	key := getEtcdKey(namespace, name)
 
-	val, found := getFromCache(name)
+	val, found := getFromCache(qualifiedName)
 	if !found {
 		// cache miss
-		f, response := getFromEtcd(name)
+		f, response := getFromEtcd(key)
 		if !response {
 			return nil, false
 		}
+		f.Namespace = namespace
 		//insert a new element to the cache
-		cache.GetCacheInstance().Set(name, f, cache.DefaultExp)
+		cache.GetCacheInstance().Set(qualifiedName, f, cache.DefaultExp)
 		return f, true
 	}
 
@@ -59,29 +105,33 @@ func GetFunction(name string) (*Function, bool) {
 }
 
 func (f *Function) String() string {
-	return f.Name
+	return f.QualifiedName()
 }
 
-func getFromCache(name string) (*Function, bool) {
+func getFromCache(qualifiedName string) (*Function, bool) {
 	localCache := cache.GetCacheInstance()
-	f, found := localCache.Get(name)
+	f, found := localCache.Get(qualifiedName)
 	if !found {
 		return nil, false
 	}
+	cached, ok := f.(*Function) // workflows are cached with the same keys
+	if !ok {
+		return nil, false
+	}
 	//cache hit
 	//return a safe copy of the function previously obtained
-	function := *f.(*Function)
+	function := *cached
 	return &function, true
 
 }
 
-func getFromEtcd(name string) (*Function, bool) {
+func getFromEtcd(key string) (*Function, bool) {
 	cli, err := utils.GetEtcdClient()
 	if err != nil {
 		return nil, false
 	}
 	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
-	getResponse, err := cli.Get(ctx, getEtcdKey(name))
+	getResponse, err := cli.Get(ctx, key)
 	if err != nil || len(getResponse.Kvs) < 1 {
 		return nil, false
 	}
@@ -91,6 +141,7 @@ func getFromEtcd(name string) (*Function, bool) {
 	if err != nil {
 		return nil, false
 	}
+	f.Revision = getResponse.Kvs[0].ModRevision
 
 	return &f, true
 }
@@ -107,18 +158,19 @@ func (f *Function) SaveToEtcd() error {
 	if err != nil {
 		return fmt.Errorf("Could not marshal function: %v", err)
 	}
-	_, err = cli.Put(ctx, f.getEtcdKey(), string(payload))
+	putResponse, err := cli.Put(ctx, f.getEtcdKey(), string(payload))
 	if err != nil {
 		return fmt.Errorf("Failed Put: %v", err)
 	}
+	f.Revision = putResponse.Header.Revision
 
 	// Add the function to the local cache
-	cache.GetCacheInstance().Set(f.Name, f, cache.DefaultExp)
+	cache.GetCacheInstance().Set(f.QualifiedName(), f, cache.DefaultExp)
 
 	return nil
 }
 
-// Delete removes a function from Etcd and the local cache.
+// Delete removes a function, with its versions and aliases, from Etcd and the local cache.
 func (f *Function) Delete() error {
 	cli, err := utils.GetEtcdClient()
 	if err != nil {
@@ -132,35 +184,41 @@ func (f *Function) Delete() error {
 	} else if dresp.Deleted != 1 {
 		fmt.Printf("no function with key '%s' exists", f.getEtcdKey())
 	}
+	if err = f.deleteVersionsAndAliases(cli); err != nil {
+		return fmt.Errorf("Failed Delete of versions: %v", err)
+	}
 
 	// Remove the function from the local cache
-	cache.GetCacheInstance().Delete(f.Name)
+	cache.GetCacheInstance().Delete(f.QualifiedName())
 
 	return nil
 }
 
 func (f *Function) Equals(f2 *Function) bool {
 	return (f == nil && f2 == nil) || (f.Name == f2.Name &&
+		f.GetNamespace() == f2.GetNamespace() &&
+		f.Version == f2.Version &&
 		f.CustomImage == f2.CustomImage &&
 		f.CPUDemand == f2.CPUDemand &&
 		f.Runtime == f2.Runtime &&
 		f.Handler == f2.Handler &&
 		f.MemoryMB == f2.MemoryMB &&
+		f.TimeoutSeconds == f2.TimeoutSeconds &&
 		f.TarFunctionCode == f2.TarFunctionCode)
 }
 
 // Exists checks if the function is already saved to Etcd
 func (f *Function) Exists() bool {
-	savedFunction, ok := GetFunction(f.Name)
+	savedFunction, ok := GetFunction(f.QualifiedName())
 	return ok && f.Equals(savedFunction)
 }
 
-// GetAll returns all function names
-func GetAll() ([]string, error) {
-	return GetAllWithPrefix("/function")
+// GetAll returns the names of all the functions in a namespace
+func GetAll(namespace string) ([]string, error) {
+	return GetAllWithPrefix("/function/" + namespace)
 }
 
-// GetAllWithPrefix is used to get all /function or /workflow currently registered in etcd
+// GetAllWithPrefix is used to get all /function/<namespace> or /workflow/<namespace> currently registered in etcd
 func GetAllWithPrefix(prefix string) ([]string, error) {
 	cli, err := utils.GetEtcdClient()
 	if err != nil {
@@ -169,7 +227,7 @@ func GetAllWithPrefix(prefix string) ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
 	defer cancel()
 
-	resp, err := cli.Get(ctx, prefix, clientv3.WithPrefix())
+	resp, err := cli.Get(ctx, prefix+"/", clientv3.WithPrefix())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,28 @@
+package function
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/serverledge-faas/serverledge/utils"
+)
+
+// legacyEtcdDirs contain the keys that were not qualified by a namespace before namespaces were introduced
+// (e.g., "/function/<name>" instead of "/function/<namespace>/<name>")
+var legacyEtcdDirs = []string{"/function/", "/workflow/", "async/"}
+
+// MigrateLegacyKeys moves the functions, workflows and results of asynchronous requests saved in Etcd before
+// namespaces were introduced to the DefaultNamespace. Keys that are already qualified are left untouched, so it
+// can be called at every startup.
+func MigrateLegacyKeys() error {
+	for _, dir := range legacyEtcdDirs {
+		moved, err := utils.MoveLegacyKeys(dir, DefaultNamespace)
+		if err != nil {
+			return fmt.Errorf("could not migrate %s to the %s namespace: %v", dir, DefaultNamespace, err)
+		}
+		if moved > 0 {
+			log.Printf("Migrated %d keys of %s to the %s namespace\n", moved, dir, DefaultNamespace)
+		}
+	}
+	return nil
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/executor"
 )
 
+// RequestIdHeader carries the ID of a request, e.g., when it is offloaded to a remote node, which keeps the same ID
+const RequestIdHeader = "Serverledge-Request-ID"
+
 // Request represents a single function invocation, with a ReqId, reference to the Function, parameters and metrics data
 type Request struct {
 	Ctx     context.Context
@@ -16,6 +21,10 @@ type Request struct {
 	CanDoOffloading bool
 	Async           bool
 	ReturnOutput    bool
+	APIKey          string // key used by the client, forwarded if the request is offloaded
+	AsyncOptions
+	HTTP   *executor.HTTPRequest // raw HTTP request, for invocations through the HTTP trigger
+	Stream func(executor.Event)  // if set, receives the output and the partial results of the handler while it runs
 }
 
 type RequestQoS struct {
@@ -23,6 +32,13 @@ type RequestQoS struct {
 	MaxRespT float64
 }
 
+// AsyncOptions control how the result of an asynchronous request is delivered
+type AsyncOptions struct {
+	CallbackURL    string `json:",omitempty"` // the result is POSTed to this URL, besides being saved for polling
+	CallbackSecret string `json:",omitempty"` // if set, callbacks are signed with HMAC-SHA256 using this secret
+	ResultTTL      int64  `json:",omitempty"` // seconds the result is kept for polling (0 for the configured default)
+}
+
 type ExecutionReport struct {
 	Result         string
 	ResponseTime   float64 // time waited by the user to get the output: completion time - arrival time
@@ -35,7 +51,8 @@ type ExecutionReport struct {
 }
 
 type Response struct {
-	Success bool
+	Success   bool
+	Cancelled bool `json:",omitempty"` // the request has been cancelled by the client
 	ExecutionReport
 }
 
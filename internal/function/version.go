@@ -0,0 +1,344 @@
+package function
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"golang.org/x/net/context"
+)
+
+// LatestVersion identifies the latest (mutable) definition of a function, as opposed to its published versions,
+// which are numbered from 1 and never change.
+const LatestVersion = 0
+
+var UnknownVersionErr = errors.New("unknown function version")
+
+// VersionWeight is a route of an Alias: the version receives a share of the invocations proportional to the weight
+type VersionWeight struct {
+	Version int
+	Weight  int
+}
+
+// Alias is a named pointer (e.g., "prod") to one or more versions of a function, with weighted traffic splitting
+type Alias struct {
+	Name     string
+	Routing  []VersionWeight
+	Previous []VersionWeight // routing before the last update, restored by a rollback
+}
+
+// WithVersion returns the reference to a version of a function, i.e. "name@version", or just the name for the LatestVersion
+func WithVersion(name string, version int) string {
+	if version == LatestVersion {
+		return name
+	}
+	return fmt.Sprintf("%s@%d", name, version)
+}
+
+// SplitQualifier returns the name and the qualifier (version or alias) of a reference "name@qualifier"
+func SplitQualifier(reference string) (name string, qualifier string) {
+	name, qualifier, _ = strings.Cut(reference, "@")
+	return name, qualifier
+}
+
+// IsValidAliasName returns true if the name can be used for an alias (i.e., it cannot be confused with a version)
+func IsValidAliasName(name string) bool {
+	_, err := strconv.Atoi(name)
+	return IsValidName(name) && err != nil
+}
+
+// VersionedName returns the name that identifies the version of the function across namespaces
+func (f *Function) VersionedName() string {
+	return WithVersion(f.QualifiedName(), f.Version)
+}
+
+func getVersionEtcdKey(namespace string, funcName string, version int) string {
+	return fmt.Sprintf("/version/%s/%s/%d", namespace, funcName, version)
+}
+
+func getAliasEtcdKey(namespace string, funcName string, alias string) string {
+	return fmt.Sprintf("/alias/%s/%s/%s", namespace, funcName, alias)
+}
+
+// Resolve retrieves the Function referenced as "[namespace/]name[@qualifier]", where the qualifier is either
+// a version number or an alias. In the latter case, the version is picked according to the weights of the alias.
+func Resolve(reference string) (*Function, bool) {
+	qualifiedName, qualifier := SplitQualifier(reference)
+	if qualifier == "" {
+		return GetFunction(qualifiedName)
+	}
+
+	version, err := strconv.Atoi(qualifier)
+	if err != nil {
+		alias, found := GetAlias(qualifiedName, qualifier)
+		if !found {
+			return nil, false
+		}
+		version = alias.PickVersion()
+	}
+	return GetFunctionVersion(qualifiedName, version)
+}
+
+// GetFunctionVersion retrieves a published version of the function with the given qualified name
+func GetFunctionVersion(qualifiedName string, version int) (*Function, bool) {
+	if version == LatestVersion {
+		return GetFunction(qualifiedName)
+	}
+
+	versionedName := WithVersion(qualifiedName, version)
+	if f, found := getFromCache(versionedName); found {
+		return f, true
+	}
+
+	namespace, name := SplitQualifiedName(qualifiedName)
+	f, found := getFromEtcd(getVersionEtcdKey(namespace, name, version))
+	if !found {
+		return nil, false
+	}
+	f.Namespace = namespace
+	// versions are immutable, so they can be safely cached
+	cache.GetCacheInstance().Set(versionedName, f, cache.DefaultExp)
+	return f, true
+}
+
+// GetVersions returns the published versions of the function, in increasing order
+func GetVersions(qualifiedName string) ([]int, error) {
+	namespace, name := SplitQualifiedName(qualifiedName)
+	list, err := GetAllWithPrefix(fmt.Sprintf("/version/%s/%s", namespace, name))
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int, 0, len(list))
+	for _, v := range list {
+		if version, err := strconv.Atoi(v); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// Publish saves the current definition of the function as a new immutable version, and returns its number
+func (f *Function) Publish() (int, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		versions, err := GetVersions(f.QualifiedName())
+		if err != nil {
+			return 0, err
+		}
+		version := 1
+		if len(versions) > 0 {
+			version = versions[len(versions)-1] + 1
+		}
+
+		published := *f
+		published.Version = version
+		payload, err := json.Marshal(published)
+		if err != nil {
+			return 0, fmt.Errorf("could not marshal function: %v", err)
+		}
+
+		// the version is saved only if no one else published the same number in the meantime
+		key := getVersionEtcdKey(f.GetNamespace(), f.Name, version)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		resp, err := cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, string(payload))).
+			Commit()
+		cancel()
+		if err != nil {
+			return 0, fmt.Errorf("failed etcd Txn: %v", err)
+		}
+		if resp.Succeeded {
+			return version, nil
+		}
+	}
+}
+
+// PickVersion returns one of the versions of the alias, chosen with probability proportional to its weight
+func (a *Alias) PickVersion() int {
+	total := 0
+	for _, r := range a.Routing {
+		total += r.Weight
+	}
+	if total <= 0 {
+		return a.Routing[0].Version
+	}
+
+	n := rand.Intn(total)
+	for _, r := range a.Routing {
+		if n < r.Weight {
+			return r.Version
+		}
+		n -= r.Weight
+	}
+	return a.Routing[len(a.Routing)-1].Version
+}
+
+// validateRouting checks that the routing points to existing versions with non-negative weights
+func validateRouting(qualifiedName string, routing []VersionWeight) error {
+	if len(routing) == 0 {
+		return fmt.Errorf("an alias must point to at least one version")
+	}
+	total := 0
+	for _, r := range routing {
+		if r.Weight < 0 {
+			return fmt.Errorf("invalid weight %d for version %d", r.Weight, r.Version)
+		}
+		total += r.Weight
+		if r.Version <= LatestVersion {
+			return fmt.Errorf("%w: %d", UnknownVersionErr, r.Version)
+		}
+		if _, found := GetFunctionVersion(qualifiedName, r.Version); !found {
+			return fmt.Errorf("%w: %d", UnknownVersionErr, r.Version)
+		}
+	}
+	if total == 0 {
+		return fmt.Errorf("the weights of an alias cannot be all zero")
+	}
+	return nil
+}
+
+// GetAlias retrieves an alias of the function with the given qualified name
+func GetAlias(qualifiedName string, aliasName string) (*Alias, bool) {
+	namespace, name := SplitQualifiedName(qualifiedName)
+	key := getAliasEtcdKey(namespace, name, aliasName)
+	if cached, found := cache.GetCacheInstance().Get(key); found {
+		alias := *cached.(*Alias)
+		return &alias, true
+	}
+
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	getResponse, err := cli.Get(ctx, key)
+	if err != nil || len(getResponse.Kvs) < 1 {
+		return nil, false
+	}
+
+	var alias Alias
+	if err = json.Unmarshal(getResponse.Kvs[0].Value, &alias); err != nil || len(alias.Routing) == 0 {
+		return nil, false
+	}
+	cache.GetCacheInstance().Set(key, &alias, cache.DefaultExp)
+	return &alias, true
+}
+
+// GetAliases returns the aliases of the function with the given qualified name
+func GetAliases(qualifiedName string) ([]Alias, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	namespace, name := SplitQualifiedName(qualifiedName)
+	resp, err := cli.Get(ctx, getAliasEtcdKey(namespace, name, ""), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]Alias, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var alias Alias
+		if err = json.Unmarshal(kv.Value, &alias); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+func (a *Alias) save(qualifiedName string) error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("could not marshal alias: %v", err)
+	}
+
+	namespace, name := SplitQualifiedName(qualifiedName)
+	key := getAliasEtcdKey(namespace, name, a.Name)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = cli.Put(ctx, key, string(payload)); err != nil {
+		return fmt.Errorf("failed etcd Put: %v", err)
+	}
+	cache.GetCacheInstance().Set(key, a, cache.DefaultExp)
+	return nil
+}
+
+// SetAlias creates the alias of a function, or points it to different versions. The previous routing is kept for rollbacks.
+func SetAlias(qualifiedName string, aliasName string, routing []VersionWeight) (*Alias, error) {
+	if err := validateRouting(qualifiedName, routing); err != nil {
+		return nil, err
+	}
+
+	alias := &Alias{Name: aliasName, Routing: routing}
+	if old, found := GetAlias(qualifiedName, aliasName); found {
+		alias.Previous = old.Routing
+	}
+	return alias, alias.save(qualifiedName)
+}
+
+// RollbackAlias restores the routing of the alias before its last update
+func RollbackAlias(qualifiedName string, aliasName string) (*Alias, error) {
+	alias, found := GetAlias(qualifiedName, aliasName)
+	if !found {
+		return nil, fmt.Errorf("unknown alias: %s", aliasName)
+	}
+	if len(alias.Previous) == 0 {
+		return nil, fmt.Errorf("alias %s has no previous routing", aliasName)
+	}
+
+	alias.Routing, alias.Previous = alias.Previous, alias.Routing
+	return alias, alias.save(qualifiedName)
+}
+
+// deleteVersionsAndAliases removes all the published versions and the aliases of the function from Etcd and the local cache
+func (f *Function) deleteVersionsAndAliases(cli *clientv3.Client) error {
+	versions, err := GetVersions(f.QualifiedName())
+	if err != nil {
+		return err
+	}
+	aliases, err := GetAliases(f.QualifiedName())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = cli.Delete(ctx, fmt.Sprintf("/version/%s/%s/", f.GetNamespace(), f.Name), clientv3.WithPrefix()); err != nil {
+		return err
+	}
+	if _, err = cli.Delete(ctx, getAliasEtcdKey(f.GetNamespace(), f.Name, ""), clientv3.WithPrefix()); err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		cache.GetCacheInstance().Delete(WithVersion(f.QualifiedName(), v))
+	}
+	for _, a := range aliases {
+		cache.GetCacheInstance().Delete(getAliasEtcdKey(f.GetNamespace(), f.Name, a.Name))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualifiers(t *testing.T) {
+	assert.Equal(t, "f", WithVersion("f", LatestVersion))
+	assert.Equal(t, "ns/f@2", WithVersion("ns/f", 2))
+
+	name, qualifier := SplitQualifier("ns/f@prod")
+	assert.Equal(t, "ns/f", name)
+	assert.Equal(t, "prod", qualifier)
+	name, qualifier = SplitQualifier("f")
+	assert.Equal(t, "f", name)
+	assert.Empty(t, qualifier)
+
+	assert.True(t, IsValidAliasName("prod"))
+	assert.False(t, IsValidAliasName("3"))
+	assert.False(t, IsValidAliasName("a@b"))
+
+	f := Function{Name: "f", Namespace: "ns", Version: 4}
+	assert.Equal(t, "ns/f@4", f.VersionedName())
+}
+
+func TestPickVersion(t *testing.T) {
+	alias := Alias{Name: "prod", Routing: []VersionWeight{{Version: 1, Weight: 0}, {Version: 2, Weight: 1}}}
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, 2, alias.PickVersion())
+	}
+
+	alias.Routing = []VersionWeight{{Version: 1, Weight: 80}, {Version: 2, Weight: 20}}
+	counts := make(map[int]int)
+	for i := 0; i < 10000; i++ {
+		counts[alias.PickVersion()]++
+	}
+	assert.InDelta(t, 8000, counts[1], 500)
+	assert.InDelta(t, 2000, counts[2], 500)
+}
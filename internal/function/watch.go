@@ -0,0 +1,137 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/serverledge-faas/serverledge/utils"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchChanges keeps the local cache consistent with the functions, versions and aliases saved in Etcd,
+// as they are updated or deleted by any node. onChange (if not nil) is called with the previous definition of every
+// function (or version) that has changed, e.g., to discard its warm containers.
+// The watches run in background until the context is cancelled.
+func WatchChanges(ctx context.Context, onChange func(old *Function)) {
+	if onChange == nil {
+		onChange = func(*Function) {}
+	}
+
+	go utils.WatchPrefix(ctx, "/function/", func(ev *clientv3.Event) {
+		onFunctionEvent(ev, onChange)
+	}, func() {
+		resyncFunctions(onChange, func(f *Function) bool { return f.Version == LatestVersion })
+	})
+
+	go utils.WatchPrefix(ctx, "/version/", func(ev *clientv3.Event) {
+		onVersionEvent(ev, onChange)
+	}, func() {
+		resyncFunctions(onChange, func(f *Function) bool { return f.Version != LatestVersion })
+	})
+
+	go utils.WatchPrefix(ctx, "/alias/", func(ev *clientv3.Event) {
+		cache.GetCacheInstance().Delete(string(ev.Kv.Key))
+	}, func() {
+		cache.GetCacheInstance().DeleteMatching(func(_ string, x interface{}) bool {
+			_, isAlias := x.(*Alias)
+			return isAlias
+		})
+	})
+}
+
+// parseEtcdKey returns the namespace and the name of a function from a key such as /function/<namespace>/<name>,
+// and the remaining part of the key (e.g., the version)
+func parseEtcdKey(key string) (namespace string, name string, suffix string, ok bool) {
+	parts := strings.SplitN(key, "/", 5)
+	if len(parts) < 4 || parts[0] != "" {
+		return "", "", "", false
+	}
+	if len(parts) == 5 {
+		suffix = parts[4]
+	}
+	return parts[2], parts[3], suffix, true
+}
+
+// decodePrevious returns the definition of the function before the event, if available
+func decodePrevious(ev *clientv3.Event, namespace string) *Function {
+	if ev.PrevKv == nil {
+		return nil
+	}
+	var f Function
+	if err := json.Unmarshal(ev.PrevKv.Value, &f); err != nil {
+		return nil
+	}
+	f.Namespace = namespace
+	f.Revision = ev.PrevKv.ModRevision
+	return &f
+}
+
+func onFunctionEvent(ev *clientv3.Event, onChange func(old *Function)) {
+	namespace, name, _, ok := parseEtcdKey(string(ev.Kv.Key))
+	if !ok {
+		return
+	}
+	if ev.Type == mvccpb.PUT && ev.PrevKv != nil && bytes.Equal(ev.PrevKv.Value, ev.Kv.Value) {
+		return
+	}
+
+	qualifiedName := QualifiedName(namespace, name)
+	localCache := cache.GetCacheInstance()
+	// workflows are cached with the same keys
+	if _, cached := getFromCache(qualifiedName); cached {
+		var f Function
+		if ev.Type == mvccpb.PUT && json.Unmarshal(ev.Kv.Value, &f) == nil {
+			// refresh the cached definition
+			f.Namespace = namespace
+			f.Revision = ev.Kv.ModRevision
+			localCache.Set(qualifiedName, &f, cache.DefaultExp)
+		} else {
+			localCache.Delete(qualifiedName)
+		}
+	}
+
+	if old := decodePrevious(ev, namespace); old != nil {
+		onChange(old)
+	}
+}
+
+func onVersionEvent(ev *clientv3.Event, onChange func(old *Function)) {
+	if ev.Type != mvccpb.DELETE {
+		return // versions are immutable
+	}
+	namespace, name, suffix, ok := parseEtcdKey(string(ev.Kv.Key))
+	version, err := strconv.Atoi(suffix)
+	if !ok || err != nil {
+		return
+	}
+	cache.GetCacheInstance().Delete(WithVersion(QualifiedName(namespace, name), version))
+
+	if old := decodePrevious(ev, namespace); old != nil {
+		old.Version = version
+		onChange(old)
+	}
+}
+
+// resyncFunctions drops the selected functions from the local cache, after a watch interruption. onChange is called
+// for those that have been updated or deleted in the meantime.
+func resyncFunctions(onChange func(old *Function), selected func(f *Function) bool) {
+	removed := cache.GetCacheInstance().DeleteMatching(func(_ string, x interface{}) bool {
+		f, isFunction := x.(*Function)
+		return isFunction && selected(f)
+	})
+	log.Printf("Resynchronizing %d cached functions\n", len(removed))
+
+	for _, x := range removed {
+		old := x.(*Function)
+		current, found := GetFunctionVersion(old.QualifiedName(), old.Version)
+		if !found || !current.Equals(old) {
+			onChange(old)
+		}
+	}
+}
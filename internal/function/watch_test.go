@@ -0,0 +1,79 @@
+package function
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func newEvent(t *testing.T, evType mvccpb.Event_EventType, key string, prev *Function, current *Function) *clientv3.Event {
+	ev := &clientv3.Event{Type: evType, Kv: &mvccpb.KeyValue{Key: []byte(key)}}
+	if current != nil {
+		value, err := json.Marshal(current)
+		assert.NoError(t, err)
+		ev.Kv.Value = value
+	}
+	if prev != nil {
+		value, err := json.Marshal(prev)
+		assert.NoError(t, err)
+		ev.PrevKv = &mvccpb.KeyValue{Key: []byte(key), Value: value}
+	}
+	return ev
+}
+
+func TestParseEtcdKey(t *testing.T) {
+	namespace, name, suffix, ok := parseEtcdKey("/function/ns/f")
+	assert.True(t, ok)
+	assert.Equal(t, "ns", namespace)
+	assert.Equal(t, "f", name)
+	assert.Empty(t, suffix)
+
+	_, name, suffix, ok = parseEtcdKey("/version/default/f/3")
+	assert.True(t, ok)
+	assert.Equal(t, "f", name)
+	assert.Equal(t, "3", suffix)
+
+	_, _, _, ok = parseEtcdKey("/function/ns")
+	assert.False(t, ok)
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	var changed []*Function
+	onChange := func(old *Function) { changed = append(changed, old) }
+
+	old := &Function{Name: "watched", Namespace: "ns", Runtime: "python310", MemoryMB: 128}
+	cache.GetCacheInstance().Set(old.QualifiedName(), old, cache.DefaultExp)
+
+	// an update refreshes the cached definition
+	updated := *old
+	updated.MemoryMB = 256
+	onFunctionEvent(newEvent(t, mvccpb.PUT, "/function/ns/watched", old, &updated), onChange)
+	cached, found := getFromCache("ns/watched")
+	assert.True(t, found)
+	assert.Equal(t, int64(256), cached.MemoryMB)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, int64(128), changed[0].MemoryMB)
+	assert.Equal(t, "ns", changed[0].Namespace)
+
+	// saving the same definition again is not a change
+	onFunctionEvent(newEvent(t, mvccpb.PUT, "/function/ns/watched", &updated, &updated), onChange)
+	assert.Len(t, changed, 1)
+
+	onFunctionEvent(newEvent(t, mvccpb.DELETE, "/function/ns/watched", &updated, nil), onChange)
+	_, found = getFromCache("ns/watched")
+	assert.False(t, found)
+	assert.Len(t, changed, 2)
+
+	published := updated
+	published.Version = 2
+	cache.GetCacheInstance().Set(published.VersionedName(), &published, cache.DefaultExp)
+	onVersionEvent(newEvent(t, mvccpb.DELETE, "/version/ns/watched/2", &published, nil), onChange)
+	_, found = cache.GetCacheInstance().Get("ns/watched@2")
+	assert.False(t, found)
+	assert.Len(t, changed, 3)
+	assert.Equal(t, 2, changed[2].Version)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/lithammer/shortuuid"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/config"
 	"github.com/serverledge-faas/serverledge/internal/container"
 	"github.com/serverledge-faas/serverledge/internal/function"
@@ -64,8 +65,9 @@ func (b *ArchitectureAwareBalancer) Next(c echo.Context) *middleware.ProxyTarget
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	funcName := extractFunctionName(c)        // get function's name from request's URL
-	fun, ok := function.GetFunction(funcName) // we use this to leverage cache before asking etcd
+	funcName := extractFunctionName(c) // get function's name from request's URL
+	namespace := auth.RequestNamespace(c.Request())
+	fun, ok := function.GetFunction(function.QualifiedName(namespace, funcName)) // we use this to leverage cache before asking etcd
 	if !ok {
 		log.Printf("Dropping request for unknown fun '%s'\n", funcName)
 		return nil
@@ -118,16 +120,23 @@ func (b *ArchitectureAwareBalancer) Next(c echo.Context) *middleware.ProxyTarget
 	return candidate
 }
 
-// extractFunctionName retrieves the function's name by parsing the request's URL.
+// extractFunctionName retrieves the function's name by parsing the request's URL (of either an invocation or
+// a request to the HTTP trigger). The version or alias possibly following the name is discarded, as all the
+// versions share the same balancing state.
 func extractFunctionName(c echo.Context) string {
 	path := c.Request().URL.Path
 
-	const prefix = "/invoke/"
-	if !strings.HasPrefix(path, prefix) {
+	var reference string
+	if rest, ok := strings.CutPrefix(path, "/invoke/"); ok {
+		reference = rest
+	} else if rest, ok := strings.CutPrefix(path, "/http/"); ok {
+		reference, _, _ = strings.Cut(rest, "/")
+	} else {
 		return "" // not an invocation
 	}
 
-	return path[len(prefix):]
+	name, _ := function.SplitQualifier(reference)
+	return name
 }
 
 // Deprecated
@@ -141,7 +150,7 @@ func (b *ArchitectureAwareBalancer) selectArchitecture(fun *function.Function) (
 
 	if supportsArm && supportsX86 {
 		cacheValidity := 30 * time.Second // may be fine-tuned
-		cacheEntry, ok := ArchitectureCacheLB.cache[fun.Name]
+		cacheEntry, ok := ArchitectureCacheLB.cache[fun.QualifiedName()]
 
 		// If we have a valid cache entry, we try to use it
 		expiry := time.Unix(cacheEntry.Timestamp, 0).Add(cacheValidity)
@@ -151,7 +160,7 @@ func (b *ArchitectureAwareBalancer) selectArchitecture(fun *function.Function) (
 				(cacheEntry.Arch == container.X86 && b.x86Ring.Size() > 0) {
 				// If the cached architecture is still valid and has available nodes, use it
 				cacheEntry.Timestamp = time.Now().Unix() // Update timestamp
-				ArchitectureCacheLB.cache[fun.Name] = cacheEntry
+				ArchitectureCacheLB.cache[fun.QualifiedName()] = cacheEntry
 				return cacheEntry.Arch, nil
 			}
 
@@ -173,7 +182,7 @@ func (b *ArchitectureAwareBalancer) selectArchitecture(fun *function.Function) (
 			Arch:      chosenArch,
 			Timestamp: time.Now().Unix(),
 		}
-		ArchitectureCacheLB.cache[fun.Name] = newCacheEntry
+		ArchitectureCacheLB.cache[fun.QualifiedName()] = newCacheEntry
 
 		return chosenArch, nil
 	}
@@ -287,3 +287,17 @@ func getNewLb(targets []*middleware.ProxyTarget) *ArchitectureAwareBalancer {
 	mab.InitBanditManager()
 	return NewArchitectureAwareBalancer(targets)
 }
+
+func TestExtractFunctionName(t *testing.T) {
+	e := echo.New()
+	for path, expected := range map[string]string{
+		"/invoke/func":          "func",
+		"/http/func":            "func",
+		"/http/func/items/1":    "func",
+		"/status":               "",
+		"/cancel/func-12345678": "",
+	} {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, path, nil), httptest.NewRecorder())
+		assert.Equal(t, expected, extractFunctionName(c), path)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/lithammer/shortuuid"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/config"
 	"github.com/serverledge-faas/serverledge/internal/container"
 	"github.com/serverledge-faas/serverledge/internal/function"
@@ -53,8 +54,9 @@ func (b *ArchitectureUNawareBalancer) Next(c echo.Context) *middleware.ProxyTarg
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	funcName := extractFunctionName(c)        // get function's name from request's URL
-	fun, ok := function.GetFunction(funcName) // we use this to leverage cache before asking etcd
+	funcName := extractFunctionName(c) // get function's name from request's URL
+	namespace := auth.RequestNamespace(c.Request())
+	fun, ok := function.GetFunction(function.QualifiedName(namespace, funcName)) // we use this to leverage cache before asking etcd
 	if !ok {
 		log.Printf("Dropping request for unknown fun '%s'\n", funcName)
 		return nil
@@ -0,0 +1,59 @@
+package lb
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/serverledge-faas/serverledge/internal/auth"
+	"github.com/serverledge-faas/serverledge/internal/function"
+)
+
+// isCancellation returns true for the requests to cancel an invocation, which are not bound to a function
+func isCancellation(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().URL.Path, "/cancel/")
+}
+
+// forwardCancellation forwards the cancellation of an invocation to all the targets, as the LB does not keep track of
+// the node serving each request. The cancellation succeeds if any target knows the request.
+func forwardCancellation(c echo.Context) error {
+	targets := currentTargets
+	statusCodes := make(chan int, len(targets))
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(targetUrl string) {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodPost, targetUrl+c.Request().URL.Path, nil)
+			if err != nil {
+				statusCodes <- http.StatusInternalServerError
+				return
+			}
+			for _, header := range []string{auth.APIKeyHeader, auth.NamespaceHeader, echo.HeaderAuthorization} {
+				if value := c.Request().Header.Get(header); value != "" {
+					req.Header.Set(header, value)
+				}
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				statusCodes <- http.StatusBadGateway
+				return
+			}
+			_ = resp.Body.Close()
+			statusCodes <- resp.StatusCode
+		}(target.URL.String())
+	}
+	wg.Wait()
+	close(statusCodes)
+
+	status := http.StatusNotFound
+	for code := range statusCodes {
+		if code == http.StatusOK {
+			return c.JSON(http.StatusOK, function.AsyncResponse{ReqId: c.Param("reqId")})
+		} else if code != http.StatusNotFound {
+			status = code
+		}
+	}
+	return c.NoContent(status)
+}
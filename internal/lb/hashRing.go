@@ -48,7 +48,7 @@ func (r *HashRing) Get(fun *function.Function) *middleware.ProxyTarget {
 		return nil
 	}
 
-	h := hash(fun.Name)
+	h := hash(fun.QualifiedName())
 	// we'll return the node whose hash is the next in the ring, starting from the hash of the function's name
 	idx := sort.Search(len(r.ring), func(i int) bool { return r.ring[i] >= h })
 	if idx == len(r.ring) {
@@ -11,11 +11,13 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/executor"
 	"github.com/serverledge-faas/serverledge/internal/mab"
 	"github.com/serverledge-faas/serverledge/internal/registration"
 )
@@ -51,43 +53,58 @@ func StartReverseProxy(e *echo.Echo, region string) {
 	// includes the memory freed by the function, once it's executed.
 	proxyConfig := middleware.ProxyConfig{
 		Balancer: balancer,
+		Skipper:  isCancellation,
 
 		// We use ModifyResponse to process these headers
 		ModifyResponse: func(res *http.Response) error {
 
-			// Here we read the body, and then we restore it. This is done to avoid a potential race condition:
-			// the main thread of this LB will send the body back to the original user/caller, since it's acting as a
-			// reverse proxy. In the meantime UpdateBandit will try to read the same stream of data to get the
-			// stats about the execution, to update the bandit. Even if the goroutine tries to restore the response after
-			// reading it, chaches are that that won't happen quick eough (and it will not be a reliable solution anyway),
-			// so the solution here is the following:
-			// 1. We read the response body
-			// 2. We extract the fileds needed to update the bandit, and we pass those to UpdateBandit
-			// 3. We restore the response body so that it can be read by the ProxyBalancer in order to send it back to the user.
-			// All of this is because we don't want to call UpdateBandit synchronously, since that would add more
-			// latency for the final user.
-			bodyBytes, err := io.ReadAll(res.Body)
-			if err != nil {
-				return err
-			}
-			_ = res.Body.Close()
-
-			res.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // reset the body, as discussed earlier
-
 			// Extract the necessary data for UpdateBandit
 			nodeArch := res.Header.Get("Serverledge-Node-Arch")
 			reqPath := res.Request.URL.Path
 			reqID := res.Request.Header.Get("Serverledge-MAB-Request-ID")
 
-			go func(data []byte, path string, arch string, reqID string) {
-				if !isAware {
-					return // if we're using the unaware LB no need for bandit update (there isn't one)
-				}
-				err := mab.UpdateBandit(data, path, arch, reqID)
+			updateBandit := func(data []byte) {
+				go func(data []byte, path string, arch string, reqID string) {
+					if !isAware {
+						return // if we're using the unaware LB no need for bandit update (there isn't one)
+					}
+					if strings.HasPrefix(path, "/http/") {
+						// the response of the HTTP trigger does not include the execution report
+						mab.GlobalContextStorage.RetrieveAndDelete(reqID)
+						return
+					}
+					err := mab.UpdateBandit(data, path, arch, reqID)
+					if err != nil {
+						log.Printf("Failed to update bandit: %v", err)
+					}
+				}(data, reqPath, nodeArch, reqID)
+			}
+
+			if strings.HasPrefix(res.Header.Get("Content-Type"), executor.StreamContentType) {
+				// Streamed responses are sent to the user as they are read, so the bandit is updated with the
+				// result that ends the stream, once it has been forwarded.
+				res.Body = observeStream(res.Body, updateBandit)
+			} else {
+				// Here we read the body, and then we restore it. This is done to avoid a potential race condition:
+				// the main thread of this LB will send the body back to the original user/caller, since it's acting as a
+				// reverse proxy. In the meantime UpdateBandit will try to read the same stream of data to get the
+				// stats about the execution, to update the bandit. Even if the goroutine tries to restore the response after
+				// reading it, chaches are that that won't happen quick eough (and it will not be a reliable solution anyway),
+				// so the solution here is the following:
+				// 1. We read the response body
+				// 2. We extract the fileds needed to update the bandit, and we pass those to UpdateBandit
+				// 3. We restore the response body so that it can be read by the ProxyBalancer in order to send it back to the user.
+				// All of this is because we don't want to call UpdateBandit synchronously, since that would add more
+				// latency for the final user.
+				bodyBytes, err := io.ReadAll(res.Body)
 				if err != nil {
-					log.Printf("Failed to update bandit: %v", err)
+					return err
 				}
-			}(bodyBytes, reqPath, nodeArch, reqID)
+				_ = res.Body.Close()
+
+				res.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // reset the body, as discussed earlier
+				updateBandit(bodyBytes)
+			}
 
 			nodeName := res.Header.Get("Serverledge-Node-Name")
 			freeMemStr := res.Header.Get("Serverledge-Free-Mem")
@@ -122,6 +139,7 @@ func StartReverseProxy(e *echo.Echo, region string) {
 	}
 
 	e.Use(middleware.ProxyWithConfig(proxyConfig))
+	e.POST("/cancel/:reqId", forwardCancellation)
 	go updateTargets(balancer, region)
 
 	portNumber := config.GetInt(config.API_PORT, 1323)
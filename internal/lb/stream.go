@@ -0,0 +1,49 @@
+package lb
+
+import (
+	"io"
+
+	"github.com/serverledge-faas/serverledge/internal/executor"
+)
+
+// observedStream is the body of a streamed response, whose events are parsed in the background as the body is read
+type observedStream struct {
+	io.ReadCloser
+	events *io.PipeWriter
+}
+
+// observeStream returns a body that forwards the events of the stream as they arrive, and calls onResult with the
+// data of the result that ends the stream (if any) once it has been read
+func observeStream(body io.ReadCloser, onResult func(data []byte)) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		var result []byte
+		_ = executor.ReadEvents(reader, func(event executor.Event) error {
+			if event.Name == executor.ResultEvent {
+				result = []byte(event.Data)
+			}
+			return nil
+		})
+		_, _ = io.Copy(io.Discard, reader) // the reads of the body must never block
+		if result != nil {
+			onResult(result)
+		}
+	}()
+	return &observedStream{ReadCloser: body, events: writer}
+}
+
+func (s *observedStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = s.events.Write(p[:n])
+	}
+	if err != nil {
+		_ = s.events.Close()
+	}
+	return n, err
+}
+
+func (s *observedStream) Close() error {
+	_ = s.events.Close()
+	return s.ReadCloser.Close()
+}
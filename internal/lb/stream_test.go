@@ -0,0 +1,46 @@
+package lb
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveStream(t *testing.T) {
+	var stream bytes.Buffer
+	_ = executor.WriteEvent(&stream, executor.Event{Name: executor.OutputEvent, Data: "started"})
+	_ = executor.WriteEvent(&stream, executor.Event{Name: executor.ResultEvent, Data: `{"Success": true}`})
+	expected := stream.String()
+
+	results := make(chan []byte, 1)
+	body := observeStream(io.NopCloser(&stream), func(data []byte) { results <- data })
+	forwarded, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, expected, string(forwarded), "the stream is forwarded unchanged")
+
+	select {
+	case result := <-results:
+		assert.Equal(t, `{"Success": true}`, string(result))
+	case <-time.After(time.Second):
+		t.Fatal("result not observed")
+	}
+}
+
+func TestObserveStreamClosedEarly(t *testing.T) {
+	reader, writer := io.Pipe()
+	go func() {
+		_ = executor.WriteEvent(writer, executor.Event{Name: executor.OutputEvent, Data: "started"})
+	}()
+
+	body := observeStream(reader, func(data []byte) { t.Error("no result expected") })
+	buf := make([]byte, 1024)
+	_, err := body.Read(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	time.Sleep(100 * time.Millisecond)
+}
@@ -20,7 +20,7 @@ func UpdateBandit(body []byte, reqPath string, arch string, reqID string) error
 	if len(pathParts) < 3 || pathParts[len(pathParts)-2] != "invoke" {
 		return fmt.Errorf("could not extract function name from URL: %s", reqPath)
 	}
-	functionName := pathParts[len(pathParts)-1]
+	functionName, _ := function.SplitQualifier(pathParts[len(pathParts)-1])
 
 	bandit := GlobalBanditManager.GetBandit(functionName)
 	ctx := GlobalContextStorage.RetrieveAndDelete(reqID)
@@ -3,6 +3,7 @@ package metrics
 import (
 	"fmt"
 	"log"
+	"strconv"
 
 	"net/http"
 
@@ -26,6 +27,10 @@ const (
 	INITIALIZATION_TIME = "init_time"
 	OUTPUT_SIZE         = "output_size"
 	BRANCH_COUNT        = "branch_count"
+	QUEUEING_TIME       = "queueing_time"
+	KEEPALIVE_WINDOW    = "keepalive_window"
+	PREWARM_WINDOW      = "prewarm_window"
+	PREDICTIVE_PREWARMS = "predictive_prewarms_count"
 )
 
 var (
@@ -54,8 +59,45 @@ var (
 		Name: BRANCH_COUNT,
 		Help: "Number of executions of a task among multiple alternatives",
 	}, []string{"task", "next_task"})
+	metricQueueingTime = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    QUEUEING_TIME,
+		Help:    "Time spent by requests in the scheduler queue per QoS class",
+		Buckets: durationBuckets,
+	}, []string{"class"})
 )
 
+// keepAliveCollector exports the decisions of the keep-alive controller of the node
+type keepAliveCollector struct {
+	keepAlive *prometheus.Desc
+	prewarm   *prometheus.Desc
+	prewarmed *prometheus.Desc
+}
+
+func newKeepAliveCollector() *keepAliveCollector {
+	return &keepAliveCollector{
+		keepAlive: prometheus.NewDesc(KEEPALIVE_WINDOW, "Time idle containers of the function are kept warm",
+			[]string{"function"}, nil),
+		prewarm: prometheus.NewDesc(PREWARM_WINDOW, "Time after an execution before a container of the function is prewarmed",
+			[]string{"function"}, nil),
+		prewarmed: prometheus.NewDesc(PREDICTIVE_PREWARMS, "Number of containers prewarmed ahead of predicted arrivals",
+			[]string{"function"}, nil),
+	}
+}
+
+func (c *keepAliveCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.keepAlive
+	ch <- c.prewarm
+	ch <- c.prewarmed
+}
+
+func (c *keepAliveCollector) Collect(ch chan<- prometheus.Metric) {
+	for funcName, decision := range node.KeepAliveStatus() {
+		ch <- prometheus.MustNewConstMetric(c.keepAlive, prometheus.GaugeValue, decision.KeepAlive, funcName)
+		ch <- prometheus.MustNewConstMetric(c.prewarm, prometheus.GaugeValue, decision.PrewarmWindow, funcName)
+		ch <- prometheus.MustNewConstMetric(c.prewarmed, prometheus.CounterValue, float64(decision.Prewarmed), funcName)
+	}
+}
+
 type RetrievedMetrics struct {
 	RemoteColdStartProbability map[string]float64
 	AvgRemoteExecutionTime     map[string]float64
@@ -101,6 +143,8 @@ func Init() {
 	registry.MustRegister(metricInitializationTime)
 	registry.MustRegister(metricOutputSize)
 	registry.MustRegister(metricBranchCount)
+	registry.MustRegister(metricQueueingTime)
+	registry.MustRegister(newKeepAliveCollector())
 
 	ScrapingHandler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{
 		EnableOpenMetrics: true})
@@ -126,3 +170,6 @@ func AddFunctionOutputSizeValue(funcName string, size float64) {
 func AddBranchCount(taskId string, nextTaskId string) {
 	metricBranchCount.With(prometheus.Labels{"task": taskId, "next_task": nextTaskId}).Inc()
 }
+func AddQueueingTimeValue(class int64, queueingTime float64) {
+	metricQueueingTime.With(prometheus.Labels{"class": strconv.FormatInt(class, 10)}).Observe(queueingTime)
+}
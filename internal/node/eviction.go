@@ -0,0 +1,147 @@
+package node
+
+import (
+	"log"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/container"
+)
+
+// Eviction policies
+const (
+	LRUEviction        = "lru"
+	LFUEviction        = "lfu"
+	GreedyDualEviction = "greedydual"
+)
+
+// EvictionPolicy decides which idle containers are dismissed first, when memory is needed for new containers.
+// Functions are identified by their versioned name. Methods are called with LocalResources locked.
+type EvictionPolicy interface {
+	// OnCreation is called when a container of the function is created, with the time it took (i.e., the cost of a cold start)
+	OnCreation(fun string, cont *container.Container, memoryMB int64, duration time.Duration)
+	// OnUse is called when a container is acquired to serve an invocation of the function
+	OnUse(fun string, cont *container.Container)
+	// OnRemoval is called when a container of the function is destroyed, for any reason
+	OnRemoval(fun string, cont *container.Container)
+	// Priority returns the priority of an idle container: containers with the lowest priority are dismissed first
+	Priority(fun string, cont *container.Container) float64
+	// OnEviction is called when an idle container is dismissed to free memory (before OnRemoval)
+	OnEviction(fun string, cont *container.Container)
+}
+
+// NewEvictionPolicy returns the eviction policy with the given name (LRU, if unknown)
+func NewEvictionPolicy(name string) EvictionPolicy {
+	switch name {
+	case LFUEviction:
+		return &LFUPolicy{frequency: make(map[string]int), lastUse: make(map[container.ContainerID]time.Time)}
+	case GreedyDualEviction:
+		return &GreedyDualPolicy{functions: make(map[string]*functionCost), priority: make(map[container.ContainerID]float64)}
+	case LRUEviction:
+	default:
+		log.Printf("Unknown eviction policy '%s': using '%s'\n", name, LRUEviction)
+	}
+	return &LRUPolicy{lastUse: make(map[container.ContainerID]time.Time)}
+}
+
+// LRUPolicy dismisses the least recently used containers first
+type LRUPolicy struct {
+	lastUse map[container.ContainerID]time.Time
+}
+
+func (p *LRUPolicy) OnCreation(_ string, cont *container.Container, _ int64, _ time.Duration) {
+	p.lastUse[cont.ID] = time.Now()
+}
+
+func (p *LRUPolicy) OnUse(_ string, cont *container.Container) {
+	p.lastUse[cont.ID] = time.Now()
+}
+
+func (p *LRUPolicy) OnRemoval(_ string, cont *container.Container) {
+	delete(p.lastUse, cont.ID)
+}
+
+func (p *LRUPolicy) Priority(_ string, cont *container.Container) float64 {
+	return float64(p.lastUse[cont.ID].UnixNano())
+}
+
+func (p *LRUPolicy) OnEviction(string, *container.Container) {}
+
+// LFUPolicy dismisses the containers of the least frequently invoked functions first (the least recently used first,
+// among the containers of the same function)
+type LFUPolicy struct {
+	frequency map[string]int
+	lastUse   map[container.ContainerID]time.Time
+}
+
+func (p *LFUPolicy) OnCreation(_ string, cont *container.Container, _ int64, _ time.Duration) {
+	p.lastUse[cont.ID] = time.Now()
+}
+
+func (p *LFUPolicy) OnUse(fun string, cont *container.Container) {
+	p.frequency[fun]++
+	p.lastUse[cont.ID] = time.Now()
+}
+
+func (p *LFUPolicy) OnRemoval(_ string, cont *container.Container) {
+	delete(p.lastUse, cont.ID)
+}
+
+func (p *LFUPolicy) Priority(fun string, cont *container.Container) float64 {
+	// the use time (in [0,1), relative to the recent past) only breaks ties between containers of the same function
+	age := time.Since(p.lastUse[cont.ID]).Hours() / 24
+	return float64(p.frequency[fun]) + 1/(2+age)
+}
+
+func (p *LFUPolicy) OnEviction(string, *container.Container) {}
+
+type functionCost struct {
+	frequency int
+	coldStart time.Duration // average creation time of the containers
+	creations int
+	memoryMB  int64
+}
+
+// GreedyDualPolicy is a cost-aware policy, which dismisses first the containers with the lowest priority
+// Clock + Frequency * ColdStartCost / Memory, where the Clock is the priority of the last dismissed container
+// (see "FaasCache: Keeping Serverless Computing Alive with Greedy-Dual Caching", Fuerst and Sharma, ASPLOS 2021).
+type GreedyDualPolicy struct {
+	clock     float64
+	functions map[string]*functionCost
+	priority  map[container.ContainerID]float64
+}
+
+func (p *GreedyDualPolicy) update(fun string, cont *container.Container) {
+	cost := p.functions[fun]
+	p.priority[cont.ID] = p.clock + float64(cost.frequency)*cost.coldStart.Seconds()/float64(max(cost.memoryMB, 1))
+}
+
+func (p *GreedyDualPolicy) OnCreation(fun string, cont *container.Container, memoryMB int64, duration time.Duration) {
+	cost, ok := p.functions[fun]
+	if !ok {
+		cost = &functionCost{}
+		p.functions[fun] = cost
+	}
+	cost.coldStart = (cost.coldStart*time.Duration(cost.creations) + duration) / time.Duration(cost.creations+1)
+	cost.creations++
+	cost.memoryMB = memoryMB
+	p.update(fun, cont)
+}
+
+func (p *GreedyDualPolicy) OnUse(fun string, cont *container.Container) {
+	if cost, ok := p.functions[fun]; ok {
+		cost.frequency++
+		p.update(fun, cont)
+	}
+}
+
+func (p *GreedyDualPolicy) OnRemoval(_ string, cont *container.Container) {
+	delete(p.priority, cont.ID)
+}
+
+func (p *GreedyDualPolicy) Priority(_ string, cont *container.Container) float64 {
+	return p.priority[cont.ID]
+}
+
+func (p *GreedyDualPolicy) OnEviction(_ string, cont *container.Container) {
+	p.clock = max(p.clock, p.priority[cont.ID])
+}
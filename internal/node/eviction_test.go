@@ -0,0 +1,152 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFactory is a container.Factory that only keeps track of the containers
+type fakeFactory struct {
+	sync.Mutex
+	next      int
+	memory    map[container.ContainerID]int64
+	destroyed []container.ContainerID
+}
+
+func newFakeFactory() *fakeFactory {
+	return &fakeFactory{memory: make(map[container.ContainerID]int64)}
+}
+
+func (f *fakeFactory) Create(_ string, opts *container.ContainerOptions) (container.ContainerID, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.next++
+	id := fmt.Sprintf("fake-%d", f.next)
+	f.memory[id] = opts.MemoryMB
+	return id, nil
+}
+
+func (f *fakeFactory) CopyToContainer(container.ContainerID, io.Reader, string) error { return nil }
+func (f *fakeFactory) Start(container.ContainerID) error                              { return nil }
+func (f *fakeFactory) HasImage(string) bool                                           { return true }
+func (f *fakeFactory) PullImage(string) error                                         { return nil }
+func (f *fakeFactory) GetIPAddress(container.ContainerID) (string, error)             { return "127.0.0.1", nil }
+func (f *fakeFactory) GetLog(container.ContainerID) (string, error)                   { return "", nil }
+func (f *fakeFactory) GetImageArchitectures(string) ([]string, error)                 { return []string{"amd64"}, nil }
+
+func (f *fakeFactory) GetMemoryMB(id container.ContainerID) (int64, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.memory[id], nil
+}
+
+func (f *fakeFactory) Destroy(id container.ContainerID) error {
+	f.Lock()
+	defer f.Unlock()
+	f.destroyed = append(f.destroyed, id)
+	return nil
+}
+
+// setupNode initializes the resources of the node with a fake factory and the given eviction policy
+func setupNode(t *testing.T, policy string, memoryMB int) *fakeFactory {
+	factory := newFakeFactory()
+	previous := container.GetFactory()
+	container.SetFactory(factory)
+	t.Cleanup(func() { container.SetFactory(previous) })
+
+	viper.Set(config.POOL_MEMORY_MB, memoryMB)
+	viper.Set(config.POOL_CPUS, 4.0)
+	viper.Set(config.CONTAINER_EVICTION_POLICY, policy)
+	LocalResources = Resources{}
+	LocalResources.Init()
+	return factory
+}
+
+func newTestFunction(name string, memoryMB int64) *function.Function {
+	return &function.Function{Name: name, Runtime: container.CUSTOM_RUNTIME, CustomImage: "fake", MemoryMB: memoryMB, MaxConcurrency: 1}
+}
+
+// invoke acquires a container for the function and releases it, returning its id
+func invoke(t *testing.T, f *function.Function) container.ContainerID {
+	cont, _, err := AcquireContainer(f, false)
+	assert.NoError(t, err)
+	HandleCompletion(cont, f)
+	return cont.ID
+}
+
+func TestLRUEviction(t *testing.T) {
+	factory := setupNode(t, LRUEviction, 300)
+	f1, f2, f3, f4 := newTestFunction("f1", 100), newTestFunction("f2", 100), newTestFunction("f3", 100), newTestFunction("f4", 100)
+
+	c1 := invoke(t, f1)
+	c2 := invoke(t, f2)
+	invoke(t, f3)
+	assert.Equal(t, c1, invoke(t, f1), "the warm container should be reused")
+
+	invoke(t, f4)
+	assert.Equal(t, []container.ContainerID{c2}, factory.destroyed)
+	assert.Equal(t, 0, WarmStatus()["f2"])
+	assert.Equal(t, 1, WarmStatus()["f1"])
+}
+
+func TestLFUEviction(t *testing.T) {
+	factory := setupNode(t, LFUEviction, 300)
+	f1, f2, f3, f4 := newTestFunction("f1", 100), newTestFunction("f2", 100), newTestFunction("f3", 100), newTestFunction("f4", 200)
+
+	c1 := invoke(t, f1)
+	invoke(t, f2)
+	invoke(t, f2)
+	invoke(t, f2)
+	c3 := invoke(t, f3)
+	invoke(t, f3)
+
+	// f1 is the least recently used, and then f3, but f2 is the most frequently invoked
+	invoke(t, f4)
+	assert.ElementsMatch(t, []container.ContainerID{c1, c3}, factory.destroyed)
+	assert.Equal(t, 1, WarmStatus()["f2"])
+}
+
+func TestEvictionNotEnoughMemory(t *testing.T) {
+	factory := setupNode(t, LRUEviction, 200)
+	f1, f2 := newTestFunction("f1", 100), newTestFunction("f2", 300)
+
+	invoke(t, f1)
+	_, _, err := AcquireContainer(f2, false)
+	assert.ErrorIs(t, err, OutOfResourcesErr)
+	assert.Empty(t, factory.destroyed, "containers should not be dismissed if not enough memory can be freed")
+}
+
+func TestGreedyDualPriority(t *testing.T) {
+	p := NewEvictionPolicy(GreedyDualEviction)
+	cheap := &container.Container{ID: "cheap"}
+	expensive := &container.Container{ID: "expensive"}
+	large := &container.Container{ID: "large"}
+
+	p.OnCreation("cheap", cheap, 128, 100*time.Millisecond)
+	p.OnCreation("expensive", expensive, 128, 2*time.Second)
+	p.OnCreation("large", large, 1024, 2*time.Second)
+	for _, c := range []*container.Container{cheap, expensive, large} {
+		p.OnUse(c.ID, c)
+	}
+
+	// cold starts of small functions are the most expensive to repeat
+	assert.Less(t, p.Priority("cheap", cheap), p.Priority("large", large))
+	assert.Less(t, p.Priority("large", large), p.Priority("expensive", expensive))
+
+	// after an eviction, the containers that are used again are prioritized over those that are not (by the clock)
+	clock := p.Priority("large", large)
+	p.OnEviction("large", large)
+	p.OnRemoval("large", large)
+	p.OnUse("cheap", cheap)
+	assert.InDelta(t, clock+2*0.1/128, p.Priority("cheap", cheap), 1e-9)
+	assert.InDelta(t, 2.0/128, p.Priority("expensive", expensive), 1e-9)
+}
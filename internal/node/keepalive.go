@@ -0,0 +1,274 @@
+package node
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+)
+
+// Keep-alive policies
+const (
+	// FixedKeepAlive keeps every idle container warm for container.expiration seconds
+	FixedKeepAlive = "fixed"
+	// HistogramKeepAlive adapts the keep-alive of the containers of each function to the distribution of the
+	// inter-arrival times of its invocations, and prewarms containers ahead of the predicted arrivals
+	// (see the hybrid histogram policy in "Serverless in the Wild", Shahrad et al., USENIX ATC 2020).
+	HistogramKeepAlive = "histogram"
+)
+
+const (
+	headPercentile = 0.05 // the prewarm window ends at this percentile of the inter-arrival times
+	tailPercentile = 0.99 // the keep-alive window ends at this percentile of the inter-arrival times
+	windowMargin   = 0.1  // windows are extended by this fraction, to tolerate small deviations
+	minCV          = 2.0  // histograms with a lower coefficient of variation are not considered representative
+	maxOutOfBounds = 0.5  // histograms with more inter-arrival times beyond their range are not considered representative
+)
+
+// KeepAliveDecision describes how the idle containers of a function are managed
+type KeepAliveDecision struct {
+	Samples        int     // inter-arrival times recorded
+	Representative bool    // whether the windows below are predicted from the histogram, or the fixed keep-alive is used
+	PrewarmWindow  float64 // seconds after an execution before a container is prewarmed (0 means that containers are kept warm)
+	KeepAlive      float64 // seconds an idle (or prewarmed) container is kept warm
+	NextPrewarm    int64   // Unix time of the next scheduled prewarming (0 if none)
+	Prewarmed      int     // containers prewarmed so far
+}
+
+type arrivalHistogram struct {
+	fun         *function.Function // the definition to use for prewarming
+	bins        []int
+	outOfBounds int
+	samples     int
+	lastArrival time.Time
+	prewarmed   int
+	nextPrewarm time.Time
+	timer       *time.Timer
+}
+
+type keepAliveController struct {
+	sync.Mutex
+	policy     string
+	binWidth   time.Duration
+	bins       int
+	minSamples int
+	fixed      time.Duration
+	histograms map[string]*arrivalHistogram // per function version
+}
+
+var keepAlive *keepAliveController
+var keepAliveOnce sync.Once
+
+func getKeepAliveController() *keepAliveController {
+	keepAliveOnce.Do(func() {
+		keepAlive = newKeepAliveController(config.GetString(config.KEEPALIVE_POLICY, FixedKeepAlive),
+			time.Duration(config.GetInt(config.KEEPALIVE_HISTOGRAM_BIN, 60))*time.Second,
+			config.GetInt(config.KEEPALIVE_HISTOGRAM_BINS, 240),
+			config.GetInt(config.KEEPALIVE_HISTOGRAM_MIN_SAMPLES, 10),
+			time.Duration(config.GetInt(config.CONTAINER_EXPIRATION_TIME, 600))*time.Second)
+	})
+	return keepAlive
+}
+
+func newKeepAliveController(policy string, binWidth time.Duration, bins int, minSamples int, fixed time.Duration) *keepAliveController {
+	if policy != FixedKeepAlive && policy != HistogramKeepAlive {
+		log.Printf("Unknown keep-alive policy '%s': using '%s'\n", policy, FixedKeepAlive)
+		policy = FixedKeepAlive
+	}
+	return &keepAliveController{
+		policy:     policy,
+		binWidth:   binWidth,
+		bins:       max(bins, 1),
+		minSamples: minSamples,
+		fixed:      fixed,
+		histograms: make(map[string]*arrivalHistogram),
+	}
+}
+
+// recordArrival updates the histogram of the inter-arrival times of the function with an invocation
+func (k *keepAliveController) recordArrival(f *function.Function, t time.Time) {
+	if k.policy != HistogramKeepAlive {
+		return
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	h, ok := k.histograms[f.VersionedName()]
+	if !ok {
+		h = &arrivalHistogram{bins: make([]int, k.bins)}
+		k.histograms[f.VersionedName()] = h
+	}
+	h.fun = f
+	if !h.lastArrival.IsZero() {
+		bin := int(t.Sub(h.lastArrival) / k.binWidth)
+		if bin < len(h.bins) {
+			h.bins[max(bin, 0)]++
+		} else {
+			h.outOfBounds++
+		}
+		h.samples++
+	}
+	h.lastArrival = t
+}
+
+// windows returns the prewarm and keep-alive windows predicted by the histogram, or the fixed keep-alive
+// if the histogram is not representative
+func (k *keepAliveController) windows(h *arrivalHistogram) (prewarm time.Duration, keepAlive time.Duration, representative bool) {
+	if h == nil || h.samples < k.minSamples || float64(h.outOfBounds) > maxOutOfBounds*float64(h.samples) {
+		return 0, k.fixed, false
+	}
+
+	total := h.samples - h.outOfBounds
+	mean := float64(total) / float64(len(h.bins))
+	variance := 0.0
+	for _, count := range h.bins {
+		variance += (float64(count) - mean) * (float64(count) - mean)
+	}
+	variance /= float64(len(h.bins))
+	if mean == 0 || math.Sqrt(variance)/mean < minCV {
+		return 0, k.fixed, false
+	}
+
+	head, tail := -1, -1
+	cumulative := 0
+	for i, count := range h.bins {
+		cumulative += count
+		if head < 0 && float64(cumulative) >= headPercentile*float64(total) {
+			head = i
+		}
+		if float64(cumulative) >= tailPercentile*float64(total) {
+			tail = i
+			break
+		}
+	}
+
+	prewarm = time.Duration(float64(time.Duration(head)*k.binWidth) * (1 - windowMargin))
+	end := time.Duration(float64(time.Duration(tail+1)*k.binWidth) * (1 + windowMargin))
+	return prewarm, end - prewarm, true
+}
+
+// onIdle returns the expiration time of a container of the function that has become idle. If containers are not
+// expected to be needed for a while, the container expires immediately and a new one is prewarmed ahead of the
+// next predicted arrival.
+func (k *keepAliveController) onIdle(f *function.Function, now time.Time) time.Time {
+	if k.policy != HistogramKeepAlive {
+		return now.Add(k.fixed)
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	h := k.histograms[f.VersionedName()]
+	prewarm, keepAlive, _ := k.windows(h)
+	if prewarm <= 0 {
+		return now.Add(keepAlive)
+	}
+
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	h.nextPrewarm = now.Add(prewarm)
+	h.timer = time.AfterFunc(prewarm, func() { k.prewarm(f.VersionedName()) })
+	return now
+}
+
+// expirationForPrewarmed returns the expiration time of a prewarmed container of the function
+func (k *keepAliveController) expirationForPrewarmed(f *function.Function, now time.Time) time.Time {
+	if k.policy != HistogramKeepAlive {
+		return now.Add(k.fixed)
+	}
+
+	k.Lock()
+	defer k.Unlock()
+	_, keepAlive, _ := k.windows(k.histograms[f.VersionedName()])
+	return now.Add(keepAlive)
+}
+
+// prewarm spawns a container for the function, unless there is one already
+func (k *keepAliveController) prewarm(versionedName string) {
+	k.Lock()
+	h, ok := k.histograms[versionedName]
+	if !ok {
+		k.Unlock()
+		return
+	}
+	h.timer = nil
+	h.nextPrewarm = time.Time{}
+	f := h.fun
+	k.Unlock()
+
+	if countValidWarmContainers(versionedName) > 0 {
+		return
+	}
+	spawned, err := PrewarmInstances(f, 1, false)
+	if err != nil {
+		log.Printf("Predictive prewarming of %s failed: %v\n", versionedName, err)
+	}
+
+	k.Lock()
+	h.prewarmed += int(spawned)
+	k.Unlock()
+}
+
+// countValidWarmContainers returns the number of idle containers of the function that have not expired yet
+func countValidWarmContainers(versionedName string) int {
+	LocalResources.RLock()
+	defer LocalResources.RUnlock()
+
+	fp, ok := LocalResources.containerPools[versionedName]
+	if !ok {
+		return 0
+	}
+	now := time.Now().UnixNano()
+	count := 0
+	for _, c := range fp.idle {
+		if c.ExpirationTime > now {
+			count++
+		}
+	}
+	return count
+}
+
+// forget drops the histogram of the function, cancelling any scheduled prewarming
+func (k *keepAliveController) forget(f *function.Function) {
+	k.Lock()
+	defer k.Unlock()
+
+	if h, ok := k.histograms[f.VersionedName()]; ok {
+		if h.timer != nil {
+			h.timer.Stop()
+		}
+		delete(k.histograms, f.VersionedName())
+	}
+}
+
+func (k *keepAliveController) status() map[string]KeepAliveDecision {
+	k.Lock()
+	defer k.Unlock()
+
+	decisions := make(map[string]KeepAliveDecision, len(k.histograms))
+	for name, h := range k.histograms {
+		prewarm, keepAlive, representative := k.windows(h)
+		decision := KeepAliveDecision{
+			Samples:        h.samples,
+			Representative: representative,
+			PrewarmWindow:  prewarm.Seconds(),
+			KeepAlive:      keepAlive.Seconds(),
+			Prewarmed:      h.prewarmed,
+		}
+		if !h.nextPrewarm.IsZero() {
+			decision.NextPrewarm = h.nextPrewarm.Unix()
+		}
+		decisions[name] = decision
+	}
+	return decisions
+}
+
+// KeepAliveStatus returns the current keep-alive decisions for each function (version), if the histogram policy is used
+func KeepAliveStatus() map[string]KeepAliveDecision {
+	return getKeepAliveController().status()
+}
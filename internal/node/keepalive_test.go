@@ -0,0 +1,92 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordArrivals(k *keepAliveController, f *function.Function, intervals ...time.Duration) {
+	t := time.Now()
+	if h, ok := k.histograms[f.VersionedName()]; ok {
+		t = h.lastArrival
+	} else {
+		k.recordArrival(f, t)
+	}
+	for _, interval := range intervals {
+		t = t.Add(interval)
+		k.recordArrival(f, t)
+	}
+}
+
+func repeat(interval time.Duration, n int) []time.Duration {
+	intervals := make([]time.Duration, n)
+	for i := range intervals {
+		intervals[i] = interval
+	}
+	return intervals
+}
+
+func TestFixedKeepAlive(t *testing.T) {
+	k := newKeepAliveController(FixedKeepAlive, time.Minute, 240, 10, 10*time.Minute)
+	f := &function.Function{Name: "f"}
+	recordArrivals(k, f, repeat(5*time.Minute, 20)...)
+
+	now := time.Now()
+	assert.Equal(t, now.Add(10*time.Minute), k.onIdle(f, now))
+	assert.Empty(t, k.status())
+}
+
+func TestHistogramKeepAlive(t *testing.T) {
+	k := newKeepAliveController(HistogramKeepAlive, time.Minute, 240, 10, 10*time.Minute)
+	f := &function.Function{Name: "periodic"}
+
+	// not enough samples: the fixed keep-alive is used
+	recordArrivals(k, f, repeat(30*time.Minute, 5)...)
+	prewarm, keepAlive, representative := k.windows(k.histograms["periodic"])
+	assert.False(t, representative)
+	assert.Zero(t, prewarm)
+	assert.Equal(t, 10*time.Minute, keepAlive)
+
+	// periodic invocations: the container is prewarmed shortly before the next one
+	recordArrivals(k, f, repeat(30*time.Minute, 20)...)
+	prewarm, keepAlive, representative = k.windows(k.histograms["periodic"])
+	assert.True(t, representative)
+	assert.Equal(t, 27*time.Minute, prewarm)
+	assert.Equal(t, 2046*time.Second-prewarm, keepAlive)
+
+	now := time.Now()
+	assert.Equal(t, now, k.onIdle(f, now), "the idle container should expire immediately")
+	decision := k.status()["periodic"]
+	assert.Equal(t, now.Add(prewarm).Unix(), decision.NextPrewarm)
+	assert.Equal(t, prewarm.Seconds(), decision.PrewarmWindow)
+
+	k.forget(f)
+	assert.Empty(t, k.status())
+}
+
+func TestHistogramKeepAliveFrequent(t *testing.T) {
+	k := newKeepAliveController(HistogramKeepAlive, time.Minute, 240, 10, 10*time.Minute)
+	f := &function.Function{Name: "frequent"}
+	recordArrivals(k, f, repeat(10*time.Second, 50)...)
+
+	// containers are kept warm, for a shorter time than the fixed keep-alive
+	now := time.Now()
+	assert.Equal(t, now.Add(66*time.Second), k.onIdle(f, now))
+}
+
+func TestHistogramNotRepresentative(t *testing.T) {
+	k := newKeepAliveController(HistogramKeepAlive, time.Minute, 10, 10, 10*time.Minute)
+	f := &function.Function{Name: "uniform"}
+	intervals := make([]time.Duration, 0, 50)
+	for i := 0; i < 50; i++ {
+		intervals = append(intervals, time.Duration(i%10)*time.Minute+30*time.Second)
+	}
+	recordArrivals(k, f, intervals...)
+
+	_, keepAlive, representative := k.windows(k.histograms["uniform"])
+	assert.False(t, representative)
+	assert.Equal(t, 10*time.Minute, keepAlive)
+}
@@ -40,6 +40,7 @@ type Resources struct {
 	warmPoolUsedMem int64   // amount of memory used by warm containers
 	usedCPUs        float64 // number of CPU used by functions currently running
 	containerPools  map[string]*ContainerPool
+	eviction        EvictionPolicy // chooses the idle containers to dismiss when memory is needed
 }
 
 func (n *Resources) Init() {
@@ -47,6 +48,7 @@ func (n *Resources) Init() {
 	n.totalCPUs = config.GetFloat(config.POOL_CPUS, float64(availableCores))
 	n.totalMemory = int64(config.GetInt(config.POOL_MEMORY_MB, 1024))
 	n.containerPools = make(map[string]*ContainerPool)
+	n.eviction = NewEvictionPolicy(config.GetString(config.CONTAINER_EVICTION_POLICY, LRUEviction))
 }
 
 func (n *Resources) String() string {
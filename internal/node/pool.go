@@ -3,9 +3,9 @@ package node
 import (
 	"errors"
 	"log"
+	"sort"
 	"time"
 
-	"github.com/serverledge-faas/serverledge/internal/config"
 	"github.com/serverledge-faas/serverledge/internal/container"
 	"github.com/serverledge-faas/serverledge/internal/function"
 )
@@ -14,21 +14,35 @@ type ContainerPool struct {
 	// for better efficiently we now use slices here instead of linked lists
 	busy []*container.Container
 	idle []*container.Container
+	// latest revision of the function definition seen by the node: containers created from older ones run stale code
+	revision int64
 }
 
 var NoWarmFoundErr = errors.New("no warm container is available")
 
 // GetContainerPool retrieves (or creates) the container pool for a function.
 func GetContainerPool(f *function.Function) *ContainerPool {
-	if fp, ok := LocalResources.containerPools[f.Name]; ok {
+	if fp, ok := LocalResources.containerPools[f.VersionedName()]; ok {
 		return fp
 	}
 
 	fp := newContainerPool()
-	LocalResources.containerPools[f.Name] = fp
+	LocalResources.containerPools[f.VersionedName()] = fp
 	return fp
 }
 
+// observe records the revision of a definition of the function, if it is the latest one
+func (fp *ContainerPool) observe(f *function.Function) {
+	if f.Revision > fp.revision {
+		fp.revision = f.Revision
+	}
+}
+
+// isStale reports whether the container has been created from an outdated definition of the function
+func (fp *ContainerPool) isStale(c *container.Container) bool {
+	return c.Revision < fp.revision
+}
+
 func (fp *ContainerPool) popIdleContainer() (*container.Container, bool) {
 	n := len(fp.idle)
 	if n == 0 {
@@ -46,7 +60,7 @@ func (fp *ContainerPool) popIdleContainer() (*container.Container, bool) {
 func (fp *ContainerPool) getReusableContainer(maxConcurrency int16) (*container.Container, bool) {
 	for _, elem := range fp.busy {
 		c := elem
-		if c.RequestsCount < maxConcurrency {
+		if c.RequestsCount < maxConcurrency && !fp.isStale(c) {
 			return c, true
 		}
 	}
@@ -93,19 +107,25 @@ func acquireWarmContainer(f *function.Function) (*container.Container, error) {
 	defer LocalResources.Unlock()
 
 	fp := GetContainerPool(f)
+	fp.observe(f)
 
 	if f.MaxConcurrency > 1 {
 		// 1. try to reuse a running container
 		c, found := fp.getReusableContainer(f.MaxConcurrency)
 		if found {
 			c.RequestsCount += 1
+			LocalResources.eviction.OnUse(f.VersionedName(), c)
 			log.Printf("Re-Using busy %s for %s. ", c.ID, f)
 			return c, nil
 		}
 	}
 
-	// 2. try to pick a idle container
+	// 2. try to pick a idle container, destroying those running an outdated definition
 	c, found := fp.popIdleContainer()
+	for found && fp.isStale(c) {
+		destroyIdleContainer(f.VersionedName(), c)
+		c, found = fp.popIdleContainer()
+	}
 	if !found {
 		return nil, NoWarmFoundErr
 	}
@@ -121,14 +141,17 @@ func acquireWarmContainer(f *function.Function) (*container.Container, error) {
 	// add container to the busy pool
 	c.RequestsCount = 1
 	fp.busy = append(fp.busy, c)
+	LocalResources.eviction.OnUse(f.VersionedName(), c)
 
 	log.Printf("Using warm %s for %s. Now: %v", c.ID, f, &LocalResources)
 	return c, nil
 }
 
 func AcquireContainer(f *function.Function, onlyIfWarm bool) (*container.Container, bool, error) {
+	arrival := time.Now()
 	c, err := acquireWarmContainer(f)
 	if err == nil {
+		getKeepAliveController().recordArrival(f, arrival)
 		return c, true, nil
 	} else if !errors.Is(err, NoWarmFoundErr) {
 		return nil, true, err
@@ -143,6 +166,9 @@ func AcquireContainer(f *function.Function, onlyIfWarm bool) (*container.Contain
 		return nil, false, OutOfResourcesErr
 	}
 	c, err = NewContainerWithAcquiredResources(f, false, false)
+	if err == nil {
+		getKeepAliveController().recordArrival(f, arrival)
+	}
 	return c, false, err
 }
 
@@ -150,33 +176,26 @@ func HandleCompletion(cont *container.Container, f *function.Function) {
 	LocalResources.Lock()
 	defer LocalResources.Unlock()
 
+	if cont.RequestsCount <= 0 {
+		return // the container has been discarded
+	}
 	cont.RequestsCount--
 	if cont.RequestsCount == 0 {
 		// the container is now idle and must be moved to the warm pool
 		fp := GetContainerPool(f)
-		// Search the container index in the slice
-		idx := -1
-		for i, c := range fp.busy {
-			if c == cont { // with slices, we can compare pointers
-				idx = i
-				break
-			}
+		if fp.isStale(cont) {
+			// the function has been updated in the meantime: the container would keep running the old code
+			discardContainer(fp, cont, f)
+			return
 		}
 
-		if idx == -1 {
+		if !fp.removeBusyContainer(cont) {
 			log.Println("Failed to release a container! Not found in busy pool.")
 			return
 		}
 
-		// swap then pop from the slice. This way we don't have to
-		lastIdx := len(fp.busy) - 1
-		fp.busy[idx] = fp.busy[lastIdx] // swap between last element and the one we want to delete
-		fp.busy[lastIdx] = nil          // nil to favor garbage collection
-		fp.busy = fp.busy[:lastIdx]     // pop the slice
-
 		// finally, we add the container to the idle pool
-		d := time.Duration(config.GetInt(config.CONTAINER_EXPIRATION_TIME, 600)) * time.Second
-		cont.ExpirationTime = time.Now().Add(d).UnixNano()
+		cont.ExpirationTime = getKeepAliveController().onIdle(f, time.Now()).UnixNano()
 		fp.idle = append(fp.idle, cont)
 
 		LocalResources.usedCPUs -= f.CPUDemand
@@ -185,6 +204,54 @@ func HandleCompletion(cont *container.Container, f *function.Function) {
 	}
 }
 
+// DiscardContainer removes a busy container that cannot be reused (e.g., its
+// executor stopped responding) and releases its resources. Any other request
+// running on the same container is lost as well. Calling it more than once for
+// the same container has no effect. Actual termination happens asynchronously.
+func DiscardContainer(cont *container.Container, f *function.Function) {
+	LocalResources.Lock()
+	defer LocalResources.Unlock()
+
+	discardContainer(GetContainerPool(f), cont, f)
+}
+
+// discardContainer removes a busy container from the pool and destroys it, releasing its resources. The caller must
+// hold the lock on LocalResources.
+func discardContainer(fp *ContainerPool, cont *container.Container, f *function.Function) {
+	if !fp.removeBusyContainer(cont) {
+		return // already discarded
+	}
+	cont.RequestsCount = 0
+	LocalResources.eviction.OnRemoval(f.VersionedName(), cont)
+
+	LocalResources.usedCPUs -= f.CPUDemand
+	LocalResources.busyPoolUsedMem -= f.MemoryMB
+
+	go func(contID container.ContainerID) {
+		if err := container.Destroy(contID); err != nil {
+			log.Printf("An error occurred while deleting %s: %v\n", contID, err)
+		} else {
+			log.Printf("Discarded %s\n", contID)
+		}
+	}(cont.ID)
+}
+
+// destroyIdleContainer destroys a container already removed from the idle pool, releasing its memory. The caller
+// must hold the lock on LocalResources. Actual termination happens asynchronously.
+func destroyIdleContainer(fun string, cont *container.Container) {
+	memory, _ := container.GetMemoryMB(cont.ID)
+	LocalResources.warmPoolUsedMem -= memory
+	LocalResources.eviction.OnRemoval(fun, cont)
+
+	go func(contID container.ContainerID) {
+		if err := container.Destroy(contID); err != nil {
+			log.Printf("An error occurred while deleting %s: %v\n", contID, err)
+		} else {
+			log.Printf("Deleted outdated %s\n", contID)
+		}
+	}(cont.ID)
+}
+
 func AcquireResourcesForNewContainer(fun *function.Function, forWarmPool bool) bool {
 	LocalResources.Lock()
 	defer LocalResources.Unlock()
@@ -207,6 +274,7 @@ func AcquireResourcesForNewContainer(fun *function.Function, forWarmPool bool) b
 // function, assuming that the required CPU and memory resources have been
 // already been acquired.
 func NewContainerWithAcquiredResources(fun *function.Function, startAsIdle bool, forceImagePull bool) (*container.Container, error) {
+	creationStart := time.Now()
 	cont, err := container.CreateContainer(fun, forceImagePull)
 
 	if err != nil {
@@ -222,11 +290,16 @@ func NewContainerWithAcquiredResources(fun *function.Function, startAsIdle bool,
 	}
 
 	fp := GetContainerPool(fun)
+	fp.observe(fun)
+	cont.Revision = fun.Revision
+	LocalResources.eviction.OnCreation(fun.VersionedName(), cont, fun.MemoryMB, time.Since(creationStart))
 	if startAsIdle {
+		cont.ExpirationTime = getKeepAliveController().expirationForPrewarmed(fun, time.Now()).UnixNano()
 		fp.idle = append(fp.idle, cont)
 	} else {
 		cont.RequestsCount = 1
 		fp.busy = append(fp.busy, cont) // We immediately mark it as busy
+		LocalResources.eviction.OnUse(fun.VersionedName(), cont)
 	}
 
 	return cont, nil
@@ -234,6 +307,7 @@ func NewContainerWithAcquiredResources(fun *function.Function, startAsIdle bool,
 
 func NewContainerWithAcquiredResourcesAsync(fun *function.Function, okCallback func(cont *container.Container), errCallback func(e error)) {
 	go func() {
+		creationStart := time.Now()
 		cont, err := container.CreateContainer(fun, false)
 		if err != nil {
 			log.Printf("Failed container creation: %v\n", err)
@@ -250,16 +324,37 @@ func NewContainerWithAcquiredResourcesAsync(fun *function.Function, okCallback f
 		}
 
 		fp := GetContainerPool(fun)
+		fp.observe(fun)
+		cont.Revision = fun.Revision
+		LocalResources.eviction.OnCreation(fun.VersionedName(), cont, fun.MemoryMB, time.Since(creationStart))
 		cont.RequestsCount = 1
 		fp.busy = append(fp.busy, cont) // We immediately mark it as busy
+		LocalResources.eviction.OnUse(fun.VersionedName(), cont)
 		okCallback(cont)
 	}()
 }
 
 type itemToDismiss struct {
-	cont   *container.Container
-	pool   *ContainerPool
-	memory int64
+	cont     *container.Container
+	pool     *ContainerPool
+	fun      string
+	memory   int64
+	priority float64
+}
+
+// removeBusyContainer removes a specific container from the busy pool.
+func (fp *ContainerPool) removeBusyContainer(target *container.Container) bool {
+	for i, c := range fp.busy {
+		if c == target { // with slices, we can compare pointers
+			// swap then pop from the slice
+			lastIdx := len(fp.busy) - 1
+			fp.busy[i] = fp.busy[lastIdx]
+			fp.busy[lastIdx] = nil // nil to favor garbage collection
+			fp.busy = fp.busy[:lastIdx]
+			return true
+		}
+	}
+	return false
 }
 
 // removeContainerFromIdle removes a specific container from the idle pool.
@@ -280,37 +375,41 @@ func (fp *ContainerPool) removeContainerFromIdle(target *container.Container) bo
 }
 
 // dismissContainer attempts to free memory by dismissing idle containers.
-// It works in 2 phases: research (collect candidates) and cleanup (destroy them).
+// It works in 2 phases: research (collect candidates, in the order given by the eviction policy) and cleanup (destroy them).
 // Containers are actually cleaned only if they free enough memory for the new function/container
 func dismissContainer(requiredMemoryMB int64) (bool, error) {
 	log.Printf("Trying to dismiss containers to free up at least %d MB", requiredMemoryMB)
 	var cleanedMB int64 = 0
-	var containerToDismiss []itemToDismiss
+	var candidates []itemToDismiss
 
 	// Phase 1: Research
-	// We iterate through all pools to find idle containers to (potentially) remove.
-	for _, funPool := range LocalResources.containerPools {
-
-		if len(funPool.idle) > 0 {
-			for _, cont := range funPool.idle {
-				memory, _ := container.GetMemoryMB(cont.ID)
-
-				// We collect the pointer to the container and the pool reference
-				containerToDismiss = append(containerToDismiss,
-					itemToDismiss{cont: cont, pool: funPool, memory: memory})
+	// We collect all the idle containers, and sort them by priority (the lowest first)
+	for fun, funPool := range LocalResources.containerPools {
+		for _, cont := range funPool.idle {
+			memory, _ := container.GetMemoryMB(cont.ID)
+			candidates = append(candidates, itemToDismiss{cont: cont, pool: funPool, fun: fun, memory: memory,
+				priority: LocalResources.eviction.Priority(fun, cont)})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].priority < candidates[j].priority
+	})
 
-				cleanedMB += memory
-				if cleanedMB >= requiredMemoryMB {
-					goto cleanup
-				}
-			}
+	var containerToDismiss []itemToDismiss
+	for _, item := range candidates {
+		if cleanedMB >= requiredMemoryMB {
+			break
 		}
+		containerToDismiss = append(containerToDismiss, item)
+		cleanedMB += item.memory
 	}
 
-cleanup: // Phase 2: Cleanup
+	// Phase 2: Cleanup
 	if cleanedMB >= requiredMemoryMB { // if we'd actually free enough memory we do it, otherwise there's no point
 		for _, item := range containerToDismiss {
 			if item.pool.removeContainerFromIdle(item.cont) {
+				LocalResources.eviction.OnEviction(item.fun, item.cont)
+				LocalResources.eviction.OnRemoval(item.fun, item.cont)
 				// Destroy the actual container resources (Docker/Containerd)
 				err := container.Destroy(item.cont.ID)
 				if err != nil {
@@ -335,7 +434,7 @@ func DeleteExpiredContainer() {
 	LocalResources.Lock()
 	defer LocalResources.Unlock()
 
-	for _, pool := range LocalResources.containerPools {
+	for fun, pool := range LocalResources.containerPools {
 		// Index to track the position of kept elements
 		// Basically, since we now have slices, we want to avoid modifying the slice length while we're iterating
 		// over it. So, we keep track of the element (containers) we want to keep, then we move them to the front of
@@ -348,6 +447,7 @@ func DeleteExpiredContainer() {
 		for _, warm := range pool.idle {
 			if now > warm.ExpirationTime {
 				// remove the expired container
+				LocalResources.eviction.OnRemoval(fun, warm)
 
 				// Update resources
 				memory, _ := container.GetMemoryMB(warm.ID)
@@ -383,10 +483,15 @@ func ShutdownWarmContainersFor(f *function.Function) {
 	LocalResources.Lock()
 	defer LocalResources.Unlock()
 
-	fp, ok := LocalResources.containerPools[f.Name]
+	// the definition used for predictive prewarming may be stale
+	getKeepAliveController().forget(f)
+
+	fp, ok := LocalResources.containerPools[f.VersionedName()]
 	if !ok {
 		return
 	}
+	// busy containers created from older definitions are discarded once they complete
+	fp.observe(f)
 
 	containersToDelete := make([]container.ContainerID, 0, len(fp.idle)) // we already know how long it'll need to be, so no need for reallocation
 
@@ -396,6 +501,7 @@ func ShutdownWarmContainersFor(f *function.Function) {
 
 		memory, _ := container.GetMemoryMB(warmed.ID)
 		LocalResources.warmPoolUsedMem -= memory
+		LocalResources.eviction.OnRemoval(f.VersionedName(), warmed)
 		containersToDelete = append(containersToDelete, warmed.ID)
 		fp.idle[i] = nil
 	}
@@ -421,7 +527,7 @@ func ShutdownAllContainers() {
 	defer LocalResources.Unlock()
 
 	for fun, pool := range LocalResources.containerPools {
-		functionDescriptor, _ := function.GetFunction(fun)
+		functionDescriptor, _ := function.Resolve(fun)
 		if functionDescriptor == nil {
 			log.Printf("Could not find function, cannot shutdown containers: %s\n", fun)
 			continue // should not happen
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/stretchr/testify/assert"
+)
+
+// destroyedIDs returns the containers destroyed so far, which may happen asynchronously
+func (f *fakeFactory) destroyedIDs() []container.ContainerID {
+	f.Lock()
+	defer f.Unlock()
+	return slices.Clone(f.destroyed)
+}
+
+func TestUpdatedFunctionDiscardsOutdatedContainers(t *testing.T) {
+	factory := setupNode(t, LRUEviction, 300)
+	old := newTestFunction("f", 100)
+	old.Revision = 1
+
+	idle := invoke(t, old)
+	busy, _, err := AcquireContainer(old, false)
+	assert.NoError(t, err)
+	assert.Equal(t, idle, busy.ID, "the warm container should be reused")
+	other, _, err := AcquireContainer(old, false)
+	assert.NoError(t, err)
+	HandleCompletion(other, old)
+
+	// the function is updated while a container is busy
+	updated := newTestFunction("f", 100)
+	updated.Revision = 2
+	ShutdownWarmContainersFor(updated)
+	HandleCompletion(busy, old)
+	assert.Equal(t, 0, WarmStatus()["f"], "the busy container should not return to the warm pool")
+
+	cont, warm, err := AcquireContainer(updated, false)
+	assert.NoError(t, err)
+	assert.False(t, warm)
+	HandleCompletion(cont, updated)
+
+	// outdated containers are destroyed asynchronously
+	assert.Eventually(t, func() bool { return len(factory.destroyedIDs()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []container.ContainerID{other.ID, busy.ID}, factory.destroyedIDs())
+	assert.Equal(t, 1, WarmStatus()["f"])
+	assert.Equal(t, int64(100), LocalResources.warmPoolUsedMem)
+	assert.Equal(t, int64(0), LocalResources.busyPoolUsedMem)
+}
+
+func TestOutdatedIdleContainerNotReused(t *testing.T) {
+	factory := setupNode(t, LRUEviction, 300)
+	old := newTestFunction("f", 100)
+	old.Revision = 1
+	c1 := invoke(t, old)
+
+	// e.g., the update is received through the cache, without shutting down the warm containers
+	updated := newTestFunction("f", 100)
+	updated.Revision = 2
+	c2 := invoke(t, updated)
+	assert.NotEqual(t, c1, c2)
+	assert.Eventually(t, func() bool { return len(factory.destroyedIDs()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []container.ContainerID{c1}, factory.destroyedIDs())
+
+	// a request resolved with the outdated definition can still use the new container
+	assert.Equal(t, c2, invoke(t, old))
+	assert.Equal(t, 1, WarmStatus()["f"])
+}
@@ -435,6 +435,16 @@ func GetRemoteOffloadingTarget() *NodeRegistration {
 	return nil
 }
 
+// SetRemoteOffloadingTarget replaces the remote offloading target, or clears it if nil (e.g., for testing)
+func SetRemoteOffloadingTarget(target *NodeRegistration, latencyMs float64) {
+	if target == nil {
+		remoteOffloadingTarget = NodeRegistration{}
+	} else {
+		remoteOffloadingTarget = *target
+	}
+	remoteOffloadingTargetLatencyMs = latencyMs
+}
+
 func GetRemoteOffloadingTargetLatencyMs() float64 {
 	return remoteOffloadingTargetLatencyMs
 }
@@ -27,4 +27,6 @@ type StatusInformation struct {
 	Coordinates             vivaldi.Coordinate
 	LoadAvg                 []float64
 	LastUpdateTime          int64 // timestamp of last update of this information
+	// keep-alive and prewarming decisions per function (only with the histogram keep-alive policy)
+	KeepAlive map[string]node.KeepAliveDecision `json:",omitempty"`
 }
@@ -0,0 +1,128 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/serverledge-faas/serverledge/internal/auth"
+)
+
+// CancelledErr is returned for the invocations cancelled by the client
+var CancelledErr = errors.New("the invocation has been cancelled")
+
+// UnknownRequestErr is returned when cancelling a request that is not in progress on this node
+var UnknownRequestErr = errors.New("unknown or already completed request")
+
+// localQueue is the queue of the scheduling policy, if any
+var localQueue queue
+
+type inFlightRequest struct {
+	r          *scheduledRequest
+	cancel     context.CancelCauseFunc
+	remoteHost string // set if the request has been offloaded
+}
+
+var inFlight = struct {
+	sync.Mutex
+	requests map[string]*inFlightRequest
+}{requests: make(map[string]*inFlightRequest)}
+
+// track makes the request cancellable, until the returned function is called
+func track(r *scheduledRequest) (untrack func()) {
+	ctx, cancel := context.WithCancelCause(r.Ctx)
+	r.Ctx = ctx
+	entry := &inFlightRequest{r: r, cancel: cancel}
+	reqId := r.Id()
+
+	inFlight.Lock()
+	inFlight.requests[reqId] = entry
+	inFlight.Unlock()
+
+	return func() {
+		inFlight.Lock()
+		if inFlight.requests[reqId] == entry {
+			delete(inFlight.requests, reqId)
+		}
+		inFlight.Unlock()
+		cancel(nil)
+	}
+}
+
+// setRemoteHost records the node the request has been offloaded to, where the cancellation must be propagated
+func setRemoteHost(r *scheduledRequest, serverUrl string) {
+	inFlight.Lock()
+	defer inFlight.Unlock()
+	if entry, ok := inFlight.requests[r.Id()]; ok && entry.r == r {
+		entry.remoteHost = serverUrl
+	}
+}
+
+// isCancelled returns true if the request has been cancelled by the client
+func isCancelled(r *scheduledRequest) bool {
+	return r.Ctx != nil && errors.Is(context.Cause(r.Ctx), CancelledErr)
+}
+
+// Cancel cancels a request of the given namespace: the request is removed from the queue if it is waiting for
+// resources, its handler is killed if it is running, and the cancellation is propagated to the remote node if it has
+// been offloaded.
+func Cancel(reqId string, namespace string) error {
+	inFlight.Lock()
+	entry, ok := inFlight.requests[reqId]
+	if !ok || entry.r.Fun.GetNamespace() != namespace {
+		inFlight.Unlock()
+		return UnknownRequestErr
+	}
+	r, remoteHost := entry.r, entry.remoteHost
+	inFlight.Unlock()
+
+	log.Printf("[%s] Cancelling the request\n", r)
+	// the executor kills the handler as soon as the invocation is aborted
+	entry.cancel(CancelledErr)
+
+	if localQueue != nil {
+		localQueue.Lock()
+		removed := localQueue.remove(r)
+		localQueue.Unlock()
+		if removed {
+			log.Printf("[%s] Removed from the queue\n", r)
+			dropRequest(r)
+		}
+	}
+
+	if remoteHost != "" {
+		return cancelRemote(r, remoteHost)
+	}
+	return nil
+}
+
+// cancelRemote propagates the cancellation of an offloaded request to the remote node
+func cancelRemote(r *scheduledRequest, serverUrl string) error {
+	req, err := http.NewRequest(http.MethodPost, serverUrl+"/cancel/"+r.Id(), nil)
+	if err != nil {
+		return err
+	}
+	auth.SetHeaders(req, r.Fun.GetNamespace(), r.APIKey)
+	resp, err := offloadingClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not propagate the cancellation to %s: %v", serverUrl, err)
+	}
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		if r.Async {
+			// the remote node has already published the result
+			return UnknownRequestErr
+		}
+		// the invocation has been aborted anyway
+		return nil
+	default:
+		return fmt.Errorf("could not propagate the cancellation to %s: remote returned %v", serverUrl, resp.StatusCode)
+	}
+}
@@ -0,0 +1,33 @@
+package scheduling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelQueuedRequest(t *testing.T) {
+	q := newFIFOQueue(2)
+	localQueue = q
+	defer func() { localQueue = nil }()
+
+	r := newQueuedRequest("f", 0, time.Now(), 0)
+	r.Ctx = context.WithValue(context.Background(), "ReqId", "f-1")
+	r.decisionChannel = make(chan schedDecision, 1)
+	untrack := track(r)
+	defer untrack()
+	q.enqueue(r)
+
+	assert.ErrorIs(t, Cancel("f-1", "other"), UnknownRequestErr, "requests of other namespaces cannot be cancelled")
+	assert.False(t, isCancelled(r))
+
+	assert.NoError(t, Cancel("f-1", r.Fun.GetNamespace()))
+	assert.True(t, isCancelled(r))
+	assert.True(t, q.isEmpty())
+	assert.Equal(t, DROP, (<-r.decisionChannel).action)
+
+	untrack()
+	assert.ErrorIs(t, Cancel("f-1", r.Fun.GetNamespace()), UnknownRequestErr, "the request has completed")
+}
@@ -1,6 +1,8 @@
 package scheduling
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -20,42 +22,69 @@ func Execute(cont *container.Container, r *scheduledRequest, isWarm bool) error
 	var req executor.InvocationRequest
 	if r.Fun.Runtime == container.CUSTOM_RUNTIME {
 		req = executor.InvocationRequest{
-			Params:       r.Params,
-			ReturnOutput: r.ReturnOutput,
+			Params:         r.Params,
+			ReturnOutput:   r.ReturnOutput,
+			TimeoutSeconds: r.Fun.TimeoutSeconds,
+			HTTP:           r.HTTP,
+			Stream:         r.Stream != nil,
 		}
 	} else {
 		cmd := container.RuntimeToInfo[r.Fun.Runtime].InvocationCmd
 		req = executor.InvocationRequest{
-			Command:      cmd,
-			Params:       r.Params,
-			Handler:      r.Fun.Handler,
-			HandlerDir:   HANDLER_DIR,
-			ReturnOutput: r.ReturnOutput,
+			Command:        cmd,
+			Params:         r.Params,
+			Handler:        r.Fun.Handler,
+			HandlerDir:     HANDLER_DIR,
+			ReturnOutput:   r.ReturnOutput,
+			TimeoutSeconds: r.Fun.TimeoutSeconds,
+			HTTP:           r.HTTP,
+			Stream:         r.Stream != nil,
 		}
 	}
 
 	t0 := time.Now()
 	initTime := t0.Sub(r.Arrival).Seconds()
 
-	response, invocationWait, err := container.Execute(cont.ID, &req)
+	ctx := r.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	response, invocationWait, err := container.Execute(ctx, cont.ID, &req, r.Stream)
 
 	if err != nil {
-		logs, errLog := container.GetLog(cont.ID)
-		if errLog == nil {
-			fmt.Println(logs)
+		cancelled := isCancelled(r)
+		if !cancelled {
+			logs, errLog := container.GetLog(cont.ID)
+			if errLog == nil {
+				fmt.Println(logs)
+			} else {
+				fmt.Printf("Failed to get log: %v\n", errLog)
+			}
+		}
+
+		aborted := cancelled || errors.Is(err, container.ExecutionTimeoutErr) && !errors.Is(err, container.UnresponsiveExecutorErr)
+		if aborted && container.RuntimeToInfo[r.Fun.Runtime].KillsHandler {
+			// the executor killed the handler: the container can be reused
+			node.HandleCompletion(cont, r.Fun)
 		} else {
-			fmt.Printf("Failed to get log: %v\n", errLog)
+			// the handler might still be running (e.g., a Java thread cannot be killed)
+			node.DiscardContainer(cont, r.Fun)
 		}
 
 		// notify scheduler
 		completions <- &completionNotification{r: r, cont: cont, failed: true}
-		return fmt.Errorf("[%s] Execution failed on container %v: %v ", r, cont.ID, err)
+		if cancelled {
+			return fmt.Errorf("[%s] Execution on container %v: %w", r, cont.ID, CancelledErr)
+		}
+		return fmt.Errorf("[%s] Execution failed on container %v: %w", r, cont.ID, err)
 	}
 
 	if !response.Success {
+		node.HandleCompletion(cont, r.Fun)
+
 		// notify scheduler
 		completions <- &completionNotification{r: r, cont: cont, failed: true}
-		return fmt.Errorf("[%s] Function execution failed %v", r, cont.ID)
+		return fmt.Errorf("[%s] Function execution failed %v: %w", r, cont.ID, &executor.HandlerError{Name: response.Error})
 	}
 
 	r.Result = response.Result
@@ -8,10 +8,14 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/client"
 	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/serverledge-faas/serverledge/internal/executor"
 	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/node"
 	"github.com/serverledge-faas/serverledge/internal/registration"
@@ -26,8 +30,8 @@ var NoNeighbors = errors.New("the list of neighbors is empty")
 
 func pickEdgeNodeForOffloading(r *scheduledRequest) (url string, err error) {
 	// check cache first
-	cached, ok := offloadingCache[r.Fun.Name]
-	if ok && time.Now().Before(cacheExpiration[r.Fun.Name]) {
+	cached, ok := offloadingCache[r.Fun.QualifiedName()]
+	if ok && time.Now().Before(cacheExpiration[r.Fun.QualifiedName()]) {
 		return cached.APIUrl(), nil
 	}
 
@@ -57,46 +61,64 @@ func pickEdgeNodeForOffloading(r *scheduledRequest) (url string, err error) {
 	if bestNode != nil {
 		cacheValidityInt := config.GetInt(config.OFFLOADING_CACHE_VALIDITY, 60)
 		CacheValidity = time.Duration(cacheValidityInt) * time.Second
-		offloadingCache[r.Fun.Name] = bestNode
-		cacheExpiration[r.Fun.Name] = time.Now().Add(CacheValidity)
+		offloadingCache[r.Fun.QualifiedName()] = bestNode
+		cacheExpiration[r.Fun.QualifiedName()] = time.Now().Add(CacheValidity)
 		return bestNode.APIUrl(), nil
 	}
 
 	return "", NoSuitableNode
 }
 
+// postInvocation sends an invocation request to a remote node, forwarding the identity of the client. The request is
+// aborted if it gets cancelled. Streamed requests are streamed by the remote node as well.
+func postInvocation(serverUrl string, r *function.Request, invocationBody []byte) (*http.Response, error) {
+	url := serverUrl + "/invoke/" + function.WithVersion(r.Fun.Name, r.Fun.Version)
+	if r.Stream != nil {
+		url += "?stream=true"
+	}
+	req, err := http.NewRequestWithContext(r.Ctx, http.MethodPost, url, bytes.NewBuffer(invocationBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(function.RequestIdHeader, r.Id())
+	auth.SetHeaders(req, r.Fun.GetNamespace(), r.APIKey)
+	return offloadingClient.Do(req)
+}
+
 func Offload(r *scheduledRequest, serverUrl string) error {
 	// Prepare request
-	request := client.InvocationRequest{Params: r.Params, QoSClass: r.Class, QoSMaxRespT: r.MaxRespT, ReturnOutput: r.ReturnOutput}
+	request := client.InvocationRequest{Params: r.Params, QoSClass: r.Class, QoSMaxRespT: r.MaxRespT, ReturnOutput: r.ReturnOutput, HTTP: r.HTTP}
 	invocationBody, err := json.Marshal(request)
 	if err != nil {
 		log.Print(err)
 		return err
 	}
 	sendingTime := time.Now() // used to compute latency later on
-	resp, err := offloadingClient.Post(serverUrl+"/invoke/"+r.Fun.Name, "application/json",
-		bytes.NewBuffer(invocationBody))
+	resp, err := postInvocation(serverUrl, r.Request, invocationBody)
 
 	if err != nil {
 		log.Print(err)
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusTooManyRequests {
-			return node.OutOfResourcesErr
-		}
-		return fmt.Errorf("Remote returned: %v", resp.StatusCode)
-	}
-
-	var response function.Response
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
 			fmt.Printf("Error while closing offload response body: %s\n", err)
 		}
 	}(resp.Body)
-	body, _ := io.ReadAll(resp.Body)
-	if err = json.Unmarshal(body, &response); err != nil {
+	if resp.StatusCode != http.StatusOK {
+		return remoteError(resp.StatusCode)
+	}
+
+	var response function.Response
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), executor.StreamContentType) {
+		err = readRemoteStream(resp.Body, r.Stream, &response)
+	} else {
+		body, _ := io.ReadAll(resp.Body)
+		err = json.Unmarshal(body, &response)
+	}
+	if err != nil {
 		return err
 	}
 	now := time.Now()
@@ -110,19 +132,60 @@ func Offload(r *scheduledRequest, serverUrl string) error {
 	return nil
 }
 
+// remoteError returns the error of an invocation, given the status code of the response of the remote node
+func remoteError(statusCode int) error {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return node.OutOfResourcesErr
+	case http.StatusGatewayTimeout:
+		return container.ExecutionTimeoutErr
+	case http.StatusGone:
+		return CancelledErr
+	}
+	return fmt.Errorf("Remote returned: %v", statusCode)
+}
+
+// readRemoteStream reads the events streamed by the remote node, passing the output and the partial results of the
+// handler to onEvent, until the result (or the error) that ends the stream
+func readRemoteStream(body io.Reader, onEvent func(executor.Event), response *function.Response) error {
+	completed := false
+	err := executor.ReadEvents(body, func(event executor.Event) error {
+		switch event.Name {
+		case executor.ResultEvent:
+			completed = true
+			return json.Unmarshal([]byte(event.Data), response)
+		case executor.ErrorEvent:
+			var streamErr executor.StreamError
+			if err := json.Unmarshal([]byte(event.Data), &streamErr); err != nil {
+				return err
+			}
+			return remoteError(streamErr.StatusCode)
+		default:
+			if onEvent != nil {
+				onEvent(event)
+			}
+		}
+		return nil
+	})
+	if err == nil && !completed {
+		return fmt.Errorf("remote stream ended with no result")
+	}
+	return err
+}
+
 func OffloadAsync(r *function.Request, serverUrl string) error {
 	// Prepare request
 	request := client.InvocationRequest{Params: r.Params,
-		QoSClass:    r.Class,
-		QoSMaxRespT: r.MaxRespT,
-		Async:       true}
+		QoSClass:     r.Class,
+		QoSMaxRespT:  r.MaxRespT,
+		Async:        true,
+		AsyncOptions: r.AsyncOptions}
 	invocationBody, err := json.Marshal(request)
 	if err != nil {
 		log.Print(err)
 		return err
 	}
-	resp, err := offloadingClient.Post(serverUrl+"/invoke/"+r.Fun.Name, "application/json",
-		bytes.NewBuffer(invocationBody))
+	resp, err := postInvocation(serverUrl, r, invocationBody)
 
 	if err != nil {
 		log.Print(err)
@@ -0,0 +1,46 @@
+package scheduling
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/serverledge-faas/serverledge/internal/executor"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRemoteStream(t *testing.T) {
+	var body bytes.Buffer
+	_ = executor.WriteEvent(&body, executor.Event{Name: executor.OutputEvent, Data: "started"})
+	_ = executor.WriteEvent(&body, executor.Event{Name: executor.PartialEvent, Data: `{"epoch": 1}`})
+	_ = executor.WriteEvent(&body, executor.Event{Name: executor.ResultEvent, Data: `{"Success": true, "Result": "42"}`})
+
+	var events []executor.Event
+	var response function.Response
+	err := readRemoteStream(&body, func(event executor.Event) { events = append(events, event) }, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, []executor.Event{
+		{Name: executor.OutputEvent, Data: "started"},
+		{Name: executor.PartialEvent, Data: `{"epoch": 1}`},
+	}, events)
+	assert.True(t, response.Success)
+	assert.Equal(t, "42", response.Result)
+
+	body.Reset()
+	_ = executor.WriteEvent(&body, executor.Event{Name: executor.OutputEvent, Data: "started"})
+	_ = executor.WriteEvent(&body, executor.Event{Name: executor.ErrorEvent, Data: `{"StatusCode": 504, "Message": "timeout"}`})
+	err = readRemoteStream(&body, nil, &response)
+	assert.ErrorIs(t, err, container.ExecutionTimeoutErr)
+
+	body.Reset()
+	_ = executor.WriteEvent(&body, executor.Event{Name: executor.OutputEvent, Data: "started"})
+	assert.Error(t, readRemoteStream(&body, nil, &response), "the stream must end with a result")
+}
+
+func TestRemoteError(t *testing.T) {
+	assert.ErrorIs(t, remoteError(http.StatusGatewayTimeout), container.ExecutionTimeoutErr)
+	assert.ErrorIs(t, remoteError(http.StatusGone), CancelledErr)
+	assert.Error(t, remoteError(http.StatusInternalServerError))
+}
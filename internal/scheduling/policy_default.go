@@ -3,6 +3,7 @@ package scheduling
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/serverledge-faas/serverledge/internal/config"
 	"github.com/serverledge-faas/serverledge/internal/container"
@@ -12,36 +13,67 @@ import (
 
 // DefaultLocalPolicy can be used on single node deployments. Directly executes the function locally, or drops the request if there aren't enough resources.
 type DefaultLocalPolicy struct {
-	queue queue
+	queue       queue
+	dropExpired bool
 }
 
 func (p *DefaultLocalPolicy) Init() {
+	p.queue, p.dropExpired = newConfiguredQueue(FIFO_QUEUE, false)
+}
+
+// newConfiguredQueue creates the queue of the scheduler, according to the configuration. It returns nil if the queue
+// is disabled, and whether requests whose deadline has passed must be dropped.
+func newConfiguredQueue(defaultDiscipline string, defaultDropExpired bool) (queue, bool) {
 	queueCapacity := config.GetInt(config.SCHEDULER_QUEUE_CAPACITY, 0)
-	if queueCapacity > 0 {
-		log.Printf("Configured queue with capacity %d\n", queueCapacity)
-		p.queue = newFIFOQueue(queueCapacity)
-	} else {
-		p.queue = nil
+	if queueCapacity <= 0 {
+		return nil, false
+	}
+	discipline := config.GetString(config.SCHEDULER_QUEUE_DISCIPLINE, defaultDiscipline)
+	q, err := newQueue(discipline, queueCapacity)
+	if err != nil {
+		log.Fatalf("Invalid scheduler queue: %v", err)
 	}
+	log.Printf("Configured %s queue with capacity %d\n", discipline, queueCapacity)
+	localQueue = q
+	return q, config.GetBool(config.SCHEDULER_QUEUE_DROP_EXPIRED, defaultDropExpired)
 }
 
 func (p *DefaultLocalPolicy) OnCompletion(_ *function.Function, _ *function.ExecutionReport) {
-	if p.queue == nil {
+	serveQueue(p.queue, p.dropExpired)
+}
+
+// serveQueue executes the queued requests, as long as there are enough resources
+func serveQueue(q queue, dropExpired bool) {
+	if q == nil {
 		return
 	}
 
-	p.queue.Lock()
-	defer p.queue.Unlock()
+	q.Lock()
+	defer q.Unlock()
 
 	tryDequeueing := true
 
-	for !p.queue.isEmpty() && tryDequeueing {
-		req := p.queue.front()
+	for !q.isEmpty() && tryDequeueing {
+		req := q.front()
+
+		if isCancelled(req) {
+			q.dequeue()
+			log.Printf("[%s] Dropped from the queue: cancelled\n", req)
+			dropRequest(req)
+			continue
+		}
+
+		if dropExpired && isExpired(req, time.Now()) {
+			q.dequeue()
+			log.Printf("[%s] Dropped from the queue: deadline expired\n", req)
+			dropRequest(req)
+			continue
+		}
 
 		containerID, _, err := node.AcquireContainer(req.Fun, true)
 		if err == nil {
-			p.queue.dequeue()
-			log.Printf("[%s] Exec warm from the queue (length=%d)\n", req, p.queue.len())
+			q.dequeue()
+			log.Printf("[%s] Exec warm from the queue (length=%d)\n", req, q.len())
 			execLocally(req, containerID, true)
 			continue
 		}
@@ -49,7 +81,7 @@ func (p *DefaultLocalPolicy) OnCompletion(_ *function.Function, _ *function.Exec
 		if errors.Is(err, node.NoWarmFoundErr) {
 			if node.AcquireResourcesForNewContainer(req.Fun, false) {
 				log.Printf("[%s] Cold start from the queue\n", req)
-				p.queue.dequeue()
+				q.dequeue()
 
 				// This avoids blocking the thread during the cold
 				// start, but also allows us to check for resource
@@ -59,13 +91,15 @@ func (p *DefaultLocalPolicy) OnCompletion(_ *function.Function, _ *function.Exec
 				}, func(e error) {
 					dropRequest(req)
 				})
+			} else {
+				tryDequeueing = false
 			}
 		} else if errors.Is(err, node.OutOfResourcesErr) {
 			tryDequeueing = false
 		} else {
 			// other error
 			log.Printf("%v", err)
-			p.queue.dequeue()
+			q.dequeue()
 			dropRequest(req)
 		}
 	}
@@ -0,0 +1,237 @@
+package scheduling
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/metrics"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/internal/registration"
+)
+
+// statsSmoothing is the weight of the last observation in the moving averages of the execution times
+const statsSmoothing = 0.3
+
+// QoSAwarePolicy estimates the response time of each request for every possible choice (local execution in a warm
+// or new container, queueing, offloading to an Edge neighbor or to the Cloud) and picks the first choice, in this
+// order, that meets the deadline of the request (i.e., its MaxRespT). If no choice meets the deadline, the request is
+// dropped, as it would be completed late anyway, wasting resources that other requests can use. Requests without
+// MaxRespT are handled as by the CloudEdgePolicy, with queueing.
+//
+// The queue is served in earliest-deadline-first order by default, and requests whose deadline has passed are
+// dropped; with the priority discipline, requests of higher QoS classes are served first. When the queue is full, a
+// request takes the place of the queued request of the lowest QoS class, if lower than its own: the displaced request
+// is offloaded, if offloading meets its deadline, or dropped.
+type QoSAwarePolicy struct {
+	queue       queue
+	dropExpired bool
+	mutex       sync.Mutex
+	stats       map[string]*functionStats
+}
+
+// functionStats keeps the moving averages of the times observed in the local executions of a function
+type functionStats struct {
+	duration float64
+	initTime float64
+	warmRuns int
+	coldRuns int
+}
+
+func (p *QoSAwarePolicy) Init() {
+	p.stats = make(map[string]*functionStats)
+	p.queue, p.dropExpired = newConfiguredQueue(EDF_QUEUE, true)
+}
+
+func (p *QoSAwarePolicy) OnCompletion(fun *function.Function, report *function.ExecutionReport) {
+	if report != nil && report.Duration > 0 {
+		p.mutex.Lock()
+		p.getStats(fun.QualifiedName()).update(report)
+		p.mutex.Unlock()
+	}
+
+	serveQueue(p.queue, p.dropExpired)
+}
+
+func (s *functionStats) update(report *function.ExecutionReport) {
+	if s.warmRuns+s.coldRuns == 0 {
+		s.duration = report.Duration
+	} else {
+		s.duration = statsSmoothing*report.Duration + (1-statsSmoothing)*s.duration
+	}
+	if report.IsWarmStart {
+		s.warmRuns++
+		return
+	}
+	// the init time of a cold start also includes the time spent in the queue
+	initTime := math.Max(0, report.InitTime-report.QueueingTime)
+	if s.coldRuns == 0 {
+		s.initTime = initTime
+	} else {
+		s.initTime = statsSmoothing*initTime + (1-statsSmoothing)*s.initTime
+	}
+	s.coldRuns++
+}
+
+// getStats returns the statistics of a function, which are zero until the first completion (i.e., the execution
+// times of unknown functions are optimistically assumed to be negligible)
+func (p *QoSAwarePolicy) getStats(name string) *functionStats {
+	s, ok := p.stats[name]
+	if !ok {
+		s = &functionStats{}
+		p.stats[name] = s
+	}
+	return s
+}
+
+// meetsDeadline returns true if a request is expected to be completed in time, given the estimated time still
+// needed to complete it
+func meetsDeadline(r *scheduledRequest, estimatedTime float64, now time.Time) bool {
+	d, ok := deadline(r)
+	if !ok {
+		return true
+	}
+	return !now.Add(time.Duration(estimatedTime * float64(time.Second))).After(d)
+}
+
+func (p *QoSAwarePolicy) OnArrival(r *scheduledRequest) {
+	now := time.Now()
+	p.mutex.Lock()
+	stats := *p.getStats(r.Fun.QualifiedName())
+	p.mutex.Unlock()
+
+	if r.Fun.SupportsArch(node.LocalNode.Arch) {
+		if p.tryLocalExecution(r, stats, now) {
+			return
+		}
+	}
+
+	if !tryOffloading(r, stats, now) {
+		log.Printf("[%s] Dropping request: no choice meets the deadline\n", r)
+		dropRequest(r)
+	}
+}
+
+// tryOffloading offloads the request to an Edge neighbor or to the Cloud, if it is expected to be completed in time
+func tryOffloading(r *scheduledRequest, stats functionStats, now time.Time) bool {
+	if !r.CanDoOffloading {
+		return false
+	}
+
+	url, err := pickEdgeNodeForOffloading(r)
+	if url != "" && meetsDeadline(r, estimateEdgeResponseTime(r, url, stats), now) {
+		handleOffload(r, url)
+		return true
+	} else if err != nil && !errors.Is(err, NoNeighbors) && !errors.Is(err, NoSuitableNode) {
+		log.Printf("[%s] Could not pick an Edge node: %v\n", r, err)
+	}
+
+	target := registration.GetRemoteOffloadingTarget()
+	if target != nil && meetsDeadline(r, estimateCloudResponseTime(r, stats), now) {
+		handleCloudOffload(r)
+		return true
+	}
+	return false
+}
+
+// tryLocalExecution executes or enqueues the request if it is expected to be completed in time
+func (p *QoSAwarePolicy) tryLocalExecution(r *scheduledRequest, stats functionStats, now time.Time) bool {
+	estimate := stats.duration
+	if node.WarmStatus()[r.Fun.VersionedName()] == 0 {
+		estimate += stats.initTime
+	}
+	if meetsDeadline(r, estimate, now) {
+		containerID, warm, err := node.AcquireContainer(r.Fun, false)
+		if err == nil {
+			execLocally(r, containerID, warm)
+			return true
+		} else if !errors.Is(err, node.OutOfResourcesErr) {
+			log.Printf("[%s] Local execution failed: %v\n", r, err)
+			return false
+		}
+	}
+
+	if p.queue == nil {
+		return false
+	}
+	p.queue.Lock()
+	if !meetsDeadline(r, p.estimateQueueingTime(r, stats)+stats.duration, now) {
+		p.queue.Unlock()
+		return false
+	}
+	if p.queue.enqueue(r) {
+		log.Printf("[%s] Added to queue (length=%d)\n", r, p.queue.len())
+		p.queue.Unlock()
+		return true
+	}
+	// the queue is full: the request takes the place of one of a lower class, if any
+	displaced := p.queue.lowestClass()
+	if displaced == nil || displaced.Class >= r.Class || !p.queue.remove(displaced) {
+		p.queue.Unlock()
+		return false
+	}
+	p.queue.enqueue(r)
+	p.queue.Unlock()
+
+	log.Printf("[%s] Added to queue in place of %s\n", r, displaced)
+	p.mutex.Lock()
+	displacedStats := *p.getStats(displaced.Fun.QualifiedName())
+	p.mutex.Unlock()
+	if !tryOffloading(displaced, displacedStats, now) {
+		log.Printf("[%s] Dropping request: displaced from the queue by a request of a higher class\n", displaced)
+		dropRequest(displaced)
+	}
+	return true
+}
+
+// estimateQueueingTime estimates the time needed to serve the request and those served before it in the queue,
+// assuming that they take as long as the arriving one and that one request per available core is executed at a time
+func (p *QoSAwarePolicy) estimateQueueingTime(r *scheduledRequest, stats functionStats) float64 {
+	parallelism := math.Max(1, math.Floor(node.LocalResources.TotalCPUs()))
+	return float64(p.queue.countAhead(r)+1) * stats.duration / parallelism
+}
+
+// estimateEdgeResponseTime estimates the response time on the Edge node with the given URL, from its distance in the
+// network coordinates space and from the availability of warm containers
+func estimateEdgeResponseTime(r *scheduledRequest, url string, stats functionStats) float64 {
+	estimate := stats.duration + stats.initTime
+	for _, neighbor := range registration.GetNearestNeighbors() {
+		if neighbor.APIUrl() != url {
+			continue
+		}
+		status, ok := registration.GetFullNeighborInfo()[neighbor.Key]
+		if !ok {
+			break
+		}
+		if status.AvailableWarmContainers[r.Fun.VersionedName()] > 0 {
+			estimate = stats.duration
+		}
+		if registration.VivaldiClient != nil {
+			estimate += registration.VivaldiClient.DistanceTo(&status.Coordinates).Seconds()
+		}
+	}
+	return estimate
+}
+
+// estimateCloudResponseTime estimates the response time on the Cloud from the metrics collected by Prometheus,
+// if available, and from the latency to the Cloud node. A cold start is assumed if its probability is unknown.
+func estimateCloudResponseTime(r *scheduledRequest, stats functionStats) float64 {
+	duration, initTime, coldStartProbability := stats.duration, stats.initTime, 1.0
+	if metrics.Enabled {
+		m := metrics.GetMetrics()
+		if v, ok := m.AvgRemoteExecutionTime[r.Fun.QualifiedName()]; ok {
+			duration = v
+		}
+		if v, ok := m.AvgRemoteInitTime[r.Fun.QualifiedName()]; ok {
+			initTime = v
+		}
+		if v, ok := m.RemoteColdStartProbability[r.Fun.QualifiedName()]; ok {
+			coldStartProbability = v
+		}
+	}
+	latency := registration.GetRemoteOffloadingTargetLatencyMs() / 1000.0
+	return duration + coldStartProbability*initTime + latency
+}
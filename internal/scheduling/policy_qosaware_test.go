@@ -0,0 +1,182 @@
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/internal/registration"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFactory creates containers that are never started, to test the scheduling decisions
+type fakeFactory struct{ next int }
+
+func (f *fakeFactory) Create(string, *container.ContainerOptions) (container.ContainerID, error) {
+	f.next++
+	return fmt.Sprintf("fake-%d", f.next), nil
+}
+
+func (f *fakeFactory) CopyToContainer(container.ContainerID, io.Reader, string) error { return nil }
+func (f *fakeFactory) Start(container.ContainerID) error                              { return nil }
+func (f *fakeFactory) Destroy(container.ContainerID) error                            { return nil }
+func (f *fakeFactory) HasImage(string) bool                                           { return true }
+func (f *fakeFactory) PullImage(string) error                                         { return nil }
+func (f *fakeFactory) GetIPAddress(container.ContainerID) (string, error)             { return "127.0.0.1", nil }
+func (f *fakeFactory) GetMemoryMB(container.ContainerID) (int64, error)               { return 100, nil }
+func (f *fakeFactory) GetLog(container.ContainerID) (string, error)                   { return "", nil }
+func (f *fakeFactory) GetImageArchitectures(string) ([]string, error)                 { return []string{"amd64"}, nil }
+
+// setupQoSAwarePolicy returns a policy with a queue of the given capacity, on a node with room for a single container.
+// Every function takes 1 second to execute, with no cold start.
+func setupQoSAwarePolicy(t *testing.T, discipline string, capacity int) *QoSAwarePolicy {
+	previous := container.GetFactory()
+	container.SetFactory(&fakeFactory{})
+	viper.Set(config.POOL_MEMORY_MB, 100)
+	viper.Set(config.POOL_CPUS, 1.0)
+	node.LocalResources = node.Resources{}
+	node.LocalResources.Init()
+	arch := node.LocalNode.Arch
+	node.LocalNode.Arch = "amd64"
+	t.Cleanup(func() {
+		container.SetFactory(previous)
+		node.LocalNode.Arch = arch
+		registration.SetRemoteOffloadingTarget(nil, 0)
+		offloadingCache = make(map[string]*registration.NodeRegistration)
+		cacheExpiration = make(map[string]time.Time)
+	})
+
+	q, err := newQueue(discipline, capacity)
+	assert.NoError(t, err)
+	p := &QoSAwarePolicy{queue: q, stats: make(map[string]*functionStats)}
+	p.getStats("f").duration = 1
+	return p
+}
+
+func newArrivingRequest(id string, class int64, maxRespT float64, canDoOffloading bool) *scheduledRequest {
+	r := newQueuedRequest("f", class, time.Now(), maxRespT)
+	r.Fun = &function.Function{Name: "f", Runtime: container.CUSTOM_RUNTIME, CustomImage: "fake", MemoryMB: 100,
+		MaxConcurrency: 1, SupportedArchs: []string{"amd64"}}
+	r.Ctx = context.WithValue(context.Background(), "ReqId", id)
+	r.CanDoOffloading = canDoOffloading
+	r.decisionChannel = make(chan schedDecision, 1)
+	return r
+}
+
+// decision returns the scheduling decision taken for the request, if any
+func decision(r *scheduledRequest) *schedDecision {
+	select {
+	case d := <-r.decisionChannel:
+		return &d
+	default:
+		return nil
+	}
+}
+
+func TestFunctionStats(t *testing.T) {
+	s := &functionStats{}
+	s.update(&function.ExecutionReport{Duration: 1.0, InitTime: 3.0, QueueingTime: 0.5})
+	assert.InDelta(t, 1.0, s.duration, 1e-9)
+	assert.InDelta(t, 2.5, s.initTime, 1e-9, "the queueing time is not part of the init time")
+
+	s.update(&function.ExecutionReport{Duration: 2.0, InitTime: 0.001, IsWarmStart: true})
+	assert.InDelta(t, 0.3*2.0+0.7*1.0, s.duration, 1e-9)
+	assert.InDelta(t, 2.5, s.initTime, 1e-9, "warm starts do not change the init time")
+
+	s.update(&function.ExecutionReport{Duration: 1.3, InitTime: 0.5})
+	assert.InDelta(t, 1.3, s.duration, 1e-9)
+	assert.InDelta(t, 0.3*0.5+0.7*2.5, s.initTime, 1e-9)
+	assert.Equal(t, 1, s.warmRuns)
+	assert.Equal(t, 2, s.coldRuns)
+}
+
+func TestMeetsDeadline(t *testing.T) {
+	now := time.Now()
+	r := newQueuedRequest("f", 0, now.Add(-time.Second), 3)
+	assert.True(t, meetsDeadline(r, 1.5, now))
+	assert.False(t, meetsDeadline(r, 2.5, now))
+	assert.True(t, meetsDeadline(newQueuedRequest("g", 0, now, 0), 1000, now),
+		"requests without MaxRespT always meet their deadline")
+}
+
+func TestQoSAwareOnArrival(t *testing.T) {
+	p := setupQoSAwarePolicy(t, PRIORITY_QUEUE, 1)
+
+	// the only container of the node is busy with the first request
+	local := newArrivingRequest("local", 0, 10, true)
+	p.OnArrival(local)
+	d := decision(local)
+	assert.NotNil(t, d)
+	assert.Equal(t, action(EXEC_LOCAL), d.action)
+
+	queued := newArrivingRequest("queued", 0, 10, true)
+	p.OnArrival(queued)
+	assert.Nil(t, decision(queued), "the request should wait in the queue")
+	assert.Equal(t, 1, p.queue.len())
+
+	// the queue is full: a request of a higher class takes the place of the queued one, which cannot be offloaded
+	queued.CanDoOffloading = false
+	displacing := newArrivingRequest("displacing", 1, 10, true)
+	p.OnArrival(displacing)
+	assert.Nil(t, decision(displacing))
+	assert.Equal(t, displacing, p.queue.front())
+	d = decision(queued)
+	assert.NotNil(t, d)
+	assert.Equal(t, DROP, d.action)
+
+	// requests of the same or lower classes are offloaded to the Edge, or to the Cloud if no neighbor is available
+	edge := &registration.NodeRegistration{IPAddress: "10.0.0.2", APIPort: 1323}
+	offloadingCache["f"] = edge
+	cacheExpiration["f"] = time.Now().Add(time.Minute)
+	toEdge := newArrivingRequest("edge", 1, 10, true)
+	p.OnArrival(toEdge)
+	d = decision(toEdge)
+	assert.NotNil(t, d)
+	assert.Equal(t, action(EXEC_REMOTE), d.action)
+	assert.Equal(t, edge.APIUrl(), d.remoteHost)
+
+	delete(offloadingCache, "f")
+	cloud := &registration.NodeRegistration{NodeID: node.NodeID{Key: "cloud"}, IPAddress: "10.0.1.1", APIPort: 1323,
+		IsLoadBalancer: true}
+	registration.SetRemoteOffloadingTarget(cloud, 100)
+	toCloud := newArrivingRequest("cloud", 0, 10, true)
+	p.OnArrival(toCloud)
+	d = decision(toCloud)
+	assert.NotNil(t, d)
+	assert.Equal(t, action(EXEC_REMOTE), d.action)
+	assert.Equal(t, cloud.APIUrl(), d.remoteHost)
+
+	// the Cloud is too far for the deadline
+	late := newArrivingRequest("late", 0, 1.05, true)
+	p.OnArrival(late)
+	d = decision(late)
+	assert.NotNil(t, d)
+	assert.Equal(t, DROP, d.action)
+	assert.Equal(t, displacing, p.queue.front())
+}
+
+func TestQoSAwareQueueingOfHigherClasses(t *testing.T) {
+	p := setupQoSAwarePolicy(t, PRIORITY_QUEUE, 3)
+	p.OnArrival(newArrivingRequest("local", 0, 0, false))
+	p.OnArrival(newArrivingRequest("low-1", 0, 0, false))
+	p.OnArrival(newArrivingRequest("low-2", 0, 0, false))
+
+	// waiting for both the queued requests would take too long, but a request of a higher class is served first
+	low := newArrivingRequest("low-3", 0, 3.5, false)
+	p.OnArrival(low)
+	d := decision(low)
+	assert.NotNil(t, d)
+	assert.Equal(t, DROP, d.action)
+
+	high := newArrivingRequest("high", 1, 3.5, false)
+	p.OnArrival(high)
+	assert.Nil(t, decision(high))
+	assert.Equal(t, high, p.queue.front())
+}
@@ -1,14 +1,28 @@
 package scheduling
 
 import (
+	"container/heap"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/metrics"
+)
+
+// Queue disciplines
+const (
+	FIFO_QUEUE     = "fifo"
+	PRIORITY_QUEUE = "priority"
+	EDF_QUEUE      = "edf"
 )
 
 type queue interface {
 	enqueue(r *scheduledRequest) bool
 	dequeue() *scheduledRequest
 	front() *scheduledRequest
+	remove(r *scheduledRequest) bool
+	countAhead(r *scheduledRequest) int
+	lowestClass() *scheduledRequest
 	len() int
 	isEmpty() bool
 	isFull() bool
@@ -26,6 +40,20 @@ type circularFifoQueue struct {
 	size     int
 }
 
+// newQueue creates a queue with the given discipline
+func newQueue(discipline string, n int) (queue, error) {
+	switch discipline {
+	case FIFO_QUEUE:
+		return newFIFOQueue(n), nil
+	case PRIORITY_QUEUE:
+		return newPriorityQueue(n), nil
+	case EDF_QUEUE:
+		return newEDFQueue(n), nil
+	default:
+		return nil, fmt.Errorf("unknown queue discipline: %s", discipline)
+	}
+}
+
 // newFIFOQueue creates a queue
 func newFIFOQueue(n int) queue {
 	if n < 1 {
@@ -71,8 +99,7 @@ func (q *circularFifoQueue) dequeue() *scheduledRequest {
 	q.head = (q.head + 1) % q.capacity
 	q.size = q.size - 1
 
-	v.QueueingTime = time.Now().Sub(v.Arrival).Seconds()
-
+	onDequeue(v)
 	return v
 }
 
@@ -84,7 +111,203 @@ func (q *circularFifoQueue) front() *scheduledRequest {
 	return v
 }
 
+// remove drops the request from the queue, preserving the order of the others. It returns false if the request
+// is not in the queue.
+func (q *circularFifoQueue) remove(r *scheduledRequest) bool {
+	for i := 0; i < q.size; i++ {
+		if q.data[(q.head+i)%q.capacity] != r {
+			continue
+		}
+		// shift the following requests back by one position
+		for j := i; j < q.size-1; j++ {
+			q.data[(q.head+j)%q.capacity] = q.data[(q.head+j+1)%q.capacity]
+		}
+		q.tail = (q.tail - 1 + q.capacity) % q.capacity
+		q.data[q.tail] = nil
+		q.size = q.size - 1
+		return true
+	}
+	return false
+}
+
+// countAhead returns the number of queued requests that would be served before the request, if it were enqueued now
+func (q *circularFifoQueue) countAhead(_ *scheduledRequest) int {
+	return q.size
+}
+
+// lowestClass returns the queued request with the lowest QoS class (the latest one, among those of the same class)
+func (q *circularFifoQueue) lowestClass() *scheduledRequest {
+	var lowest *scheduledRequest
+	for i := 0; i < q.size; i++ {
+		r := q.data[(q.head+i)%q.capacity]
+		if lowest == nil || r.Class <= lowest.Class {
+			lowest = r
+		}
+	}
+	return lowest
+}
+
 // Len returns the current length of the queue
 func (q *circularFifoQueue) len() int {
 	return q.size
 }
+
+// onDequeue sets the queueing time of a request leaving the queue
+func onDequeue(r *scheduledRequest) {
+	r.QueueingTime = time.Now().Sub(r.Arrival).Seconds()
+	if metrics.Enabled {
+		metrics.AddQueueingTimeValue(r.Class, r.QueueingTime)
+	}
+}
+
+// deadline returns the time by which the request should be completed, according to its MaxRespT
+func deadline(r *scheduledRequest) (time.Time, bool) {
+	if r.MaxRespT <= 0 {
+		return time.Time{}, false
+	}
+	return r.Arrival.Add(time.Duration(r.MaxRespT * float64(time.Second))), true
+}
+
+// isExpired returns true if the deadline of the request has already passed
+func isExpired(r *scheduledRequest, now time.Time) bool {
+	d, ok := deadline(r)
+	return ok && now.After(d)
+}
+
+// heapQueue is a bounded queue whose requests are ordered by less. Requests that are equivalent according to less
+// are served in FIFO order.
+type heapQueue struct {
+	sync.Mutex
+	items    []heapItem
+	capacity int
+	sequence uint64
+	less     func(a, b *scheduledRequest) bool
+}
+
+type heapItem struct {
+	r        *scheduledRequest
+	sequence uint64
+}
+
+// newPriorityQueue creates a queue where requests with higher QoS class are served first
+func newPriorityQueue(n int) queue {
+	if n < 1 {
+		return nil
+	}
+	return &heapQueue{capacity: n, less: func(a, b *scheduledRequest) bool {
+		return a.Class > b.Class
+	}}
+}
+
+// newEDFQueue creates a queue where requests are served in earliest-deadline-first order. Requests without a
+// deadline (i.e., with no MaxRespT) are served after the others.
+func newEDFQueue(n int) queue {
+	if n < 1 {
+		return nil
+	}
+	return &heapQueue{capacity: n, less: func(a, b *scheduledRequest) bool {
+		da, okA := deadline(a)
+		db, okB := deadline(b)
+		if okA && okB {
+			return da.Before(db)
+		}
+		return okA && !okB
+	}}
+}
+
+// heap.Interface, used through the methods of the queue interface
+
+func (q *heapQueue) Len() int { return len(q.items) }
+
+func (q *heapQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if q.less(a.r, b.r) {
+		return true
+	} else if q.less(b.r, a.r) {
+		return false
+	}
+	return a.sequence < b.sequence
+}
+
+func (q *heapQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *heapQueue) Push(x any) { q.items = append(q.items, x.(heapItem)) }
+
+func (q *heapQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+func (q *heapQueue) enqueue(r *scheduledRequest) bool {
+	if q.isFull() {
+		return false
+	}
+	heap.Push(q, heapItem{r: r, sequence: q.sequence})
+	q.sequence++
+	return true
+}
+
+func (q *heapQueue) dequeue() *scheduledRequest {
+	if q.isEmpty() {
+		return nil
+	}
+	v := heap.Pop(q).(heapItem).r
+	onDequeue(v)
+	return v
+}
+
+func (q *heapQueue) front() *scheduledRequest {
+	if q.isEmpty() {
+		return nil
+	}
+	return q.items[0].r
+}
+
+func (q *heapQueue) remove(r *scheduledRequest) bool {
+	for i, item := range q.items {
+		if item.r == r {
+			heap.Remove(q, i)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *heapQueue) countAhead(r *scheduledRequest) int {
+	n := 0
+	for _, item := range q.items {
+		// requests equivalent to r have been enqueued before it
+		if !q.less(r, item.r) {
+			n++
+		}
+	}
+	return n
+}
+
+func (q *heapQueue) lowestClass() *scheduledRequest {
+	var lowest *heapItem
+	for i := range q.items {
+		item := &q.items[i]
+		if lowest == nil || item.r.Class < lowest.r.Class ||
+			(item.r.Class == lowest.r.Class && item.sequence > lowest.sequence) {
+			lowest = item
+		}
+	}
+	if lowest == nil {
+		return nil
+	}
+	return lowest.r
+}
+
+func (q *heapQueue) len() int {
+	return len(q.items)
+}
+
+func (q *heapQueue) isEmpty() bool {
+	return q != nil && len(q.items) == 0
+}
+
+func (q *heapQueue) isFull() bool {
+	return len(q.items) == q.capacity
+}
@@ -0,0 +1,137 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/stretchr/testify/assert"
+)
+
+func newQueuedRequest(id string, class int64, arrival time.Time, maxRespT float64) *scheduledRequest {
+	return &scheduledRequest{
+		Request: &function.Request{
+			Fun:        &function.Function{Name: id},
+			RequestQoS: function.RequestQoS{Class: class, MaxRespT: maxRespT},
+			Arrival:    arrival,
+		},
+		ExecutionReport: &function.ExecutionReport{},
+	}
+}
+
+func dequeueAll(q queue) []string {
+	var ids []string
+	for !q.isEmpty() {
+		ids = append(ids, q.dequeue().Fun.Name)
+	}
+	return ids
+}
+
+func TestFIFOQueue(t *testing.T) {
+	now := time.Now()
+	q, err := newQueue(FIFO_QUEUE, 3)
+	assert.NoError(t, err)
+	assert.True(t, q.enqueue(newQueuedRequest("a", 0, now, 0)))
+	assert.True(t, q.enqueue(newQueuedRequest("b", 2, now, 1)))
+	assert.True(t, q.enqueue(newQueuedRequest("c", 1, now, 0.5)))
+	assert.False(t, q.enqueue(newQueuedRequest("d", 0, now, 0)), "the queue is full")
+	assert.Equal(t, "a", q.front().Fun.Name)
+	assert.Equal(t, []string{"a", "b", "c"}, dequeueAll(q))
+}
+
+func TestPriorityQueue(t *testing.T) {
+	now := time.Now()
+	q, err := newQueue(PRIORITY_QUEUE, 5)
+	assert.NoError(t, err)
+	q.enqueue(newQueuedRequest("low1", 0, now, 0))
+	q.enqueue(newQueuedRequest("high1", 2, now, 0))
+	q.enqueue(newQueuedRequest("mid", 1, now, 0))
+	q.enqueue(newQueuedRequest("high2", 2, now, 0))
+	q.enqueue(newQueuedRequest("low2", 0, now, 0))
+	assert.True(t, q.isFull())
+	assert.Equal(t, "high1", q.front().Fun.Name)
+	// requests of the same class are served in FIFO order
+	assert.Equal(t, []string{"high1", "high2", "mid", "low1", "low2"}, dequeueAll(q))
+}
+
+func TestEDFQueue(t *testing.T) {
+	now := time.Now()
+	q, err := newQueue(EDF_QUEUE, 5)
+	assert.NoError(t, err)
+	q.enqueue(newQueuedRequest("none1", 0, now, 0))
+	q.enqueue(newQueuedRequest("late", 0, now, 10))
+	q.enqueue(newQueuedRequest("early", 0, now.Add(time.Second), 1))
+	q.enqueue(newQueuedRequest("none2", 0, now, 0))
+	q.enqueue(newQueuedRequest("mid", 0, now.Add(-time.Second), 5))
+	// requests without a deadline are served last
+	assert.Equal(t, []string{"early", "mid", "late", "none1", "none2"}, dequeueAll(q))
+}
+
+func TestQueueingTime(t *testing.T) {
+	q, err := newQueue(EDF_QUEUE, 1)
+	assert.NoError(t, err)
+	q.enqueue(newQueuedRequest("a", 0, time.Now().Add(-2*time.Second), 1))
+	r := q.dequeue()
+	assert.GreaterOrEqual(t, r.QueueingTime, 2.0)
+	assert.True(t, isExpired(r, time.Now()))
+	assert.False(t, isExpired(newQueuedRequest("b", 0, time.Now().Add(-time.Hour), 0), time.Now()),
+		"requests without MaxRespT never expire")
+}
+
+func TestUnknownQueueDiscipline(t *testing.T) {
+	_, err := newQueue("lifo", 10)
+	assert.Error(t, err)
+}
+
+func TestQueueRemove(t *testing.T) {
+	now := time.Now()
+	for _, discipline := range []string{FIFO_QUEUE, PRIORITY_QUEUE, EDF_QUEUE} {
+		q, err := newQueue(discipline, 4)
+		assert.NoError(t, err)
+		// fill the queue after a dequeue, so that the FIFO queue wraps around
+		q.enqueue(newQueuedRequest("x", 0, now, 0))
+		q.dequeue()
+		requests := []*scheduledRequest{
+			newQueuedRequest("a", 0, now, 0),
+			newQueuedRequest("b", 0, now, 0),
+			newQueuedRequest("c", 0, now, 0),
+			newQueuedRequest("d", 0, now, 0),
+		}
+		for _, r := range requests {
+			assert.True(t, q.enqueue(r))
+		}
+
+		assert.True(t, q.remove(requests[1]), discipline)
+		assert.False(t, q.remove(requests[1]), "%s: the request has already been removed", discipline)
+		assert.Equal(t, 3, q.len())
+		assert.True(t, q.enqueue(newQueuedRequest("e", 0, now, 0)), "%s: there is room again", discipline)
+		assert.Equal(t, []string{"a", "c", "d", "e"}, dequeueAll(q), discipline)
+	}
+}
+
+func TestQueueCountAheadAndLowestClass(t *testing.T) {
+	now := time.Now()
+	for _, discipline := range []string{FIFO_QUEUE, PRIORITY_QUEUE, EDF_QUEUE} {
+		q, err := newQueue(discipline, 4)
+		assert.NoError(t, err)
+		assert.Nil(t, q.lowestClass(), discipline)
+		q.enqueue(newQueuedRequest("low1", 0, now, 5))
+		q.enqueue(newQueuedRequest("high", 2, now, 5))
+		q.enqueue(newQueuedRequest("low2", 0, now, 5))
+		q.enqueue(newQueuedRequest("mid", 1, now, 5))
+		assert.Equal(t, "low2", q.lowestClass().Fun.Name, "%s: the latest request of the lowest class", discipline)
+	}
+
+	fifo, _ := newQueue(FIFO_QUEUE, 4)
+	priority, _ := newQueue(PRIORITY_QUEUE, 4)
+	edf, _ := newQueue(EDF_QUEUE, 4)
+	for _, q := range []queue{fifo, priority, edf} {
+		q.enqueue(newQueuedRequest("low", 0, now, 5))
+		q.enqueue(newQueuedRequest("high", 2, now, 1))
+		q.enqueue(newQueuedRequest("mid", 1, now, 10))
+	}
+	r := newQueuedRequest("r", 1, now, 2)
+	assert.Equal(t, 3, fifo.countAhead(r))
+	assert.Equal(t, 2, priority.countAhead(r), "requests of the same class are served first")
+	assert.Equal(t, 1, edf.countAhead(r))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/serverledge-faas/serverledge/internal/registration"
 
+	"github.com/serverledge-faas/serverledge/internal/async"
 	"github.com/serverledge-faas/serverledge/internal/container"
 	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/metrics"
@@ -29,7 +30,7 @@ func Run(p Policy) {
 	node.LocalResources.Init()
 	log.Printf("Current resources: %v\n", &node.LocalResources)
 
-	container.InitDockerContainerFactory()
+	container.InitFactory()
 
 	//janitor periodically remove expired warm container
 	node.GetJanitorInstance()
@@ -57,15 +58,15 @@ func Run(p Policy) {
 			p.OnCompletion(c.r.Fun, c.r.ExecutionReport)
 
 			if metrics.Enabled && !c.failed && c.r.ExecutionReport != nil {
-				metrics.AddCompletedInvocation(c.r.Fun.Name, !c.r.ExecutionReport.IsWarmStart)
+				metrics.AddCompletedInvocation(c.r.Fun.QualifiedName(), !c.r.ExecutionReport.IsWarmStart)
 				if !c.r.offloaded {
-					metrics.AddFunctionDurationValue(c.r.Fun.Name, c.r.ExecutionReport.Duration)
+					metrics.AddFunctionDurationValue(c.r.Fun.QualifiedName(), c.r.ExecutionReport.Duration)
 					if !c.r.ExecutionReport.IsWarmStart {
-						metrics.AddFunctionInitTimeValue(c.r.Fun.Name, c.r.ExecutionReport.InitTime)
+						metrics.AddFunctionInitTimeValue(c.r.Fun.QualifiedName(), c.r.ExecutionReport.InitTime)
 					}
 				}
 				outputSize := len(c.r.ExecutionReport.Result)
-				metrics.AddFunctionOutputSizeValue(r.Fun.Name, float64(outputSize))
+				metrics.AddFunctionOutputSizeValue(r.Fun.QualifiedName(), float64(outputSize))
 			}
 		}
 	}
@@ -78,6 +79,8 @@ func SubmitRequest(r *function.Request) (*function.ExecutionReport, error) {
 		Request:         r,
 		ExecutionReport: &function.ExecutionReport{},
 		decisionChannel: make(chan schedDecision, 1)}
+	untrack := track(&schedRequest)
+	defer untrack()
 	requests <- &schedRequest
 
 	if telemetry.DefaultTracer != nil {
@@ -97,10 +100,17 @@ func SubmitRequest(r *function.Request) (*function.ExecutionReport, error) {
 
 	if schedDecision.action == DROP {
 		//log.Printf("[%s] Dropping request", r)
+		if isCancelled(&schedRequest) {
+			return nil, CancelledErr
+		}
 		return nil, node.OutOfResourcesErr
 	} else if schedDecision.action == EXEC_REMOTE {
 		//log.Printf("Offloading request")
+		setRemoteHost(&schedRequest, schedDecision.remoteHost)
 		err := Offload(&schedRequest, schedDecision.remoteHost)
+		if err != nil && isCancelled(&schedRequest) {
+			err = CancelledErr
+		}
 		return schedRequest.ExecutionReport, err
 	} else {
 		err := Execute(schedDecision.cont, &schedRequest, schedDecision.useWarm)
@@ -114,31 +124,40 @@ func SubmitAsyncRequest(r *function.Request) {
 		Request:         r,
 		ExecutionReport: &function.ExecutionReport{},
 		decisionChannel: make(chan schedDecision, 1)}
+	untrack := track(&schedRequest)
 	requests <- &schedRequest // send async request
 
 	// wait on channel for scheduling action
 	schedDecision, ok := <-schedRequest.decisionChannel
 	if !ok {
-		publishAsyncResponse(r.Id(), function.Response{Success: false})
+		untrack()
+		publishAsyncResponse(r, function.Response{Success: false})
 		return
 	}
 
 	var err error
 	if schedDecision.action == DROP {
-		publishAsyncResponse(r.Id(), function.Response{Success: false})
+		untrack()
+		publishAsyncResponse(r, function.Response{Success: false, Cancelled: isCancelled(&schedRequest)})
 	} else if schedDecision.action == EXEC_REMOTE {
 		//log.Printf("Offloading request\n")
+		setRemoteHost(&schedRequest, schedDecision.remoteHost)
 		err = OffloadAsync(r, schedDecision.remoteHost)
 		if err != nil {
-			publishAsyncResponse(r.Id(), function.Response{Success: false})
+			untrack()
+			publishAsyncResponse(r, function.Response{Success: false, Cancelled: isCancelled(&schedRequest)})
+			return
 		}
+		// the request can be cancelled on the remote node until its result expires
+		time.AfterFunc(async.ResultTTL(r.AsyncOptions), untrack)
 	} else {
 		err = Execute(schedDecision.cont, &schedRequest, schedDecision.useWarm)
+		untrack()
 		if err != nil {
-			publishAsyncResponse(r.Id(), function.Response{Success: false})
+			publishAsyncResponse(r, function.Response{Success: false, Cancelled: isCancelled(&schedRequest)})
 			return
 		}
-		publishAsyncResponse(r.Id(), function.Response{Success: true, ExecutionReport: *schedRequest.ExecutionReport})
+		publishAsyncResponse(r, function.Response{Success: true, ExecutionReport: *schedRequest.ExecutionReport})
 	}
 }
 
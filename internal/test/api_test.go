@@ -32,13 +32,24 @@ func TestContainerPool(t *testing.T) {
 		createApiIfNotExistsTest(t, fn, HOST, PORT)
 	}
 
-	// creating java function
-	javaFn, err := InitializeJavaFunction("hello-java", "com.test.HelloFunction", function.NewSignature().
-		AddInput("name", function.Text{}).
-		AddOutput("greeting", function.Text{}).
-		Build())
-	utils.AssertNil(t, err)
-	createApiIfNotExistsTest(t, javaFn, HOST, PORT)
+	// creating java function, unless the container factory cannot run it
+	withJava := isRuntimeSupported("java21")
+	var javaFn *function.Function
+	if withJava {
+		var err error
+		javaFn, err = InitializeJavaFunction("hello-java", "com.test.HelloFunction", function.NewSignature().
+			AddInput("name", function.Text{}).
+			AddOutput("greeting", function.Text{}).
+			Build())
+		utils.AssertNil(t, err)
+		createApiIfNotExistsTest(t, javaFn, HOST, PORT)
+	} else {
+		t.Log("Skipping java function: runtime java21 not supported by the container factory")
+	}
+	nFuncs := len(pyFuncs)
+	if withJava {
+		nFuncs++
+	}
 
 	// executing all functions
 	channel := make(chan error)
@@ -54,6 +65,9 @@ func TestContainerPool(t *testing.T) {
 				channel <- err
 			}()
 		}
+		if !withJava {
+			continue
+		}
 		// invoke java func
 		x := make(map[string]interface{})
 		x["name"] = "World"
@@ -65,7 +79,7 @@ func TestContainerPool(t *testing.T) {
 	}
 
 	// wait for all functions to complete and checking the errors
-	for i := 0; i < (len(pyFuncs)+1)*n; i++ {
+	for i := 0; i < nFuncs*n; i++ {
 		err := <-channel
 		utils.AssertNil(t, err)
 	}
@@ -73,7 +87,9 @@ func TestContainerPool(t *testing.T) {
 	for _, name := range pyFuncs {
 		deleteApiTest(t, name, HOST, PORT)
 	}
-	deleteApiTest(t, javaFn.Name, HOST, PORT)
+	if withJava {
+		deleteApiTest(t, javaFn.Name, HOST, PORT)
+	}
 	//utils.AssertTrueMsg(t, workflow.IsEmptyPartialDataCache(), "partial data cache is not empty")
 }
 
@@ -213,7 +229,7 @@ func TestDeleteWorkflow(t *testing.T) {
 	// the API under test is the following
 	deleteWorkflowApiTest(t, fcName, HOST, PORT)
 
-	list, err := workflow.GetAllWorkflows()
+	list, err := workflow.GetAllWorkflows(function.DefaultNamespace)
 	found := false
 	for _, w := range list {
 		if strings.Compare(w, wflow.Name) == 0 {
@@ -33,7 +33,7 @@ func TestParsedWorkflowName(t *testing.T) {
 // commonTest creates a function, parses a json AWS State Language file producing a function composition,
 // then checks if the composition is saved onto ETCD. Lastly, it runs the composition and expects the correct result.
 func commonTest(t *testing.T, name string, expectedResult int) {
-	all, err := workflow.GetAllWorkflows()
+	all, err := workflow.GetAllWorkflows(function.DefaultNamespace)
 	utils.AssertNil(t, err)
 
 	//initializeAllPyFunctionFromNames(t, "inc", "double", "hello", "noop")
@@ -48,7 +48,7 @@ func commonTest(t *testing.T, name string, expectedResult int) {
 		err := comp.Save()
 		utils.AssertNilMsg(t, err, "unable to save parsed composition")
 
-		all2, err := workflow.GetAllWorkflows()
+		all2, err := workflow.GetAllWorkflows(function.DefaultNamespace)
 		utils.AssertNil(t, err)
 		utils.AssertEqualsMsg(t, len(all2), len(all)+1, "the number of created functions differs")
 
@@ -66,6 +66,46 @@ func commonTest(t *testing.T, name string, expectedResult int) {
 	utils.AssertNil(t, err2)
 }
 
+// TestParsingSharedCatcherHandler verifies that the catchers of different tasks continuing with the same state share its task
+func TestParsingSharedCatcherHandler(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	initializeAllPyFunctionFromNames(t, "inc")
+	catch := `"Catch": [
+		{ "ErrorEquals": ["States.Timeout"], "Next": "Recover" },
+		{ "ErrorEquals": ["States.ALL"], "ResultPath": "$.error", "Next": "Recover" }
+	]`
+	src := []byte(`{
+		"StartAt": "First",
+		"States": {
+			"First": { "Type": "Task", "Resource": "inc", ` + catch + `, "Next": "Second" },
+			"Second": { "Type": "Task", "Resource": "inc", ` + catch + `, "End": true },
+			"Recover": { "Type": "Task", "Resource": "inc", "End": true }
+		}
+	}`)
+	comp, err := workflow.FromASL("catch", src)
+	utils.AssertNilMsg(t, err, "unable to parse json")
+
+	// start, First, Second, Recover, end
+	utils.AssertEquals(t, 5, len(comp.Tasks))
+	for _, id := range []workflow.TaskId{"First", "Second"} {
+		task, found := comp.Find(id)
+		utils.AssertTrue(t, found)
+		catchers := task.(*workflow.FunctionTask).Catch
+		utils.AssertEquals(t, 2, len(catchers))
+		for _, catcher := range catchers {
+			utils.AssertEquals(t, workflow.TaskId("Recover"), catcher.Next)
+		}
+	}
+	recoverTask, found := comp.Find("Recover")
+	utils.AssertTrue(t, found)
+	utils.AssertEquals(t, comp.End.GetId(), recoverTask.(*workflow.FunctionTask).GetNext())
+
+	deleteApiTest(t, "inc", HOST, PORT)
+}
+
 // TestParsingSimple verifies that a simple json with 2 state is correctly parsed and it is equal to a sequence workflow with 2 simple nodes
 
 func TestParsingSimple(t *testing.T) {
@@ -258,3 +298,54 @@ func TestParsingChoiceWorkflowWithBoolExpr(t *testing.T) {
 func TestParsingWorkflowWithMalformedJson(t *testing.T) {}
 
 func TestParsingWorkflowWithUnknownFunction(t *testing.T) {}
+
+// TestParsingParallel verifies that a json file with a Parallel state with 2 branches is correctly parsed and executed
+func TestParsingParallel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	initializeAllPyFunctionFromNames(t, "inc", "double")
+
+	comp := parseFileName(t, "parallel")
+	// Start, FanOut, Inc, Double, IncAfterDouble, FanIn, End
+	utils.AssertEquals(t, 7, len(comp.Tasks))
+
+	params := make(map[string]interface{})
+	params["input"] = 2
+	request := workflow.NewRequest(shortuuid.New(), comp, params, approximateMapSize(params))
+	err := comp.Invoke(request)
+	utils.AssertNil(t, err)
+
+	results, ok := request.ExecReport.Result[workflow.JoinedResultsKey].([]interface{})
+	utils.AssertTrueMsg(t, ok, "joined results not found")
+	utils.AssertEquals(t, 2, len(results))
+
+	deleteApiTest(t, "inc", HOST, PORT)
+	deleteApiTest(t, "double", HOST, PORT)
+}
+
+// TestParsingMap verifies that a json file with a Map state is correctly parsed and executed
+func TestParsingMap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	initializeAllPyFunctionFromNames(t, "inc")
+
+	comp := parseFileName(t, "map")
+	// Start, IncEach, End
+	utils.AssertEquals(t, 3, len(comp.Tasks))
+
+	params := make(map[string]interface{})
+	params["numbers"] = []int{1, 2, 3}
+	request := workflow.NewRequest(shortuuid.New(), comp, params, approximateMapSize(params))
+	err := comp.Invoke(request)
+	utils.AssertNil(t, err)
+
+	results, ok := request.ExecReport.Result[workflow.JoinedResultsKey].([]interface{})
+	utils.AssertTrueMsg(t, ok, "joined results not found")
+	utils.AssertEquals(t, 3, len(results))
+
+	deleteApiTest(t, "inc", HOST, PORT)
+}
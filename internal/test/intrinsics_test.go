@@ -0,0 +1,154 @@
+package test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	u "github.com/serverledge-faas/serverledge/utils"
+)
+
+func evaluateIntrinsic(t *testing.T, expression string, input interface{}) (interface{}, error) {
+	function, err := asl.ParseIntrinsicFunction(expression)
+	u.AssertNilMsg(t, err, "failed to parse "+expression)
+	return function.Evaluate(input, unmarshalJson(t, `{"Execution": {"Id": "req-1"}}`))
+}
+
+func TestIntrinsicFunctions(t *testing.T) {
+	input := unmarshalJson(t, `{
+		"name": "Foo",
+		"someString": "{\"number\": 20}",
+		"someJson": {"name": "Foo", "year": 2020},
+		"inputArray": [1, 2, 3, 4, 5, 6, 7, 8, 9],
+		"duplicates": [1, 2, 3, 3, 3, 3, 3, 3, 4],
+		"lookingFor": 5,
+		"index": 5,
+		"input": "Data to encode",
+		"base64": "RGF0YSB0byBlbmNvZGU=",
+		"Data": "input data",
+		"Algorithm": "SHA-1",
+		"json1": {"a": {"a1": 1, "a2": 2}, "b": 2},
+		"json2": {"a": {"a3": 1, "a4": 2}, "c": 3},
+		"value1": 111,
+		"step": -1,
+		"inputString": "1,2,3,4,5",
+		"splitter": ","
+	}`)
+
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{`States.Format('Your name is {}, we are in the year {}', $.name, 2020)`, `"Your name is Foo, we are in the year 2020"`},
+		{`States.Format('Escaped \{} and \'quotes\' for {}', $.name)`, `"Escaped {} and 'quotes' for Foo"`},
+		{`States.Format('{} {} {}', true, null, 1.5)`, `"true null 1.5"`},
+		{`States.Format('Execution {}', $$.Execution.Id)`, `"Execution req-1"`},
+		{`States.StringToJson($.someString)`, `{"number": 20}`},
+		{`States.JsonToString($.someJson)`, `"{\"name\":\"Foo\",\"year\":2020}"`},
+		{`States.Array('Foo', 2020, $.someJson, null)`, `["Foo", 2020, {"name": "Foo", "year": 2020}, null]`},
+		{`States.Array()`, `[]`},
+		{`States.ArrayPartition($.inputArray,4)`, `[[1, 2, 3, 4], [5, 6, 7, 8], [9]]`},
+		{`States.ArrayContains($.inputArray, $.lookingFor)`, `true`},
+		{`States.ArrayContains($.inputArray, 'a')`, `false`},
+		{`States.ArrayRange(1, 9, 2)`, `[1, 3, 5, 7, 9]`},
+		{`States.ArrayRange(9, 1, -3)`, `[9, 6, 3]`},
+		{`States.ArrayRange(1, 2, -1)`, `[]`},
+		{`States.ArrayGetItem($.inputArray, $.index)`, `6`},
+		{`States.ArrayLength($.inputArray)`, `9`},
+		{`States.ArrayUnique($.duplicates)`, `[1, 2, 3, 4]`},
+		{`States.Base64Encode($.input)`, `"RGF0YSB0byBlbmNvZGU="`},
+		{`States.Base64Decode($.base64)`, `"Data to encode"`},
+		{`States.Hash($.Data, $.Algorithm)`, `"aaff4a450a104cd177d28d18d74485e8cae074b7"`},
+		{`States.Hash('input data', 'SHA-256')`, `"b4a697a057313163aee33cd8d40c66e9f0f177e00cac2de32475ffff6169c3e3"`},
+		{`States.JsonMerge($.json1, $.json2, false)`, `{"a": {"a3": 1, "a4": 2}, "b": 2, "c": 3}`},
+		{`States.MathAdd($.value1, $.step)`, `110`},
+		{`States.MathRandom(3, 3)`, `3`},
+		{`States.StringSplit($.inputString, $.splitter)`, `["1", "2", "3", "4", "5"]`},
+		{`States.StringSplit('This.is+a,test=string', '.+,=')`, `["This", "is", "a", "test", "string"]`},
+		// nested functions
+		{`States.ArrayLength(States.StringSplit($.inputString, ','))`, `5`},
+		{`States.Format('{} items', States.ArrayLength(States.Array(1, 2)))`, `"2 items"`},
+	}
+	for _, test := range tests {
+		value, err := evaluateIntrinsic(t, test.expression, input)
+		u.AssertNilMsg(t, err, "failed to evaluate "+test.expression)
+		assertJsonEquals(t, test.expected, value)
+	}
+
+	// the same seed gives the same number
+	first, err := evaluateIntrinsic(t, `States.MathRandom(1, 999, 42)`, input)
+	u.AssertNil(t, err)
+	second, err := evaluateIntrinsic(t, `States.MathRandom(1, 999, 42)`, input)
+	u.AssertNil(t, err)
+	u.AssertEquals(t, first, second)
+
+	uuid, err := evaluateIntrinsic(t, `States.UUID()`, input)
+	u.AssertNil(t, err)
+	uuidRegexp := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	u.AssertTrueMsg(t, uuidRegexp.MatchString(uuid.(string)), "invalid uuid "+uuid.(string))
+}
+
+func TestMalformedIntrinsicFunctions(t *testing.T) {
+	tests := []struct {
+		expression string
+		position   int
+	}{
+		{`States.Unknown(1)`, 0},
+		{`States.Format`, 13},
+		{`States.Format('{}', $.name`, 26},
+		{`States.Format('unterminated)`, 14},
+		{`States.Format('{}' $.name)`, 19},
+		{`States.Format('{}', name)`, 20},
+		{`States.Format('\n')`, 15},
+		{`States.ArrayLength()`, 0},
+		{`States.MathAdd(1, 2, 3)`, 0},
+		{`States.ArrayLength($.items) + 1`, 28},
+		{`States.ArrayLength($..items)`, 19},
+		{`States.Array(1, , 2)`, 16},
+		{`States.Array(States.Nested())`, 13},
+	}
+	for _, test := range tests {
+		_, err := asl.ParseIntrinsicFunction(test.expression)
+		var syntaxErr *asl.IntrinsicSyntaxError
+		u.AssertTrueMsg(t, errors.As(err, &syntaxErr), "expected a syntax error for "+test.expression)
+		u.AssertEquals(t, test.position, syntaxErr.Position)
+	}
+}
+
+func TestIntrinsicFunctionsErrors(t *testing.T) {
+	input := unmarshalJson(t, `{"array": [1, 2], "object": {"a": 1}, "text": "a"}`)
+	invalidCalls := []string{
+		`States.Format('{} and {}', 1)`,
+		`States.Format('{}', 1, 2)`,
+		`States.Format('{}', $.array)`,
+		`States.StringToJson('{')`,
+		`States.ArrayPartition($.array, 0)`,
+		`States.ArrayGetItem($.array, 2)`,
+		`States.ArrayLength($.text)`,
+		`States.ArrayRange(1, 2000, 1)`,
+		`States.ArrayRange(1, 5, 0)`,
+		`States.Base64Decode('not base64!')`,
+		`States.Hash($.text, 'SHA-3')`,
+		`States.JsonMerge($.object, $.object, true)`,
+		`States.MathAdd(1.5, 1)`,
+		`States.MathRandom(10, 1)`,
+		`States.ArrayLength($.missing)`,
+	}
+	for _, expression := range invalidCalls {
+		_, err := evaluateIntrinsic(t, expression, input)
+		u.AssertNonNil(t, err)
+	}
+}
+
+func TestPayloadTemplateWithIntrinsicFunctions(t *testing.T) {
+	template := asl.NewPayloadTemplate(`{"greeting.$": "States.Format('Hello, {}', $.name)", "length.$": "States.ArrayLength($.items)"}`)
+	u.AssertNil(t, template.Validate())
+
+	payload, err := template.Apply(unmarshalJson(t, `{"name": "Foo", "items": [1, 2, 3]}`), nil)
+	u.AssertNil(t, err)
+	assertJsonEquals(t, `{"greeting": "Hello, Foo", "length": 3}`, payload)
+
+	u.AssertNonNil(t, asl.NewPayloadTemplate(`{"value.$": "States.Format('{}'"}`).Validate())
+	u.AssertNonNil(t, asl.NewPayloadTemplate(`{"value.$": "States.Unknown()"}`).Validate())
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	u "github.com/serverledge-faas/serverledge/utils"
+)
+
+func unmarshalJson(t *testing.T, src string) interface{} {
+	var value interface{}
+	err := json.Unmarshal([]byte(src), &value)
+	u.AssertNilMsg(t, err, "invalid json in test")
+	return value
+}
+
+func TestPathEvaluate(t *testing.T) {
+	input := unmarshalJson(t, `{
+		"order": {"id": 7, "items": [{"name": "a", "qty": 1}, {"name": "b", "qty": 2}]},
+		"special key": true
+	}`)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"", `{"order": {"id": 7, "items": [{"name": "a", "qty": 1}, {"name": "b", "qty": 2}]}, "special key": true}`},
+		{"$", `{"order": {"id": 7, "items": [{"name": "a", "qty": 1}, {"name": "b", "qty": 2}]}, "special key": true}`},
+		{"$.order.id", `7`},
+		{"$['special key']", `true`},
+		{"$.order.items[1].name", `"b"`},
+		{"$.order.items[-1].qty", `2`},
+		{"$.order.items[*].qty", `[1, 2]`},
+		{"$.order.items[*]['name']", `["a", "b"]`},
+		{"$.order.*", `[7, [{"name": "a", "qty": 1}, {"name": "b", "qty": 2}]]`},
+	}
+	for _, test := range tests {
+		value, err := asl.Path(test.path).Evaluate(input)
+		u.AssertNilMsg(t, err, "failed to evaluate "+test.path)
+		assertJsonEquals(t, test.expected, value)
+	}
+
+	for _, missing := range []string{"$.customer", "$.order.items[2]", "$.order.id.value"} {
+		_, err := asl.Path(missing).Evaluate(input)
+		u.AssertNonNil(t, err)
+	}
+}
+
+func TestPathSet(t *testing.T) {
+	input := unmarshalJson(t, `{"order": {"id": 7}}`)
+
+	output, err := asl.Path("$.result.total").Set(input, 10)
+	u.AssertNil(t, err)
+	assertJsonEquals(t, `{"order": {"id": 7}, "result": {"total": 10}}`, output)
+	// the input is not modified
+	assertJsonEquals(t, `{"order": {"id": 7}}`, input)
+
+	output, err = asl.Path("$.order.id").Set(input, "x")
+	u.AssertNil(t, err)
+	assertJsonEquals(t, `{"order": {"id": "x"}}`, output)
+
+	// the whole input is replaced
+	output, err = asl.Path("$").Set(input, []interface{}{1, 2})
+	u.AssertNil(t, err)
+	assertJsonEquals(t, `[1, 2]`, output)
+
+	// reference paths cannot contain wildcards
+	_, err = asl.Path("$.order[*]").Set(input, 1)
+	u.AssertNonNil(t, err)
+}
+
+func TestPayloadTemplateApply(t *testing.T) {
+	template := asl.NewPayloadTemplate(`{
+		"id.$": "$.order.id",
+		"kind": "order",
+		"names.$": "$.order.items[*].name",
+		"nested": {"execution.$": "$$.Execution.Id", "fixed": [1, 2]}
+	}`)
+	u.AssertNil(t, template.Validate())
+
+	input := unmarshalJson(t, `{"order": {"id": 7, "items": [{"name": "a"}, {"name": "b"}]}}`)
+	context := unmarshalJson(t, `{"Execution": {"Id": "req-1"}}`)
+
+	payload, err := template.Apply(input, context)
+	u.AssertNil(t, err)
+	assertJsonEquals(t, `{"id": 7, "kind": "order", "names": ["a", "b"], "nested": {"execution": "req-1", "fixed": [1, 2]}}`, payload)
+
+	// the path of a dynamic field must exist in the input
+	_, err = asl.NewPayloadTemplate(`{"id.$": "$.customer.id"}`).Apply(input, context)
+	u.AssertNonNil(t, err)
+}
+
+func TestInvalidPayloadTemplates(t *testing.T) {
+	invalidTemplates := []string{
+		`[1, 2]`,
+		`{"id.$": 1}`,
+		`{"id.$": "order.id"}`,
+		`{"id.$": "$..id"}`,
+		`{"nested": {"id.$": "$.order["}}`,
+	}
+	for _, template := range invalidTemplates {
+		u.AssertNonNil(t, asl.NewPayloadTemplate(template).Validate())
+	}
+}
+
+// assertJsonEquals checks that value, once encoded in JSON, is equal to the expected JSON
+func assertJsonEquals(t *testing.T, expected string, value interface{}) {
+	encoded, err := json.Marshal(value)
+	u.AssertNil(t, err)
+	u.AssertTrueMsg(t, reflect.DeepEqual(unmarshalJson(t, expected), unmarshalJson(t, string(encoded))),
+		fmt.Sprintf("expected %s, got %s", expected, encoded))
+}
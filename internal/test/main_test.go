@@ -22,6 +22,7 @@ import (
 	"github.com/serverledge-faas/serverledge/internal/scheduling"
 	"github.com/serverledge-faas/serverledge/internal/workflow"
 	u "github.com/serverledge-faas/serverledge/utils"
+	"github.com/spf13/viper"
 	"google.golang.org/grpc/codes"
 )
 
@@ -90,6 +91,12 @@ func TestMain(m *testing.M) {
 		log.Fatalf("test cannot be executed without internet connection")
 	}
 
+	// the container factory can be selected through the environment, e.g., "process" to run the tests without Docker
+	if factory, ok := os.LookupEnv("SERVERLEDGE_TEST_FACTORY"); ok {
+		viper.Set(config.FACTORY_TYPE, factory)
+		viper.Set(config.FACTORY_PROCESS_IMAGES_DIR, "../../images")
+	}
+
 	// TODO: avoid full setup if testing.Short()
 
 	echoServer, ok := setupServerledge(outboundIp.String())
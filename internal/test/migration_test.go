@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/function"
+	u "github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// TestMigrateLegacyKeys checks that the keys saved before namespaces were introduced are moved to the default one
+func TestMigrateLegacyKeys(t *testing.T) {
+	// it's an integration test because it needs etcd
+	if testing.Short() {
+		t.Skip()
+	}
+	cli, err := u.GetEtcdClient()
+	u.AssertNil(t, err)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		for _, key := range []string{"/function/legacyfn", "/function/default/legacyfn", "async/legacy-req",
+			"async/default/legacy-req", "/workflow/legacywf", "/workflow/default/legacywf"} {
+			_, _ = cli.Delete(ctx, key)
+		}
+	})
+
+	legacyFunction := function.Function{Name: "legacyfn", Runtime: "python314", MemoryMB: PY_MEMORY, Handler: "inc.handler"}
+	payload, err := json.Marshal(legacyFunction)
+	u.AssertNil(t, err)
+	_, err = cli.Put(ctx, "/function/legacyfn", string(payload))
+	u.AssertNil(t, err)
+
+	lease, err := cli.Grant(ctx, 60)
+	u.AssertNil(t, err)
+	_, err = cli.Put(ctx, "async/legacy-req", `{"Success":true}`, clientv3.WithLease(lease.ID))
+	u.AssertNil(t, err)
+
+	// a workflow re-created after the upgrade is not overwritten by the legacy one
+	_, err = cli.Put(ctx, "/workflow/legacywf", "old")
+	u.AssertNil(t, err)
+	_, err = cli.Put(ctx, "/workflow/default/legacywf", "new")
+	u.AssertNil(t, err)
+
+	err = function.MigrateLegacyKeys()
+	u.AssertNil(t, err)
+
+	f, found := function.GetFunction("legacyfn")
+	u.AssertTrueMsg(t, found, "the legacy function should be found in the default namespace")
+	u.AssertEquals(t, function.DefaultNamespace, f.GetNamespace())
+	resp, err := cli.Get(ctx, "/function/legacyfn")
+	u.AssertNil(t, err)
+	u.AssertEqualsMsg(t, int64(0), resp.Count, "the legacy key should be deleted")
+
+	resp, err = cli.Get(ctx, "async/default/legacy-req")
+	u.AssertNil(t, err)
+	u.AssertEqualsMsg(t, int64(1), resp.Count, "the legacy async result should be moved")
+	u.AssertEqualsMsg(t, int64(lease.ID), resp.Kvs[0].Lease, "the async result should keep its lease")
+
+	resp, err = cli.Get(ctx, "/workflow/default/legacywf")
+	u.AssertNil(t, err)
+	u.AssertEquals(t, "new", string(resp.Kvs[0].Value))
+	resp, err = cli.Get(ctx, "/workflow/legacywf")
+	u.AssertNil(t, err)
+	u.AssertEqualsMsg(t, int64(1), resp.Count, "conflicting legacy keys should be kept")
+
+	// the migration can be repeated at every startup
+	err = function.MigrateLegacyKeys()
+	u.AssertNil(t, err)
+	f, found = function.GetFunction("legacyfn")
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, "legacyfn", f.Name)
+}
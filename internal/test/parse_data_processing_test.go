@@ -0,0 +1,103 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/utils"
+)
+
+func TestParseDataProcessing(t *testing.T) {
+
+	src := []byte(`{
+		"StartAt": "Prepare",
+		"States": {
+			"Prepare": {
+				"Type": "Pass",
+				"Result": {"increment": 1},
+				"ResultPath": "$.config",
+				"Next": "Inc"
+			},
+			"Inc": {
+				"Type": "Task",
+				"Resource": "inc",
+				"InputPath": "$.order",
+				"Parameters": {"input.$": "$.value","execution.$": "$$.Execution.Id"},
+				"ResultSelector": {"value.$": "$.result"},
+				"ResultPath": "$.order",
+				"OutputPath": "$.order",
+				"Next": "Done"
+			},
+			"Done": {
+				"Type": "Pass",
+				"Result": "done",
+				"ResultPath": null,
+				"End": true
+			}
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("data", src)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+
+	prepare := asl.NewEmptyPass()
+	prepare.Result = `{"increment": 1}`
+	prepare.ResultPath = "$.config"
+	prepare.Next = "Inc"
+
+	inc := asl.NewNonTerminalTask("inc", "Done")
+	inc.InputPath = "$.order"
+	inc.Parameters = asl.NewPayloadTemplate(`{"input.$": "$.value","execution.$": "$$.Execution.Id"}`)
+	inc.ResultSelector = asl.NewPayloadTemplate(`{"value.$": "$.result"}`)
+	inc.ResultPath = "$.order"
+	inc.OutputPath = "$.order"
+
+	done := asl.NewEmptyPass()
+	done.Result = `"done"`
+	done.ResultPath = asl.NullPath
+	done.End = true
+
+	smExpected := &asl.StateMachine{
+		StartAt: "Prepare",
+		Version: "1.0",
+		Name:    "data",
+		States: map[string]asl.State{
+			"Prepare": prepare,
+			"Inc":     inc,
+			"Done":    done,
+		},
+	}
+	utils.AssertTrueMsg(t, smExpected.Equals(sm), "state machines differs")
+	utils.AssertNil(t, sm.Validate(sm.GetAllStateNames()))
+}
+
+func TestParseInvalidDataProcessing(t *testing.T) {
+	invalidFields := []string{
+		// unterminated brackets
+		`"InputPath": "$.order["`,
+		// reference paths cannot contain wildcards
+		`"ResultPath": "$.items[*]"`,
+		// recursive descent is not supported
+		`"OutputPath": "$..value"`,
+		// templates must be objects
+		`"Parameters": "$.value"`,
+		// dynamic fields must contain paths
+		`"ResultSelector": {"value.$": 1}`,
+	}
+
+	for _, field := range invalidFields {
+		src := strings.Replace(`{
+			"StartAt": "Inc",
+			"States": {
+				"Inc": { "Type": "Task", "Resource": "inc", FIELD, "End": true }
+			}
+		}`, "FIELD", field, 1)
+
+		sm, err := asl.ParseFrom("invalid", []byte(src))
+		if err == nil {
+			err = sm.Validate(sm.GetAllStateNames())
+		}
+		utils.AssertNonNil(t, err)
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/utils"
+)
+
+func TestParseMap(t *testing.T) {
+
+	mapSrc := []byte(`{
+		"StartAt": "IncEach",
+		"States": {
+			"IncEach": {
+				"Type": "Map",
+				"ItemsPath": "$.numbers",
+				"MaxConcurrency": 2,
+				"ItemProcessor": {
+					"StartAt": "Inc",
+					"States": {
+						"Inc": { "Type": "Task", "Resource": "inc", "End": true }
+					}
+				},
+				"End": true
+			}
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("map", mapSrc)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+
+	expectedMap := asl.NewEmptyMap()
+	expectedMap.ItemsPath = "$.numbers"
+	expectedMap.MaxConcurrency = 2
+	expectedMap.End = true
+	expectedMap.ItemProcessor = &asl.StateMachine{
+		StartAt: "Inc",
+		Version: "1.0",
+		Name:    "ItemProcessor",
+		States: map[string]asl.State{
+			"Inc": asl.NewTerminalTask("inc"),
+		},
+	}
+
+	smExpected := &asl.StateMachine{
+		StartAt: "IncEach",
+		Version: "1.0",
+		Name:    "map",
+		States: map[string]asl.State{
+			"IncEach": expectedMap,
+		},
+	}
+	utils.AssertTrueMsg(t, smExpected.Equals(sm), "state machines differs")
+	utils.AssertNil(t, sm.Validate(sm.GetAllStateNames()))
+	utils.AssertEquals(t, 1, len(sm.States["IncEach"].(*asl.MapState).GetResources()))
+}
+
+// TestParseMapWithIterator checks that the deprecated Iterator field is accepted in place of ItemProcessor
+func TestParseMapWithIterator(t *testing.T) {
+
+	mapSrc := []byte(`{
+		"StartAt": "IncEach",
+		"States": {
+			"IncEach": {
+				"Type": "Map",
+				"Iterator": {
+					"StartAt": "Inc",
+					"States": {
+						"Inc": { "Type": "Task", "Resource": "inc", "End": true }
+					}
+				},
+				"Next": "Last"
+			},
+			"Last": { "Type": "Task", "Resource": "inc", "End": true }
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("map", mapSrc)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+	mapState := sm.States["IncEach"].(*asl.MapState)
+	utils.AssertNonNil(t, mapState.ItemProcessor)
+	utils.AssertEquals(t, "Inc", mapState.ItemProcessor.StartAt)
+	utils.AssertEquals(t, uint32(0), mapState.MaxConcurrency)
+	utils.AssertNil(t, sm.Validate(sm.GetAllStateNames()))
+}
+
+func TestParseMapWithoutItemProcessor(t *testing.T) {
+
+	mapSrc := []byte(`{
+		"StartAt": "IncEach",
+		"States": {
+			"IncEach": { "Type": "Map", "End": true }
+		}
+	}`)
+
+	_, err := asl.ParseFrom("map", mapSrc)
+	utils.AssertNonNil(t, err)
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/utils"
+)
+
+func TestParseParallel(t *testing.T) {
+
+	parallel := []byte(`{
+		"Comment": "A state machine with a parallel state",
+		"StartAt": "Parallel",
+		"States": {
+			"Parallel": {
+				"Type": "Parallel",
+				"Branches": [
+					{
+						"StartAt": "Inc",
+						"States": {
+							"Inc": { "Type": "Task", "Resource": "inc", "End": true }
+						}
+					},
+					{
+						"StartAt": "Double",
+						"States": {
+							"Double": { "Type": "Task", "Resource": "double", "Next": "IncAfterDouble" },
+							"IncAfterDouble": { "Type": "Task", "Resource": "inc", "End": true }
+						}
+					}
+				],
+				"Next": "Last"
+			},
+			"Last": { "Type": "Task", "Resource": "hello", "End": true }
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("parallel", parallel)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+
+	expectedParallel := asl.NewEmptyParallel()
+	expectedParallel.Next = "Last"
+	expectedParallel.Branches = []*asl.StateMachine{
+		{
+			StartAt: "Inc",
+			Version: "1.0",
+			Name:    "branch0",
+			States: map[string]asl.State{
+				"Inc": asl.NewTerminalTask("inc"),
+			},
+		},
+		{
+			StartAt: "Double",
+			Version: "1.0",
+			Name:    "branch1",
+			States: map[string]asl.State{
+				"Double":         asl.NewNonTerminalTask("double", "IncAfterDouble"),
+				"IncAfterDouble": asl.NewTerminalTask("inc"),
+			},
+		},
+	}
+
+	smExpected := &asl.StateMachine{
+		StartAt: "Parallel",
+		Comment: "A state machine with a parallel state",
+		Version: "1.0",
+		Name:    "parallel",
+		States: map[string]asl.State{
+			"Parallel": expectedParallel,
+			"Last":     asl.NewTerminalTask("hello"),
+		},
+	}
+	utils.AssertTrueMsg(t, smExpected.Equals(sm), "state machines differs")
+	utils.AssertNil(t, sm.States["Parallel"].(*asl.ParallelState).Validate(sm.GetAllStateNames()))
+	utils.AssertEquals(t, 3, len(sm.States["Parallel"].(*asl.ParallelState).GetResources()))
+}
+
+func TestParseParallelWithoutBranches(t *testing.T) {
+
+	parallel := []byte(`{
+		"StartAt": "Parallel",
+		"States": {
+			"Parallel": { "Type": "Parallel", "Branches": [], "End": true }
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("parallel", parallel)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+	utils.AssertNonNil(t, sm.Validate(sm.GetAllStateNames()))
+}
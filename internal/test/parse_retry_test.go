@@ -0,0 +1,80 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/utils"
+)
+
+func TestParseRetryAndCatch(t *testing.T) {
+
+	src := []byte(`{
+		"StartAt": "Divide",
+		"States": {
+			"Divide": {
+				"Type": "Task",
+				"Resource": "inc",
+				"Retry": [
+					{ "ErrorEquals": ["States.Timeout"], "IntervalSeconds": 3, "BackoffRate": 1.5, "MaxAttempts": 2 },
+					{ "ErrorEquals": ["States.ALL"] }
+				],
+				"Catch": [
+					{ "ErrorEquals": ["ZeroDivisionError", "States.TaskFailed"], "ResultPath": "$.error", "Next": "Recover" }
+				],
+				"End": true
+			},
+			"Recover": { "Type": "Task", "Resource": "inc", "End": true }
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("retry", src)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+
+	divide := asl.NewTerminalTask("inc")
+	divide.Retry = []asl.Retrier{
+		{ErrorEquals: []string{asl.ErrorTimeout}, IntervalSeconds: 3, BackoffRate: 1.5, MaxAttempts: 2},
+		asl.NewRetrier(asl.ErrorAll), // default values
+	}
+	divide.Catch = []asl.Catcher{
+		{ErrorEquals: []string{"ZeroDivisionError", asl.ErrorTaskFailed}, ResultPath: "$.error", Next: "Recover"},
+	}
+
+	smExpected := &asl.StateMachine{
+		StartAt: "Divide",
+		Version: "1.0",
+		Name:    "retry",
+		States: map[string]asl.State{
+			"Divide":  divide,
+			"Recover": asl.NewTerminalTask("inc"),
+		},
+	}
+	utils.AssertTrueMsg(t, smExpected.Equals(sm), "state machines differs")
+	utils.AssertNil(t, sm.Validate(sm.GetAllStateNames()))
+}
+
+func TestParseInvalidRetryAndCatch(t *testing.T) {
+	invalidFields := []string{
+		// empty ErrorEquals
+		`"Retry": [ { "ErrorEquals": [] } ]`,
+		// States.ALL is not alone
+		`"Retry": [ { "ErrorEquals": ["States.ALL", "States.Timeout"] } ]`,
+		// States.ALL is not in the last retrier
+		`"Retry": [ { "ErrorEquals": ["States.ALL"] }, { "ErrorEquals": ["States.Timeout"] } ]`,
+		// invalid BackoffRate
+		`"Retry": [ { "ErrorEquals": ["States.ALL"], "BackoffRate": 0.5 } ]`,
+		// Next is not a state
+		`"Catch": [ { "ErrorEquals": ["States.ALL"], "Next": "Missing" } ]`,
+		// States.ALL is not in the last catcher
+		`"Catch": [ { "ErrorEquals": ["States.ALL"], "Next": "Task" }, { "ErrorEquals": ["States.Timeout"], "Next": "Task" } ]`,
+	}
+
+	for _, field := range invalidFields {
+		src := []byte(`{ "StartAt": "Task", "States": { "Task": { "Type": "Task", "Resource": "inc", ` + field + `, "End": true } } }`)
+		sm, err := asl.ParseFrom("retry", src)
+		if err == nil {
+			err = sm.Validate(sm.GetAllStateNames())
+		}
+		utils.AssertNonNil(t, err)
+	}
+}
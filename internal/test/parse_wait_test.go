@@ -0,0 +1,78 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/utils"
+)
+
+func TestParseWait(t *testing.T) {
+
+	waitSrc := []byte(`{
+		"StartAt": "WaitTenSeconds",
+		"States": {
+			"WaitTenSeconds": { "Type": "Wait", "Seconds": 10, "Next": "WaitForDelay" },
+			"WaitForDelay": { "Type": "Wait", "SecondsPath": "$.delay", "Next": "WaitUntil" },
+			"WaitUntil": { "Type": "Wait", "Timestamp": "2026-03-14T01:59:00Z", "Next": "WaitForDeadline" },
+			"WaitForDeadline": { "Type": "Wait", "TimestampPath": "$.deadline", "End": true }
+		}
+	}`)
+
+	sm, err := asl.ParseFrom("wait", waitSrc)
+	utils.AssertNilMsg(t, err, "failed to parse state machine")
+
+	seconds := asl.NewEmptyWait()
+	seconds.Seconds = 10
+	seconds.Next = "WaitForDelay"
+	secondsPath := asl.NewEmptyWait()
+	secondsPath.SecondsPath = "$.delay"
+	secondsPath.Next = "WaitUntil"
+	timestamp := asl.NewEmptyWait()
+	timestamp.Timestamp = "2026-03-14T01:59:00Z"
+	timestamp.Next = "WaitForDeadline"
+	timestampPath := asl.NewEmptyWait()
+	timestampPath.TimestampPath = "$.deadline"
+	timestampPath.End = true
+
+	smExpected := &asl.StateMachine{
+		StartAt: "WaitTenSeconds",
+		Version: "1.0",
+		Name:    "wait",
+		States: map[string]asl.State{
+			"WaitTenSeconds":  seconds,
+			"WaitForDelay":    secondsPath,
+			"WaitUntil":       timestamp,
+			"WaitForDeadline": timestampPath,
+		},
+	}
+	utils.AssertTrueMsg(t, smExpected.Equals(sm), "state machines differs")
+	utils.AssertNil(t, sm.Validate(sm.GetAllStateNames()))
+}
+
+func TestParseInvalidWait(t *testing.T) {
+	invalidStates := []string{
+		// no duration
+		`{ "Type": "Wait", "End": true }`,
+		// more than one duration
+		`{ "Type": "Wait", "Seconds": 5, "SecondsPath": "$.delay", "End": true }`,
+		// invalid timestamp
+		`{ "Type": "Wait", "Timestamp": "tomorrow", "End": true }`,
+		// the path does not identify a single value
+		`{ "Type": "Wait", "SecondsPath": "$.delays[*]", "End": true }`,
+		`{ "Type": "Wait", "TimestampPath": "$..deadline", "End": true }`,
+	}
+
+	for _, state := range invalidStates {
+		src := []byte(`{ "StartAt": "Wait", "States": { "Wait": ` + state + ` } }`)
+		sm, err := asl.ParseFrom("wait", src)
+		if err == nil {
+			err = sm.Validate(sm.GetAllStateNames())
+		}
+		utils.AssertNonNil(t, err)
+	}
+
+	// Seconds must be positive
+	_, err := asl.ParseFrom("wait", []byte(`{ "StartAt": "Wait", "States": { "Wait": { "Type": "Wait", "Seconds": -1, "End": true } } }`))
+	utils.AssertNonNil(t, err)
+}
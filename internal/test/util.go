@@ -12,17 +12,24 @@ import (
 
 	"github.com/serverledge-faas/serverledge/internal/cli"
 	"github.com/serverledge-faas/serverledge/internal/client"
+	"github.com/serverledge-faas/serverledge/internal/container"
 	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/workflow"
 	"github.com/serverledge-faas/serverledge/utils"
 )
 
 const PY_MEMORY = 20
-const JS_MEMORY = 50
+const JS_MEMORY = 128 // handlers run in worker threads, each with a heap of its own
 const JAVA_MEMORY = 100
 const X86 = "amd64"
 const ARM = "arm64"
 
+// isRuntimeSupported returns false for the runtimes that the process container factory cannot run (e.g., java21)
+func isRuntimeSupported(runtime string) bool {
+	factory, isProcess := container.GetFactory().(*container.ProcessFactory)
+	return !isProcess || factory.HasImage(container.RuntimeToInfo[runtime].Image)
+}
+
 func initializeExamplePyFunction() (*function.Function, error) {
 	oldF, found := function.GetFunction("inc")
 	if found {
@@ -408,3 +415,10 @@ func GetIntSingleResult(cer *workflow.ExecutionReport) (int, error) {
 	}
 	return 0, fmt.Errorf("there is not exactly one result: there are %d result(s)", len(cer.Result))
 }
+
+// mustCreateSequenceWorkflow is like CreateSequenceWorkflow, but fails the test in case of errors
+func mustCreateSequenceWorkflow(t *testing.T, funcs ...*function.Function) *workflow.Workflow {
+	wflow, err := CreateSequenceWorkflow(funcs...)
+	utils.AssertNil(t, err)
+	return wflow
+}
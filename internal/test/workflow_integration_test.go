@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/lithammer/shortuuid"
 	"github.com/serverledge-faas/serverledge/internal/function"
@@ -44,7 +45,7 @@ func TestComposeFC(t *testing.T) {
 	}
 
 	// GET1 - initially we do not have any function composition
-	funcs, err := workflow.GetAllWorkflows()
+	funcs, err := workflow.GetAllWorkflows(function.DefaultNamespace)
 	lenFuncs := len(funcs)
 	u.AssertNil(t, err)
 
@@ -66,7 +67,7 @@ func TestComposeFC(t *testing.T) {
 
 	// The creation is successful: we have one more function composition?
 	// GET2
-	funcs2, err3 := workflow.GetAllWorkflows()
+	funcs2, err3 := workflow.GetAllWorkflows(function.DefaultNamespace)
 	u.AssertNil(t, err3)
 	u.AssertEqualsMsg(t, lenFuncs+1, len(funcs2), "creation of function failed")
 
@@ -81,7 +82,7 @@ func TestComposeFC(t *testing.T) {
 
 	// The deletion is successful?
 	// GET3
-	funcs3, err5 := workflow.GetAllWorkflows()
+	funcs3, err5 := workflow.GetAllWorkflows(function.DefaultNamespace)
 	u.AssertNil(t, err5)
 	u.AssertEqualsMsg(t, len(funcs3), lenFuncs, "deletion of function failed")
 }
@@ -537,3 +538,257 @@ func TestResumeWorkflow(t *testing.T) {
 	err3 := wflow.Delete()
 	u.AssertNil(t, err3)
 }
+
+// TestInvokeParallelFC executes a Workflow with two parallel branches, whose outputs are joined in an array
+func TestInvokeParallelFC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	incPy, errPy := initializeExamplePyFunction()
+	u.AssertNil(t, errPy)
+	doublePy, errDp := InitializePyFunction("double", "handler", function.NewSignature().
+		AddInput("input", function.Int{}).
+		AddOutput("result", function.Int{}).Build())
+	u.AssertNil(t, errDp)
+
+	wflow, err := workflow.NewBuilder().
+		AddParallelNode().
+		NextBranch(CreateSequenceWorkflow(incPy)).
+		NextBranch(CreateSequenceWorkflow(doublePy, incPy)).
+		EndParallel().
+		Build()
+	u.AssertNil(t, err)
+	wflow.Name = "parallel"
+	err1 := wflow.Save()
+	u.AssertNil(t, err1)
+
+	params := make(map[string]interface{})
+	params[incPy.Signature.GetInputs()[0].Name] = 2
+
+	request := workflow.NewRequest(shortuuid.New(), wflow, params, approximateMapSize(params))
+	request.CanDoOffloading = false
+	err2 := wflow.Invoke(request)
+	u.AssertNil(t, err2)
+
+	// the output of each branch is in the same position as the branch: [2+1, 2*2+1]
+	results, ok := request.ExecReport.Result[workflow.JoinedResultsKey].([]interface{})
+	u.AssertTrueMsg(t, ok, "joined results not found")
+	u.AssertEquals(t, 2, len(results))
+	u.AssertEquals(t, 3, cast.ToInt(results[0].(map[string]interface{})["result"]))
+	u.AssertEquals(t, 5, cast.ToInt(results[1].(map[string]interface{})["result"]))
+	// one report for each function task
+	u.AssertEquals(t, 3, len(request.ExecReport.Reports))
+
+	err3 := wflow.Delete()
+	u.AssertNil(t, err3)
+}
+
+// TestInvokeMapFC executes a Workflow with a map node, which increments each item of an array
+func TestInvokeMapFC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	incPy, errPy := initializeExamplePyFunction()
+	u.AssertNil(t, errPy)
+
+	iterator, err := CreateSequenceWorkflow(incPy)
+	u.AssertNil(t, err)
+	wflow, err := workflow.NewBuilder().
+		AddMapNode(iterator, "numbers", 2).
+		Build()
+	u.AssertNil(t, err)
+	wflow.Name = "map"
+	err1 := wflow.Save()
+	u.AssertNil(t, err1)
+
+	params := make(map[string]interface{})
+	params["numbers"] = []int{1, 2, 3, 4, 5}
+
+	request := workflow.NewRequest(shortuuid.New(), wflow, params, approximateMapSize(params))
+	request.CanDoOffloading = false
+	err2 := wflow.Invoke(request)
+	u.AssertNil(t, err2)
+
+	// the results are in the same order as the items
+	results, ok := request.ExecReport.Result[workflow.JoinedResultsKey].([]interface{})
+	u.AssertTrueMsg(t, ok, "joined results not found")
+	u.AssertEquals(t, 5, len(results))
+	for i, result := range results {
+		u.AssertEquals(t, i+2, cast.ToInt(result.(map[string]interface{})["result"]))
+	}
+	u.AssertEquals(t, 5, len(request.ExecReport.Reports))
+
+	err3 := wflow.Delete()
+	u.AssertNil(t, err3)
+}
+
+// TestInvokeMapItemsPathFC executes a Workflow with a map node over an array selected by a JSONPath
+func TestInvokeMapItemsPathFC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	incPy, errPy := initializeExamplePyFunction()
+	u.AssertNil(t, errPy)
+
+	iterator, err := CreateSequenceWorkflow(incPy)
+	u.AssertNil(t, err)
+	wflow, err := workflow.NewBuilder().
+		AddMapNode(iterator, "$.data.batches[-1]", 0).
+		Build()
+	u.AssertNil(t, err)
+
+	params := map[string]interface{}{
+		"data": map[string]interface{}{
+			"batches": []interface{}{[]interface{}{10}, []interface{}{1, 2, 3}},
+		},
+	}
+	request := workflow.NewRequest(shortuuid.New(), wflow, params, approximateMapSize(params))
+	request.CanDoOffloading = false
+	err2 := wflow.Invoke(request)
+	u.AssertNil(t, err2)
+
+	results, ok := request.ExecReport.Result[workflow.JoinedResultsKey].([]interface{})
+	u.AssertTrueMsg(t, ok, "joined results not found")
+	u.AssertEquals(t, 3, len(results))
+	for i, result := range results {
+		u.AssertEquals(t, i+2, cast.ToInt(result.(map[string]interface{})["result"]))
+	}
+}
+
+// TestInvokeDataPathsFC executes a Workflow whose function reads its input from a nested object, and whose result is
+// placed next to the input, as defined by the data paths of the task
+func TestInvokeDataPathsFC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	incPy, errPy := initializeExamplePyFunction()
+	u.AssertNil(t, errPy)
+
+	wflow, err := workflow.NewBuilder().
+		AddFunctionTask(incPy).
+		SetDataPaths(workflow.DataPaths{
+			InputPath:      "$.order",
+			Parameters:     `{"input.$": "$.quantity"}`,
+			ResultSelector: `{"quantity.$": "$.result"}`,
+			ResultPath:     "$.updated",
+		}).
+		Build()
+	u.AssertNil(t, err)
+	wflow.Name = "data_paths"
+	err1 := wflow.Save()
+	u.AssertNil(t, err1)
+
+	params := map[string]interface{}{
+		"order": map[string]interface{}{"id": "A1", "quantity": 2},
+	}
+
+	request := workflow.NewRequest(shortuuid.New(), wflow, params, approximateMapSize(params))
+	request.CanDoOffloading = false
+	err2 := wflow.Invoke(request)
+	u.AssertNil(t, err2)
+
+	// the input is passed on, with the selected result at ResultPath
+	order, ok := request.ExecReport.Result["order"].(map[string]interface{})
+	u.AssertTrueMsg(t, ok, "input not found in the output")
+	u.AssertEquals(t, "A1", order["id"])
+	updated, ok := request.ExecReport.Result["updated"].(map[string]interface{})
+	u.AssertTrueMsg(t, ok, "result not found at ResultPath")
+	u.AssertEquals(t, 3, cast.ToInt(updated["quantity"]))
+
+	err3 := wflow.Delete()
+	u.AssertNil(t, err3)
+}
+
+// TestInvokeWaitFC checks that a Wait state suspends the workflow, which is resumed by a timer and completed asynchronously
+func TestInvokeWaitFC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	incPy, errPy := initializeExamplePyFunction()
+	u.AssertNil(t, errPy)
+
+	wflow, err := workflow.NewBuilder().
+		AddFunctionTask(incPy).
+		AddWaitNode(2).
+		AddFunctionTask(incPy).
+		Build()
+	u.AssertNil(t, err)
+	// the workflow is retrieved by name when resumed
+	wflow.Name = "wait"
+	err1 := wflow.Save()
+	u.AssertNil(t, err1)
+
+	params := make(map[string]interface{})
+	params["input"] = 0
+
+	request := workflow.NewRequest(shortuuid.New(), wflow, params, approximateMapSize(params))
+	request.CanDoOffloading = false
+	start := time.Now()
+	err2 := wflow.Invoke(request)
+	u.AssertNil(t, err2)
+	u.AssertTrueMsg(t, request.Suspended, "the workflow should be suspended while waiting")
+	u.AssertTrue(t, request.ExecReport.Result == nil)
+
+	// the result is published when the timer expires
+	var response workflow.InvocationResponse
+	for i := 0; i < 100; i++ {
+		pollResult := pollWorkflowTest(t, request.Id, HOST, PORT)
+		if json.Unmarshal([]byte(pollResult), &response) == nil {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	u.AssertTrueMsg(t, response.Success, "the resumed workflow did not complete successfully")
+	u.AssertTrue(t, time.Since(start) >= 2*time.Second)
+	u.AssertEquals(t, 2, cast.ToInt(response.Result["result"]))
+	u.AssertEquals(t, 2, len(response.Reports))
+
+	err3 := wflow.Delete()
+	u.AssertNil(t, err3)
+}
+
+// TestInvokeWaitSecondsPathFC checks that the seconds to wait are read from the input at a JSONPath
+func TestInvokeWaitSecondsPathFC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	wait := workflow.NewWaitTask()
+	wait.SecondsPath = "$.delays[1]"
+	wflow, err := workflow.NewBuilder().
+		AddWaitTask(wait).
+		AddPassNodeWithId("", "Done").
+		Build()
+	u.AssertNil(t, err)
+	wflow.Name = "waitpath"
+	err1 := wflow.Save()
+	u.AssertNil(t, err1)
+
+	params := map[string]interface{}{"delays": []interface{}{30, 1}}
+	request := workflow.NewRequest(shortuuid.New(), wflow, params, approximateMapSize(params))
+	request.CanDoOffloading = false
+	start := time.Now()
+	err2 := wflow.Invoke(request)
+	u.AssertNil(t, err2)
+	u.AssertTrueMsg(t, request.Suspended, "the workflow should be suspended while waiting")
+
+	var response workflow.InvocationResponse
+	for i := 0; i < 100; i++ {
+		pollResult := pollWorkflowTest(t, request.Id, HOST, PORT)
+		if json.Unmarshal([]byte(pollResult), &response) == nil {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	u.AssertTrueMsg(t, response.Success, "the resumed workflow did not complete successfully")
+	elapsed := time.Since(start)
+	u.AssertTrueMsg(t, elapsed >= time.Second && elapsed < 30*time.Second, "the second delay should be used")
+
+	err3 := wflow.Delete()
+	u.AssertNil(t, err3)
+}
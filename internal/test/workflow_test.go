@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 
+	"github.com/serverledge-faas/serverledge/internal/asl"
 	"github.com/serverledge-faas/serverledge/internal/workflow"
 	u "github.com/serverledge-faas/serverledge/utils"
 )
@@ -270,3 +272,287 @@ func TestVisit(t *testing.T) {
 	u.AssertEquals(t, 4, len(visitedNodes))
 
 }
+
+// TestParallelWorkflowBuilder checks the structure of a workflow with two parallel branches
+//
+//	[ Start  ]
+//	    |
+//	[ FanOut ]
+//	  |     |
+//	[Simple] [Simple]
+//	  |     |
+//	  |   [Simple]
+//	  |     |
+//	[ FanIn  ]
+//	    |
+//	[ Simple ]
+//	    |
+//	[  End   ]
+func TestParallelWorkflowBuilder(t *testing.T) {
+	f, err := initializeExamplePyFunction()
+	u.AssertNil(t, err)
+	wflow, err := workflow.NewBuilder().
+		AddParallelNodeWithId("parallel").
+		NextBranch(CreateSequenceWorkflow(f)).
+		NextBranch(CreateSequenceWorkflow(f, f)).
+		EndParallel().
+		AddFunctionTask(f).
+		Build()
+	u.AssertNil(t, err)
+	u.AssertEquals(t, 8, len(wflow.Tasks))
+
+	fanOutTask, found := wflow.Find("parallel")
+	u.AssertTrue(t, found)
+	fanOut := fanOutTask.(*workflow.FanOutTask)
+	u.AssertEquals(t, 2, len(fanOut.NextTasks))
+
+	fanInTask, found := wflow.Find("parallel_FanIn")
+	u.AssertTrue(t, found)
+	fanIn := fanInTask.(*workflow.FanInTask)
+	u.AssertEquals(t, 2, fanIn.Branches)
+	u.AssertEquals(t, 2, len(wflow.GetPreviousTasks(fanIn.Id)))
+	for _, prev := range wflow.GetPreviousTasks(fanIn.Id) {
+		_, isBranchEnd := fanIn.BranchOf[prev]
+		u.AssertTrue(t, isBranchEnd)
+	}
+	last, found := wflow.Find(fanIn.NextTask)
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, wflow.End.GetId(), last.(*workflow.FunctionTask).NextTask)
+
+	// all the tasks are reachable from the FanOutTask
+	visitedNodes := workflow.Visit(wflow, fanOut.Id, false)
+	u.AssertEquals(t, len(wflow.Tasks)-1, len(visitedNodes))
+
+	marshal, errMarshal := json.Marshal(wflow)
+	u.AssertNil(t, errMarshal)
+	var retrieved workflow.Workflow
+	errUnmarshal := json.Unmarshal(marshal, &retrieved)
+	u.AssertNil(t, errUnmarshal)
+	u.AssertTrue(t, retrieved.Equals(wflow))
+	retrievedFanIn, _ := retrieved.Find(fanIn.Id)
+	u.AssertTrue(t, reflect.DeepEqual(fanIn.BranchOf, retrievedFanIn.(*workflow.FanInTask).BranchOf))
+}
+
+// TestParallelWorkflowWithEmptyBranch checks that a parallel node cannot have empty branches
+func TestParallelWorkflowWithEmptyBranch(t *testing.T) {
+	f, err := initializeExamplePyFunction()
+	u.AssertNil(t, err)
+	_, err = workflow.NewBuilder().
+		AddParallelNode().
+		NextBranch(CreateSequenceWorkflow(f)).
+		NextBranch(workflow.CreateEmptyWorkflow()).
+		EndParallel().
+		Build()
+	u.AssertNonNil(t, err)
+}
+
+// TestMapWorkflowBuilder checks the structure of a workflow with a map node
+func TestMapWorkflowBuilder(t *testing.T) {
+	f, err := initializeExamplePyFunction()
+	u.AssertNil(t, err)
+	wflow, err := workflow.NewBuilder().
+		AddMapNodeWithId(mustCreateSequenceWorkflow(t, f, f), "numbers", 2, "map").
+		AddFunctionTask(f).
+		Build()
+	u.AssertNil(t, err)
+	u.AssertEquals(t, 4, len(wflow.Tasks))
+
+	mapTask, found := wflow.Find("map")
+	u.AssertTrue(t, found)
+	mapNode := mapTask.(*workflow.MapTask)
+	u.AssertEquals(t, 2, mapNode.MaxConcurrency)
+	u.AssertEqualsMsg(t, "$.numbers", mapNode.ItemsPath, "keys are converted to reference paths")
+	u.AssertEquals(t, 4, len(mapNode.Iterator.Tasks))
+	u.AssertEquals(t, 1, len(wflow.GetUniqueFunctions()))
+
+	marshal, errMarshal := json.Marshal(wflow)
+	u.AssertNil(t, errMarshal)
+	var retrieved workflow.Workflow
+	errUnmarshal := json.Unmarshal(marshal, &retrieved)
+	u.AssertNil(t, errUnmarshal)
+	u.AssertTrue(t, retrieved.Equals(wflow))
+	retrievedMap, _ := retrieved.Find("map")
+	u.AssertTrue(t, mapNode.Iterator.Equals(retrievedMap.(*workflow.MapTask).Iterator))
+
+	// the iterator cannot be empty
+	_, err = workflow.NewBuilder().
+		AddMapNode(mustCreateSequenceWorkflow(t), "numbers", 0).
+		Build()
+	u.AssertNonNil(t, err)
+	// the items path must identify a single array
+	_, err = workflow.NewBuilder().
+		AddMapNode(mustCreateSequenceWorkflow(t, f), "$.batches[*]", 0).
+		Build()
+	u.AssertNonNil(t, err)
+}
+
+func TestWaitWorkflowBuilder(t *testing.T) {
+	f, err := initializeExamplePyFunction()
+	u.AssertNil(t, err)
+	wait := workflow.NewWaitTask()
+	wait.SecondsPath = "delay"
+	wflow, err := workflow.NewBuilder().
+		AddFunctionTask(f).
+		AddWaitNode(5).
+		AddWaitTask(wait).
+		Build()
+	u.AssertNil(t, err)
+	u.AssertEquals(t, 5, len(wflow.Tasks))
+
+	marshal, errMarshal := json.Marshal(wflow)
+	u.AssertNil(t, errMarshal)
+	var retrieved workflow.Workflow
+	errUnmarshal := json.Unmarshal(marshal, &retrieved)
+	u.AssertNil(t, errUnmarshal)
+	u.AssertTrue(t, retrieved.Equals(wflow))
+	retrievedWait, found := retrieved.Find(wait.Id)
+	u.AssertTrue(t, found)
+	u.AssertEqualsMsg(t, "$.delay", retrievedWait.(*workflow.WaitTask).SecondsPath, "keys are converted to reference paths")
+
+	// exactly one duration must be defined
+	_, err = workflow.NewBuilder().AddWaitNode(0).Build()
+	u.AssertNonNil(t, err)
+	invalidPath := workflow.NewWaitTask()
+	invalidPath.SecondsPath = "$.delays[*]"
+	_, err = workflow.NewBuilder().AddWaitTask(invalidPath).Build()
+	u.AssertNonNil(t, err)
+	invalid := workflow.NewWaitTask()
+	invalid.Seconds = 5
+	invalid.Timestamp = "2026-03-14T01:59:00Z"
+	_, err = workflow.NewBuilder().AddWaitTask(invalid).Build()
+	u.AssertNonNil(t, err)
+}
+
+func TestCatchWorkflowBuilder(t *testing.T) {
+	f, err := initializeExamplePyFunction()
+	u.AssertNil(t, err)
+	handler, err := workflow.NewBuilder().AddFunctionTaskWithId(f, "Recover").Build()
+	u.AssertNil(t, err)
+
+	wflow, err := workflow.NewBuilder().
+		AddFunctionTaskWithId(f, "Task").
+		AddRetrier(workflow.NewRetrier(asl.ErrorTimeout)).
+		AddCatcher([]string{asl.ErrorAll}, "$.error", handler).
+		AddFunctionTask(f).
+		Build()
+	u.AssertNil(t, err)
+	// start, task, handler, next task, end
+	u.AssertEquals(t, 5, len(wflow.Tasks))
+
+	task, found := wflow.Find("Task")
+	u.AssertTrue(t, found)
+	functionTask := task.(*workflow.FunctionTask)
+	u.AssertEquals(t, 1, len(functionTask.Retry))
+	u.AssertEquals(t, 1, len(functionTask.Catch))
+	u.AssertEquals(t, workflow.TaskId("Recover"), functionTask.Catch[0].Next)
+	recoverTask, found := wflow.Find("Recover")
+	u.AssertTrue(t, found)
+	// the handler ends the workflow
+	u.AssertEquals(t, wflow.End.GetId(), recoverTask.(*workflow.FunctionTask).GetNext())
+
+	marshal, errMarshal := json.Marshal(wflow)
+	u.AssertNil(t, errMarshal)
+	var retrieved workflow.Workflow
+	errUnmarshal := json.Unmarshal(marshal, &retrieved)
+	u.AssertNil(t, errUnmarshal)
+	u.AssertTrue(t, retrieved.Equals(wflow))
+	retrievedTask, found := retrieved.Find("Task")
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, "$.error", retrievedTask.(*workflow.FunctionTask).Catch[0].ResultPath)
+	u.AssertEquals(t, 3, retrievedTask.(*workflow.FunctionTask).Retry[0].MaxAttempts)
+
+	// retriers and catchers can only be added to function tasks
+	_, err = workflow.NewBuilder().AddWaitNode(1).AddRetrier(workflow.NewRetrier(asl.ErrorAll)).Build()
+	u.AssertNonNil(t, err)
+	_, err = workflow.NewBuilder().AddFunctionTask(f).AddCatcher([]string{asl.ErrorAll}, "", nil).Build()
+	u.AssertNonNil(t, err)
+}
+
+func TestSharedCatcherHandler(t *testing.T) {
+	f, err := initializeExamplePyFunction()
+	u.AssertNil(t, err)
+	newHandler := func() *workflow.Workflow {
+		handler, err := workflow.NewBuilder().AddFunctionTaskWithId(f, "Recover").Build()
+		u.AssertNil(t, err)
+		return handler
+	}
+
+	// the catchers of both tasks continue with the same handler, built once for each task
+	handler := newHandler()
+	wflow, err := workflow.NewBuilder().
+		AddFunctionTaskWithId(f, "First").
+		AddCatcher([]string{asl.ErrorTimeout}, "", handler).
+		AddCatcher([]string{asl.ErrorAll}, "$.error", handler).
+		AddFunctionTaskWithId(f, "Second").
+		AddCatcher([]string{asl.ErrorAll}, "$.error", newHandler()).
+		Build()
+	u.AssertNil(t, err)
+	// start, first task, second task, handler, end
+	u.AssertEquals(t, 5, len(wflow.Tasks))
+	for _, id := range []workflow.TaskId{"First", "Second"} {
+		task, found := wflow.Find(id)
+		u.AssertTrue(t, found)
+		for _, catcher := range task.(*workflow.FunctionTask).Catch {
+			u.AssertEquals(t, workflow.TaskId("Recover"), catcher.Next)
+		}
+	}
+	recoverTask, found := wflow.Find("Recover")
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, wflow.End.GetId(), recoverTask.(*workflow.FunctionTask).GetNext())
+
+	// a handler cannot replace a task that is not part of another handler
+	collidingHandler, err := workflow.NewBuilder().AddFunctionTaskWithId(f, "First").Build()
+	u.AssertNil(t, err)
+	_, err = workflow.NewBuilder().
+		AddFunctionTaskWithId(f, "First").
+		AddCatcher([]string{asl.ErrorAll}, "", collidingHandler).
+		Build()
+	u.AssertNonNil(t, err)
+}
+
+func TestDataPathsWorkflowBuilder(t *testing.T) {
+	branch, err := workflow.NewBuilder().AddPassNodeWithId("", "Branch").Build()
+	u.AssertNil(t, err)
+
+	wflow, err := workflow.NewBuilder().
+		AddPassNodeWithId(`{"increment": 1}`, "Config").
+		SetDataPaths(workflow.DataPaths{ResultPath: "$.config"}).
+		AddParallelNodeWithId("Parallel").
+		NextBranch(branch, nil).
+		EndParallel().
+		SetDataPaths(workflow.DataPaths{
+			InputPath:      "$.config",
+			ResultSelector: `{"first.$": "$[0]"}`,
+			ResultPath:     "$.parallel",
+		}).
+		Build()
+	u.AssertNil(t, err)
+
+	// the input is processed by the FanOutTask, the joined results by the FanInTask
+	fanOut, found := wflow.Find("Parallel")
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, workflow.DataPaths{InputPath: "$.config"}, fanOut.(*workflow.FanOutTask).DataPaths)
+	fanIn, found := wflow.Find("Parallel_FanIn")
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, "$.parallel", fanIn.(*workflow.FanInTask).ResultPath)
+	u.AssertEquals(t, "", fanIn.(*workflow.FanInTask).InputPath)
+
+	marshal, errMarshal := json.Marshal(wflow)
+	u.AssertNil(t, errMarshal)
+	var retrieved workflow.Workflow
+	errUnmarshal := json.Unmarshal(marshal, &retrieved)
+	u.AssertNil(t, errUnmarshal)
+	u.AssertTrue(t, retrieved.Equals(wflow))
+	config, found := retrieved.Find("Config")
+	u.AssertTrue(t, found)
+	u.AssertEquals(t, "$.config", config.(*workflow.PassTask).ResultPath)
+	u.AssertEquals(t, `{"increment": 1}`, config.(*workflow.PassTask).Result)
+
+	// invalid paths and unsupported fields are rejected
+	_, err = workflow.NewBuilder().AddPassNode("").SetDataPaths(workflow.DataPaths{ResultPath: "$.items[*]"}).Build()
+	u.AssertNonNil(t, err)
+	_, err = workflow.NewBuilder().AddWaitNode(1).SetDataPaths(workflow.DataPaths{ResultPath: "$.waited"}).Build()
+	u.AssertNonNil(t, err)
+	_, err = workflow.NewBuilder().AddPassNode("not json").Build()
+	u.AssertNonNil(t, err)
+}
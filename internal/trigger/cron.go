@@ -0,0 +1,165 @@
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronExpr is a parsed cron expression, with the standard 5 fields: minute, hour, day of month, month and day of week
+type CronExpr struct {
+	minutes, hours, days, months, weekdays uint64 // bitsets of the allowed values
+	anyDay, anyWeekday                     bool   // the field is "*": days are restricted by the other one only
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7,
+		"aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}},
+	{min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// maxCronYears bounds the search for the next occurrence of expressions that never match (e.g., "0 0 31 2 *")
+const maxCronYears = 5
+
+// ParseCron parses a cron expression, e.g. "*/15 9-17 * * mon-fri". Each field is "*", a value, a range "a-b" or
+// a list of them, optionally followed by a step "/n". Months and days of week can be given by name, and Sunday is
+// either 0 or 7. Macros such as "@hourly" and "@daily" are supported as well.
+func ParseCron(expr string) (*CronExpr, error) {
+	spec := strings.TrimSpace(strings.ToLower(expr))
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected %d fields", expr, len(cronFields))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %v", expr, err)
+		}
+		sets[i] = set
+	}
+	// Sunday can be either 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &CronExpr{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     fields[2] == "*" || fields[2] == "?",
+		anyWeekday: fields[4] == "*" || fields[4] == "?",
+	}, nil
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepSpec)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step '%s'", stepSpec)
+			}
+		}
+
+		var first, last int
+		if rangeSpec == "*" || rangeSpec == "?" {
+			first, last = spec.min, spec.max
+		} else {
+			lower, upper, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if first, err = parseCronValue(lower, spec); err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				if last, err = parseCronValue(upper, spec); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				last = spec.max // "a/n" stands for "a-max/n"
+			}
+			if first > last {
+				return 0, fmt.Errorf("invalid range '%s'", rangeSpec)
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseCronValue(value string, spec cronField) (int, error) {
+	if v, ok := spec.names[value]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < spec.min || v > spec.max {
+		return 0, fmt.Errorf("invalid value '%s' (allowed: %d-%d)", value, spec.min, spec.max)
+	}
+	return v, nil
+}
+
+// dayMatches follows the usual cron semantics: if both the day of month and the day of week are restricted,
+// a day matches if either of them matches
+func (c *CronExpr) dayMatches(t time.Time) bool {
+	day := c.days&(1<<t.Day()) != 0
+	weekday := c.weekdays&(1<<int(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// Next returns the first occurrence strictly after t, in the time zone of t, or the zero time if there is none
+func (c *CronExpr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxCronYears
+
+	for t.Year() <= limit {
+		if c.months&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		} else if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		} else if c.hours&(1<<t.Hour()) == 0 {
+			// adding an hour, rather than setting the next hour, handles the changes of daylight saving time
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		} else if c.minutes&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+	return time.Time{}
+}
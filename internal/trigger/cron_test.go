@@ -0,0 +1,63 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	cases := []struct {
+		expr, from, next string
+	}{
+		{"* * * * *", "2024-03-10 10:15", "2024-03-10 10:16"},
+		{"*/15 * * * *", "2024-03-10 10:15", "2024-03-10 10:30"},
+		{"0 9-17 * * mon-fri", "2024-03-08 17:00", "2024-03-11 09:00"}, // from Friday to Monday
+		{"30 2 1 * *", "2024-01-31 00:00", "2024-02-01 02:30"},
+		{"0 0 29 feb *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 * * 7", "2024-03-10 00:00", "2024-03-17 00:00"}, // Sunday as 7
+		{"0 12 1,15 * *", "2024-03-02 00:00", "2024-03-15 12:00"},
+		{"0 0 13 * fri", "2024-03-02 00:00", "2024-03-08 00:00"}, // day of month OR day of week
+		{"10/20 * * * *", "2024-03-10 10:31", "2024-03-10 10:50"},
+		{"@daily", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"@hourly", "2024-03-10 10:00", "2024-03-10 11:00"},
+	}
+	for _, c := range cases {
+		expr, err := ParseCron(c.expr)
+		assert.NoError(t, err, c.expr)
+		assert.Equal(t, date(c.next), expr.Next(date(c.from)), c.expr)
+	}
+
+	never, err := ParseCron("0 0 31 2 *")
+	assert.NoError(t, err)
+	assert.True(t, never.Next(date("2024-01-01 00:00")).IsZero())
+}
+
+func TestCronNextTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata") // UTC+5:30
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	expr, err := ParseCron("0 9 * * *")
+	assert.NoError(t, err)
+	next := expr.Next(time.Date(2024, 3, 10, 10, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 3, 11, 9, 0, 0, 0, loc), next)
+	assert.Equal(t, date("2024-03-11 03:30"), next.UTC())
+}
@@ -0,0 +1,164 @@
+package trigger
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const eventsEtcdDir = "/event/"
+const triggerStatesEtcdDir = "/triggerstate/"
+
+// claimAttempts bounds the attempts to claim an event, when the state of the trigger is concurrently updated
+const claimAttempts = 3
+
+// triggerState is updated by the leader as the events of a trigger are delivered
+type triggerState struct {
+	Revision int64 // Etcd revision of the last event delivered
+}
+
+// EventsPrefix returns the prefix of the Etcd keys whose changes are the events of the triggers in the namespace
+func EventsPrefix(namespace string) string {
+	return eventsEtcdDir + namespace + "/"
+}
+
+func getTriggerStateEtcdKey(namespace string, name string) string {
+	return fmt.Sprintf("%s%s/%s", triggerStatesEtcdDir, namespace, name)
+}
+
+// getState returns the state of the trigger, and the revision of its last update (0 if there is none)
+func (t *Trigger) getState(ctx context.Context, cli *clientv3.Client) (triggerState, int64, error) {
+	var state triggerState
+	resp, err := cli.Get(ctx, getTriggerStateEtcdKey(t.Namespace, t.Name))
+	if err != nil || len(resp.Kvs) < 1 {
+		return state, 0, err
+	}
+	err = json.Unmarshal(resp.Kvs[0].Value, &state)
+	return state, resp.Kvs[0].ModRevision, err
+}
+
+// claimRevision marks the events with the given revision as delivered, if they have not been delivered yet and this
+// node is still the leader. It returns true if the events have to be delivered by this node.
+func (t *Trigger) claimRevision(ctx context.Context, cli *clientv3.Client, revision int64, isLeader clientv3.Cmp) (bool, error) {
+	key := getTriggerStateEtcdKey(t.Namespace, t.Name)
+	payload, err := json.Marshal(triggerState{Revision: revision})
+	if err != nil {
+		return false, fmt.Errorf("could not marshal trigger state: %v", err)
+	}
+
+	for i := 0; i < claimAttempts; i++ {
+		state, modRevision, err := t.getState(ctx, cli)
+		if err != nil {
+			return false, err
+		} else if state.Revision >= revision {
+			return false, nil
+		}
+		resp, err := cli.Txn(ctx).
+			If(isLeader, clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+			Then(clientv3.OpPut(key, string(payload))).
+			Commit()
+		if err != nil {
+			return false, err
+		} else if resp.Succeeded {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("could not update the state of the trigger")
+}
+
+// etcdEventParams returns the parameters passed to the target of the trigger upon a change of a key
+func etcdEventParams(namespace string, ev *clientv3.Event) map[string]interface{} {
+	params := map[string]interface{}{
+		"Event":    strings.ToLower(ev.Type.String()), // "put" or "delete"
+		"Key":      strings.TrimPrefix(string(ev.Kv.Key), EventsPrefix(namespace)),
+		"Revision": ev.Kv.ModRevision,
+	}
+	if ev.Type == clientv3.EventTypePut {
+		if utf8.Valid(ev.Kv.Value) {
+			params["Value"] = string(ev.Kv.Value)
+		} else {
+			params["Value"] = base64.StdEncoding.EncodeToString(ev.Kv.Value)
+			params["IsBase64Encoded"] = true
+		}
+	}
+	return params
+}
+
+// watchEtcd delivers the changes of the keys under the prefix of the trigger, starting after the last event delivered
+// (or after the last update of the trigger), until the context is cancelled
+func (t *storedTrigger) watchEtcd(ctx context.Context, isLeader clientv3.Cmp) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		log.Printf("Could not watch events of %s: %v\n", t.QualifiedName(), err)
+		return
+	}
+	state, _, err := t.getState(ctx, cli)
+	if err != nil {
+		log.Printf("Could not watch events of %s: %v\n", t.QualifiedName(), err)
+		return
+	}
+	next := max(state.Revision, t.revision) + 1
+	prefix := EventsPrefix(t.Namespace) + t.Prefix
+
+	for ctx.Err() == nil {
+		watchChan := cli.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix(), clientv3.WithRev(next))
+		for resp := range watchChan {
+			if resp.CompactRevision != 0 {
+				log.Printf("Events of %s from revision %d to %d have been compacted and will not be delivered\n",
+					t.QualifiedName(), next, resp.CompactRevision-1)
+				next = resp.CompactRevision
+				break
+			}
+			if err = resp.Err(); err != nil {
+				log.Printf("Watch of events of %s failed: %v\n", t.QualifiedName(), err)
+				break
+			}
+
+			// the events of a revision (i.e., of a transaction) are claimed together
+			for start := 0; start < len(resp.Events); {
+				revision := resp.Events[start].Kv.ModRevision
+				end := start + 1
+				for end < len(resp.Events) && resp.Events[end].Kv.ModRevision == revision {
+					end++
+				}
+				t.deliverRevision(ctx, cli, resp.Events[start:end], isLeader)
+				next = revision + 1
+				start = end
+			}
+		}
+
+		if ctx.Err() == nil {
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (t *storedTrigger) deliverRevision(ctx context.Context, cli *clientv3.Client, events []*clientv3.Event, isLeader clientv3.Cmp) {
+	revision := events[0].Kv.ModRevision
+	claimed, err := t.claimRevision(ctx, cli, revision, isLeader)
+	if err != nil {
+		log.Printf("Could not deliver events of %s at revision %d: %v\n", t.QualifiedName(), revision, err)
+		return
+	} else if !claimed {
+		return
+	}
+
+	submit, err := t.invoker(t.Namespace, t.APIKey)
+	if err != nil {
+		log.Printf("Dropping events of %s at revision %d: %v\n", t.QualifiedName(), revision, err)
+		return
+	}
+	for i, ev := range events {
+		reqId := fmt.Sprintf("%s-%d-%d", t.Name, revision, i)
+		log.Printf("Trigger %s fired: %s\n", t.QualifiedName(), reqId)
+		submit(reqId, etcdEventParams(t.Namespace, ev))
+	}
+}
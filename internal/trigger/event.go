@@ -0,0 +1,293 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Sources of the events of a Trigger
+const (
+	EtcdEvents = "etcd" // changes of the keys under a prefix of the events of the namespace in Etcd
+	FileEvents = "file" // files created in a local directory
+	MqttEvents = "mqtt" // messages published to a topic of the configured MQTT broker
+)
+
+const triggersEtcdDir = "/trigger/"
+
+// syncInterval is the interval between the updates of the watchers, according to the triggers saved in Etcd
+const syncInterval = 5 * time.Second
+
+var UnknownTriggerErr = errors.New("unknown trigger")
+
+// Trigger invokes a function or a workflow upon the events of a source, passing the event as parameters. Triggers
+// are saved in Etcd, and each event is delivered by one node of the area of the trigger.
+type Trigger struct {
+	Name       string
+	Namespace  string
+	Source     string // EtcdEvents, FileEvents or MqttEvents
+	Prefix     string `json:",omitempty"` // prefix of the keys watched (EtcdEvents), relative to the events of the namespace
+	Directory  string `json:",omitempty"` // directory watched (FileEvents), relative to the one of the namespace
+	Pattern    string `json:",omitempty"` // pattern of the names of the files (FileEvents), e.g. "*.csv"
+	Topic      string `json:",omitempty"` // topic filter subscribed (MqttEvents), relative to the topics of the namespace
+	QoS        int    `json:",omitempty"` // QoS of the subscription and of the replies (MqttEvents): 0, 1 or 2
+	ReplyTopic string `json:",omitempty"` // topic the results of the invocations are published to (MqttEvents), if any
+	Area       string // area whose nodes deliver the events (default: area of the node that created it)
+	Target
+	APIKey  string `json:",omitempty"` // key of the creator, forwarded if the invocations are offloaded
+	Created time.Time
+}
+
+// storedTrigger is a trigger read from Etcd, along with the revision of its last update
+type storedTrigger struct {
+	Trigger
+	revision int64
+}
+
+func getTriggerEtcdKey(namespace string, name string) string {
+	return fmt.Sprintf("%s%s/%s", triggersEtcdDir, namespace, name)
+}
+
+// getNamespace returns the namespace of the trigger (function.DefaultNamespace if empty)
+func (t *Trigger) getNamespace() string {
+	if t.Namespace == "" {
+		return function.DefaultNamespace
+	}
+	return t.Namespace
+}
+
+// QualifiedName returns the name that identifies the trigger across namespaces
+func (t *Trigger) QualifiedName() string {
+	return function.QualifiedName(t.Namespace, t.Name)
+}
+
+// Validate checks the definition of the trigger (but not the existence of its target)
+func (t *Trigger) Validate() error {
+	if !function.IsValidName(t.Name) {
+		return fmt.Errorf("invalid trigger name: %s", t.Name)
+	}
+	if t.Prefix != "" && t.Source != EtcdEvents {
+		return fmt.Errorf("Prefix is allowed only for %s triggers", EtcdEvents)
+	}
+	if (t.Directory != "" || t.Pattern != "") && t.Source != FileEvents {
+		return fmt.Errorf("Directory and Pattern are allowed only for %s triggers", FileEvents)
+	}
+	if (t.Topic != "" || t.QoS != 0 || t.ReplyTopic != "") && t.Source != MqttEvents {
+		return fmt.Errorf("Topic, QoS and ReplyTopic are allowed only for %s triggers", MqttEvents)
+	}
+
+	switch t.Source {
+	case EtcdEvents:
+	case FileEvents:
+		if !filepath.IsLocal(t.Directory) {
+			return fmt.Errorf("invalid directory: %s", t.Directory)
+		}
+		if _, err := filepath.Match(t.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern: %s", t.Pattern)
+		}
+	case MqttEvents:
+		if !isValidTopicName(t.getNamespace()) || strings.HasPrefix(t.getNamespace(), "$") {
+			return fmt.Errorf("namespace %s cannot be used in MQTT topics", t.getNamespace())
+		}
+		if !isValidTopicFilter(t.Topic) {
+			return fmt.Errorf("invalid topic: %s", t.Topic)
+		}
+		if t.QoS < 0 || t.QoS > 2 {
+			return fmt.Errorf("invalid QoS: %d", t.QoS)
+		}
+		if t.ReplyTopic != "" && !isValidTopicName(t.ReplyTopic) {
+			return fmt.Errorf("invalid reply topic: %s", t.ReplyTopic)
+		}
+	default:
+		return fmt.Errorf("invalid source: %s", t.Source)
+	}
+	return t.Target.validate()
+}
+
+// Save creates or updates the trigger in Etcd
+func (t *Trigger) Save() error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("could not marshal trigger: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = cli.Put(ctx, getTriggerEtcdKey(t.Namespace, t.Name), string(payload))
+	if err != nil {
+		return fmt.Errorf("failed etcd Put trigger: %v", err)
+	}
+	return nil
+}
+
+// GetTrigger retrieves a trigger from Etcd. If it doesn't exist, returns false
+func GetTrigger(namespace string, name string) (*Trigger, bool) {
+	triggers, err := getTriggers(getTriggerEtcdKey(namespace, name))
+	if err != nil || len(triggers) < 1 {
+		return nil, false
+	}
+	return &triggers[0].Trigger, true
+}
+
+// GetAllTriggers returns the triggers in the namespace
+func GetAllTriggers(namespace string) ([]Trigger, error) {
+	stored, err := getTriggers(triggersEtcdDir+namespace+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	triggers := make([]Trigger, 0, len(stored))
+	for _, t := range stored {
+		triggers = append(triggers, t.Trigger)
+	}
+	return triggers, nil
+}
+
+func getTriggers(key string, opts ...clientv3.OpOption) ([]storedTrigger, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := cli.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	triggers := make([]storedTrigger, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		t := storedTrigger{revision: kv.ModRevision}
+		if err = json.Unmarshal(kv.Value, &t.Trigger); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		}
+		triggers = append(triggers, t)
+	}
+	return triggers, nil
+}
+
+// DeleteTrigger deletes a trigger. Its events are not delivered anymore within a few seconds.
+func DeleteTrigger(namespace string, name string) error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := cli.Txn(ctx).Then(
+		clientv3.OpDelete(getTriggerEtcdKey(namespace, name)),
+		clientv3.OpDelete(getTriggerStateEtcdKey(namespace, name)),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed Delete: %v", err)
+	}
+	if resp.Responses[0].GetResponseDeleteRange().Deleted != 1 {
+		return UnknownTriggerErr
+	}
+	return nil
+}
+
+// StartEventService delivers the events of the triggers of the local area. Changes in Etcd and MQTT messages are
+// watched by the leader of the area, while each node watches the local directories, claiming in Etcd each file
+// before delivering its event.
+func StartEventService() {
+	if !config.GetBool(config.TRIGGER_EVENT_ENABLED, true) {
+		log.Printf("Event triggers are disabled on this node\n")
+		return
+	}
+
+	go lead(context.Background(), "event", func(ctx context.Context, isLeader clientv3.Cmp) {
+		runWatchers(ctx, EtcdEvents, func(ctx context.Context, t *storedTrigger) {
+			t.watchEtcd(ctx, isLeader)
+		})
+	})
+
+	if config.GetString(config.TRIGGER_MQTT_BROKER, "") == "" {
+		log.Printf("MQTT triggers are disabled on this node: no %s configured\n", config.TRIGGER_MQTT_BROKER)
+	} else {
+		go lead(context.Background(), "mqtt", func(ctx context.Context, _ clientv3.Cmp) {
+			session := newMqttSession(ctx, newMqttClientOptions())
+			defer session.close()
+			runWatchers(ctx, MqttEvents, func(ctx context.Context, t *storedTrigger) {
+				session.subscribe(ctx, &t.Trigger)
+			})
+		})
+	}
+
+	if config.GetString(config.TRIGGER_FILE_ROOT, "") == "" {
+		log.Printf("File triggers are disabled on this node: no %s configured\n", config.TRIGGER_FILE_ROOT)
+		return
+	}
+	go runWatchers(context.Background(), FileEvents, func(ctx context.Context, t *storedTrigger) {
+		t.watchDirectory(ctx)
+	})
+}
+
+// runWatchers runs a watcher for each trigger of the source in the local area, until the context is cancelled.
+// Watchers are restarted when their trigger is updated, and stopped when it is deleted.
+func runWatchers(ctx context.Context, source string, watch func(ctx context.Context, t *storedTrigger)) {
+	type watcher struct {
+		revision int64
+		cancel   context.CancelFunc
+	}
+	watchers := make(map[string]watcher)
+	defer func() {
+		for _, w := range watchers {
+			w.cancel()
+		}
+	}()
+
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		triggers, err := getTriggers(triggersEtcdDir, clientv3.WithPrefix())
+		if err != nil {
+			log.Printf("Could not retrieve triggers: %v\n", err)
+		} else {
+			current := make(map[string]bool)
+			for i := range triggers {
+				t := &triggers[i]
+				if t.Source != source || t.Area != node.LocalNode.Area {
+					continue
+				}
+				name := t.QualifiedName()
+				current[name] = true
+				if w, found := watchers[name]; found {
+					if w.revision == t.revision {
+						continue
+					}
+					w.cancel()
+				}
+				watchCtx, cancel := context.WithCancel(ctx)
+				watchers[name] = watcher{revision: t.revision, cancel: cancel}
+				go watch(watchCtx, t)
+			}
+			for name, w := range watchers {
+				if !current[name] {
+					w.cancel()
+					delete(watchers, name)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package trigger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestTriggerValidate(t *testing.T) {
+	target := Target{Function: "f"}
+	for _, valid := range []Trigger{
+		{Name: "t", Source: EtcdEvents, Target: target},
+		{Name: "t", Source: EtcdEvents, Prefix: "orders/", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "uploads", Pattern: "*.csv", Target: target},
+	} {
+		assert.NoError(t, valid.Validate(), "%+v", valid)
+	}
+
+	for _, invalid := range []Trigger{
+		{Name: "t", Source: "mqtt", Target: target},
+		{Name: "t", Source: EtcdEvents},
+		{Name: "t", Source: EtcdEvents, Directory: "uploads", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "../etc", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "/etc", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "uploads", Pattern: "[", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "uploads", Prefix: "orders/", Target: target},
+	} {
+		assert.Error(t, invalid.Validate(), "%+v", invalid)
+	}
+}
+
+func TestEtcdEventParams(t *testing.T) {
+	put := &clientv3.Event{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("/event/ns/orders/1"), Value: []byte("new"), ModRevision: 42}}
+	params := etcdEventParams("ns", put)
+	assert.Equal(t, "put", params["Event"])
+	assert.Equal(t, "orders/1", params["Key"])
+	assert.Equal(t, "new", params["Value"])
+	assert.Equal(t, int64(42), params["Revision"])
+
+	binary := &clientv3.Event{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("/event/ns/k"), Value: []byte{0xff, 0x00, 0xfe}}}
+	params = etcdEventParams("ns", binary)
+	assert.Equal(t, "/wD+", params["Value"])
+	assert.Equal(t, true, params["IsBase64Encoded"])
+
+	del := &clientv3.Event{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte("/event/ns/orders/1")}}
+	params = etcdEventParams("ns", del)
+	assert.Equal(t, "delete", params["Event"])
+	assert.NotContains(t, params, "Value")
+}
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	scan := func() map[string]fileState {
+		files, err := scanDirectory(dir, "*.csv")
+		assert.NoError(t, err)
+		return files
+	}
+	start := time.Now().Add(-time.Hour)
+
+	write("old.csv", "a", start)
+	write("ignored.txt", "a", start.Add(time.Minute))
+	write(".hidden.csv", "a", start.Add(time.Minute))
+	files := scan()
+	assert.Len(t, files, 1, "only the visible files matching the pattern are watched")
+
+	w := newFileWatcher(files)
+	assert.Empty(t, w.update(scan()), "existing files are not delivered")
+
+	write("new.csv", "a", start.Add(time.Minute))
+	assert.Empty(t, w.update(scan()), "new files are delivered once they are stable")
+	write("new.csv", "ab", start.Add(2*time.Minute))
+	assert.Empty(t, w.update(scan()))
+	assert.Equal(t, map[string]string{"new.csv": "create"}, w.update(scan()))
+	assert.Empty(t, w.update(scan()), "files are delivered once")
+
+	write("old.csv", "b", start.Add(3*time.Minute))
+	w.update(scan())
+	assert.Equal(t, map[string]string{"old.csv": "write"}, w.update(scan()))
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "new.csv")))
+	w.update(scan())
+	write("new.csv", "ab", start.Add(2*time.Minute))
+	w.update(scan())
+	assert.Equal(t, map[string]string{"new.csv": "create"}, w.update(scan()), "re-created files are delivered again")
+}
+
+func TestResolveDirectory(t *testing.T) {
+	root := t.TempDir()
+	viper.Set(config.TRIGGER_FILE_ROOT, root)
+	t.Cleanup(func() { viper.Set(config.TRIGGER_FILE_ROOT, "") })
+	for _, dir := range []string{"ns/uploads", "other/private", "default/uploads"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	assert.NoError(t, os.Symlink(filepath.Join(root, "other/private"), filepath.Join(root, "ns/escape")))
+	assert.NoError(t, os.Symlink("uploads", filepath.Join(root, "ns/inner")))
+
+	resolve := func(namespace string, dir string) (string, error) {
+		trigger := Trigger{Namespace: namespace, Directory: dir}
+		return trigger.resolveDirectory()
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	assert.NoError(t, err)
+
+	dir, err := resolve("ns", "uploads")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(realRoot, "ns/uploads"), dir)
+	dir, err = resolve("ns", "inner")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(realRoot, "ns/uploads"), dir, "links within the namespace are allowed")
+	dir, err = resolve("", "uploads")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(realRoot, "default/uploads"), dir)
+
+	_, err = resolve("ns", "escape")
+	assert.Error(t, err, "links must not escape the directory of the namespace")
+	_, err = resolve("ns", "private")
+	assert.Error(t, err, "the directories of other namespaces are not visible")
+}
+
+func TestFileEventParams(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644))
+	modTime := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	params := fileEventParams(dir, "a.txt", fileState{size: 5, modTime: modTime}, "create")
+	assert.Equal(t, "create", params["Event"])
+	assert.Equal(t, "a.txt", params["Path"])
+	assert.Equal(t, int64(5), params["Size"])
+	assert.Equal(t, "2024-03-10T10:00:00Z", params["ModTime"])
+	assert.Equal(t, "hello", params["Content"])
+
+	params = fileEventParams(dir, "a.txt", fileState{size: 1 << 30, modTime: modTime}, "create")
+	assert.NotContains(t, params, "Content", "the content of large files is not passed")
+}
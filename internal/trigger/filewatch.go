@@ -0,0 +1,248 @@
+package trigger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const fileClaimsEtcdDir = "/triggerclaim/"
+
+// fileState identifies a version of a file: a file is delivered once its state does not change between two scans
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// scanDirectory returns the state of the regular files in the directory matching the pattern (if any). Hidden files
+// are ignored, so that files can be written with a temporary hidden name and then renamed.
+func scanDirectory(dir string, pattern string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, name); pattern != "" && !matched {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed in the meantime
+		}
+		files[name] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+	return files, nil
+}
+
+// fileWatcher tracks the files of a directory across scans
+type fileWatcher struct {
+	seen    map[string]fileState // files already delivered, or older than the trigger
+	pending map[string]fileState // files created or modified since the previous scan
+}
+
+func newFileWatcher(seen map[string]fileState) *fileWatcher {
+	if seen == nil {
+		seen = make(map[string]fileState)
+	}
+	return &fileWatcher{seen: seen, pending: make(map[string]fileState)}
+}
+
+// update returns the files to deliver after a scan, i.e. the new (or modified) files whose state has not changed
+// since the previous scan, with the event to deliver ("create" or "write")
+func (w *fileWatcher) update(files map[string]fileState) map[string]string {
+	ready := make(map[string]string)
+	for name, state := range files {
+		if seen, found := w.seen[name]; found && seen == state {
+			continue
+		}
+		if pending, found := w.pending[name]; !found || pending != state {
+			w.pending[name] = state
+			continue
+		}
+
+		delete(w.pending, name)
+		ready[name] = "create"
+		if _, found := w.seen[name]; found {
+			ready[name] = "write"
+		}
+		w.seen[name] = state
+	}
+
+	for name := range w.seen {
+		if _, found := files[name]; !found {
+			delete(w.seen, name)
+		}
+	}
+	for name := range w.pending {
+		if _, found := files[name]; !found {
+			delete(w.pending, name)
+		}
+	}
+	return ready
+}
+
+// resolveDirectory returns the directory watched by the trigger, i.e. Directory within the directory of the namespace
+// under the configured root, with symbolic links resolved. Directories outside of the one of the namespace (e.g.,
+// through links) are rejected.
+func (t *Trigger) resolveDirectory() (string, error) {
+	namespace := t.getNamespace()
+	namespaceDir, err := filepath.EvalSymlinks(filepath.Join(config.GetString(config.TRIGGER_FILE_ROOT, ""), namespace))
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Join(namespaceDir, t.Directory))
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(namespaceDir, dir); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s is outside of the directory of namespace %s", t.Directory, namespace)
+	}
+	return dir, nil
+}
+
+// scan returns the state of the files in the directory of the trigger, along with the directory. The directory is
+// resolved at every scan, as links may change.
+func (t *Trigger) scan() (string, map[string]fileState, error) {
+	dir, err := t.resolveDirectory()
+	if err != nil {
+		return "", nil, err
+	}
+	files, err := scanDirectory(dir, t.Pattern)
+	return dir, files, err
+}
+
+// watchDirectory periodically scans the directory of the trigger, delivering the files created (or modified) after
+// the creation of the trigger, until the context is cancelled. Files are claimed in Etcd before being delivered,
+// so that the files found when the watcher starts are not delivered again.
+func (t *storedTrigger) watchDirectory(ctx context.Context) {
+	interval := time.Duration(config.GetInt(config.TRIGGER_FILE_INTERVAL, 2)) * time.Second
+
+	_, initial, err := t.scan()
+	if err != nil {
+		log.Printf("Could not watch files of %s: %v\n", t.QualifiedName(), err)
+	}
+	watcher := newFileWatcher(nil)
+	for name, state := range initial {
+		if !state.modTime.After(t.Created) {
+			watcher.seen[name] = state
+		}
+	}
+	failing := err != nil
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		dir, files, err := t.scan()
+		if err != nil {
+			if !failing {
+				log.Printf("Could not watch files of %s: %v\n", t.QualifiedName(), err)
+			}
+			failing = true
+			continue
+		}
+		failing = false
+
+		for name, event := range watcher.update(files) {
+			t.deliverFile(dir, name, files[name], event)
+		}
+	}
+}
+
+// claimFile records the delivery of the file in Etcd, unless another node of the area (watching the same shared
+// directory) already delivered it. It returns the ID of the claim, if the file has to be delivered by this node.
+func (t *Trigger) claimFile(name string, state fileState) (string, bool, error) {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", path.Join(t.Directory, name), state.size, state.modTime.UnixNano())))
+	claimId := hex.EncodeToString(digest[:8])
+	key := fmt.Sprintf("%s%s/%s/%s", fileClaimsEtcdDir, t.Namespace, t.Name, claimId)
+
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return "", false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lease, err := cli.Grant(ctx, int64(config.GetInt(config.TRIGGER_FILE_CLAIM_TTL, 86400)))
+	if err != nil {
+		return "", false, err
+	}
+	resp, err := cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, name, clientv3.WithLease(lease.ID))).
+		Commit()
+	if err != nil {
+		return "", false, err
+	}
+	if !resp.Succeeded {
+		_, _ = cli.Revoke(ctx, lease.ID)
+	}
+	return claimId, resp.Succeeded, nil
+}
+
+// fileEventParams returns the parameters passed to the target of the trigger upon a new file. The content is
+// included only up to the configured size.
+func fileEventParams(dir string, name string, state fileState, event string) map[string]interface{} {
+	params := map[string]interface{}{
+		"Event":   event,
+		"Path":    name,
+		"Size":    state.size,
+		"ModTime": state.modTime.Format(time.RFC3339Nano),
+	}
+	if state.size > int64(config.GetInt(config.TRIGGER_FILE_MAX_SIZE, 1024*1024)) {
+		return params
+	}
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		log.Printf("Could not read %s: %v\n", name, err)
+		return params
+	}
+	if utf8.Valid(content) {
+		params["Content"] = string(content)
+	} else {
+		params["Content"] = base64.StdEncoding.EncodeToString(content)
+		params["IsBase64Encoded"] = true
+	}
+	return params
+}
+
+func (t *storedTrigger) deliverFile(dir string, name string, state fileState, event string) {
+	claimId, claimed, err := t.claimFile(name, state)
+	if err != nil {
+		log.Printf("Could not deliver file %s of %s: %v\n", name, t.QualifiedName(), err)
+		return
+	} else if !claimed {
+		return
+	}
+
+	submit, err := t.invoker(t.Namespace, t.APIKey)
+	if err != nil {
+		log.Printf("Dropping file %s of %s: %v\n", name, t.QualifiedName(), err)
+		return
+	}
+	reqId := fmt.Sprintf("%s-%s", t.Name, claimId)
+	log.Printf("Trigger %s fired: %s\n", t.QualifiedName(), reqId)
+	submit(reqId, fileEventParams(dir, name, state, event))
+}
@@ -0,0 +1,70 @@
+package trigger
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const leaderEtcdDir = "/triggerleader/"
+
+// campaignRetryInterval is waited before campaigning again after errors
+const campaignRetryInterval = 5 * time.Second
+
+// lead campaigns for the leadership of the election among the nodes of the local area, and runs the function
+// while this node is the leader. The leadership is bound to an Etcd lease, which expires if the node goes down.
+// The context passed to run is cancelled when the leadership is lost, and isLeader holds only as long as this
+// node is the leader, so that writes to Etcd can be conditional on the leadership.
+func lead(ctx context.Context, election string, run func(ctx context.Context, isLeader clientv3.Cmp)) {
+	name := leaderEtcdDir + node.LocalNode.Area + "/" + election
+	ttl := config.GetInt(config.TRIGGER_LEADER_TTL, 10)
+
+	for ctx.Err() == nil {
+		cli, err := utils.GetEtcdClient()
+		if err != nil {
+			log.Printf("Could not campaign for %s: %v\n", name, err)
+			time.Sleep(campaignRetryInterval)
+			continue
+		}
+		session, err := concurrency.NewSession(cli, concurrency.WithTTL(ttl), concurrency.WithContext(ctx))
+		if err != nil {
+			log.Printf("Could not campaign for %s: %v\n", name, err)
+			time.Sleep(campaignRetryInterval)
+			continue
+		}
+
+		e := concurrency.NewElection(session, name)
+		if err = e.Campaign(ctx, node.LocalNode.Key); err != nil {
+			_ = session.Close()
+			if ctx.Err() == nil {
+				log.Printf("Could not campaign for %s: %v\n", name, err)
+				time.Sleep(campaignRetryInterval)
+			}
+			continue
+		}
+		log.Printf("Elected leader for %s\n", name)
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-session.Done():
+				log.Printf("Lost leadership for %s\n", name)
+			case <-leaderCtx.Done():
+			}
+			cancel()
+		}()
+		run(leaderCtx, clientv3.Compare(clientv3.CreateRevision(e.Key()), "=", e.Rev()))
+		cancel()
+
+		resignCtx, resignCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = e.Resign(resignCtx)
+		resignCancel()
+		_ = session.Close()
+	}
+}
@@ -0,0 +1,324 @@
+package trigger
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/serverledge-faas/serverledge/internal/async"
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/internal/workflow"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// mqttTimeout bounds the wait for the acknowledgements of the broker
+const mqttTimeout = 10 * time.Second
+
+// mqttQuiesce is the time (in milliseconds) left to the pending work when disconnecting from the broker
+const mqttQuiesce = 250
+
+// mqttSession is the connection of the leader of the area to the MQTT broker. It keeps track of the subscriptions
+// of the triggers, to renew them whenever the connection is restored.
+type mqttSession struct {
+	ctx           context.Context
+	client        mqtt.Client
+	maxBackoff    time.Duration
+	deliver       func(t *Trigger, msg mqtt.Message)
+	mu            sync.Mutex
+	subscriptions map[string]map[string]*Trigger // triggers by topic filter and qualified name
+}
+
+// newMqttClientOptions returns the options to connect to the configured broker. The client ID is the same for all
+// the nodes of the area and the session is persistent, so that the broker keeps the messages published while the
+// leadership of the area passes from a node to another (with QoS 1 and 2).
+func newMqttClientOptions() *mqtt.ClientOptions {
+	clientId := config.GetString(config.TRIGGER_MQTT_CLIENT_ID, "")
+	if clientId == "" {
+		clientId = "serverledge-" + node.LocalNode.Area
+	}
+	return mqtt.NewClientOptions().
+		AddBroker(config.GetString(config.TRIGGER_MQTT_BROKER, "")).
+		SetClientID(clientId).
+		SetUsername(config.GetString(config.TRIGGER_MQTT_USERNAME, "")).
+		SetPassword(config.GetString(config.TRIGGER_MQTT_PASSWORD, "")).
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(time.Duration(config.GetInt(config.TRIGGER_MQTT_MAX_BACKOFF, 60)) * time.Second)
+}
+
+// newMqttSession connects to the broker in the background, retrying with an exponential backoff until the context
+// is cancelled. The connection is restored automatically when lost.
+func newMqttSession(ctx context.Context, opts *mqtt.ClientOptions) *mqttSession {
+	s := &mqttSession{
+		ctx:           ctx,
+		maxBackoff:    opts.MaxReconnectInterval,
+		subscriptions: make(map[string]map[string]*Trigger),
+	}
+	s.deliver = s.deliverMessage
+	opts.SetDefaultPublishHandler(s.handle).
+		SetOnConnectHandler(func(mqtt.Client) {
+			log.Printf("Connected to MQTT broker\n")
+			s.resubscribe()
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("Lost connection to MQTT broker: %v\n", err)
+		})
+	s.client = mqtt.NewClient(opts)
+	go s.connect()
+	return s
+}
+
+func (s *mqttSession) connect() {
+	for backoff := time.Second; s.ctx.Err() == nil; backoff = min(2*backoff, s.maxBackoff) {
+		token := s.client.Connect()
+		token.Wait()
+		if token.Error() == nil {
+			return
+		}
+		log.Printf("Could not connect to MQTT broker (retrying in %v): %v\n", backoff, token.Error())
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// close disconnects from the broker. The subscriptions are kept by the broker, for the next leader.
+func (s *mqttSession) close() {
+	s.client.Disconnect(mqttQuiesce)
+}
+
+// topicPrefix returns the prefix of the topics of the namespace of the trigger. Topics are scoped by namespace: the
+// topics of the triggers are relative to "<namespace>/", so that triggers cannot receive (or publish) the messages
+// of other namespaces.
+func (t *Trigger) topicPrefix() string {
+	return t.getNamespace() + "/"
+}
+
+// subscribe delivers the messages of the topic of the trigger, until the context is cancelled
+func (s *mqttSession) subscribe(ctx context.Context, t *Trigger) {
+	name := t.QualifiedName()
+	filter := t.topicPrefix() + t.Topic
+	s.mu.Lock()
+	if s.subscriptions[filter] == nil {
+		s.subscriptions[filter] = make(map[string]*Trigger)
+	}
+	s.subscriptions[filter][name] = t
+	qos := s.maxQoS(filter)
+	s.mu.Unlock()
+	// if not connected yet, the topic is subscribed upon connection
+	if s.client.IsConnectionOpen() {
+		s.wait(s.client.Subscribe(filter, qos, nil), "subscribe to "+filter)
+	}
+
+	<-ctx.Done()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions[filter][name] != t {
+		return // replaced by an update of the trigger
+	}
+	delete(s.subscriptions[filter], name)
+	if len(s.subscriptions[filter]) > 0 {
+		return
+	}
+	delete(s.subscriptions, filter)
+	if s.ctx.Err() == nil {
+		// the trigger has been deleted, or its topic changed (rather than the leadership lost)
+		go s.wait(s.client.Unsubscribe(filter), "unsubscribe from "+filter)
+	}
+}
+
+// maxQoS returns the highest QoS requested by the triggers of the topic filter. It must be called holding the lock.
+func (s *mqttSession) maxQoS(topic string) byte {
+	qos := 0
+	for _, t := range s.subscriptions[topic] {
+		qos = max(qos, t.QoS)
+	}
+	return byte(qos)
+}
+
+func (s *mqttSession) resubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for topic := range s.subscriptions {
+		go s.wait(s.client.Subscribe(topic, s.maxQoS(topic), nil), "subscribe to "+topic)
+	}
+}
+
+func (s *mqttSession) wait(token mqtt.Token, action string) bool {
+	if !token.WaitTimeout(mqttTimeout) {
+		log.Printf("Could not %s: timeout\n", action)
+		return false
+	} else if token.Error() != nil {
+		log.Printf("Could not %s: %v\n", action, token.Error())
+		return false
+	}
+	return true
+}
+
+// handle delivers a message to the triggers whose topic filter matches its topic. Messages of filters that are not
+// subscribed anymore (e.g., kept by the broker for a deleted trigger) are dropped.
+func (s *mqttSession) handle(_ mqtt.Client, msg mqtt.Message) {
+	var triggers []*Trigger
+	s.mu.Lock()
+	for topic, subscribed := range s.subscriptions {
+		if topicMatches(topic, msg.Topic()) {
+			for _, t := range subscribed {
+				triggers = append(triggers, t)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, t := range triggers {
+		s.deliver(t, msg)
+	}
+}
+
+func (s *mqttSession) deliverMessage(t *Trigger, msg mqtt.Message) {
+	submit, err := t.invoker(t.Namespace, t.APIKey)
+	if err != nil {
+		log.Printf("Dropping message of %s on %s: %v\n", t.QualifiedName(), msg.Topic(), err)
+		return
+	}
+	reqId := fmt.Sprintf("%s-%d", t.Name, time.Now().UnixNano())
+
+	if t.ReplyTopic != "" {
+		// the result is watched from the current revision, as it might be published before the watch starts
+		cli, err := utils.GetEtcdClient()
+		if err != nil {
+			log.Printf("Dropping message of %s on %s: %v\n", t.QualifiedName(), msg.Topic(), err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, mqttTimeout)
+		resp, err := cli.Get(ctx, async.ResultKey(t.Namespace, reqId))
+		cancel()
+		if err != nil {
+			log.Printf("Dropping message of %s on %s: %v\n", t.QualifiedName(), msg.Topic(), err)
+			return
+		}
+		go s.reply(cli, t, reqId, resp.Header.Revision)
+	}
+
+	log.Printf("Trigger %s fired: %s\n", t.QualifiedName(), reqId)
+	submit(reqId, mqttMessageParams(strings.TrimPrefix(msg.Topic(), t.topicPrefix()), msg.Payload()))
+}
+
+// reply publishes the result of the request to the reply topic of the trigger, as soon as it is saved in Etcd (after
+// the given revision). Nothing is published if the request fails, or if this node loses the leadership before.
+func (s *mqttSession) reply(cli *clientv3.Client, t *Trigger, reqId string, revision int64) {
+	ctx, cancel := context.WithTimeout(s.ctx, async.ResultTTL(t.AsyncOptions))
+	defer cancel()
+
+	watchChan := cli.Watch(clientv3.WithRequireLeader(ctx), async.ResultKey(t.Namespace, reqId),
+		clientv3.WithRev(revision+1), clientv3.WithFilterDelete())
+	for resp := range watchChan {
+		if err := resp.Err(); err != nil {
+			log.Printf("Could not reply to %s: %v\n", reqId, err)
+			return
+		}
+		for _, ev := range resp.Events {
+			payload, err := replyPayload(t.Target, ev.Kv.Value)
+			if err != nil {
+				log.Printf("Could not reply to %s: %v\n", reqId, err)
+			} else {
+				topic := t.topicPrefix() + t.ReplyTopic
+				s.wait(s.client.Publish(topic, byte(t.QoS), false, payload), "publish to "+topic)
+			}
+			return
+		}
+	}
+	log.Printf("Could not reply to %s: no result received\n", reqId)
+}
+
+// replyPayload returns the result of a successful invocation of the target, given its response saved in Etcd: the
+// result of the function, or the JSON encoding of the result of the workflow
+func replyPayload(target Target, response []byte) ([]byte, error) {
+	if target.Function != "" {
+		var r function.Response
+		if err := json.Unmarshal(response, &r); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		} else if !r.Success {
+			return nil, fmt.Errorf("invocation failed")
+		}
+		return []byte(r.Result), nil
+	}
+
+	var r workflow.InvocationResponse
+	if err := json.Unmarshal(response, &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+	} else if !r.Success {
+		return nil, fmt.Errorf("invocation failed")
+	}
+	return json.Marshal(r.Result)
+}
+
+// mqttMessageParams returns the parameters passed to the target of the trigger upon a message. The fields of JSON
+// objects are passed as parameters, while other payloads are passed as the "Payload" parameter. The topic of the
+// message is passed as well, unless the payload has a field with the same name.
+func mqttMessageParams(topic string, payload []byte) map[string]interface{} {
+	var params map[string]interface{}
+	if err := json.Unmarshal(payload, &params); err != nil || params == nil {
+		params = make(map[string]interface{})
+		if utf8.Valid(payload) {
+			params["Payload"] = string(payload)
+		} else {
+			params["Payload"] = base64.StdEncoding.EncodeToString(payload)
+			params["IsBase64Encoded"] = true
+		}
+	}
+	if _, found := params["Topic"]; !found {
+		params["Topic"] = topic
+	}
+	return params
+}
+
+// isValidTopicFilter checks that the wildcards of a topic filter are whole levels, and "#" is the last one
+func isValidTopicFilter(filter string) bool {
+	if filter == "" || strings.ContainsRune(filter, 0) {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if level == "#" && i != len(levels)-1 {
+			return false
+		} else if level != "#" && level != "+" && strings.ContainsAny(level, "+#") {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidTopicName checks that a topic can be published to, i.e., it has no wildcards
+func isValidTopicName(topic string) bool {
+	return topic != "" && !strings.ContainsAny(topic, "+#\x00")
+}
+
+// topicMatches returns true if the topic matches the filter. As prescribed by MQTT, topics starting with "$" are
+// not matched by filters starting with a wildcard.
+func topicMatches(filter string, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		} else if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
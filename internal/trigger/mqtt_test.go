@@ -0,0 +1,195 @@
+package trigger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	server "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicFilters(t *testing.T) {
+	for _, valid := range []string{"a", "a/b", "a/+/c", "+", "#", "a/#", "+/+", "/a", "$SYS/#"} {
+		assert.True(t, isValidTopicFilter(valid), valid)
+	}
+	for _, invalid := range []string{"", "a/#/b", "a+", "a/b#", "#a", "a/\x00"} {
+		assert.False(t, isValidTopicFilter(invalid), invalid)
+	}
+	assert.True(t, isValidTopicName("replies/temperature"))
+	assert.False(t, isValidTopicName("replies/+"))
+	assert.False(t, isValidTopicName(""))
+
+	for _, c := range []struct {
+		filter, topic string
+		matches       bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/+/c", "a/b/c", true},
+		{"a/#", "a", true},
+		{"a/#", "a/b/c", true},
+		{"a/#", "b/c", false},
+		{"#", "a/b", true},
+		{"#", "$SYS/uptime", false},
+		{"+/uptime", "$SYS/uptime", false},
+		{"$SYS/#", "$SYS/uptime", true},
+		{"a/b/c", "a/b", false},
+	} {
+		assert.Equal(t, c.matches, topicMatches(c.filter, c.topic), "%s %s", c.filter, c.topic)
+	}
+}
+
+func TestMqttTriggerValidate(t *testing.T) {
+	target := Target{Function: "f"}
+	for _, valid := range []Trigger{
+		{Name: "t", Source: MqttEvents, Topic: "sensors/+/temperature", Target: target},
+		{Name: "t", Source: MqttEvents, Topic: "sensors/#", QoS: 2, ReplyTopic: "replies", Target: target},
+	} {
+		assert.NoError(t, valid.Validate(), "%+v", valid)
+	}
+
+	for _, invalid := range []Trigger{
+		{Name: "t", Source: MqttEvents, Target: target},
+		{Name: "t", Source: MqttEvents, Topic: "sensors/#/temperature", Target: target},
+		{Name: "t", Source: MqttEvents, Topic: "sensors", QoS: 3, Target: target},
+		{Name: "t", Source: MqttEvents, Topic: "sensors", ReplyTopic: "replies/#", Target: target},
+		{Name: "t", Source: MqttEvents, Topic: "sensors", Prefix: "orders/", Target: target},
+		{Name: "t", Namespace: "+", Source: MqttEvents, Topic: "sensors", Target: target},
+		{Name: "t", Namespace: "$SYS", Source: MqttEvents, Topic: "sensors", Target: target},
+		{Name: "t", Source: EtcdEvents, Topic: "sensors", Target: target},
+		{Name: "t", Source: FileEvents, Directory: "uploads", QoS: 1, Target: target},
+	} {
+		assert.Error(t, invalid.Validate(), "%+v", invalid)
+	}
+}
+
+func TestMqttMessageParams(t *testing.T) {
+	params := mqttMessageParams("sensors/1", []byte(`{"Temperature": 21.5, "Unit": "C"}`))
+	assert.Equal(t, map[string]interface{}{"Temperature": 21.5, "Unit": "C", "Topic": "sensors/1"}, params)
+
+	params = mqttMessageParams("sensors/1", []byte(`{"Topic": "kitchen"}`))
+	assert.Equal(t, "kitchen", params["Topic"], "the fields of the payload are not overwritten")
+
+	params = mqttMessageParams("sensors/1", []byte("21.5"))
+	assert.Equal(t, map[string]interface{}{"Payload": "21.5", "Topic": "sensors/1"}, params)
+
+	params = mqttMessageParams("sensors/1", []byte("null"))
+	assert.Equal(t, "null", params["Payload"])
+
+	params = mqttMessageParams("sensors/1", []byte{0xff, 0x00, 0xfe})
+	assert.Equal(t, "/wD+", params["Payload"])
+	assert.Equal(t, true, params["IsBase64Encoded"])
+}
+
+func TestReplyPayload(t *testing.T) {
+	payload, err := replyPayload(Target{Function: "f"}, []byte(`{"Success": true, "Result": "{\"Sum\": 3}"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Sum": 3}`, string(payload))
+
+	_, err = replyPayload(Target{Function: "f"}, []byte(`{"Success": false, "Result": ""}`))
+	assert.Error(t, err)
+
+	payload, err = replyPayload(Target{Workflow: "w"}, []byte(`{"Success": true, "Result": {"Sum": 3}}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Sum": 3}`, string(payload))
+}
+
+// startBroker starts an in-process MQTT broker, listening on the given address
+func startBroker(t *testing.T, address string) (*server.Server, string) {
+	broker := server.New(&server.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	assert.NoError(t, broker.AddHook(new(auth.AllowHook), nil))
+	listener := listeners.NewTCP(listeners.Config{ID: "tcp", Address: address})
+	if !assert.NoError(t, broker.AddListener(listener)) {
+		t.FailNow()
+	}
+	go func() {
+		_ = broker.Serve()
+	}()
+	return broker, listener.Address()
+}
+
+func TestMqttSession(t *testing.T) {
+	broker, address := startBroker(t, "127.0.0.1:0")
+	defer func() {
+		_ = broker.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := mqtt.NewClientOptions().
+		AddBroker("tcp://" + address).
+		SetClientID("serverledge-test").
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(time.Second)
+	session := newMqttSession(ctx, opts)
+	defer session.close()
+	messages := make(chan mqtt.Message, 100)
+	session.deliver = func(tr *Trigger, msg mqtt.Message) {
+		assert.Equal(t, "t", tr.Name)
+		messages <- msg
+	}
+
+	triggerCtx, stopTrigger := context.WithCancel(ctx)
+	tr := &Trigger{Name: "t", Namespace: "ns", Source: MqttEvents, Topic: "sensors/+/temperature", QoS: 1}
+	stopped := make(chan struct{})
+	go func() {
+		session.subscribe(triggerCtx, tr)
+		close(stopped)
+	}()
+
+	// messages are published until the subscription is in place
+	received := func(broker *server.Server, topic string) mqtt.Message {
+		timeout := time.After(10 * time.Second)
+		for {
+			assert.NoError(t, broker.Publish(topic, []byte("21.5"), false, 1))
+			select {
+			case msg := <-messages:
+				return msg
+			case <-time.After(100 * time.Millisecond):
+			case <-timeout:
+				t.Fatalf("no message received on %s", topic)
+			}
+		}
+	}
+	msg := received(broker, "ns/sensors/kitchen/temperature")
+	assert.Equal(t, "ns/sensors/kitchen/temperature", msg.Topic(), "topics are relative to the namespace")
+	assert.Equal(t, "21.5", string(msg.Payload()))
+	assert.Equal(t, byte(1), msg.Qos())
+
+	// the session reconnects and subscribes again when the broker is restarted
+	assert.NoError(t, broker.Close())
+	broker, _ = startBroker(t, address)
+	msg = received(broker, "ns/sensors/bedroom/temperature")
+	assert.Equal(t, "ns/sensors/bedroom/temperature", msg.Topic())
+
+	// the messages of other namespaces are not delivered
+	assert.NoError(t, broker.Publish("other/sensors/kitchen/temperature", []byte("22"), false, 1))
+	assert.NoError(t, broker.Publish("sensors/kitchen/temperature", []byte("22"), false, 1))
+	time.Sleep(200 * time.Millisecond)
+	for len(messages) > 0 {
+		assert.Equal(t, "ns/sensors/bedroom/temperature", (<-messages).Topic())
+	}
+
+	// messages are not delivered after the trigger is stopped
+	stopTrigger()
+	<-stopped
+	time.Sleep(200 * time.Millisecond)
+	for len(messages) > 0 {
+		<-messages
+	}
+	assert.NoError(t, broker.Publish("ns/sensors/kitchen/temperature", []byte("22"), false, 1))
+	time.Sleep(200 * time.Millisecond)
+	assert.Empty(t, messages)
+}
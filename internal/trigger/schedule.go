@@ -0,0 +1,252 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Policies for the occurrences of a schedule missed while no node could fire them (e.g., because the leader of the
+// area was down), i.e., the ones that are late by more than the misfire threshold
+const (
+	MissedFireSkip = "skip" // missed occurrences are not fired (default)
+	MissedFireOnce = "once" // missed occurrences are fired once, all together
+	MissedFireAll  = "all"  // each missed occurrence is fired (up to maxCatchUp)
+)
+
+// maxCatchUp is the max number of missed occurrences fired at once with the MissedFireAll policy
+const maxCatchUp = 100
+
+const schedulesEtcdDir = "/schedule/"
+const scheduleStatesEtcdDir = "/schedulestate/"
+const scheduleHistoryEtcdDir = "/schedulehistory/"
+
+var UnknownScheduleErr = errors.New("unknown schedule")
+
+// Schedule periodically invokes a function or a workflow, according to a cron expression. Schedules are saved in
+// Etcd and fired by the leader of their area.
+type Schedule struct {
+	Name             string
+	Namespace        string
+	Cron             string // cron expression, e.g. "*/5 * * * *" (see ParseCron)
+	Timezone         string `json:",omitempty"` // IANA time zone of the expression (default: UTC)
+	MissedFirePolicy string `json:",omitempty"` // MissedFireSkip (default), MissedFireOnce or MissedFireAll
+	Area             string // area whose leader fires the schedule (default: area of the node that created it)
+	Target
+	APIKey  string `json:",omitempty"` // key of the creator, forwarded if the invocations are offloaded
+	Created time.Time
+}
+
+// Firing is a record of the history of a schedule
+type Firing struct {
+	ScheduledAt time.Time // the occurrence of the cron expression
+	FiredAt     time.Time
+	ReqId       string `json:",omitempty"` // ID of the asynchronous request, to poll its result
+	Node        string
+	Missed      int    `json:",omitempty"` // missed occurrences that have not been fired (according to the MissedFirePolicy)
+	Error       string `json:",omitempty"` // why the invocation could not be submitted
+}
+
+// scheduleState is updated by the leader every time a schedule is fired
+type scheduleState struct {
+	LastFire time.Time // the last occurrence fired or skipped
+}
+
+func getScheduleEtcdKey(namespace string, name string) string {
+	return fmt.Sprintf("%s%s/%s", schedulesEtcdDir, namespace, name)
+}
+
+func getScheduleStateEtcdKey(namespace string, name string) string {
+	return fmt.Sprintf("%s%s/%s", scheduleStatesEtcdDir, namespace, name)
+}
+
+func getScheduleHistoryEtcdDir(namespace string, name string) string {
+	return fmt.Sprintf("%s%s/%s/", scheduleHistoryEtcdDir, namespace, name)
+}
+
+// QualifiedName returns the name that identifies the schedule across namespaces
+func (s *Schedule) QualifiedName() string {
+	return function.QualifiedName(s.Namespace, s.Name)
+}
+
+// location returns the time zone of the cron expression
+func (s *Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Validate checks the definition of the schedule (but not the existence of its target)
+func (s *Schedule) Validate() error {
+	if !function.IsValidName(s.Name) {
+		return fmt.Errorf("invalid schedule name: %s", s.Name)
+	}
+	if _, err := ParseCron(s.Cron); err != nil {
+		return err
+	}
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid time zone: %s", s.Timezone)
+	}
+	switch s.MissedFirePolicy {
+	case "", MissedFireSkip, MissedFireOnce, MissedFireAll:
+	default:
+		return fmt.Errorf("invalid missed fire policy: %s", s.MissedFirePolicy)
+	}
+	return s.Target.validate()
+}
+
+// Save creates or updates the schedule in Etcd. The occurrences fired so far are kept when a schedule is updated.
+func (s *Schedule) Save() error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("could not marshal schedule: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = cli.Put(ctx, getScheduleEtcdKey(s.Namespace, s.Name), string(payload))
+	if err != nil {
+		return fmt.Errorf("failed etcd Put schedule: %v", err)
+	}
+	return nil
+}
+
+// GetSchedule retrieves a schedule from Etcd. If it doesn't exist, returns false
+func GetSchedule(namespace string, name string) (*Schedule, bool) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	getResponse, err := cli.Get(ctx, getScheduleEtcdKey(namespace, name))
+	if err != nil || len(getResponse.Kvs) < 1 {
+		return nil, false
+	}
+
+	var s Schedule
+	if err = json.Unmarshal(getResponse.Kvs[0].Value, &s); err != nil {
+		return nil, false
+	}
+	return &s, true
+}
+
+// GetAllSchedules returns the schedules in the namespace, or in all the namespaces if namespace is empty
+func GetAllSchedules(namespace string) ([]Schedule, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	prefix := schedulesEtcdDir
+	if namespace != "" {
+		prefix += namespace + "/"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := cli.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var s Schedule
+		if err = json.Unmarshal(kv.Value, &s); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule deletes a schedule, along with its history
+func DeleteSchedule(namespace string, name string) error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := cli.Txn(ctx).Then(
+		clientv3.OpDelete(getScheduleEtcdKey(namespace, name)),
+		clientv3.OpDelete(getScheduleStateEtcdKey(namespace, name)),
+		clientv3.OpDelete(getScheduleHistoryEtcdDir(namespace, name), clientv3.WithPrefix()),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed Delete: %v", err)
+	}
+	if resp.Responses[0].GetResponseDeleteRange().Deleted != 1 {
+		return UnknownScheduleErr
+	}
+	return nil
+}
+
+// GetHistory returns the latest firings of a schedule, from the oldest one
+func GetHistory(namespace string, name string) ([]Firing, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := cli.Get(ctx, getScheduleHistoryEtcdDir(namespace, name), clientv3.WithPrefix(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Firing, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var f Firing
+		if err = json.Unmarshal(kv.Value, &f); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		}
+		history = append(history, f)
+	}
+	return history, nil
+}
+
+// dueOccurrences returns the occurrences of the expression after last and until now that have to be fired according
+// to the policy, and the number of the other ones, which are missed (along with the latest of them). Occurrences are
+// late, and subject to the policy, if now is after them by more than threshold.
+func dueOccurrences(expr *CronExpr, last time.Time, now time.Time, policy string, threshold time.Duration) (due []time.Time, missed int, lastMissed time.Time) {
+	var late []time.Time
+	for t := expr.Next(last); !t.IsZero() && !t.After(now); t = expr.Next(t) {
+		if now.Sub(t) <= threshold {
+			due = append(due, t)
+			continue
+		}
+		late = append(late, t)
+		if len(late) > maxCatchUp {
+			// only the latest ones might be fired
+			late = late[1:]
+			missed++
+		}
+	}
+
+	if len(late) == 0 {
+		return due, missed, lastMissed
+	}
+	switch policy {
+	case MissedFireAll:
+		return append(late, due...), missed, lastMissed
+	case MissedFireOnce:
+		if len(late) > 1 {
+			lastMissed = late[len(late)-2]
+		}
+		return append([]time.Time{late[len(late)-1]}, due...), missed + len(late) - 1, lastMissed
+	default:
+		return due, missed + len(late), late[len(late)-1]
+	}
+}
@@ -0,0 +1,60 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleValidate(t *testing.T) {
+	valid := Schedule{Name: "s", Cron: "* * * * *", Target: Target{Function: "f"}}
+	assert.NoError(t, valid.Validate())
+
+	for _, s := range []Schedule{
+		{Name: "", Cron: "* * * * *", Target: Target{Function: "f"}},
+		{Name: "s", Cron: "* * *", Target: Target{Function: "f"}},
+		{Name: "s", Cron: "* * * * *"},
+		{Name: "s", Cron: "* * * * *", Target: Target{Function: "f", Workflow: "w"}},
+		{Name: "s", Cron: "* * * * *", Target: Target{Function: "f"}, Timezone: "Nowhere/Unknown"},
+		{Name: "s", Cron: "* * * * *", Target: Target{Function: "f"}, MissedFirePolicy: "sometimes"},
+	} {
+		assert.Error(t, s.Validate(), "%+v", s)
+	}
+}
+
+func TestDueOccurrences(t *testing.T) {
+	expr, err := ParseCron("*/10 * * * *")
+	assert.NoError(t, err)
+	threshold := time.Minute
+
+	due, missed, _ := dueOccurrences(expr, date("2024-03-10 10:00"), date("2024-03-10 10:05"), MissedFireSkip, threshold)
+	assert.Empty(t, due)
+	assert.Zero(t, missed)
+
+	due, missed, _ = dueOccurrences(expr, date("2024-03-10 10:00"), date("2024-03-10 10:10"), MissedFireSkip, threshold)
+	assert.Equal(t, []time.Time{date("2024-03-10 10:10")}, due, "on time")
+	assert.Zero(t, missed)
+
+	// the node was down from 10:05 to 10:41
+	last, now := date("2024-03-10 10:05"), date("2024-03-10 10:40").Add(30*time.Second)
+	due, missed, lastMissed := dueOccurrences(expr, last, now, MissedFireSkip, threshold)
+	assert.Equal(t, []time.Time{date("2024-03-10 10:40")}, due, "late by less than the threshold")
+	assert.Equal(t, 3, missed)
+	assert.Equal(t, date("2024-03-10 10:30"), lastMissed)
+
+	due, missed, lastMissed = dueOccurrences(expr, last, now, MissedFireOnce, threshold)
+	assert.Equal(t, []time.Time{date("2024-03-10 10:30"), date("2024-03-10 10:40")}, due)
+	assert.Equal(t, 2, missed)
+	assert.Equal(t, date("2024-03-10 10:20"), lastMissed)
+
+	due, missed, _ = dueOccurrences(expr, last, now, MissedFireAll, threshold)
+	assert.Len(t, due, 4)
+	assert.Zero(t, missed)
+
+	// catching up is bounded
+	due, missed, _ = dueOccurrences(expr, last, last.Add(30*24*time.Hour), MissedFireAll, threshold)
+	assert.Len(t, due, maxCatchUp)
+	assert.Equal(t, date("2024-04-09 10:00"), due[len(due)-1])
+	assert.Equal(t, 30*24*6-maxCatchUp, missed)
+}
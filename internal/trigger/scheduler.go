@@ -0,0 +1,182 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/node"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// StartScheduleService fires the schedules of the local area, if this node is elected as their leader. The other
+// nodes of the area take over when the leader goes down.
+func StartScheduleService() {
+	if !config.GetBool(config.TRIGGER_SCHEDULE_ENABLED, true) {
+		log.Printf("Schedules are disabled on this node\n")
+		return
+	}
+	interval := time.Duration(config.GetInt(config.TRIGGER_SCHEDULE_INTERVAL, 1)) * time.Second
+
+	go lead(context.Background(), "schedule", func(ctx context.Context, isLeader clientv3.Cmp) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fireDueSchedules(isLeader)
+			}
+		}
+	})
+}
+
+// fireDueSchedules fires the occurrences of the schedules of the local area that are due
+func fireDueSchedules(isLeader clientv3.Cmp) {
+	schedules, err := GetAllSchedules("")
+	if err != nil {
+		log.Printf("Could not retrieve schedules: %v\n", err)
+		return
+	}
+	states, err := getScheduleStates()
+	if err != nil {
+		log.Printf("Could not retrieve schedules: %v\n", err)
+		return
+	}
+
+	for _, s := range schedules {
+		if s.Area != node.LocalNode.Area {
+			continue
+		}
+		last := states[getScheduleStateEtcdKey(s.Namespace, s.Name)].LastFire
+		if last.IsZero() {
+			last = s.Created
+		}
+		if err = s.fireDue(last, time.Now(), isLeader); err != nil {
+			log.Printf("Could not fire schedule %s: %v\n", s.QualifiedName(), err)
+		}
+	}
+}
+
+func getScheduleStates() (map[string]scheduleState, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := cli.Get(ctx, scheduleStatesEtcdDir, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]scheduleState, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var state scheduleState
+		if err = json.Unmarshal(kv.Value, &state); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		}
+		states[string(kv.Key)] = state
+	}
+	return states, nil
+}
+
+// fireDue fires the occurrences of the schedule after last that are due at now. The firings are saved in the history
+// of the schedule before submitting the invocations, only if this node is still the leader, so that each occurrence
+// is fired at most once.
+func (s *Schedule) fireDue(last time.Time, now time.Time, isLeader clientv3.Cmp) error {
+	expr, err := ParseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+	loc, err := s.location()
+	if err != nil {
+		return err
+	}
+	threshold := time.Duration(config.GetInt(config.TRIGGER_SCHEDULE_MISFIRE_THRESHOLD, 60)) * time.Second
+	due, missed, lastMissed := dueOccurrences(expr, last.In(loc), now.In(loc), s.MissedFirePolicy, threshold)
+	if len(due) == 0 && missed == 0 {
+		return nil
+	}
+
+	submit, targetErr := s.invoker(s.Namespace, s.APIKey)
+	firings := make([]Firing, 0, len(due)+1)
+	for _, t := range due {
+		firing := Firing{ScheduledAt: t, FiredAt: now, ReqId: fmt.Sprintf("%s-%d", s.Name, t.Unix()), Node: node.LocalNode.Key}
+		if targetErr != nil {
+			firing.Error = targetErr.Error()
+		}
+		firings = append(firings, firing)
+	}
+	if missed > 0 {
+		if len(firings) > 0 {
+			firings[0].Missed = missed
+		} else {
+			firings = append(firings, Firing{ScheduledAt: lastMissed, FiredAt: now, Node: node.LocalNode.Key, Missed: missed})
+		}
+		log.Printf("Schedule %s missed %d occurrences\n", s.QualifiedName(), missed)
+	}
+
+	if err = s.saveFirings(firings, now, isLeader); err != nil {
+		return err
+	}
+	if targetErr != nil {
+		return targetErr
+	}
+	for _, firing := range firings {
+		if firing.ReqId != "" {
+			log.Printf("Schedule %s fired: %s\n", s.QualifiedName(), firing.ReqId)
+			submit(firing.ReqId, nil)
+		}
+	}
+	return nil
+}
+
+// saveFirings updates the state of the schedule and appends the firings to its history, if this node is the leader.
+// The oldest firings are deleted from the history when it exceeds the configured size.
+func (s *Schedule) saveFirings(firings []Firing, lastFire time.Time, isLeader clientv3.Cmp) error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	state, err := json.Marshal(scheduleState{LastFire: lastFire})
+	if err != nil {
+		return fmt.Errorf("could not marshal schedule state: %v", err)
+	}
+	historyDir := getScheduleHistoryEtcdDir(s.Namespace, s.Name)
+	ops := []clientv3.Op{clientv3.OpPut(getScheduleStateEtcdKey(s.Namespace, s.Name), string(state))}
+	for _, firing := range firings {
+		payload, err := json.Marshal(firing)
+		if err != nil {
+			return fmt.Errorf("could not marshal firing: %v", err)
+		}
+		ops = append(ops, clientv3.OpPut(fmt.Sprintf("%s%020d", historyDir, firing.ScheduledAt.UnixNano()), string(payload)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := cli.Txn(ctx).If(isLeader).Then(ops...).Commit()
+	if err != nil {
+		return err
+	} else if !resp.Succeeded {
+		return fmt.Errorf("not the leader anymore")
+	}
+
+	historySize := int64(config.GetInt(config.TRIGGER_SCHEDULE_HISTORY_SIZE, 100))
+	history, err := cli.Get(ctx, historyDir, clientv3.WithPrefix(), clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return err
+	}
+	for i := int64(0); i < history.Count-historySize; i++ {
+		if _, err = cli.Delete(ctx, string(history.Kvs[i].Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/async"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/internal/scheduling"
+	"github.com/serverledge-faas/serverledge/internal/workflow"
+)
+
+// Target is the function or the workflow invoked by a schedule or a trigger
+type Target struct {
+	Function string                 `json:",omitempty"` // function to invoke, optionally followed by "@<version>" or "@<alias>"
+	Workflow string                 `json:",omitempty"` // workflow to invoke (either Function or Workflow must be set)
+	Params   map[string]interface{} `json:",omitempty"`
+	function.AsyncOptions
+}
+
+func (t *Target) validate() error {
+	if (t.Function == "") == (t.Workflow == "") {
+		return fmt.Errorf("either a function or a workflow must be invoked")
+	}
+	return async.Validate(t.AsyncOptions)
+}
+
+// Exists returns true if the function or the workflow exists in the namespace
+func (t *Target) Exists(namespace string) bool {
+	if t.Function != "" {
+		_, ok := function.Resolve(function.QualifiedName(namespace, t.Function))
+		return ok
+	}
+	_, ok := workflow.Get(function.QualifiedName(namespace, t.Workflow))
+	return ok
+}
+
+// invoker returns a function that submits an asynchronous invocation of the target in the namespace, with the given
+// request ID. The parameters of the invocation are added to the ones of the target. It returns an error if the
+// target does not exist.
+func (t *Target) invoker(namespace string, apiKey string) (func(reqId string, params map[string]interface{}), error) {
+	withParams := func(params map[string]interface{}) map[string]interface{} {
+		merged := maps.Clone(t.Params)
+		if merged == nil && params != nil {
+			merged = make(map[string]interface{}, len(params))
+		}
+		maps.Copy(merged, params)
+		return merged
+	}
+
+	if t.Function != "" {
+		fun, ok := function.Resolve(function.QualifiedName(namespace, t.Function))
+		if !ok {
+			return nil, fmt.Errorf("unknown function: %s", t.Function)
+		}
+		return func(reqId string, params map[string]interface{}) {
+			r := &function.Request{
+				Fun:             fun,
+				Params:          withParams(params),
+				Arrival:         time.Now(),
+				CanDoOffloading: true,
+				Async:           true,
+				APIKey:          apiKey,
+				AsyncOptions:    t.AsyncOptions,
+			}
+			r.Ctx = context.WithValue(context.Background(), "ReqId", reqId)
+			go scheduling.SubmitAsyncRequest(r)
+		}, nil
+	}
+
+	wflow, ok := workflow.Get(function.QualifiedName(namespace, t.Workflow))
+	if !ok {
+		return nil, fmt.Errorf("unknown workflow: %s", t.Workflow)
+	}
+	return func(reqId string, params map[string]interface{}) {
+		r := workflow.NewRequest(reqId, wflow, withParams(params), 0)
+		r.Async = true
+		r.APIKey = apiKey
+		r.AsyncOptions = t.AsyncOptions
+		go workflow.InvokeAsync(r)
+	}, nil
+}
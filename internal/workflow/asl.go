@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/serverledge-faas/serverledge/internal/asl"
 	"github.com/serverledge-faas/serverledge/internal/function"
@@ -10,10 +11,19 @@ import (
 // FromASL parses a AWS State Language specification file and returns a Workflow with the corresponding Serverledge Workflow
 // The name of the workflow should not be the file name by default, to avoid problems when adding the same workflow multiple times.
 func FromASL(name string, aslSrc []byte) (*Workflow, error) {
+	return FromASLInNamespace(function.DefaultNamespace, name, aslSrc)
+}
+
+// FromASLInNamespace is like FromASL, but the workflow belongs to the given namespace, where the Resources of the Task
+// states are looked up.
+func FromASLInNamespace(namespace string, name string, aslSrc []byte) (*Workflow, error) {
 	stateMachine, err := asl.ParseFrom(name, aslSrc)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse the ASL file: %v", err)
 	}
+	if err = qualifyResources(stateMachine, namespace); err != nil {
+		return nil, err
+	}
 
 	nextStateName := stateMachine.StartAt
 	nextState := stateMachine.States[nextStateName]
@@ -24,10 +34,39 @@ func FromASL(name string, aslSrc []byte) (*Workflow, error) {
 	}
 
 	workflow.Name = name
+	workflow.Namespace = namespace
 
 	return workflow, nil
 }
 
+// qualifyResources replaces the function names in the Task states (also within Parallel and Map states) with
+// their qualified names in the namespace. Functions of other namespaces cannot be used.
+func qualifyResources(sm *asl.StateMachine, namespace string) error {
+	for _, state := range sm.States {
+		switch s := state.(type) {
+		case *asl.TaskState:
+			funcNamespace, funcName := function.SplitQualifiedName(s.Resource)
+			if strings.Contains(s.Resource, "/") && funcNamespace != namespace {
+				return fmt.Errorf("function %s does not belong to namespace %s", s.Resource, namespace)
+			}
+			s.Resource = function.QualifiedName(namespace, funcName)
+		case *asl.ParallelState:
+			for _, branch := range s.Branches {
+				if err := qualifyResources(branch, namespace); err != nil {
+					return err
+				}
+			}
+		case *asl.MapState:
+			if s.ItemProcessor != nil {
+				if err := qualifyResources(s.ItemProcessor, namespace); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func buildingLoop(sm *asl.StateMachine, nextState asl.State, nextStateName string) (*Workflow, error) {
 	builder := NewBuilder()
 	isTerminal := false
@@ -37,7 +76,7 @@ func buildingLoop(sm *asl.StateMachine, nextState asl.State, nextStateName strin
 		case asl.Task:
 
 			taskState := nextState.(*asl.TaskState)
-			b, err := BuildFromTaskState(builder, taskState, nextStateName)
+			b, err := BuildFromTaskState(builder, taskState, nextStateName, sm)
 			if err != nil {
 				return nil, fmt.Errorf("failed building FunctionTask from task state: %v", err)
 			}
@@ -57,7 +96,7 @@ func buildingLoop(sm *asl.StateMachine, nextState asl.State, nextStateName strin
 			mapState := nextState.(*asl.MapState)
 			b, err := BuildFromMapState(builder, mapState, nextStateName)
 			if err != nil {
-				return nil, fmt.Errorf("failed building MapNode from Map state: %v", err) // TODO: MapNode doesn't exist
+				return nil, fmt.Errorf("failed building MapTask from Map state: %v", err)
 			}
 			builder = b
 			nextState, nextStateName, isTerminal = findNextOrTerminate(mapState, sm)
@@ -66,7 +105,7 @@ func buildingLoop(sm *asl.StateMachine, nextState asl.State, nextStateName strin
 			passState := nextState.(*asl.PassState)
 			b, err := BuildFromPassState(builder, passState, nextStateName)
 			if err != nil {
-				return nil, fmt.Errorf("failed building SimplNode with function 'pass' from Pass state: %v", err)
+				return nil, fmt.Errorf("failed building PassTask from Pass state: %v", err)
 			}
 			builder = b
 			nextState, nextStateName, isTerminal = findNextOrTerminate(passState, sm)
@@ -75,7 +114,7 @@ func buildingLoop(sm *asl.StateMachine, nextState asl.State, nextStateName strin
 			waitState := nextState.(*asl.WaitState)
 			b, err := BuildFromWaitState(builder, waitState, nextStateName)
 			if err != nil {
-				return nil, fmt.Errorf("failed building FunctionTask with function 'wait' from Wait state: %v", err)
+				return nil, fmt.Errorf("failed building WaitTask from Wait state: %v", err)
 			}
 			builder = b
 			nextState, nextStateName, isTerminal = findNextOrTerminate(waitState, sm)
@@ -97,13 +136,47 @@ func buildingLoop(sm *asl.StateMachine, nextState asl.State, nextStateName strin
 	return builder.Build()
 }
 
-// BuildFromTaskState adds a FunctionTask to the previous Node. The simple node will have id as specified by the name parameter
-func BuildFromTaskState(builder *Builder, t *asl.TaskState, name string) (*Builder, error) {
+// BuildFromTaskState adds a FunctionTask to the previous Node. The simple node will have id as specified by the name parameter.
+// The states reached by the Catchers of the TaskState are added as error handlers, which end the workflow.
+func BuildFromTaskState(builder *Builder, t *asl.TaskState, name string, entireSM *asl.StateMachine) (*Builder, error) {
 	f, found := function.GetFunction(t.Resource) // Could have been used t.GetResources()[0], but it is better to avoid the array dereference
 	if !found {
 		return nil, fmt.Errorf("non existing function in workflow: %s", t.Resource)
 	}
 	builder = builder.AddFunctionTaskWithId(f, name)
+
+	for _, r := range t.Retry {
+		builder = builder.AddRetrier(Retrier{
+			ErrorEquals:     r.ErrorEquals,
+			IntervalSeconds: r.IntervalSeconds,
+			BackoffRate:     r.BackoffRate,
+			MaxAttempts:     r.MaxAttempts,
+		})
+	}
+	// catchers with the same Next share the handler
+	handlers := make(map[string]*Workflow)
+	for _, c := range t.Catch {
+		handler, built := handlers[c.Next]
+		if !built {
+			var err error
+			handler, err = buildingLoop(entireSM, entireSM.States[c.Next], c.Next)
+			if err != nil {
+				return nil, fmt.Errorf("failed building the error handler %s: %v", c.Next, err)
+			}
+			handlers[c.Next] = handler
+		}
+		builder = builder.AddCatcher(c.ErrorEquals, string(c.ResultPath), handler)
+	}
+	builder = builder.SetDataPaths(DataPaths{
+		InputPath:      string(t.InputPath),
+		Parameters:     t.Parameters.String(),
+		ResultSelector: t.ResultSelector.String(),
+		ResultPath:     string(t.ResultPath),
+		OutputPath:     string(t.OutputPath),
+	})
+	if len(builder.errors) > 0 {
+		return nil, fmt.Errorf("failed building task state %s: %v", name, builder.errors)
+	}
 	return builder, nil
 }
 
@@ -114,6 +187,10 @@ func BuildFromChoiceState(builder *Builder, c *asl.ChoiceState, name string, ent
 		return nil, err
 	}
 	branchBuilder := builder.AddChoiceNode(conds...)
+	builder.SetDataPaths(DataPaths{InputPath: string(c.InputPath), OutputPath: string(c.OutputPath)})
+	if len(builder.errors) > 0 {
+		return nil, fmt.Errorf("failed building choice state %s: %v", name, builder.errors)
+	}
 
 	// the choice state has two or more StateMachine(s) in it, one for each branch
 	i := 0
@@ -315,27 +392,95 @@ func GetBranchForChoiceFromStates(sm *asl.StateMachine, nextState string, branch
 
 // BuildFromParallelState adds a FanOutTask and a FanInTask and as many branches as defined in the ParallelState
 func BuildFromParallelState(builder *Builder, c *asl.ParallelState, name string) (*Builder, error) {
-	// TODO: implement me
+	branchBuilder := builder.AddParallelNodeWithId(name)
+
+	// each branch is a complete state machine, which is converted to a workflow and then merged
+	for i, branch := range c.Branches {
+		workflow, errBranch := buildingLoop(branch, branch.States[branch.StartAt], branch.StartAt)
+		if errBranch != nil {
+			return nil, fmt.Errorf("failed building branch %d: %v", i, errBranch)
+		}
+		branchBuilder = branchBuilder.NextBranch(workflow, nil)
+	}
+
+	builder = branchBuilder.EndParallel().SetDataPaths(DataPaths{
+		InputPath:      string(c.InputPath),
+		Parameters:     c.Parameters.String(),
+		ResultSelector: c.ResultSelector.String(),
+		ResultPath:     string(c.ResultPath),
+		OutputPath:     string(c.OutputPath),
+	})
+	if len(builder.errors) > 0 {
+		return nil, fmt.Errorf("failed building parallel state %s: %v", name, builder.errors)
+	}
 	return builder, nil
 }
 
-// BuildFromMapState is not compatible with Serverledge at the moment
+// BuildFromMapState adds a MapTask, whose Iterator is built from the ItemProcessor of the MapState
 func BuildFromMapState(builder *Builder, c *asl.MapState, name string) (*Builder, error) {
-	// TODO: implement me
-	// TODO: implement MapNode
-	panic("not compatible with serverledge currently")
-	// return builder, nil
+	if err := c.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	processor := c.ItemProcessor
+	iterator, err := buildingLoop(processor, processor.States[processor.StartAt], processor.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed building the iterator: %v", err)
+	}
+	iterator.Name = name + "_Iterator"
+
+	builder = builder.AddMapNodeWithId(iterator, string(c.ItemsPath), int(c.MaxConcurrency), name).
+		SetDataPaths(DataPaths{
+			InputPath:      string(c.InputPath),
+			ResultSelector: c.ResultSelector.String(),
+			ResultPath:     string(c.ResultPath),
+			OutputPath:     string(c.OutputPath),
+		})
+	if itemSelector := c.GetItemSelector(); !itemSelector.IsEmpty() {
+		builder = builder.SetItemSelector(itemSelector.String())
+	}
+	if len(builder.errors) > 0 {
+		return nil, fmt.Errorf("failed building map state %s: %v", name, builder.errors)
+	}
+	return builder, nil
 }
 
-// BuildFromPassState adds a FunctionTask with an identity function
+// BuildFromPassState adds a PassTask, which passes its input (or its Result) to the next task
 func BuildFromPassState(builder *Builder, p *asl.PassState, name string) (*Builder, error) {
-	// TODO: implement me
+	if err := p.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	builder = builder.AddPassNodeWithId(p.Result, name).SetDataPaths(DataPaths{
+		InputPath:  string(p.InputPath),
+		Parameters: p.Parameters.String(),
+		ResultPath: string(p.ResultPath),
+		OutputPath: string(p.OutputPath),
+	})
+	if len(builder.errors) > 0 {
+		return nil, fmt.Errorf("failed building pass state %s: %v", name, builder.errors)
+	}
 	return builder, nil
 }
 
-// BuildFromWaitState adds a Simple node with a sleep function for the specified time as described in the WaitState
+// BuildFromWaitState adds a WaitTask that suspends the workflow for the time described in the WaitState
 func BuildFromWaitState(builder *Builder, w *asl.WaitState, name string) (*Builder, error) {
-	// TODO: implement me
+	if err := w.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	waitNode := NewWaitTask()
+	waitNode.Id = TaskId(name)
+	waitNode.Seconds = w.Seconds
+	waitNode.Timestamp = w.Timestamp
+	waitNode.SecondsPath = string(w.SecondsPath)
+	waitNode.TimestampPath = string(w.TimestampPath)
+
+	builder = builder.AddWaitTask(waitNode).
+		SetDataPaths(DataPaths{InputPath: string(w.InputPath), OutputPath: string(w.OutputPath)})
+	if len(builder.errors) > 0 {
+		return nil, fmt.Errorf("failed building wait state %s: %v", name, builder.errors)
+	}
 	return builder, nil
 }
 
@@ -344,7 +489,9 @@ func BuildFromWaitState(builder *Builder, w *asl.WaitState, name string) (*Build
 func BuildFromSucceedState(builder *Builder, s *asl.SucceedState, name string) (*Workflow, error) {
 	// 'Message' will be the key in the EndTask Result field
 	// 'Execution completed successfully' will be the value in the EndTask Result field
-	return builder.AddSucceedNodeAndBuild("Execution completed successfully")
+	builder = builder.AddSucceedNode().
+		SetDataPaths(DataPaths{InputPath: string(s.InputPath), OutputPath: string(s.OutputPath)})
+	return builder.Build()
 }
 
 // BuildFromFailState adds a FailureTask and an EndTask. When executing, the EndTask Result map will have the FailureTask Error as key and the FailureTask Cause as value.
@@ -1,39 +1,40 @@
 package workflow
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"github.com/serverledge-faas/serverledge/utils"
-	clientv3 "go.etcd.io/etcd/client/v3"
 	"log"
-)
+	"time"
 
-func PublishAsyncInvocationResponse(reqId string, response InvocationResponse) {
-	etcdClient, err := utils.GetEtcdClient()
-	if err != nil {
-		log.Fatal("Client not available")
-		return
-	}
+	"github.com/serverledge-faas/serverledge/internal/async"
+	"github.com/serverledge-faas/serverledge/internal/function"
+)
 
-	ctx := context.Background()
+// PublishAsyncInvocationResponse saves the response of an asynchronous invocation in Etcd, in the namespace of the
+// workflow (so that it can be polled as the results of functions), and delivers it to the callback URL, if any
+func PublishAsyncInvocationResponse(namespace string, reqId string, options function.AsyncOptions, response InvocationResponse) {
+	async.Publish(namespace, reqId, options, response)
+}
 
-	resp, err := etcdClient.Grant(ctx, 1800)
-	if err != nil {
-		log.Fatal(err)
+// InvokeAsync executes the request and publishes its response. Suspended requests publish their response when
+// they are resumed and completed.
+func InvokeAsync(r *Request) {
+	errInvoke := r.W.Invoke(r)
+	if errInvoke != nil {
+		log.Printf("Invocation failed: %v", errInvoke)
+		PublishAsyncInvocationResponse(r.W.GetNamespace(), r.Id, r.AsyncOptions, InvocationResponse{Success: false})
 		return
 	}
-
-	key := fmt.Sprintf("async/%s", reqId) // async is for function and workflows, so we can reuse poll!!!
-	payload, err := json.Marshal(response)
-	if err != nil {
-		log.Printf("Could not marshal response: %v", err)
+	if r.Suspended {
+		// the response is published when the workflow is resumed and completed
 		return
 	}
 
-	_, err = etcdClient.Put(ctx, key, string(payload), clientv3.WithLease(resp.ID))
-	if err != nil {
-		log.Fatal(err)
-		return
-	}
+	log.Printf("Invocation succeeded. Publishing: %v", r.ExecReport)
+	r.ExecReport.ResponseTime = time.Now().Sub(r.Arrival).Seconds()
+	PublishAsyncInvocationResponse(r.W.GetNamespace(), r.Id, r.AsyncOptions, InvocationResponse{
+		Success:      true,
+		Result:       r.ExecReport.Result,
+		Reports:      r.ExecReport.Reports,
+		Retries:      r.ExecReport.Retries,
+		ResponseTime: r.ExecReport.ResponseTime,
+	})
 }
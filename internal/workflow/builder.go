@@ -1,8 +1,11 @@
 package workflow
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/serverledge-faas/serverledge/internal/asl"
 	"github.com/serverledge-faas/serverledge/internal/function"
 )
 
@@ -15,6 +18,7 @@ type Builder struct {
 	prevNode     Task
 	errors       []error
 	BranchNumber int
+	handlerTasks map[TaskId]bool // tasks added by the handlers of the catchers, which can be shared among catchers
 }
 
 func (b *Builder) appendError(err error) {
@@ -51,7 +55,7 @@ func (b *Builder) AddFunctionTaskWithId(f *function.Function, id string) *Builde
 		return b
 	}
 
-	simpleNode := NewFunctionTask(f.Name)
+	simpleNode := NewFunctionTask(f.QualifiedName())
 	if id != "" {
 		simpleNode.Id = TaskId(id)
 	}
@@ -152,6 +156,9 @@ func (c *ChoiceBranchBuilder) NextBranch(toMerge *Workflow, err1 error) *ChoiceB
 				case *ChoiceTask:
 					c.builder.workflow.add(n)
 					continue
+				case *FanOutTask:
+					c.builder.workflow.add(n)
+					continue
 				case UnaryTask:
 					c.builder.workflow.add(n)
 					nextNode, _ := toMerge.Find(typedTask.GetNext())
@@ -294,6 +301,186 @@ func (c *ChoiceBranchBuilder) ForEachBranch(dagger func() (*Workflow, error)) *C
 	return c
 }
 
+// AddMapNode connects a MapTask to the previous node. The iterator workflow is executed for each item of the
+// array found in the input at itemsPath, with at most maxConcurrency items processed at the same time (0 = no limit).
+// The itemsPath is a reference path in JSONPath format (e.g., "$.data.items"), or the key of the array.
+func (b *Builder) AddMapNode(iterator *Workflow, itemsPath string, maxConcurrency int) *Builder {
+	return b.AddMapNodeWithId(iterator, itemsPath, maxConcurrency, "")
+}
+
+// AddMapNodeWithId connects a MapTask with the specified id to the previous node
+func (b *Builder) AddMapNodeWithId(iterator *Workflow, itemsPath string, maxConcurrency int, id string) *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("AddMapNode skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
+	if iterator == nil || iterator.IsEmpty() {
+		b.appendError(fmt.Errorf("the iterator of a map node cannot be empty"))
+		return b
+	}
+	if maxConcurrency < 0 {
+		b.appendError(fmt.Errorf("invalid max concurrency for map node: %d", maxConcurrency))
+		return b
+	}
+	path, err := normalizeReferencePath(itemsPath)
+	if err != nil {
+		b.appendError(fmt.Errorf("invalid items path for map node: %v", err))
+		return b
+	}
+
+	mapNode := NewMapTask(iterator, path, maxConcurrency)
+	if id != "" {
+		mapNode.Id = TaskId(id)
+	}
+	b.workflow.add(mapNode)
+
+	switch prevTask := b.prevNode.(type) {
+	case UnaryTask:
+		err := prevTask.SetNext(mapNode)
+		if err != nil {
+			b.appendError(err)
+			return b
+		}
+	default:
+		panic("Unsupported previous task:" + prevTask.String())
+	}
+
+	b.prevNode = mapNode
+	return b
+}
+
+type ParallelBranchBuilder struct {
+	builder   *Builder
+	fanOut    *FanOutTask
+	fanIn     *FanInTask
+	completed int // counter of branches added to the parallel node
+}
+
+// AddParallelNode connects a FanOutTask to the previous node. Each branch added with NextBranch is executed in parallel,
+// until the branches are joined by a FanInTask with EndParallel
+func (b *Builder) AddParallelNode() *ParallelBranchBuilder {
+	return b.AddParallelNodeWithId("")
+}
+
+// AddParallelNodeWithId connects a FanOutTask with the specified id to the previous node. The id of the corresponding
+// FanInTask is obtained by adding the suffix "_FanIn".
+func (b *Builder) AddParallelNodeWithId(id string) *ParallelBranchBuilder {
+	fanOut := NewFanOutTask()
+	fanIn := NewFanInTask()
+	if id != "" {
+		fanOut.Id = TaskId(id)
+		fanIn.Id = TaskId(id + "_FanIn")
+	}
+	fanIn.FanOut = fanOut.Id
+	parallelBuilder := &ParallelBranchBuilder{builder: b, fanOut: fanOut, fanIn: fanIn, completed: 0}
+
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("AddParallelNode skipped, because of %d error(s) in builder\n", nErrors)
+		return parallelBuilder
+	}
+
+	b.workflow.add(fanOut)
+	b.workflow.add(fanIn)
+	switch prevTask := b.prevNode.(type) {
+	case UnaryTask:
+		err := prevTask.SetNext(fanOut)
+		if err != nil {
+			b.appendError(err)
+			return parallelBuilder
+		}
+	default:
+		panic("Unsupported previous task:" + prevTask.String())
+	}
+
+	b.prevNode = fanOut
+	return parallelBuilder
+}
+
+// NextBranch adds a branch to the parallel node.
+// Tip: use a NewBuilder() as a parameter, instead of manually creating the Workflow!
+// Internally, NextBranch replaces the StartTask of the input workflow with the FanOutTask
+// and chains the last node(s) of the workflow to the FanInTask.
+func (p *ParallelBranchBuilder) NextBranch(toMerge *Workflow, err1 error) *ParallelBranchBuilder {
+	if err1 != nil {
+		p.builder.appendError(err1)
+	}
+	nErrors := len(p.builder.errors)
+	if nErrors > 0 {
+		fmt.Printf("NextBranch skipped, because of %d error(s) in builder\n", nErrors)
+		return p
+	}
+	if toMerge.IsEmpty() {
+		p.builder.appendError(fmt.Errorf("branch %d of parallel node %s is empty", p.completed, p.fanOut.Id))
+		return p
+	}
+
+	branch := p.completed
+	p.builder.BranchNumber++
+	for _, n := range toMerge.Tasks {
+		switch typedTask := n.(type) {
+		case *StartTask:
+			continue
+		case *EndTask:
+			continue
+		case ConditionalTask:
+			// alternatives that reach the end of the branch are chained to the FanInTask instead
+			if choice, ok := typedTask.(*ChoiceTask); ok {
+				for i, alternative := range choice.AlternativeNextTasks {
+					if alternative == toMerge.End.GetId() {
+						choice.AlternativeNextTasks[i] = p.fanIn.GetId()
+						p.fanIn.BranchOf[choice.GetId()] = branch
+					}
+				}
+			}
+		case UnaryTask:
+			// chain the last node(s) of the branch to the FanInTask
+			if typedTask.GetNext() == toMerge.End.GetId() {
+				errEnd := typedTask.SetNext(p.fanIn)
+				if errEnd != nil {
+					p.builder.appendError(errEnd)
+					return p
+				}
+				p.fanIn.BranchOf[typedTask.GetId()] = branch
+			}
+		case *FanOutTask:
+			// a nested parallel node is always followed by its own FanInTask
+		default:
+			panic("Unsupported task in parallel branch:" + n.String())
+		}
+
+		if _, found := p.builder.workflow.Find(n.GetId()); found {
+			p.builder.appendError(fmt.Errorf("duplicate task %s in branch %d of parallel node %s", n.GetId(), branch, p.fanOut.Id))
+			return p
+		}
+		p.builder.workflow.add(n)
+	}
+
+	startNext, _ := toMerge.Find(toMerge.Start.GetNext())
+	p.fanOut.AddBranch(startNext)
+	p.completed++
+	p.fanIn.Branches = p.completed
+	return p
+}
+
+// EndParallel joins all the branches with the FanInTask and returns the Builder, so that
+// more tasks can be chained after the parallel node
+func (p *ParallelBranchBuilder) EndParallel() *Builder {
+	nErrors := len(p.builder.errors)
+	if nErrors > 0 {
+		fmt.Printf("EndParallel skipped, because of %d error(s) in builder\n", nErrors)
+		return p.builder
+	}
+	if p.completed == 0 {
+		p.builder.appendError(fmt.Errorf("parallel node %s has no branches", p.fanOut.Id))
+		return p.builder
+	}
+
+	p.builder.prevNode = p.fanIn
+	return p.builder
+}
+
 func (b *Builder) AddFailNodeAndBuild(errorName, errorMessage string) (*Workflow, error) {
 	nErrors := len(b.errors)
 	if nErrors > 0 {
@@ -321,6 +508,16 @@ func (b *Builder) AddSucceedNodeAndBuild(message string) (*Workflow, error) {
 	if nErrors > 0 {
 		return nil, fmt.Errorf("AddSucceedNodeAndBuild failed because of the following %d error(s) in builder\n%v", nErrors, b.errors)
 	}
+	return b.AddSucceedNode().Build()
+}
+
+// AddSucceedNode adds a SuccessTask, so that its DataPaths can be set before building the workflow
+func (b *Builder) AddSucceedNode() *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("AddSucceedNode skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
 
 	succeedNode := NewSuccessTask()
 
@@ -329,23 +526,36 @@ func (b *Builder) AddSucceedNodeAndBuild(message string) (*Workflow, error) {
 	case UnaryTask:
 		err := prevTask.SetNext(succeedNode)
 		if err != nil {
-			return nil, fmt.Errorf("failed to chain the SuccessTask: %v", err)
+			b.appendError(fmt.Errorf("failed to chain the SuccessTask: %v", err))
+			return b
 		}
 	default:
 		panic("Unsupported previous task:" + prevTask.String())
 	}
 	b.prevNode = succeedNode
-	return b.Build()
+	return b
 }
 
 func (b *Builder) AddPassNode(result string) *Builder {
+	return b.AddPassNodeWithId(result, "")
+}
+
+// AddPassNodeWithId adds a PassTask with the specified id, whose result, if not empty, is a JSON value
+func (b *Builder) AddPassNodeWithId(result string, id string) *Builder {
 	nErrors := len(b.errors)
 	if nErrors > 0 {
-		fmt.Printf("AddFunctionTask skipped, because of %d error(s) in builder\n", nErrors)
+		fmt.Printf("AddPassNode skipped, because of %d error(s) in builder\n", nErrors)
 		return b
 	}
 
 	passNode := NewPassTask(result)
+	if id != "" {
+		passNode.Id = TaskId(id)
+	}
+	if result != "" && !json.Valid([]byte(result)) {
+		b.appendError(fmt.Errorf("the result of pass task %s is not a JSON value: %s", passNode.Id, result))
+		return b
+	}
 
 	b.workflow.add(passNode)
 	switch prevTask := b.prevNode.(type) {
@@ -363,8 +573,249 @@ func (b *Builder) AddPassNode(result string) *Builder {
 	return b
 }
 
+// AddWaitNode adds a WaitTask that delays the execution of the next task by the given number of seconds
+func (b *Builder) AddWaitNode(seconds int) *Builder {
+	waitNode := NewWaitTask()
+	waitNode.Seconds = seconds
+	return b.AddWaitTask(waitNode)
+}
+
+// AddWaitTask adds a WaitTask, which must define exactly one of Seconds, Timestamp, SecondsPath and TimestampPath
+func (b *Builder) AddWaitTask(waitNode *WaitTask) *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("AddWaitTask skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
+
+	defined := 0
+	for _, isSet := range []bool{waitNode.Seconds > 0, waitNode.Timestamp != "", waitNode.SecondsPath != "", waitNode.TimestampPath != ""} {
+		if isSet {
+			defined++
+		}
+	}
+	if defined != 1 || waitNode.Seconds < 0 {
+		b.appendError(fmt.Errorf("a wait node must define exactly one positive duration or timestamp"))
+		return b
+	}
+	if waitNode.Timestamp != "" {
+		if _, err := time.Parse(time.RFC3339, waitNode.Timestamp); err != nil {
+			b.appendError(fmt.Errorf("invalid timestamp for wait node: %v", err))
+			return b
+		}
+	}
+	for _, path := range []*string{&waitNode.SecondsPath, &waitNode.TimestampPath} {
+		if *path == "" {
+			continue
+		}
+		normalized, err := normalizeReferencePath(*path)
+		if err != nil {
+			b.appendError(fmt.Errorf("invalid path for wait node: %v", err))
+			return b
+		}
+		*path = normalized
+	}
+
+	b.workflow.add(waitNode)
+	switch prevTask := b.prevNode.(type) {
+	case UnaryTask:
+		err := prevTask.SetNext(waitNode)
+		if err != nil {
+			b.appendError(err)
+			return b
+		}
+	default:
+		panic("Unsupported previous task:" + prevTask.String())
+	}
+
+	b.prevNode = waitNode
+	return b
+}
+
+// AddRetrier adds a Retrier to the last added FunctionTask. Retriers are evaluated in the order they are added.
+func (b *Builder) AddRetrier(retrier Retrier) *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("AddRetrier skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
+
+	functionTask, ok := b.prevNode.(*FunctionTask)
+	if !ok {
+		b.appendError(fmt.Errorf("a retrier can only be added to a function task"))
+		return b
+	}
+	if len(retrier.ErrorEquals) == 0 || retrier.IntervalSeconds < 0 || retrier.BackoffRate < 1.0 || retrier.MaxAttempts < 0 {
+		b.appendError(fmt.Errorf("invalid retrier for function task %s: %v", functionTask.Id, retrier))
+		return b
+	}
+
+	functionTask.Retry = append(functionTask.Retry, retrier)
+	return b
+}
+
+// AddCatcher adds a Catcher to the last added FunctionTask. When the task fails with an error listed in errorEquals,
+// the execution continues with the handler workflow, whose last node(s) are chained to the EndTask of the building
+// workflow. The error is injected in the input of the failed task at resultPath, a JSONPath (e.g., "$.error"), or
+// replaces the input if resultPath is empty. Catchers can share the tasks of their handlers: a task with the same id
+// and type of one already added by a handler is reused, while any other task with the id of an existing one is an
+// error.
+func (b *Builder) AddCatcher(errorEquals []string, resultPath string, handler *Workflow) *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("AddCatcher skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
+
+	functionTask, ok := b.prevNode.(*FunctionTask)
+	if !ok {
+		b.appendError(fmt.Errorf("a catcher can only be added to a function task"))
+		return b
+	}
+	if len(errorEquals) == 0 {
+		b.appendError(fmt.Errorf("the catcher of function task %s does not match any error", functionTask.Id))
+		return b
+	}
+	if handler == nil || handler.IsEmpty() {
+		b.appendError(fmt.Errorf("the handler of a catcher cannot be empty"))
+		return b
+	}
+	if err := (&DataPaths{ResultPath: resultPath}).Validate(); err != nil {
+		b.appendError(fmt.Errorf("invalid result path for the catcher of function task %s: %v", functionTask.Id, err))
+		return b
+	}
+
+	for id, n := range handler.Tasks {
+		existing, found := b.workflow.Tasks[id]
+		if found && existing != n && (!b.handlerTasks[id] || existing.GetType() != n.GetType()) {
+			b.appendError(fmt.Errorf("task %s of the handler of function task %s collides with another task", id, functionTask.Id))
+			return b
+		}
+	}
+
+	// adds the nodes of the handler to the building workflow, reusing those already added by other handlers
+	if b.handlerTasks == nil {
+		b.handlerTasks = make(map[TaskId]bool)
+	}
+	for _, n := range handler.Tasks {
+		if _, found := b.workflow.Tasks[n.GetId()]; found {
+			continue
+		}
+		switch typedTask := n.(type) {
+		case *StartTask:
+			continue
+		case *EndTask:
+			continue
+		case *ChoiceTask:
+			for i, alternative := range typedTask.AlternativeNextTasks {
+				if alternative == handler.End.GetId() {
+					typedTask.AlternativeNextTasks[i] = b.workflow.End.GetId()
+				}
+			}
+		case UnaryTask:
+			// chain the last node(s) of the handler to the end node of the building workflow
+			if typedTask.GetNext() == handler.End.GetId() {
+				errEnd := typedTask.SetNext(b.workflow.End)
+				if errEnd != nil {
+					b.appendError(errEnd)
+					return b
+				}
+			}
+		}
+		b.workflow.add(n)
+		b.handlerTasks[n.GetId()] = true
+	}
+
+	functionTask.Catch = append(functionTask.Catch, Catcher{
+		ErrorEquals: errorEquals,
+		ResultPath:  resultPath,
+		Next:        handler.Start.GetNext(),
+	})
+	return b
+}
+
+// SetDataPaths sets the DataPaths of the last added node. Choice, Wait and Success tasks only support InputPath and
+// OutputPath, Pass tasks do not support ResultSelector, and Map tasks select the input of the iterator with
+// SetItemSelector instead of Parameters. After EndParallel, InputPath and Parameters are applied by the FanOutTask,
+// while ResultSelector, ResultPath and OutputPath are applied to the joined results by the FanInTask.
+func (b *Builder) SetDataPaths(paths DataPaths) *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("SetDataPaths skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
+
+	if err := paths.Validate(); err != nil {
+		b.appendError(fmt.Errorf("invalid data paths for task %s: %v", b.prevNode.GetId(), err))
+		return b
+	}
+	onlyInputOutput := DataPaths{InputPath: paths.InputPath, OutputPath: paths.OutputPath}
+
+	switch task := b.prevNode.(type) {
+	case *FunctionTask:
+		task.DataPaths = paths
+	case *PassTask:
+		if paths.ResultSelector != "" {
+			b.appendError(fmt.Errorf("pass task %s does not support ResultSelector", task.Id))
+			return b
+		}
+		task.DataPaths = paths
+	case *MapTask:
+		if paths.Parameters != "" {
+			b.appendError(fmt.Errorf("map task %s does not support Parameters: use an ItemSelector", task.Id))
+			return b
+		}
+		task.DataPaths = paths
+	case *ChoiceTask, *WaitTask, *SuccessTask:
+		if paths != onlyInputOutput {
+			b.appendError(fmt.Errorf("task %s only supports InputPath and OutputPath", task.GetId()))
+			return b
+		}
+		getDataPaths(task).InputPath = paths.InputPath
+		getDataPaths(task).OutputPath = paths.OutputPath
+	case *FanInTask:
+		fanOut, ok := b.workflow.Tasks[task.FanOut].(*FanOutTask)
+		if !ok {
+			b.appendError(fmt.Errorf("fan in task %s is not joining a parallel node", task.Id))
+			return b
+		}
+		fanOut.DataPaths = DataPaths{InputPath: paths.InputPath, Parameters: paths.Parameters}
+		task.DataPaths = DataPaths{ResultSelector: paths.ResultSelector, ResultPath: paths.ResultPath, OutputPath: paths.OutputPath}
+	default:
+		b.appendError(fmt.Errorf("task %s does not support data paths", b.prevNode.GetId()))
+	}
+	return b
+}
+
+// SetItemSelector sets the payload template that builds the input of the iterator for each item of the last added
+// MapTask. The template can refer to the item and to its index through "$$.Map.Item.Value" and "$$.Map.Item.Index".
+func (b *Builder) SetItemSelector(itemSelector string) *Builder {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		fmt.Printf("SetItemSelector skipped, because of %d error(s) in builder\n", nErrors)
+		return b
+	}
+
+	mapTask, ok := b.prevNode.(*MapTask)
+	if !ok {
+		b.appendError(fmt.Errorf("an item selector can only be set on a map task"))
+		return b
+	}
+	if err := asl.NewPayloadTemplate(itemSelector).Validate(); err != nil {
+		b.appendError(fmt.Errorf("invalid item selector for map task %s: %v", mapTask.Id, err))
+		return b
+	}
+	mapTask.ItemSelector = itemSelector
+	return b
+}
+
 // Build ends the single branch with an EndTask. If there is more than one branch, it panics!
 func (b *Builder) Build() (*Workflow, error) {
+	nErrors := len(b.errors)
+	if nErrors > 0 {
+		return nil, fmt.Errorf("build failed because of the following %d error(s) in builder\n%v", nErrors, b.errors)
+	}
+
 	switch typedTask := b.prevNode.(type) {
 	case nil:
 		return &b.workflow, nil
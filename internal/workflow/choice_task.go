@@ -9,6 +9,7 @@ import (
 // ChoiceTask receives one input and produces one result to one of alternative tasks, based on condition
 type ChoiceTask struct {
 	baseTask
+	DataPaths
 	Conditions           []Condition
 	AlternativeNextTasks []TaskId
 }
@@ -0,0 +1,165 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+)
+
+// DataPaths filter and transform the input and the output of a task, as in the ASL input and output processing.
+// The effective input of the task is selected from its input by InputPath and built by the Parameters template.
+// The result of the task is transformed by the ResultSelector template and placed in the input at ResultPath.
+// Finally, the output of the task is selected by OutputPath.
+//
+// Paths are in JSONPath format (e.g., "$.data.items[0]"): an empty path selects the whole data, while asl.NullPath
+// selects nothing (an empty input or output, or, for ResultPath, the result is discarded and the input is passed on).
+// Parameters and ResultSelector are JSON payload templates (see asl.PayloadTemplate).
+type DataPaths struct {
+	InputPath      string
+	Parameters     string
+	ResultSelector string
+	ResultPath     string
+	OutputPath     string
+}
+
+// IsEmpty returns true if the data of the task is not filtered nor transformed
+func (d *DataPaths) IsEmpty() bool {
+	return *d == DataPaths{}
+}
+
+// Validate checks the paths and the templates
+func (d *DataPaths) Validate() error {
+	return asl.ValidateDataProcessing(asl.Path(d.InputPath), asl.NewPayloadTemplate(d.Parameters),
+		asl.NewPayloadTemplate(d.ResultSelector), asl.Path(d.ResultPath), asl.Path(d.OutputPath))
+}
+
+// effectiveInput applies InputPath and Parameters to the input of the task
+func (d *DataPaths) effectiveInput(input map[string]interface{}, context map[string]interface{}) (map[string]interface{}, error) {
+	selected, err := selectPath(d.InputPath, input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid InputPath: %v", err)
+	}
+	if d.Parameters != "" {
+		parameters, err := asl.NewPayloadTemplate(d.Parameters).Apply(selected, context)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Parameters: %v", err)
+		}
+		return parameters, nil
+	}
+	return toObject(selected, "InputPath")
+}
+
+// output applies ResultSelector, ResultPath and OutputPath to the result of the task. The result can be any JSON value,
+// e.g., the array of results of a Parallel or Map task.
+func (d *DataPaths) output(input map[string]interface{}, result interface{}, context map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	if d.ResultSelector != "" {
+		result, err = asl.NewPayloadTemplate(d.ResultSelector).Apply(result, context)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ResultSelector: %v", err)
+		}
+	}
+
+	var combined interface{}
+	switch d.ResultPath {
+	case string(asl.NullPath):
+		combined = input
+	default:
+		combined, err = asl.Path(d.ResultPath).Set(input, result)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ResultPath: %v", err)
+		}
+	}
+
+	selected, err := selectPath(d.OutputPath, combined)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OutputPath: %v", err)
+	}
+	if array, ok := selected.([]interface{}); ok {
+		// the output of a task is an object, so arrays are wrapped as the results of joined branches
+		return map[string]interface{}{JoinedResultsKey: array}, nil
+	}
+	return toObject(selected, "OutputPath")
+}
+
+// selectPath returns the value selected by the path in the data
+func selectPath(path string, data interface{}) (interface{}, error) {
+	if path == string(asl.NullPath) {
+		return make(map[string]interface{}), nil
+	}
+	return asl.Path(path).Evaluate(data)
+}
+
+// toObject checks that the value is a JSON object, as the input and the output of tasks
+func toObject(value interface{}, field string) (map[string]interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must select a JSON object, but selects %v", field, value)
+	}
+	return object, nil
+}
+
+// getDataPaths returns the DataPaths applied by executeTask to the input and the result of a task. The output of a
+// FanInTask is processed when the branches are joined, as it also depends on the input of the FanOutTask.
+func getDataPaths(task Task) *DataPaths {
+	switch t := task.(type) {
+	case *FunctionTask:
+		return &t.DataPaths
+	case *PassTask:
+		return &t.DataPaths
+	case *MapTask:
+		return &t.DataPaths
+	case *WaitTask:
+		return &t.DataPaths
+	case *ChoiceTask:
+		return &t.DataPaths
+	case *SuccessTask:
+		return &t.DataPaths
+	case *FanOutTask:
+		return &t.DataPaths
+	}
+	return nil
+}
+
+// getResult returns the result of a task, given its output: the array of the results of a MapTask, or the Result of
+// a PassTask, which can be any JSON value
+func getResult(task Task, output map[string]interface{}) (interface{}, error) {
+	switch t := task.(type) {
+	case *MapTask:
+		return output[JoinedResultsKey], nil
+	case *PassTask:
+		if t.Result == "" {
+			return output, nil
+		}
+		var result interface{}
+		err := json.Unmarshal([]byte(t.Result), &result)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Result of pass task %s: %v", t.Id, err)
+		}
+		return result, nil
+	}
+	return output, nil
+}
+
+// contextObject returns the ASL context object of the execution of a task, accessed by payload templates with "$$"
+func contextObject(r *Request, task Task) map[string]interface{} {
+	context := map[string]interface{}{
+		"Execution": map[string]interface{}{
+			"Id":        r.Id,
+			"Input":     r.Params,
+			"StartTime": r.Arrival.Format(time.RFC3339Nano),
+		},
+		"State": map[string]interface{}{
+			"Name":        string(task.GetId()),
+			"EnteredTime": time.Now().Format(time.RFC3339Nano),
+		},
+	}
+	if r.W != nil {
+		context["StateMachine"] = map[string]interface{}{
+			"Name": r.W.Name,
+		}
+	}
+	return context
+}
@@ -26,8 +26,8 @@ func (f *FailureTask) GetNext() TaskId {
 }
 
 func (f *FailureTask) SetNext(nextTask Task) error {
-	if nextTask.GetType() != End {
-		return fmt.Errorf("the Fail can only be chained to an end task")
+	if nextTask.GetType() != End && nextTask.GetType() != FanIn {
+		return fmt.Errorf("the Fail can only be chained to an end task or to the end of a parallel branch")
 	}
 	f.NextTask = nextTask.GetId()
 	return nil
@@ -0,0 +1,74 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+
+	"github.com/lithammer/shortuuid"
+)
+
+// JoinedResultsKey is the key under which a FanInTask returns the outputs of the joined branches
+const JoinedResultsKey = "Results"
+
+// FanInTask waits for the completion of all the branches started by a FanOutTask and joins their outputs
+// in an array, ordered as the branches. Its ResultSelector, ResultPath and OutputPath are applied to the array.
+type FanInTask struct {
+	baseTask
+	DataPaths
+	FanOut   TaskId         // the FanOutTask that started the branches
+	BranchOf map[TaskId]int // index of the branch each previous task belongs to
+	Branches int
+	NextTask TaskId
+}
+
+func NewFanInTask() *FanInTask {
+	return &FanInTask{
+		baseTask: baseTask{Id: TaskId(shortuuid.New()), Type: FanIn},
+		BranchOf: make(map[TaskId]int),
+	}
+}
+
+func (f *FanInTask) GetNext() TaskId {
+	return f.NextTask
+}
+
+func (f *FanInTask) SetNext(nextTask Task) error {
+	f.NextTask = nextTask.GetId()
+	return nil
+}
+
+// needsParallelInput returns true if the output depends on the input of the parallel node, i.e., of the FanOutTask
+func (f *FanInTask) needsParallelInput() bool {
+	return f.ResultPath != "" && f.ResultPath != "$"
+}
+
+// join merges the outputs of the last executed task of each branch. The array of the results is placed in the input
+// of the parallel node (parallelInput) at ResultPath.
+func (f *FanInTask) join(inputs map[TaskId]*TaskData, parallelInput map[string]interface{}, context map[string]interface{}) (*TaskData, error) {
+	results := make([]interface{}, f.Branches)
+	for prev, data := range inputs {
+		branch, ok := f.BranchOf[prev]
+		if !ok || branch < 0 || branch >= f.Branches {
+			return nil, fmt.Errorf("task %s is not the end of a branch of %s", prev, f.Id)
+		}
+		if results[branch] != nil {
+			return nil, fmt.Errorf("more than one output for branch %d of %s", branch, f.Id)
+		}
+		results[branch] = data.Data
+	}
+
+	output, err := f.DataPaths.output(parallelInput, results, context)
+	if err != nil {
+		return nil, &TaskError{Name: asl.ErrorRuntime, Cause: err.Error()}
+	}
+	return NewTaskData(output), nil
+}
+
+func (f *FanInTask) execute(input *TaskData, r *Request) (map[string]interface{}, error) {
+	return input.Data, nil
+}
+
+func (f *FanInTask) String() string {
+	return fmt.Sprintf("[FanInTask (%s) %d branches]->%v", f.Id, f.Branches, f.NextTask)
+}
@@ -0,0 +1,50 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/lithammer/shortuuid"
+)
+
+// FanOutTask starts the parallel execution of multiple branches. Each branch receives a copy of the input of the task.
+// The branches are joined again by a FanInTask.
+type FanOutTask struct {
+	baseTask
+	DataPaths
+	NextTasks []TaskId // first task of each branch, in order
+}
+
+func NewFanOutTask() *FanOutTask {
+	return &FanOutTask{
+		baseTask:  baseTask{Id: TaskId(shortuuid.New()), Type: FanOut},
+		NextTasks: make([]TaskId, 0),
+	}
+}
+
+// AddBranch connects the output of this task to the first Task of a new branch
+func (f *FanOutTask) AddBranch(nextTask Task) {
+	f.NextTasks = append(f.NextTasks, nextTask.GetId())
+}
+
+func (f *FanOutTask) GetNextTasks() []TaskId {
+	return f.NextTasks
+}
+
+// transformsInput returns true if the branches do not receive the input of the task as is
+func (f *FanOutTask) transformsInput() bool {
+	return f.InputPath != "" || f.Parameters != ""
+}
+
+// getParallelInputId returns the id under which the input of a FanOutTask is saved, when it is transformed before
+// being passed to the branches, as it is needed by the FanInTask to compute the output of the parallel node
+func getParallelInputId(fanOut TaskId) TaskId {
+	return TaskId(fmt.Sprintf("%s/input", fanOut))
+}
+
+func (f *FanOutTask) execute(input *TaskData, r *Request) (map[string]interface{}, error) {
+	return input.Data, nil
+}
+
+func (f *FanOutTask) String() string {
+	return fmt.Sprintf("[FanOutTask (%s)]->%v", f.Id, f.NextTasks)
+}
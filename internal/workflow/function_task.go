@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/serverledge-faas/serverledge/internal/node"
+	"log"
 	"time"
 
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/internal/node"
+
 	"github.com/lithammer/shortuuid"
 	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/scheduling"
@@ -15,8 +18,11 @@ import (
 // FunctionTask is a Task that receives one input and sends one result
 type FunctionTask struct {
 	baseTask
+	DataPaths
 	Func     string
 	NextTask TaskId
+	Retry    []Retrier // retry policies for failed executions, evaluated in order (optional)
+	Catch    []Catcher // error handlers, evaluated in order when the execution fails after retries (optional)
 }
 
 func NewFunctionTask(f string) *FunctionTask {
@@ -39,14 +45,25 @@ func (s *FunctionTask) execute(input *TaskData, r *Request) (map[string]interfac
 
 	err := s.CheckInput(input.Data)
 	if err != nil {
-		return nil, err
-	}
-	output, err := s.exec(r, input.Data)
-	if err != nil {
-		return nil, err
+		return nil, &TaskError{Name: asl.ErrorRuntime, Cause: err.Error()}
 	}
 
-	return output, nil
+	retries := make([]int, len(s.Retry))
+	for {
+		output, err := s.exec(r, input.Data)
+		if err == nil {
+			return output, nil
+		}
+
+		taskErr := newTaskError(err)
+		delay, retry := nextRetry(s.Retry, taskErr, retries)
+		if !retry {
+			return nil, taskErr
+		}
+		log.Printf("Task %s failed with %s: retrying in %v", s.Id, taskErr.Name, delay)
+		r.addRetries(CreateExecutionReportId(s), 1)
+		time.Sleep(delay)
+	}
 }
 
 func (s *FunctionTask) exec(compRequest *Request, params ...map[string]interface{}) (map[string]interface{}, error) {
@@ -63,8 +80,9 @@ func (s *FunctionTask) exec(compRequest *Request, params ...map[string]interface
 		RequestQoS:      compRequest.QoS,
 		CanDoOffloading: true,
 		Async:           false,
+		APIKey:          compRequest.APIKey,
 	}
-	requestId := fmt.Sprintf("%s-%s%d", s.Func, node.LocalNode.String()[len(node.LocalNode.String())-5:], r.Arrival.Nanosecond())
+	requestId := fmt.Sprintf("%s-%s%d", funct.Name, node.LocalNode.String()[len(node.LocalNode.String())-5:], r.Arrival.Nanosecond())
 	r.Ctx = context.WithValue(context.Background(), "ReqId", requestId)
 
 	report, err := scheduling.SubmitRequest(r)
@@ -102,7 +120,7 @@ func (s *FunctionTask) exec(compRequest *Request, params ...map[string]interface
 	}
 
 	// saving execution report for this function
-	compRequest.ExecReport.Reports[CreateExecutionReportId(s)] = report
+	compRequest.addReport(CreateExecutionReportId(s), report)
 
 	return outputData, nil
 }
@@ -0,0 +1,247 @@
+package workflow
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lithammer/shortuuid"
+	"github.com/serverledge-faas/serverledge/internal/asl"
+)
+
+// MapItemKey is the key under which an item that is not an object is passed to the Iterator
+const MapItemKey = "Item"
+
+// MapTask runs the Iterator workflow for each item of an input array, and joins the results in an array, ordered
+// as the items. The items are processed concurrently, up to MaxConcurrency at a time.
+type MapTask struct {
+	baseTask
+	DataPaths
+	Iterator       *Workflow
+	ItemsPath      string // reference path of the input array, in JSONPath format (e.g., "$.data.items")
+	ItemSelector   string // payload template applied to each item, with the item at "$$.Map.Item.Value" (optional)
+	MaxConcurrency int    // max number of items processed concurrently (0 = no limit)
+	NextTask       TaskId
+}
+
+func NewMapTask(iterator *Workflow, itemsPath string, maxConcurrency int) *MapTask {
+	return &MapTask{
+		baseTask:       baseTask{Id: TaskId(shortuuid.New()), Type: Map},
+		Iterator:       iterator,
+		ItemsPath:      itemsPath,
+		MaxConcurrency: maxConcurrency,
+	}
+}
+
+func (m *MapTask) GetNext() TaskId {
+	return m.NextTask
+}
+
+func (m *MapTask) SetNext(nextTask Task) error {
+	m.NextTask = nextTask.GetId()
+	return nil
+}
+
+// getItems returns the array found at ItemsPath. If ItemsPath is not set, the input must contain exactly one array.
+func (m *MapTask) getItems(input map[string]interface{}) ([]interface{}, error) {
+	if m.ItemsPath == "" {
+		var items []interface{}
+		found := 0
+		for _, value := range input {
+			if array, ok := toArray(value); ok {
+				items = array
+				found++
+			}
+		}
+		if found != 1 {
+			return nil, fmt.Errorf("ItemsPath is not set and the input contains %d arrays", found)
+		}
+		return items, nil
+	}
+
+	value, err := lookupPath(input, m.ItemsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := toArray(value)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", m.ItemsPath)
+	}
+	return items, nil
+}
+
+func toArray(value interface{}) ([]interface{}, bool) {
+	if array, ok := value.([]interface{}); ok {
+		return array, true
+	}
+	// typed slices are found when the input is not decoded from JSON
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	array := make([]interface{}, v.Len())
+	for i := range array {
+		array[i] = v.Index(i).Interface()
+	}
+	return array, true
+}
+
+// itemInput returns the input of the Iterator for an item. The ItemSelector, if set, is applied to the input of
+// the task, with the item and its index in the context object.
+func (m *MapTask) itemInput(r *Request, input map[string]interface{}, index int, item interface{}) (map[string]interface{}, error) {
+	if m.ItemSelector != "" {
+		context := contextObject(r, m)
+		context["Map"] = map[string]interface{}{
+			"Item": map[string]interface{}{"Index": index, "Value": item},
+		}
+		return asl.NewPayloadTemplate(m.ItemSelector).Apply(input, context)
+	}
+	if object, ok := item.(map[string]interface{}); ok {
+		return maps.Clone(object), nil
+	}
+	return map[string]interface{}{MapItemKey: item}, nil
+}
+
+// processItem runs the Iterator on a single item, as a separate workflow request
+func (m *MapTask) processItem(r *Request, input map[string]interface{}, index int, item interface{}) (map[string]interface{}, error) {
+	params, err := m.itemInput(r, input, index, item)
+	if err != nil {
+		return nil, fmt.Errorf("item %d: invalid ItemSelector: %v", index, err)
+	}
+
+	// each item gets its own copy of the Iterator, as the workflow is not safe for concurrent use
+	iterator := *m.Iterator
+	itemRequest := NewRequest(fmt.Sprintf("%s/%s/%d", r.Id, m.Id, index), &iterator, params, 0)
+	itemRequest.QoS = r.QoS
+	// the Iterator is not registered on its own, so it cannot be resumed on other nodes
+	itemRequest.CanDoOffloading = false
+
+	err = iterator.Invoke(itemRequest)
+	if err != nil {
+		return nil, fmt.Errorf("item %d: %v", index, err)
+	}
+	if itemRequest.Suspended {
+		return nil, fmt.Errorf("item %d: the Iterator cannot be suspended by a WaitTask", index)
+	}
+
+	for id, report := range itemRequest.ExecReport.Reports {
+		r.addReport(fmt.Sprintf("%s_%d_%s", CreateExecutionReportId(m), index, id), report)
+	}
+	return itemRequest.ExecReport.Result, nil
+}
+
+// getItemTaskId returns the id used to save the result of an item as partial data
+func (m *MapTask) getItemTaskId(index int) TaskId {
+	return TaskId(fmt.Sprintf("%s/%d", m.Id, index))
+}
+
+// retrieveCompletedItems loads the results of the items completed by a previous (interrupted) execution of the task,
+// possibly on another node
+func (m *MapTask) retrieveCompletedItems(r *Request, results []interface{}) map[int]bool {
+	completed := make(map[int]bool)
+	saved, err := RetrieveAllPartialData(ReqId(r.Id), m.Id+"/")
+	if err != nil {
+		log.Printf("Could not retrieve completed items of %s: %v", m.Id, err)
+		return completed
+	}
+	for itemTaskId, data := range saved {
+		index, err := strconv.Atoi(strings.TrimPrefix(string(itemTaskId), string(m.Id)+"/"))
+		if err != nil || index < 0 || index >= len(results) {
+			continue
+		}
+		results[index] = data.Data
+		completed[index] = true
+	}
+	if len(completed) > 0 {
+		log.Printf("Resuming %s: %d/%d items already completed", m.Id, len(completed), len(results))
+	}
+	return completed
+}
+
+func (m *MapTask) execute(input *TaskData, r *Request) (map[string]interface{}, error) {
+	if m.Iterator == nil {
+		return nil, fmt.Errorf("map task %s has no Iterator", m.Id)
+	}
+	items, err := m.getItems(input.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrency := m.MaxConcurrency
+	if maxConcurrency <= 0 || maxConcurrency > len(items) {
+		maxConcurrency = len(items)
+	}
+
+	results := make([]interface{}, len(items))
+	errs := make([]error, len(items))
+
+	// the results of the items are saved when the workflow may be resumed on another node
+	checkpoint := r.CanDoOffloading || r.Resuming
+	completed := make(map[int]bool)
+	if checkpoint {
+		completed = m.retrieveCompletedItems(r, results)
+	}
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrency)
+
+	for i, item := range items {
+		if completed[i] {
+			continue
+		}
+		slots <- struct{}{}
+		if failed.Load() {
+			// no more items are started after a failure
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func(i int, item interface{}) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result, err := m.processItem(r, input.Data, i, item)
+			if err != nil {
+				errs[i] = err
+				failed.Store(true)
+				return
+			}
+			results[i] = result
+			if checkpoint {
+				err = NewTaskData(result).Save(ReqId(r.Id), m.getItemTaskId(i))
+				if err != nil {
+					log.Printf("Could not save the result of item %d of %s: %v", i, m.Id, err)
+				}
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("map task %s failed: %v", m.Id, err)
+		}
+	}
+
+	if checkpoint {
+		// the results of the single items are not needed anymore
+		err = DeletePartialDataWithPrefix(ReqId(r.Id), m.Id+"/")
+		if err != nil {
+			log.Printf("Could not delete the results of the items of %s: %v", m.Id, err)
+		}
+	}
+
+	output := make(map[string]interface{})
+	output[JoinedResultsKey] = results
+	return output, nil
+}
+
+func (m *MapTask) String() string {
+	return fmt.Sprintf("[MapTask (%s) over %s]->%v", m.Id, m.ItemsPath, m.NextTask)
+}
@@ -4,11 +4,13 @@ import (
 	"github.com/lithammer/shortuuid"
 )
 
+// PassTask passes its input to the next task. If Result is set, it is used as the result of the task, which is
+// placed in the input at ResultPath.
 type PassTask struct {
 	baseTask
-	Result     string
-	ResultPath string
-	NextTask   TaskId
+	DataPaths
+	Result   string // any JSON value (optional)
+	NextTask TaskId
 }
 
 func NewPassTask(result string) *PassTask {
@@ -32,6 +34,7 @@ func (p *PassTask) String() string {
 	return "[ Pass ]"
 }
 
+// execute returns the effective input, which is replaced by the Result, if set (see getResult)
 func (p *PassTask) execute(input *TaskData, r *Request) (map[string]interface{}, error) {
 	return input.Data, nil
 }
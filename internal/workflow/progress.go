@@ -16,6 +16,7 @@ type Progress struct {
 	ReqId          ReqId // requestId, used to distinguish different workflow's progresses
 	Status         map[TaskId]TaskStatus
 	ReadyToExecute []TaskId
+	WakeUp         map[TaskId]time.Time // expiration time of the WaitTasks that are ready
 }
 
 type TaskStatus string
@@ -52,6 +53,9 @@ const (
 	Succeed  TaskType = "SuccessTask"
 	Pass     TaskType = "PassTask"
 	Wait     TaskType = "WaitNode"
+	FanOut   TaskType = "FanOutTask"
+	FanIn    TaskType = "FanInTask"
+	Map      TaskType = "MapTask"
 )
 
 func TaskFromType(nodeType TaskType) Task {
@@ -70,6 +74,14 @@ func TaskFromType(nodeType TaskType) Task {
 		return &SuccessTask{}
 	case Pass:
 		return &PassTask{}
+	case FanOut:
+		return &FanOutTask{}
+	case FanIn:
+		return &FanInTask{}
+	case Map:
+		return &MapTask{}
+	case Wait:
+		return &WaitTask{}
 	default:
 		return &FunctionTask{}
 	}
@@ -93,6 +105,12 @@ func printType(t TaskType) string {
 		return "Pass"
 	case Wait:
 		return "Wait"
+	case FanOut:
+		return "FanOut"
+	case FanIn:
+		return "FanIn"
+	case Map:
+		return "Map"
 	}
 	return ""
 }
@@ -100,6 +118,7 @@ func printType(t TaskType) string {
 // Complete sets the progress status of the node with the id input to 'Completed'
 func (p *Progress) Complete(id TaskId) {
 	p.Status[id] = Executed
+	delete(p.WakeUp, id)
 
 	// TODO: check for concurrent execution and pop()
 	for i, nid := range p.ReadyToExecute {
@@ -145,6 +164,7 @@ func InitProgress(reqId ReqId, workflow *Workflow) *Progress {
 		ReqId:          reqId,
 		Status:         statusMap,
 		ReadyToExecute: make([]TaskId, 0),
+		WakeUp:         make(map[TaskId]time.Time),
 	}
 
 	p.ReadyToExecute = append(p.ReadyToExecute, workflow.Start.Id)
@@ -221,6 +241,9 @@ func RetrieveProgress(reqId ReqId) (*Progress, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal progress json: %v", err)
 	}
+	if progress.WakeUp == nil {
+		progress.WakeUp = make(map[TaskId]time.Time)
+	}
 
 	return &progress, nil
 }
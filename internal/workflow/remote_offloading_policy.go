@@ -109,7 +109,7 @@ func tupleKey(s1, s2 string) string {
 	return string(keyBytes)
 }
 
-// TODO: Update this function as soon as tasks that split/merge their inputs are implemented (e.g., Map)
+// TODO: Update this function to account for tasks that split/merge their inputs (e.g., FanInTask, Map)
 func computeOutputSize(workflow *Workflow, inputParamsSize float64) map[string]float64 {
 
 	task := workflow.Start
@@ -138,6 +138,7 @@ func computeOutputSize(workflow *Workflow, inputParamsSize float64) map[string]f
 			currentOutputSize = currentInputSize
 		case UnaryTask:
 			nextTasks = append(nextTasks, typedTask.GetNext())
+			nextTasks = append(nextTasks, getCatchTargets(task)...)
 
 			ft, ok := typedTask.(*FunctionTask)
 			if ok {
@@ -145,6 +146,9 @@ func computeOutputSize(workflow *Workflow, inputParamsSize float64) map[string]f
 			} else {
 				currentOutputSize = currentInputSize
 			}
+		case *FanOutTask:
+			nextTasks = typedTask.GetNextTasks()
+			currentOutputSize = currentInputSize
 		default:
 			currentOutputSize = currentInputSize
 		}
@@ -291,7 +295,7 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 					log.Printf("No data about exec times on %s", n)
 					params.ExecTime[tupleKey(string(tid), n)] = 0.01 // no data: just guessing
 				} else {
-					t, found := nodeTimes[f.Name]
+					t, found := nodeTimes[f.QualifiedName()]
 					if found {
 						params.ExecTime[tupleKey(string(tid), n)] = t
 					} else {
@@ -310,12 +314,12 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 
 				coldStart := false
 				if n == LOCAL {
-					warmCount, ok := localWarmStatus[f.Name]
+					warmCount, ok := localWarmStatus[f.VersionedName()]
 					if !ok || warmCount < 1 {
 						coldStart = true
 					}
 				} else {
-					warmCount, ok := nearbyServers[n].AvailableWarmContainers[f.Name]
+					warmCount, ok := nearbyServers[n].AvailableWarmContainers[f.VersionedName()]
 					if !ok || warmCount < 1 {
 						coldStart = true
 					}
@@ -324,7 +328,7 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 				if !coldStart {
 					params.InitTime[tupleKey(string(tid), n)] = 0
 				} else {
-					t, found := initTimes[f.Name]
+					t, found := initTimes[f.QualifiedName()]
 					if found {
 						params.InitTime[tupleKey(string(tid), n)] = t
 					} else {
@@ -335,7 +339,7 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 
 			if len(params.CloudNodes) > 0 {
 				// Cloud Execution Time
-				t, found := retrievedMetrics.AvgRemoteExecutionTime[f.Name]
+				t, found := retrievedMetrics.AvgRemoteExecutionTime[f.QualifiedName()]
 				if found {
 					params.ExecTime[tupleKey(string(tid), CLOUD)] = t
 				} else {
@@ -343,14 +347,14 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 				}
 
 				// Init Time
-				coldStartProb := retrievedMetrics.RemoteColdStartProbability[f.Name]
+				coldStartProb := retrievedMetrics.RemoteColdStartProbability[f.QualifiedName()]
 				if math.IsNaN(coldStartProb) || math.IsInf(coldStartProb, 1) {
 					coldStartProb = 1.0
 				} else if coldStartProb < 0.0 {
 					log.Printf("Cold start probability is negative: %f", coldStartProb)
 					coldStartProb = 0.0
 				}
-				t, found = retrievedMetrics.AvgRemoteInitTime[f.Name]
+				t, found = retrievedMetrics.AvgRemoteInitTime[f.QualifiedName()]
 				if found {
 					params.InitTime[tupleKey(string(tid), CLOUD)] = t * coldStartProb
 				} else {
@@ -408,6 +412,11 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 			nextTid := string(typedTask.GetNext())
 			entry := tupleKey(nextTid, "1.0")
 			params.Adj[string(tid)] = append(params.Adj[string(tid)], entry)
+			// error handlers are assumed to be rarely executed
+			for _, catchTarget := range getCatchTargets(task) {
+				entry = tupleKey(string(catchTarget), "0.0")
+				params.Adj[string(tid)] = append(params.Adj[string(tid)], entry)
+			}
 
 			ft, ok := typedTask.(*FunctionTask)
 			if ok {
@@ -416,6 +425,13 @@ func prepareParameters(r *Request, p *Progress) *remotePolicyParams {
 			} else {
 				params.TaskMemory[string(tid)] = float64(10)
 			}
+		case *FanOutTask:
+			// all the branches are always executed
+			for _, nextTask := range typedTask.GetNextTasks() {
+				entry := tupleKey(string(nextTask), "1.0")
+				params.Adj[string(tid)] = append(params.Adj[string(tid)], entry)
+			}
+			params.TaskMemory[string(tid)] = float64(10)
 
 		default:
 			params.TaskMemory[string(tid)] = float64(10)
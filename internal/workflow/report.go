@@ -12,7 +12,8 @@ func CreateExecutionReportId(task Task) string {
 type ExecutionReport struct {
 	Result       map[string]interface{}
 	Reports      map[string]*function.ExecutionReport
-	ResponseTime float64 // time waited by the user to get the output of the entire workflow
+	Retries      map[string]int // number of retries of the tasks that failed, if any
+	ResponseTime float64        // time waited by the user to get the output of the entire workflow
 }
 
 func (cer *ExecutionReport) String() string {
@@ -32,6 +33,10 @@ func (cer *ExecutionReport) String() string {
 		str += ",\n"
 	}
 
+	if len(cer.Retries) > 0 {
+		str += fmt.Sprintf("\n\tRetries: %v,", cer.Retries)
+	}
+
 	str += "\n\tResult: {"
 	i := 0
 	lll := len(cer.Result)
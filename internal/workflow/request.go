@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"sync"
 	"time"
 
 	"github.com/serverledge-faas/serverledge/internal/client"
@@ -21,8 +22,13 @@ type Request struct {
 	QoS             function.RequestQoS // every function should have its QoS
 	CanDoOffloading bool                // every function inherits this flag
 	Async           bool
-	Resuming        bool            // indicating whether the function is resuming from a previous (partial) execution
-	Plan            *OffloadingPlan // optional; execution plan
+	function.AsyncOptions
+	Resuming  bool            // indicating whether the function is resuming from a previous (partial) execution
+	Plan      *OffloadingPlan // optional; execution plan
+	Suspended bool            // set when the execution is suspended by a WaitTask, and will be resumed by a timer
+	APIKey    string          // key used by the client, forwarded if the request is offloaded
+
+	reportsMutex sync.Mutex // tasks of parallel branches may complete concurrently
 }
 
 func NewRequest(reqId string, workflow *Workflow, params map[string]interface{}, paramsSize uint64) *Request {
@@ -41,11 +47,30 @@ func NewRequest(reqId string, workflow *Workflow, params map[string]interface{},
 	}
 }
 
+// addReport saves the execution report of a task
+func (r *Request) addReport(id string, report *function.ExecutionReport) {
+	r.reportsMutex.Lock()
+	defer r.reportsMutex.Unlock()
+	r.ExecReport.Reports[id] = report
+}
+
+// addRetries counts the retries of a task
+func (r *Request) addRetries(id string, retries int) {
+	r.reportsMutex.Lock()
+	defer r.reportsMutex.Unlock()
+	if r.ExecReport.Retries == nil {
+		r.ExecReport.Retries = make(map[string]int)
+	}
+	r.ExecReport.Retries[id] += retries
+}
+
 type InvocationResponse struct {
 	Success      bool
 	Result       map[string]interface{}
 	Reports      map[string]*function.ExecutionReport
-	ResponseTime float64 // time waited by the user to get the output of the entire workflow (in seconds)
+	Retries      map[string]int // number of retries of the tasks that failed, if any
+	ResponseTime float64        // time waited by the user to get the output of the entire workflow (in seconds)
+	Suspended    bool           // the workflow is waiting for a timer, and its result will be published asynchronously
 }
 
 type AsyncInvocationResponse struct {
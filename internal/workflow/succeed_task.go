@@ -7,6 +7,7 @@ import (
 
 type SuccessTask struct {
 	baseTask
+	DataPaths
 	NextTask TaskId
 }
 
@@ -17,8 +18,8 @@ func NewSuccessTask() *SuccessTask {
 }
 
 func (s *SuccessTask) SetNext(nextTask Task) error {
-	if nextTask.GetType() != End {
-		return fmt.Errorf("the SuccessTask can only be chained to an end task")
+	if nextTask.GetType() != End && nextTask.GetType() != FanIn {
+		return fmt.Errorf("the SuccessTask can only be chained to an end task or to the end of a parallel branch")
 	}
 	s.NextTask = nextTask.GetId()
 	return nil
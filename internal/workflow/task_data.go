@@ -9,6 +9,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/serverledge-faas/serverledge/internal/asl"
 	"github.com/serverledge-faas/serverledge/utils"
 )
 
@@ -47,6 +48,25 @@ func NewTaskData(data map[string]interface{}) *TaskData {
 	}
 }
 
+// lookupPath returns the value found in the data at the given reference path, in JSONPath format (e.g.,
+// "$.data.items[0]"). Paths without '$' are keys, as accepted by the builder (e.g., "data.items").
+func lookupPath(data map[string]interface{}, path string) (interface{}, error) {
+	p, err := asl.NewReferencePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Evaluate(data)
+}
+
+// normalizeReferencePath converts a key to a reference path (see lookupPath) and checks it
+func normalizeReferencePath(path string) (string, error) {
+	p, err := asl.NewReferencePath(path)
+	if err == nil {
+		err = asl.ValidateReferencePath(p)
+	}
+	return string(p), err
+}
+
 func getTaskDataEtcdDir(reqId ReqId) string {
 	return fmt.Sprintf("/data/%s", reqId)
 }
@@ -105,14 +125,48 @@ func RetrievePartialData(reqId ReqId, task TaskId) (*TaskData, error) {
 
 	return nil, fmt.Errorf("failed to retrieve partialDatas for requestId: %s", key)
 }
+
+// RetrieveAllPartialData retrieves the TaskData saved for all the tasks whose id starts with prefix
+func RetrieveAllPartialData(reqId ReqId, prefix TaskId) (map[TaskId]*TaskData, error) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return nil, errors.New("failed to connect to ETCD")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key := getTaskDataEtcdKey(reqId, prefix)
+	getResponse, err := cli.Get(ctx, key, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve partialDatas with prefix: %s", key)
+	}
+
+	partialData := make(map[TaskId]*TaskData)
+	dirLen := len(getTaskDataEtcdDir(reqId)) + 1
+	for _, v := range getResponse.Kvs {
+		var data *TaskData
+		err = json.Unmarshal(v.Value, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal partialDatas json: %v", err)
+		}
+		partialData[TaskId(string(v.Key)[dirLen:])] = data
+	}
+	return partialData, nil
+}
+
 func DeleteAllTaskData(reqId ReqId) error {
+	// the key ends with a slash, to avoid deleting the data of requests whose id starts with reqId
+	return DeletePartialDataWithPrefix(reqId, "")
+}
+
+// DeletePartialDataWithPrefix deletes the TaskData saved for all the tasks whose id starts with prefix
+func DeletePartialDataWithPrefix(reqId ReqId, prefix TaskId) error {
 	cli, err := utils.GetEtcdClient()
 	if err != nil {
 		return errors.New("failed to connect to ETCD")
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	key := getTaskDataEtcdDir(reqId)
+	key := getTaskDataEtcdKey(reqId, prefix)
 	_, err = cli.Delete(ctx, key, clientv3.WithPrefix())
 	if err != nil {
 		return fmt.Errorf("failed to delete task data for requestId: %s", key)
@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/internal/container"
+	"github.com/serverledge-faas/serverledge/internal/executor"
+)
+
+// TaskError is the error of a failed task. Its Name is matched against the ErrorEquals field of Retriers and Catchers.
+type TaskError struct {
+	Name  string
+	Cause string
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Cause)
+}
+
+// newTaskError gives a name to the error of a function execution
+func newTaskError(err error) *TaskError {
+	var taskErr *TaskError
+	if errors.As(err, &taskErr) {
+		return taskErr
+	}
+	if errors.Is(err, container.ExecutionTimeoutErr) {
+		return &TaskError{Name: asl.ErrorTimeout, Cause: err.Error()}
+	}
+	var handlerErr *executor.HandlerError
+	if errors.As(err, &handlerErr) && handlerErr.Name != "" {
+		return &TaskError{Name: handlerErr.Name, Cause: err.Error()}
+	}
+	return &TaskError{Name: asl.ErrorTaskFailed, Cause: err.Error()}
+}
+
+// matches returns true if the error name appears in errorEquals, considering the wildcards States.ALL and
+// States.TaskFailed. Runtime errors are never matched.
+func (e *TaskError) matches(errorEquals []string) bool {
+	if e.Name == asl.ErrorRuntime {
+		return false
+	}
+	for _, name := range errorEquals {
+		switch name {
+		case asl.ErrorAll:
+			return true
+		case asl.ErrorTaskFailed:
+			if e.Name != asl.ErrorTimeout {
+				return true
+			}
+		default:
+			if name == e.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// output returns the error as a JSON object, as expected by the ASL specification
+func (e *TaskError) output() map[string]interface{} {
+	return map[string]interface{}{
+		"Error": e.Name,
+		"Cause": e.Cause,
+	}
+}
+
+// Retrier retries a task when it fails with an error listed in ErrorEquals, up to MaxAttempts times. The first retry
+// happens after IntervalSeconds, and the interval is multiplied by BackoffRate at each retry.
+type Retrier struct {
+	ErrorEquals     []string
+	IntervalSeconds int
+	BackoffRate     float64
+	MaxAttempts     int
+}
+
+// NewRetrier returns a Retrier with the default values of the ASL specification: 3 attempts, starting after 1 second
+// and doubling the interval at each retry
+func NewRetrier(errorEquals ...string) Retrier {
+	return Retrier{
+		ErrorEquals:     errorEquals,
+		IntervalSeconds: 1,
+		BackoffRate:     2.0,
+		MaxAttempts:     3,
+	}
+}
+
+// delay returns how long to wait before the given retry (starting from 1)
+func (r *Retrier) delay(retry int) time.Duration {
+	seconds := float64(r.IntervalSeconds) * math.Pow(r.BackoffRate, float64(retry-1))
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// nextRetry looks for the first Retrier that matches the error. It returns whether the task should be retried and
+// after how long, given the number of retries already done by each Retrier, which is updated.
+func nextRetry(retriers []Retrier, err *TaskError, retries []int) (time.Duration, bool) {
+	for i := range retriers {
+		if !err.matches(retriers[i].ErrorEquals) {
+			continue
+		}
+		// only the first matching Retrier is considered
+		if retries[i] >= retriers[i].MaxAttempts {
+			return 0, false
+		}
+		retries[i]++
+		return retriers[i].delay(retries[i]), true
+	}
+	return 0, false
+}
+
+// Catcher routes the execution to the task Next, when a task fails with an error listed in ErrorEquals (after retries).
+// The error is placed in the input of the failed task at ResultPath, or replaces it if ResultPath is empty.
+type Catcher struct {
+	ErrorEquals []string
+	ResultPath  string // reference path of the error in the input (e.g., "$.error"), or asl.NullPath to discard it
+	Next        TaskId
+}
+
+// findCatcher returns the first Catcher that matches the error, if any
+func findCatcher(catchers []Catcher, err *TaskError) (*Catcher, bool) {
+	for i := range catchers {
+		if err.matches(catchers[i].ErrorEquals) {
+			return &catchers[i], true
+		}
+	}
+	return nil, false
+}
+
+// handlerInput returns the input of the task Next, placing the error in the input of the failed task
+func (c *Catcher) handlerInput(input map[string]interface{}, err *TaskError) (map[string]interface{}, error) {
+	output, errOutput := (&DataPaths{ResultPath: c.ResultPath}).output(input, err.output(), nil)
+	if errOutput != nil {
+		return nil, fmt.Errorf("cannot place the error %s in the input: %v", err.Name, errOutput)
+	}
+	return output, nil
+}
+
+// getCatchTargets returns the tasks that handle the errors of a task, if any
+func getCatchTargets(task Task) []TaskId {
+	functionTask, ok := task.(*FunctionTask)
+	if !ok {
+		return nil
+	}
+	targets := make([]TaskId, 0, len(functionTask.Catch))
+	for _, catcher := range functionTask.Catch {
+		targets = append(targets, catcher.Next)
+	}
+	return targets
+}
+
+// getAlternatives returns the alternative next tasks of a task, of which only one is taken: the branches of a
+// ConditionalTask, or the next task and the error handlers of a task with Catchers
+func getAlternatives(task Task) []TaskId {
+	switch typedTask := task.(type) {
+	case ConditionalTask:
+		return typedTask.GetAlternatives()
+	case UnaryTask:
+		catchTargets := getCatchTargets(task)
+		if len(catchTargets) == 0 {
+			return nil
+		}
+		return append([]TaskId{typedTask.GetNext()}, catchTargets...)
+	}
+	return nil
+}
@@ -86,6 +86,10 @@ func (policy *ThresholdBasedPolicy) Evaluate(r *Request, p *Progress) (Offloadin
 			for _, tid := range typedTask.GetAlternatives() {
 				nextTasks = append(nextTasks, tid)
 			}
+		case *FanOutTask:
+			log.Printf("%v being added to offloaded group (FanOutTask)", nextTaskId)
+			offloadedTasks = append(offloadedTasks, nextTaskId)
+			nextTasks = append(nextTasks, typedTask.GetNextTasks()...)
 		default:
 			// execute locally
 		}
@@ -0,0 +1,251 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/serverledge-faas/serverledge/internal/async"
+	"github.com/serverledge-faas/serverledge/internal/config"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const timersEtcdDir = "/timer/"
+
+// workflowTimer is saved in Etcd while a workflow is suspended by a WaitTask. When it expires, the timer is claimed
+// by exactly one node, which resumes the execution of the workflow and publishes its result.
+type workflowTimer struct {
+	ReqId           string
+	Workflow        string // qualified name of the workflow
+	WakeUp          time.Time
+	Arrival         time.Time
+	QoS             function.RequestQoS
+	CanDoOffloading bool
+	Reports         map[string]*function.ExecutionReport // reports of the tasks executed before suspension
+	Retries         map[string]int
+	APIKey          string                // key used by the client, forwarded if the resumed request is offloaded
+	AsyncOptions    function.AsyncOptions // without the CallbackSecret, which is saved encrypted
+	SealedSecret    string                // CallbackSecret encrypted by async.SealSecret
+}
+
+func getTimerEtcdKey(reqId ReqId) string {
+	return timersEtcdDir + string(reqId)
+}
+
+func (t *workflowTimer) save() error {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("could not marshal timer: %v", err)
+	}
+	_, err = cli.Put(context.TODO(), getTimerEtcdKey(ReqId(t.ReqId)), string(payload))
+	if err != nil {
+		return fmt.Errorf("failed etcd Put timer: %v", err)
+	}
+	return nil
+}
+
+// suspend saves the progress of the request in Etcd and registers a timer to resume it at wakeUp
+func (wflow *Workflow) suspend(r *Request, progress *Progress, dataMap map[TaskId]*TaskData, wakeUp time.Time) error {
+	requestId := ReqId(r.Id)
+	asyncOptions := r.AsyncOptions
+	sealedSecret := ""
+	if asyncOptions.CallbackSecret != "" {
+		var err error
+		sealedSecret, err = async.SealSecret(asyncOptions.CallbackSecret)
+		if err != nil {
+			return fmt.Errorf("Could not save the callback secret: %v", err)
+		}
+		asyncOptions.CallbackSecret = ""
+	}
+
+	err := progress.Save()
+	if err != nil {
+		return fmt.Errorf("Could not save progress: %v", err)
+	}
+	err = wflow.savePartialDataForPendingTasks(requestId, progress, dataMap)
+	if err != nil {
+		return fmt.Errorf("Could not save partial data: %v", err)
+	}
+
+	timer := workflowTimer{
+		ReqId:           r.Id,
+		Workflow:        wflow.QualifiedName(),
+		WakeUp:          wakeUp,
+		Arrival:         r.Arrival,
+		QoS:             r.QoS,
+		CanDoOffloading: r.CanDoOffloading,
+		Reports:         r.ExecReport.Reports,
+		Retries:         r.ExecReport.Retries,
+		APIKey:          r.APIKey,
+		AsyncOptions:    asyncOptions,
+		SealedSecret:    sealedSecret,
+	}
+	err = timer.save()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Request %s suspended until %v", requestId, wakeUp)
+	r.Suspended = true
+
+	// the timer is fired locally, unless another node claims it first
+	time.AfterFunc(time.Until(wakeUp), func() {
+		fireTimer(requestId)
+	})
+	return nil
+}
+
+// claimTimer deletes the timer from Etcd, unless it has been modified since it was read. It returns true
+// if the timer has been deleted, so that it is fired by exactly one node.
+func claimTimer(cli *clientv3.Client, key string, modRevision int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+		Then(clientv3.OpDelete(key)).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// fireTimer resumes the request suspended by the timer, if it is expired and no other node already claimed it
+func fireTimer(reqId ReqId) {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		log.Printf("Could not fire timer of %s: %v", reqId, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	getResponse, err := cli.Get(ctx, getTimerEtcdKey(reqId))
+	cancel()
+	if err != nil {
+		log.Printf("Could not fire timer of %s: %v", reqId, err)
+		return
+	}
+	for _, kv := range getResponse.Kvs {
+		fireIfExpired(cli, string(kv.Key), kv.Value, kv.ModRevision)
+	}
+}
+
+// fireExpiredTimers resumes all the expired timers, including the ones registered by nodes that are not available
+func fireExpiredTimers() {
+	cli, err := utils.GetEtcdClient()
+	if err != nil {
+		log.Printf("Could not retrieve timers: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	getResponse, err := cli.Get(ctx, timersEtcdDir, clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		log.Printf("Could not retrieve timers: %v", err)
+		return
+	}
+	for _, kv := range getResponse.Kvs {
+		fireIfExpired(cli, string(kv.Key), kv.Value, kv.ModRevision)
+	}
+}
+
+func fireIfExpired(cli *clientv3.Client, key string, value []byte, modRevision int64) {
+	var timer workflowTimer
+	err := json.Unmarshal(value, &timer)
+	if err != nil {
+		log.Printf("Could not unmarshal timer %s: %v", key, err)
+		return
+	}
+	if time.Now().Before(timer.WakeUp) {
+		return
+	}
+
+	claimed, err := claimTimer(cli, key, modRevision)
+	if err != nil {
+		log.Printf("Could not claim timer %s: %v", key, err)
+		return
+	}
+	if claimed {
+		go timer.resume()
+	}
+}
+
+// resume continues the execution of the suspended request, and publishes its result as for asynchronous requests
+func (t *workflowTimer) resume() {
+	log.Printf("Resuming request %s after wait", t.ReqId)
+	namespace, _ := function.SplitQualifiedName(t.Workflow)
+	if t.SealedSecret != "" {
+		secret, err := async.OpenSecret(t.SealedSecret)
+		if err != nil {
+			// the result is still published, but the callback is not sent without its secret
+			log.Printf("Could not restore the callback secret of %s: %v", t.ReqId, err)
+			t.AsyncOptions.CallbackURL = ""
+		} else {
+			t.AsyncOptions.CallbackSecret = secret
+		}
+	}
+
+	wflow, found := Get(t.Workflow)
+	if !found {
+		log.Printf("Could not resume request %s: workflow %s not found", t.ReqId, t.Workflow)
+		PublishAsyncInvocationResponse(namespace, t.ReqId, t.AsyncOptions, InvocationResponse{Success: false})
+		return
+	}
+
+	r := NewRequest(t.ReqId, wflow, nil, 0)
+	r.Arrival = t.Arrival
+	r.QoS = t.QoS
+	r.CanDoOffloading = t.CanDoOffloading
+	r.APIKey = t.APIKey
+	r.AsyncOptions = t.AsyncOptions
+	r.Resuming = true
+	for id, report := range t.Reports {
+		r.ExecReport.Reports[id] = report
+	}
+	r.ExecReport.Retries = t.Retries
+
+	err := wflow.Invoke(r)
+	if err != nil {
+		log.Printf("Resumed request %s failed: %v", t.ReqId, err)
+		PublishAsyncInvocationResponse(namespace, t.ReqId, t.AsyncOptions, InvocationResponse{Success: false})
+		return
+	}
+	if r.Suspended {
+		// waiting for another WaitTask
+		return
+	}
+
+	r.ExecReport.ResponseTime = time.Now().Sub(r.Arrival).Seconds()
+	PublishAsyncInvocationResponse(namespace, t.ReqId, t.AsyncOptions, InvocationResponse{
+		Success:      true,
+		Result:       r.ExecReport.Result,
+		Reports:      r.ExecReport.Reports,
+		Retries:      r.ExecReport.Retries,
+		ResponseTime: r.ExecReport.ResponseTime,
+	})
+}
+
+// StartTimerService periodically fires the expired timers of suspended workflows. Timers are normally fired by the
+// node that registered them; the periodic scan takes over the timers of nodes that went down.
+func StartTimerService() {
+	interval := config.GetInt(config.WORKFLOW_TIMER_SCAN_INTERVAL, 5)
+	if interval <= 0 {
+		log.Printf("Periodic scan of workflow timers is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			fireExpiredTimers()
+		}
+	}()
+}
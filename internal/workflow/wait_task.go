@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lithammer/shortuuid"
+)
+
+// WaitTask delays the execution of the next task. Exactly one of Seconds, Timestamp, SecondsPath and TimestampPath
+// should be set. While waiting, the workflow is suspended: its progress is saved in Etcd and a timer resumes it
+// when the WaitTask expires, possibly on another node (see timer.go).
+type WaitTask struct {
+	baseTask
+	DataPaths
+	Seconds       int    // seconds to wait
+	Timestamp     string // time to wait until, in RFC3339 format
+	SecondsPath   string // reference path of the seconds to wait in the input, in JSONPath format (e.g., "$.delay")
+	TimestampPath string // reference path of the timestamp to wait until in the input, in JSONPath format
+	NextTask      TaskId
+}
+
+func NewWaitTask() *WaitTask {
+	return &WaitTask{
+		baseTask: baseTask{Id: TaskId(shortuuid.New()), Type: Wait},
+	}
+}
+
+func (w *WaitTask) GetNext() TaskId {
+	return w.NextTask
+}
+
+func (w *WaitTask) SetNext(nextTask Task) error {
+	w.NextTask = nextTask.GetId()
+	return nil
+}
+
+// wakeUpTime returns the time at which the task expires, if it becomes ready at the given time
+func (w *WaitTask) wakeUpTime(input map[string]interface{}, now time.Time) (time.Time, error) {
+	switch {
+	case w.Seconds > 0:
+		return now.Add(time.Duration(w.Seconds) * time.Second), nil
+	case w.Timestamp != "":
+		return time.Parse(time.RFC3339, w.Timestamp)
+	case w.SecondsPath != "":
+		value, err := lookupPath(input, w.SecondsPath)
+		if err != nil {
+			return time.Time{}, err
+		}
+		var seconds float64
+		switch v := value.(type) {
+		case float64:
+			seconds = v
+		case int:
+			seconds = float64(v)
+		case int64:
+			seconds = float64(v)
+		default:
+			return time.Time{}, fmt.Errorf("%s is not a number: %v", w.SecondsPath, value)
+		}
+		if seconds < 0 {
+			return time.Time{}, fmt.Errorf("%s is negative: %v", w.SecondsPath, seconds)
+		}
+		return now.Add(time.Duration(seconds * float64(time.Second))), nil
+	case w.TimestampPath != "":
+		value, err := lookupPath(input, w.TimestampPath)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timestamp, ok := value.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("%s is not a string: %v", w.TimestampPath, value)
+		}
+		return time.Parse(time.RFC3339, timestamp)
+	}
+	return time.Time{}, fmt.Errorf("wait task %s has no duration", w.Id)
+}
+
+// execute is called once the task has expired, and passes its input to the next task
+func (w *WaitTask) execute(input *TaskData, r *Request) (map[string]interface{}, error) {
+	return input.Data, nil
+}
+
+func (w *WaitTask) String() string {
+	var duration string
+	switch {
+	case w.Seconds > 0:
+		duration = fmt.Sprintf("%ds", w.Seconds)
+	case w.Timestamp != "":
+		duration = "until " + w.Timestamp
+	case w.SecondsPath != "":
+		duration = "seconds at " + w.SecondsPath
+	case w.TimestampPath != "":
+		duration = "until " + w.TimestampPath
+	}
+	return fmt.Sprintf("[WaitTask (%s) %s]->%v", w.Id, duration, w.NextTask)
+}
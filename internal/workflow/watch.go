@@ -0,0 +1,35 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+
+	"github.com/serverledge-faas/serverledge/internal/cache"
+	"github.com/serverledge-faas/serverledge/internal/function"
+	"github.com/serverledge-faas/serverledge/utils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchChanges keeps the local cache consistent with the workflows saved in Etcd, dropping the cached workflows
+// as soon as they are updated or deleted by any node. The watch runs in background until the context is cancelled.
+func WatchChanges(ctx context.Context) {
+	go utils.WatchPrefix(ctx, "/workflow/", func(ev *clientv3.Event) {
+		// the key is /workflow/<namespace>/<name>
+		parts := strings.SplitN(string(ev.Kv.Key), "/", 4)
+		if len(parts) != 4 {
+			return
+		}
+		qualifiedName := function.QualifiedName(parts[2], parts[3])
+		// functions are cached with the same keys
+		if cached, found := cache.GetCacheInstance().Get(qualifiedName); found {
+			if _, isWorkflow := cached.(*Workflow); isWorkflow {
+				cache.GetCacheInstance().Delete(qualifiedName)
+			}
+		}
+	}, func() {
+		cache.GetCacheInstance().DeleteMatching(func(_ string, x interface{}) bool {
+			_, isWorkflow := x.(*Workflow)
+			return isWorkflow
+		})
+	})
+}
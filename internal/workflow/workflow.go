@@ -1,13 +1,16 @@
 package workflow
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"log"
@@ -21,6 +24,7 @@ import (
 	"github.com/serverledge-faas/serverledge/utils"
 
 	"github.com/serverledge-faas/serverledge/internal/asl"
+	"github.com/serverledge-faas/serverledge/internal/auth"
 	"github.com/serverledge-faas/serverledge/internal/function"
 	"github.com/serverledge-faas/serverledge/internal/types"
 )
@@ -45,10 +49,11 @@ func CreateOffloadingPolicy() {
 
 // Workflow is a Workflow to drive the execution of the workflow
 type Workflow struct {
-	Name  string // identifier of the Workflow
-	Start *StartTask
-	Tasks map[TaskId]Task
-	End   *EndTask
+	Name      string // identifier of the Workflow
+	Namespace string // namespace (i.e., tenant) of the Workflow; function.DefaultNamespace if empty
+	Start     *StartTask
+	Tasks     map[TaskId]Task
+	End       *EndTask
 
 	prevTasks map[TaskId][]TaskId
 }
@@ -116,6 +121,9 @@ func (wflow *Workflow) computePreviousTasks() {
 			nextTasks = typedTask.GetAlternatives()
 		case UnaryTask:
 			nextTasks = append(nextTasks, typedTask.GetNext())
+			nextTasks = append(nextTasks, getCatchTargets(typedTask)...)
+		case *FanOutTask:
+			nextTasks = typedTask.GetNextTasks()
 		case *EndTask:
 			continue
 		default:
@@ -158,6 +166,9 @@ func Visit(workflow *Workflow, taskId TaskId, excludeEnd bool) []Task {
 			nextTasks = typedTask.GetAlternatives()
 		case UnaryTask:
 			nextTasks = append(nextTasks, typedTask.GetNext())
+			nextTasks = append(nextTasks, getCatchTargets(typedTask)...)
+		case *FanOutTask:
+			nextTasks = typedTask.GetNextTasks()
 		case *EndTask:
 			continue
 		default:
@@ -180,7 +191,7 @@ func Visit(workflow *Workflow, taskId TaskId, excludeEnd bool) []Task {
 }
 
 func (wflow *Workflow) IsTaskEligibleForExecution(id TaskId, p *Progress) bool {
-	for _, prev := range wflow.prevTasks[id] {
+	for _, prev := range wflow.GetPreviousTasks(id) {
 		if p.Status[prev] == Pending {
 			return false
 		}
@@ -189,78 +200,193 @@ func (wflow *Workflow) IsTaskEligibleForExecution(id TaskId, p *Progress) bool {
 	return true
 }
 
-func (wflow *Workflow) ExecuteTask(r *Request, taskToExecute TaskId, input *TaskData, progress *Progress) (*TaskData, error) {
-	var err error
-	var outputData *TaskData
+// taskOutcome is the result of the execution of a single task
+type taskOutcome struct {
+	taskId        TaskId
+	output        *TaskData
+	chosen        TaskId    // next task picked by a ConditionalTask
+	parallelInput *TaskData // input of a FanOutTask that transforms it before passing it to the branches
+	err           error
+}
+
+// executeTask runs a single task without updating the workflow progress, so that
+// tasks of parallel branches can be executed concurrently
+func (wflow *Workflow) executeTask(r *Request, taskToExecute TaskId, input *TaskData) *taskOutcome {
+	outcome := &taskOutcome{taskId: taskToExecute}
 
 	n, ok := wflow.Find(taskToExecute)
 	if !ok {
-		return nil, fmt.Errorf("failed to find task %s", n.GetId())
+		outcome.err = fmt.Errorf("failed to find task %s", taskToExecute)
+		return outcome
+	}
+
+	// the input and the output of the task are filtered and transformed by its DataPaths
+	dataPaths := getDataPaths(n)
+	effectiveInput := input
+	var context map[string]interface{}
+	if dataPaths != nil && !dataPaths.IsEmpty() {
+		context = contextObject(r, n)
+		data, err := dataPaths.effectiveInput(input.Data, context)
+		if err != nil {
+			outcome.err = &TaskError{Name: asl.ErrorRuntime, Cause: fmt.Sprintf("task %s: %v", n.GetId(), err)}
+			return outcome
+		}
+		effectiveInput = NewTaskData(data)
 	}
 
 	switch task := n.(type) {
 	case UnaryTask:
-		output, err := task.execute(input, r)
+		output, err := task.execute(effectiveInput, r)
 		if err != nil {
-			progress.Fail(n.GetId())
-			return nil, err
+			outcome.err = err
+			if functionTask, ok := task.(*FunctionTask); ok {
+				taskErr := newTaskError(err)
+				if catcher, found := findCatcher(functionTask.Catch, taskErr); found {
+					log.Printf("Task %s failed with %s: continuing with %s", task.GetId(), taskErr.Name, catcher.Next)
+					handlerInput, errCatch := catcher.handlerInput(input.Data, taskErr)
+					if errCatch != nil {
+						outcome.err = &TaskError{Name: asl.ErrorRuntime, Cause: fmt.Sprintf("task %s: %v", task.GetId(), errCatch)}
+						return outcome
+					}
+					outcome.err = nil
+					outcome.output = NewTaskData(handlerInput)
+					outcome.chosen = catcher.Next
+				}
+			}
+			return outcome
 		}
-
-		outputData = NewTaskData(output)
-		progress.Complete(task.GetId())
-
-		nextTask := task.GetNext()
-		if wflow.IsTaskEligibleForExecution(nextTask, progress) {
-			progress.ReadyToExecute = append(progress.ReadyToExecute, nextTask)
-		} else {
-			fmt.Printf("task %s complete, but %s not eligible for execution", task.GetId(), nextTask)
+		if dataPaths != nil && !dataPaths.IsEmpty() {
+			output, err = processOutput(task, dataPaths, input.Data, output, context)
+			if err != nil {
+				outcome.err = err
+				return outcome
+			}
 		}
-
+		outcome.output = NewTaskData(output)
+		outcome.chosen = task.GetNext()
 	case ConditionalTask:
-		nextTaskId, err := task.Evaluate(input, r)
+		nextTaskId, err := task.Evaluate(effectiveInput, r)
 		if err != nil {
-			progress.Fail(n.GetId())
-			return nil, err
+			outcome.err = err
+			return outcome
 		}
-
-		// we skip all tasks that will not be executed
-		toSkip := make([]Task, 0)
-		toNotSkip := Visit(wflow, nextTaskId, false)
-		for _, a := range task.GetAlternatives() {
-			if a == nextTaskId {
-				continue
-			}
-			branchTasks := Visit(wflow, a, false)
-			for _, otherTask := range branchTasks {
-				if !slices.Contains(toNotSkip, otherTask) {
-					toSkip = append(toSkip, otherTask)
-				}
+		outcome.chosen = nextTaskId
+		output := effectiveInput.Data
+		if dataPaths != nil && !dataPaths.IsEmpty() {
+			// only InputPath and OutputPath are applied
+			output, err = processOutput(n, &DataPaths{OutputPath: dataPaths.OutputPath}, input.Data, output, context)
+			if err != nil {
+				outcome.err = err
+				return outcome
 			}
 		}
-		for _, t := range toSkip {
-			progress.Skip(t.GetId())
+		outcome.output = NewTaskData(output)
+	case *FanOutTask:
+		output, err := task.execute(effectiveInput, r)
+		if err != nil {
+			outcome.err = err
+			return outcome
+		}
+		outcome.output = NewTaskData(output)
+		if task.transformsInput() {
+			outcome.parallelInput = input
+		}
+	case *EndTask:
+		outcome.output = input
+	}
+
+	return outcome
+}
+
+// processOutput applies ResultSelector, ResultPath and OutputPath to the output of a task, given its raw input
+func processOutput(task Task, dataPaths *DataPaths, input map[string]interface{}, output map[string]interface{}, context map[string]interface{}) (map[string]interface{}, error) {
+	result, err := getResult(task, output)
+	if err == nil {
+		output, err = dataPaths.output(input, result, context)
+	}
+	if err != nil {
+		return nil, &TaskError{Name: asl.ErrorRuntime, Cause: fmt.Sprintf("task %s: %v", task.GetId(), err)}
+	}
+	return output, nil
+}
+
+// skipAlternatives skips all the tasks that will not be executed, as they can only be reached through
+// alternatives other than the chosen one
+func (wflow *Workflow) skipAlternatives(alternatives []TaskId, chosen TaskId, progress *Progress) {
+	toSkip := make([]Task, 0)
+	toNotSkip := Visit(wflow, chosen, false)
+	for _, a := range alternatives {
+		if a == chosen {
+			continue
+		}
+		branchTasks := Visit(wflow, a, false)
+		for _, otherTask := range branchTasks {
+			if !slices.Contains(toNotSkip, otherTask) {
+				toSkip = append(toSkip, otherTask)
+			}
 		}
-		progress.Complete(task.GetId())
+	}
+	for _, t := range toSkip {
+		progress.Skip(t.GetId())
+	}
+}
+
+// completeTask updates the progress with the outcome of a task, marking the following tasks as ready when all their
+// previous tasks are complete
+func (wflow *Workflow) completeTask(outcome *taskOutcome, progress *Progress) error {
+	if outcome.err != nil {
+		progress.Fail(outcome.taskId)
+		return outcome.err
+	}
 
-		outputData = NewTaskData(input.Data)
-		if wflow.IsTaskEligibleForExecution(nextTaskId, progress) {
-			progress.ReadyToExecute = append(progress.ReadyToExecute, nextTaskId)
+	var nextTasks []TaskId
+	switch task := wflow.Tasks[outcome.taskId].(type) {
+	case UnaryTask:
+		if alternatives := getAlternatives(task); len(alternatives) > 0 {
+			// the next task or an error handler
+			wflow.skipAlternatives(alternatives, outcome.chosen, progress)
+			nextTasks = append(nextTasks, outcome.chosen)
+		} else {
+			nextTasks = append(nextTasks, task.GetNext())
 		}
+	case ConditionalTask:
+		nextTaskId := outcome.chosen
+		wflow.skipAlternatives(task.GetAlternatives(), nextTaskId, progress)
+		nextTasks = append(nextTasks, nextTaskId)
 
 		// Update metrics, if enabled
 		if metrics.Enabled {
 			metrics.AddBranchCount(string(task.GetId()), string(nextTaskId))
 		}
-	case *EndTask:
-		progress.Complete(task.GetId())
-		outputData = input
+	case *FanOutTask:
+		nextTasks = task.GetNextTasks()
+	}
+
+	progress.Complete(outcome.taskId)
+
+	for _, nextTask := range nextTasks {
+		if slices.Contains(progress.ReadyToExecute, nextTask) {
+			continue
+		}
+		if wflow.IsTaskEligibleForExecution(nextTask, progress) {
+			progress.ReadyToExecute = append(progress.ReadyToExecute, nextTask)
+		} else {
+			log.Printf("task %s complete, but %s not eligible for execution", outcome.taskId, nextTask)
+		}
 	}
+
+	return nil
+}
+
+// ExecuteTask executes a single task and updates the progress accordingly
+func (wflow *Workflow) ExecuteTask(r *Request, taskToExecute TaskId, input *TaskData, progress *Progress) (*TaskData, error) {
+	outcome := wflow.executeTask(r, taskToExecute, input)
+	err := wflow.completeTask(outcome, progress)
 	if err != nil {
-		progress.Fail(n.GetId())
 		return nil, err
 	}
 
-	return outputData, nil
+	return outcome.output, nil
 }
 
 // GetUniqueFunctions returns a list with the function names used in the Workflow. The returned function names are unique and in alphabetical order
@@ -270,6 +396,12 @@ func (wflow *Workflow) GetUniqueFunctions() []string {
 		switch n := task.(type) {
 		case *FunctionTask:
 			allFunctionsMap[n.Func] = nil
+		case *MapTask:
+			if n.Iterator != nil {
+				for _, fName := range n.Iterator.GetUniqueFunctions() {
+					allFunctionsMap[fName] = nil
+				}
+			}
 		default:
 			continue
 		}
@@ -285,38 +417,77 @@ func (wflow *Workflow) GetUniqueFunctions() []string {
 }
 
 func (wflow *Workflow) getEtcdKey() string {
-	return getEtcdKey(wflow.Name)
+	return getEtcdKey(wflow.GetNamespace(), wflow.Name)
+}
+
+func getEtcdKey(namespace string, workflowName string) string {
+	return fmt.Sprintf("/workflow/%s/%s", namespace, workflowName)
 }
 
-func getEtcdKey(workflowName string) string {
-	return fmt.Sprintf("/workflow/%s", workflowName)
+// GetNamespace returns the namespace of the workflow
+func (wflow *Workflow) GetNamespace() string {
+	if wflow.Namespace == "" {
+		return function.DefaultNamespace
+	}
+	return wflow.Namespace
 }
 
-// GetAllWorkflows returns the workflow names
-func GetAllWorkflows() ([]string, error) {
-	return function.GetAllWithPrefix("/workflow")
+// QualifiedName returns the name that identifies the workflow across namespaces (see function.QualifiedName)
+func (wflow *Workflow) QualifiedName() string {
+	return function.QualifiedName(wflow.GetNamespace(), wflow.Name)
 }
 
-func getFromCache(name string) (*Workflow, bool) {
+// SetNamespace moves the workflow to a namespace, qualifying the names of the functions it invokes.
+// Functions of other namespaces cannot be invoked.
+func (wflow *Workflow) SetNamespace(namespace string) error {
+	wflow.Namespace = namespace
+	for _, task := range wflow.Tasks {
+		switch t := task.(type) {
+		case *FunctionTask:
+			funcNamespace, funcName := function.SplitQualifiedName(t.Func)
+			if strings.Contains(t.Func, "/") && funcNamespace != namespace {
+				return fmt.Errorf("function %s does not belong to namespace %s", t.Func, namespace)
+			}
+			t.Func = function.QualifiedName(namespace, funcName)
+		case *MapTask:
+			if t.Iterator != nil {
+				if err := t.Iterator.SetNamespace(namespace); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// GetAllWorkflows returns the names of the workflows in a namespace
+func GetAllWorkflows(namespace string) ([]string, error) {
+	return function.GetAllWithPrefix("/workflow/" + namespace)
+}
+
+func getFromCache(qualifiedName string) (*Workflow, bool) {
 	localCache := cache.GetCacheInstance()
-	cachedObj, found := localCache.Get(name)
+	cachedObj, found := localCache.Get(qualifiedName)
 	if !found {
 		return nil, false
 	}
+	cached, ok := cachedObj.(*Workflow) // functions are cached with the same keys
+	if !ok {
+		return nil, false
+	}
 	//cache hit
 	//return a safe copy of the workflow previously obtained
-	fc := *cachedObj.(*Workflow)
+	fc := *cached
 	return &fc, true
 }
 
-func getFromEtcd(name string) (*Workflow, error) {
+func getFromEtcd(key string) (*Workflow, error) {
 	cli, err := utils.GetEtcdClient()
 	if err != nil {
 		return nil, errors.New("failed to connect to ETCD")
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	key := getEtcdKey(name)
 	getResponse, err := cli.Get(ctx, key)
 	if err != nil || len(getResponse.Kvs) < 1 {
 		return nil, fmt.Errorf("failed to retrieve value for key %s", key)
@@ -331,17 +502,21 @@ func getFromEtcd(name string) (*Workflow, error) {
 	return &f, nil
 }
 
-// Get gets the Workflow from cache or from ETCD
-func Get(name string) (*Workflow, bool) {
-	val, found := getFromCache(name)
+// Get gets the Workflow, given its qualified name (see function.QualifiedName), from cache or from ETCD
+func Get(qualifiedName string) (*Workflow, bool) {
+	namespace, name := function.SplitQualifiedName(qualifiedName)
+	key := getEtcdKey(namespace, name)
+
+	val, found := getFromCache(qualifiedName)
 	if !found {
 		// cache miss
-		f, err := getFromEtcd(name)
+		f, err := getFromEtcd(key)
 		if err != nil {
 			return nil, false
 		}
+		f.Namespace = namespace
 		//insert a new element to the cache
-		cache.GetCacheInstance().Set(name, f, cache.DefaultExp)
+		cache.GetCacheInstance().Set(qualifiedName, f, cache.DefaultExp)
 		return f, true
 	}
 
@@ -373,7 +548,7 @@ func (wflow *Workflow) Save() error {
 	}
 
 	// Add the wflow to the local cache
-	cache.GetCacheInstance().Set(wflow.Name, wflow, cache.DefaultExp)
+	cache.GetCacheInstance().Set(wflow.QualifiedName(), wflow, cache.DefaultExp)
 
 	return nil
 }
@@ -395,12 +570,17 @@ func (wflow *Workflow) initializeOrRetrieveProgress(r *Request) (*Progress, bool
 	}
 }
 
-func (wflow *Workflow) savePartialDataForReadyTasks(requestId ReqId, progress *Progress, data map[TaskId]*TaskData) error {
+// savePartialDataForPendingTasks saves on Etcd the output of the executed tasks that is still needed by some pending
+// task, i.e., a ready task or a FanInTask waiting for other parallel branches
+func (wflow *Workflow) savePartialDataForPendingTasks(requestId ReqId, progress *Progress, data map[TaskId]*TaskData) error {
 	handledTasks := make(map[TaskId]bool)
 
-	for _, task := range progress.ReadyToExecute {
-		for _, prev := range wflow.GetPreviousTasks(task) {
-			if _, found := handledTasks[prev]; found {
+	for task, prevTasks := range wflow.GetAllPreviousTasks() {
+		if progress.Status[task] != Pending {
+			continue
+		}
+		for _, prev := range prevTasks {
+			if _, found := handledTasks[prev]; found || progress.Status[prev] != Executed {
 				continue
 			}
 
@@ -416,16 +596,123 @@ func (wflow *Workflow) savePartialDataForReadyTasks(requestId ReqId, progress *P
 
 			handledTasks[prev] = true
 		}
+
+		// the input of the parallel node is needed to compute the output of a FanInTask
+		if fanIn, ok := wflow.Tasks[task].(*FanInTask); ok && fanIn.needsParallelInput() && progress.Status[fanIn.FanOut] == Executed {
+			id := wflow.getParallelInputId(fanIn)
+			if dataToSave, found := data[id]; found && !handledTasks[id] {
+				err := dataToSave.Save(requestId, id)
+				if err != nil {
+					return fmt.Errorf("Could not save partial data: %v", err)
+				}
+				handledTasks[id] = true
+			}
+		}
 	}
 
 	return nil
 }
 
-// Invoke schedules each function of the workflow and invokes them
+// isEdgeTaken returns false if prev is a ConditionalTask (or a task with Catchers) that picked an alternative other than next
+func (wflow *Workflow) isEdgeTaken(prev TaskId, next TaskId, progress *Progress) bool {
+	for _, alternative := range getAlternatives(wflow.Tasks[prev]) {
+		if alternative != next && progress.Status[alternative] != Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// prepareInput retrieves the input of a task from the output of its previous tasks.
+// Only a FanInTask can receive more than one input, which are joined together.
+func (wflow *Workflow) prepareInput(r *Request, taskId TaskId, progress *Progress, dataMap map[TaskId]*TaskData) (*TaskData, error) {
+	task := wflow.Tasks[taskId]
+	if task.GetType() == Start {
+		return NewTaskData(r.Params), nil
+	}
+
+	inputs := make(map[TaskId]*TaskData)
+	for _, previousTask := range wflow.GetPreviousTasks(taskId) {
+		if progress.Status[previousTask] != Executed || !wflow.isEdgeTaken(previousTask, taskId, progress) {
+			continue
+		}
+
+		input, found := dataMap[previousTask]
+		if !found {
+			log.Printf("Input not found in dataMap for previousTask %s", previousTask)
+			var err error
+			input, err = RetrievePartialData(ReqId(r.Id), previousTask)
+			if err != nil {
+				return nil, fmt.Errorf("Could not retrieve partial data: %v", err)
+			}
+			log.Printf("Input retrieved from etcd: %s", input)
+		}
+		inputs[previousTask] = input
+	}
+
+	if fanIn, ok := task.(*FanInTask); ok {
+		var parallelInput map[string]interface{}
+		if fanIn.needsParallelInput() {
+			data, err := wflow.retrieveParallelInput(r, fanIn, dataMap)
+			if err != nil {
+				return nil, err
+			}
+			parallelInput = data.Data
+		}
+		return fanIn.join(inputs, parallelInput, contextObject(r, fanIn))
+	}
+	if len(inputs) > 1 {
+		return nil, fmt.Errorf("task %s has %d inputs, but only a FanInTask can merge inputs", taskId, len(inputs))
+	}
+	for _, input := range inputs {
+		// the same output may be the input of multiple parallel branches, and the input may be modified
+		// during execution (e.g., when matching the function signature), so each task gets its own copy
+		return NewTaskData(maps.Clone(input.Data)), nil
+	}
+
+	log.Printf("Nil input for task: %s", taskId)
+	return nil, nil
+}
+
+// getParallelInputId returns the id of the input of the parallel node joined by the FanInTask
+func (wflow *Workflow) getParallelInputId(fanIn *FanInTask) TaskId {
+	if fanOut, ok := wflow.Tasks[fanIn.FanOut].(*FanOutTask); ok && fanOut.transformsInput() {
+		return getParallelInputId(fanIn.FanOut)
+	}
+	// the output of the FanOutTask is its input
+	return fanIn.FanOut
+}
+
+// retrieveParallelInput returns the input of the parallel node joined by the FanInTask
+func (wflow *Workflow) retrieveParallelInput(r *Request, fanIn *FanInTask, dataMap map[TaskId]*TaskData) (*TaskData, error) {
+	if fanIn.FanOut == "" {
+		return nil, fmt.Errorf("the FanOutTask of %s is unknown", fanIn.Id)
+	}
+	id := wflow.getParallelInputId(fanIn)
+	if data, found := dataMap[id]; found {
+		return data, nil
+	}
+	data, err := RetrievePartialData(ReqId(r.Id), id)
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve the input of %s: %v", fanIn.FanOut, err)
+	}
+	return data, nil
+}
+
+// waitForRunningTasks waits for the completion of the tasks that are still running, discarding their outcome
+func waitForRunningTasks(running map[TaskId]bool, outcomes <-chan *taskOutcome) {
+	for len(running) > 0 {
+		outcome := <-outcomes
+		delete(running, outcome.taskId)
+	}
+}
+
+// Invoke schedules each function of the workflow and invokes them. Tasks of parallel branches are executed concurrently.
 func (wflow *Workflow) Invoke(r *Request) error {
 
 	var err error
 	requestId := ReqId(r.Id)
+	r.Suspended = false
 
 	progress, isProgressOnEtcd, err := wflow.initializeOrRetrieveProgress(r)
 	if err != nil {
@@ -439,108 +726,131 @@ func (wflow *Workflow) Invoke(r *Request) error {
 		return fmt.Errorf("wflow resumed but no task is ready for execution: %v", requestId)
 	}
 
-	for len(progress.ReadyToExecute) > 0 {
-		decision, err := offloadingPolicy.Evaluate(r, progress)
-		if err == nil && decision.Offload {
+	// tasks currently in execution, which send their outcome through the channel
+	running := make(map[TaskId]bool)
+	outcomes := make(chan *taskOutcome, len(wflow.Tasks))
 
-			err := progress.Save()
+	for len(progress.ReadyToExecute) > 0 || len(running) > 0 {
+		// offloading is only considered when no task is being executed locally
+		if len(running) == 0 {
+			decision, err := offloadingPolicy.Evaluate(r, progress)
 			if err != nil {
-				return fmt.Errorf("Could not save progress: %v", err)
+				return fmt.Errorf("an error occurred in policy evaluation: %v", err)
 			}
-			isProgressOnEtcd = true
 
-			err = wflow.savePartialDataForReadyTasks(requestId, progress, dataMap)
-			if err != nil {
-				return fmt.Errorf("Could not save partial data: %v", err)
-			}
+			if decision.Offload {
+				err := progress.Save()
+				if err != nil {
+					return fmt.Errorf("Could not save progress: %v", err)
+				}
+				isProgressOnEtcd = true
 
-			log.Printf("Offloading request: %v", requestId)
+				err = wflow.savePartialDataForPendingTasks(requestId, progress, dataMap)
+				if err != nil {
+					return fmt.Errorf("Could not save partial data: %v", err)
+				}
 
-			err = offload(r, &decision)
-			if err != nil {
-				return err
+				log.Printf("Offloading request: %v", requestId)
+
+				err = offload(r, &decision)
+				if err != nil {
+					return err
+				}
+
+				if r.ExecReport.Result != nil || r.Suspended {
+					// Workflow execution has completed (or has been suspended) on remote node
+					return nil
+				}
+
+				progress, err = RetrieveProgress(requestId)
+				if err != nil {
+					return fmt.Errorf("Could not retrieve progress after offloading: %v", err)
+				}
+				log.Printf("Ready to execute after offloading: %v", progress.ReadyToExecute)
+				continue
 			}
+		}
 
-			if r.ExecReport.Result != nil {
-				// Workflow execution has completed on remote node
-				break
+		// start all the executable tasks
+		var nextWakeUp time.Time // earliest expiration of the WaitTasks that are not expired yet
+		for _, task := range progress.ReadyToExecute {
+			if running[task] || (r.Plan != nil && !slices.Contains(r.Plan.ToExecute, task)) {
+				continue
 			}
 
-			progress, err = RetrieveProgress(requestId)
+			input, err := wflow.prepareInput(r, task, progress, dataMap)
 			if err != nil {
-				return fmt.Errorf("Could not retrieve progress after offloading: %v", err)
-			}
-			log.Printf("Ready to execute after offloading: %v", progress.ReadyToExecute)
-		} else if err == nil {
-			// pick next executable task
-			var taskToExecute TaskId = ""
-			for _, task := range progress.ReadyToExecute {
-				if r.Plan == nil || slices.Contains(r.Plan.ToExecute, task) {
-					taskToExecute = task
-				}
-			}
-			if taskToExecute == "" {
-				break
+				waitForRunningTasks(running, outcomes)
+				return err
 			}
 
-			// Prepare input for taskToExecute
-			var input *TaskData
-			if wflow.Tasks[taskToExecute].GetType() == Start {
-				input = NewTaskData(r.Params)
-			} else {
-				var found bool
-				previousTasks := wflow.GetPreviousTasks(taskToExecute)
-				for _, previousTask := range previousTasks {
-					if progress.Status[previousTask] == Skipped {
-						continue
+			if waitTask, ok := wflow.Tasks[task].(*WaitTask); ok {
+				wakeUp, found := progress.WakeUp[task]
+				if !found {
+					var waitInput map[string]interface{}
+					waitInput, err = waitTask.effectiveInput(input.Data, contextObject(r, waitTask))
+					if err == nil {
+						wakeUp, err = waitTask.wakeUpTime(waitInput, time.Now())
 					}
-
-					if input != nil {
-						return fmt.Errorf("Merge of inputs not supported yet!")
+					if err != nil {
+						waitForRunningTasks(running, outcomes)
+						return fmt.Errorf("failed wflow execution: %v", err)
 					}
-
-					input, found = dataMap[previousTask]
-					if !found {
-						log.Printf("Input not found in dataMap for previousTask %s", previousTask)
-						input, err = RetrievePartialData(requestId, previousTask)
-						if err != nil {
-							return fmt.Errorf("Could not retrieve partial data: %v", err)
-						}
-						log.Printf("Input retrieved from etcd: %s", input)
+					progress.WakeUp[task] = wakeUp
+				}
+				if time.Now().Before(wakeUp) {
+					if nextWakeUp.IsZero() || wakeUp.Before(nextWakeUp) {
+						nextWakeUp = wakeUp
 					}
+					continue
 				}
 			}
 
-			if input == nil {
-				log.Printf("Nil input for task: %s", taskToExecute)
-			}
-			output, err := wflow.ExecuteTask(r, taskToExecute, input, progress)
-			if err != nil {
-				return fmt.Errorf("failed wflow execution: %v", err)
+			running[task] = true
+			go func(task TaskId, input *TaskData) {
+				outcomes <- wflow.executeTask(r, task, input)
+			}(task, input)
+		}
+		if len(running) == 0 {
+			if !nextWakeUp.IsZero() {
+				// nothing else to do until a WaitTask expires: the request is suspended rather than waiting here
+				return wflow.suspend(r, progress, dataMap, nextWakeUp)
 			}
+			// no ready task can be executed here
+			break
+		}
 
-			dataMap[taskToExecute] = output
+		outcome := <-outcomes
+		delete(running, outcome.taskId)
+		err = wflow.completeTask(outcome, progress)
+		if err != nil {
+			waitForRunningTasks(running, outcomes)
+			return fmt.Errorf("failed wflow execution: %v", err)
+		}
 
-			if len(progress.ReadyToExecute) == 0 && output != nil {
-				r.ExecReport.Result = output.Data
+		dataMap[outcome.taskId] = outcome.output
+		if outcome.parallelInput != nil {
+			dataMap[getParallelInputId(outcome.taskId)] = outcome.parallelInput
+		}
 
-				if isProgressOnEtcd {
-					err = DeleteProgress(requestId)
-					if err != nil {
-						log.Printf("Failed to delete progress: %v", err)
-					}
-					err = DeleteAllTaskData(requestId)
-					if err != nil {
-						log.Printf("Failed to delete task data: %v", err)
-					}
-				}
+		if outcome.taskId == wflow.End.GetId() {
+			if outcome.output != nil {
+				r.ExecReport.Result = outcome.output.Data
+			}
 
-				return nil
+			if isProgressOnEtcd {
+				err = DeleteProgress(requestId)
+				if err != nil {
+					log.Printf("Failed to delete progress: %v", err)
+				}
+				err = DeleteAllTaskData(requestId)
+				if err != nil {
+					log.Printf("Failed to delete task data: %v", err)
+				}
 			}
-		} else {
-			return fmt.Errorf("an error occurred in policy evaluation: %v", err)
-		}
 
+			return nil
+		}
 	}
 
 	if len(progress.ReadyToExecute) > 0 {
@@ -548,7 +858,7 @@ func (wflow *Workflow) Invoke(r *Request) error {
 		if err != nil {
 			return err
 		}
-		err = wflow.savePartialDataForReadyTasks(requestId, progress, dataMap)
+		err = wflow.savePartialDataForPendingTasks(requestId, progress, dataMap)
 		if err != nil {
 			return fmt.Errorf("Could not save partial data: %v", err)
 		}
@@ -580,15 +890,22 @@ func offload(r *Request, policyDecision *OffloadingDecision) error {
 		return fmt.Errorf("JSON marshaling failed: %v", err)
 	}
 
-	// Send invocation request
+	// Send invocation request, forwarding the identity of the client
 	url := fmt.Sprintf("%s/workflow/resume/%s", policyDecision.RemoteHost, r.W.Name)
-	resp, err := utils.PostJson(url, invocationBody)
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(invocationBody))
 	if err != nil {
 		return fmt.Errorf("HTTP request for offloading failed: %v", err)
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	auth.SetHeaders(httpReq, r.W.GetNamespace(), r.APIKey)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("HTTP request for offloading failed: %v", err)
+	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed offloaded workflow: %v", err)
+		return fmt.Errorf("failed offloaded workflow: %v", resp.Status)
 	}
 
 	var response InvocationResponse
@@ -603,9 +920,14 @@ func offload(r *Request, policyDecision *OffloadingDecision) error {
 	}
 
 	for k, v := range response.Reports {
-		r.ExecReport.Reports[k] = v
+		r.addReport(k, v)
+	}
+	for k, v := range response.Retries {
+		r.addRetries(k, v)
 	}
 
+	r.Suspended = response.Suspended
+
 	if response.Result == nil {
 		// workflow execution is not complete after offloading
 		r.ExecReport.Result = nil
@@ -630,19 +952,19 @@ func (wflow *Workflow) Delete() error {
 	}
 
 	// Remove the function from the local cache
-	cache.GetCacheInstance().Delete(wflow.Name)
+	cache.GetCacheInstance().Delete(wflow.QualifiedName())
 
 	return nil
 }
 
 // Exists return true if the workflow exists either in etcd or in cache. If it only exists in Etcd, it saves the workflow also in caches
 func (wflow *Workflow) Exists() bool {
-	_, found := getFromCache(wflow.Name)
+	_, found := getFromCache(wflow.QualifiedName())
 	if !found {
 		// cache miss
-		f, err := getFromEtcd(wflow.Name)
+		f, err := getFromEtcd(wflow.getEtcdKey())
 		if err != nil {
-			if err.Error() == fmt.Sprintf("failed to retrieve value for key %s", getEtcdKey(wflow.Name)) {
+			if err.Error() == fmt.Sprintf("failed to retrieve value for key %s", wflow.getEtcdKey()) {
 				return false
 			} else {
 				log.Printf("ERROR: %v", err.Error())
@@ -650,7 +972,8 @@ func (wflow *Workflow) Exists() bool {
 			}
 		}
 		//insert a new element to the cache
-		cache.GetCacheInstance().Set(f.Name, f, cache.DefaultExp)
+		f.Namespace = wflow.Namespace
+		cache.GetCacheInstance().Set(wflow.QualifiedName(), f, cache.DefaultExp)
 		return true
 	}
 	return found
@@ -660,7 +983,7 @@ func (wflow *Workflow) Equals(comparer types.Comparable) bool {
 
 	workflow2 := comparer.(*Workflow)
 
-	if wflow.Name != workflow2.Name {
+	if wflow.Name != workflow2.Name || wflow.GetNamespace() != workflow2.GetNamespace() {
 		return false
 	}
 
@@ -691,6 +1014,9 @@ func (wflow *Workflow) MarshalJSON() ([]byte, error) {
 
 	// Add the field to the map
 	data["Name"] = wflow.Name
+	if wflow.Namespace != "" {
+		data["Namespace"] = wflow.Namespace
+	}
 	data["Start"] = wflow.Start
 	data["End"] = wflow.End
 	tasks := make(map[TaskId]interface{})
@@ -738,6 +1064,12 @@ func (wflow *Workflow) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("missing 'Name' field in JSON")
 	}
 
+	if rawNamespace, ok := tempMap["Namespace"]; ok {
+		if err := json.Unmarshal(rawNamespace, &wflow.Namespace); err != nil {
+			return err
+		}
+	}
+
 	// Cycle on each map entry and decode the type
 	var tempTaskMap map[string]json.RawMessage
 	if err := json.Unmarshal(tempMap["Tasks"], &tempTaskMap); err != nil {
@@ -0,0 +1,57 @@
+package serverledge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// HTTPRequest is the raw HTTP request received by a function invoked through the HTTP trigger (/http/<function>/...)
+type HTTPRequest struct {
+	Method          string
+	Path            string // the part of the path following /http/<function>
+	Query           map[string][]string
+	Headers         map[string][]string
+	Body            string
+	IsBase64Encoded bool // the body is base64-encoded binary data
+}
+
+// HTTPResponse is returned by the handlers of functions invoked through the HTTP trigger
+type HTTPResponse struct {
+	StatusCode      int
+	Headers         map[string][]string // each header can have multiple values (e.g., Set-Cookie)
+	Body            string
+	IsBase64Encoded bool // the body is base64-encoded binary data
+}
+
+// HTTPHandlerFunc is the signature of the handler of a function invoked through the HTTP trigger
+type HTTPHandlerFunc func(req *HTTPRequest) (*HTTPResponse, error)
+
+// BodyBytes returns the body of the request, decoded if necessary
+func (r *HTTPRequest) BodyBytes() ([]byte, error) {
+	if r.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
+
+// SetBinaryBody sets the body of the response to the given binary data
+func (r *HTTPResponse) SetBinaryBody(body []byte) {
+	r.Body = base64.StdEncoding.EncodeToString(body)
+	r.IsBase64Encoded = true
+}
+
+// StartHTTP is used in place of Start to execute a handler of raw HTTP requests (e.g.: serverledge.StartHTTP(myHandler))
+func StartHTTP(handler HTTPHandlerFunc) {
+	Start(func(params map[string]interface{}) (interface{}, error) {
+		// the request is received as a generic map: convert it back to an HTTPRequest
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		var req HTTPRequest
+		if err = json.Unmarshal(encoded, &req); err != nil {
+			return nil, err
+		}
+		return handler(&req)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 )
 
@@ -82,6 +83,7 @@ func Start(handler HandlerFunc) {
 		// the output of the function
 		var req struct {
 			Params       map[string]interface{} `json:"Params"`
+			HTTP         map[string]interface{} `json:"HTTP"`
 			ReturnOutput bool                   `json:"ReturnOutput"`
 		}
 
@@ -90,6 +92,10 @@ func Start(handler HandlerFunc) {
 			http.Error(w, "Invalid Request", 500)
 			return
 		}
+		// requests to the HTTP trigger pass the raw HTTP request in place of the params (see StartHTTP)
+		if req.HTTP != nil {
+			req.Params = req.HTTP
+		}
 
 		var execRes executionResult
 
@@ -131,6 +137,14 @@ func Start(handler HandlerFunc) {
 	// start the http server inside the container
 
 	port := 8080 // standard port for all containers
-	log.Printf("Go Runtime listening on :%d", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	// the address can be overridden as for the executors (e.g., by the process container factory)
+	if envPort, ok := os.LookupEnv("EXECUTOR_PORT"); ok {
+		var err error
+		if port, err = strconv.Atoi(envPort); err != nil {
+			log.Fatalf("Invalid EXECUTOR_PORT: %s", envPort)
+		}
+	}
+	host := os.Getenv("EXECUTOR_HOST")
+	log.Printf("Go Runtime listening on %s:%d", host, port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", host, port), nil))
 }
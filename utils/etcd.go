@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,3 +40,98 @@ func GetEtcdClient() (*clientv3.Client, error) {
 	etcdClient = cli
 	return cli, nil
 }
+
+// WatchPrefix watches the keys starting with prefix, calling onEvent for every change (events carry the previous
+// value of the keys). If the watch is interrupted (e.g., etcd is unreachable, or the revision has been compacted),
+// some events may be lost: onResync is called before watching again from the current revision.
+// It blocks until the context is cancelled.
+func WatchPrefix(ctx context.Context, prefix string, onEvent func(ev *clientv3.Event), onResync func()) {
+	const retryInterval = 2 * time.Second
+	resync := false
+
+	for ctx.Err() == nil {
+		cli, err := GetEtcdClient()
+		if err != nil {
+			time.Sleep(retryInterval)
+			continue
+		}
+
+		// the current revision: earlier changes are covered by the resync
+		getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		resp, err := cli.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		cancel()
+		if err != nil {
+			log.Printf("Could not watch %s: %v\n", prefix, err)
+			resync = true
+			time.Sleep(retryInterval)
+			continue
+		}
+		if resync {
+			onResync()
+			resync = false
+		}
+
+		watchCtx, cancelWatch := context.WithCancel(clientv3.WithRequireLeader(ctx))
+		watchChan := cli.Watch(watchCtx, prefix, clientv3.WithPrefix(), clientv3.WithPrevKV(),
+			clientv3.WithRev(resp.Header.Revision+1))
+		for watchResp := range watchChan {
+			if err := watchResp.Err(); err != nil {
+				log.Printf("Watch of %s interrupted: %v\n", prefix, err)
+				break
+			}
+			for _, ev := range watchResp.Events {
+				onEvent(ev)
+			}
+		}
+		cancelWatch()
+
+		if ctx.Err() == nil {
+			resync = true
+			time.Sleep(retryInterval)
+		}
+	}
+}
+
+// MoveLegacyKeys moves the keys directly under dir (e.g., "/function/<name>") to dir/subdir (e.g.,
+// "/function/<subdir>/<name>"), keeping their leases, and returns the number of moved keys. Each key is moved
+// atomically, unless it has been modified meanwhile or its new key already exists (in which case it is kept), so that
+// it is safe to run it on several nodes at the same time.
+func MoveLegacyKeys(dir string, subdir string) (int, error) {
+	cli, err := GetEtcdClient()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := cli.Get(ctx, dir, clientv3.WithPrefix())
+	if err != nil {
+		return 0, fmt.Errorf("could not list %s: %v", dir, err)
+	}
+
+	moved := 0
+	for _, kv := range resp.Kvs {
+		name := strings.TrimPrefix(string(kv.Key), dir)
+		if name == "" || strings.Contains(name, "/") {
+			continue
+		}
+		newKey := dir + subdir + "/" + name
+		var putOpts []clientv3.OpOption
+		if kv.Lease != 0 {
+			putOpts = append(putOpts, clientv3.WithLease(clientv3.LeaseID(kv.Lease)))
+		}
+		txnResp, err := cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(string(kv.Key)), "=", kv.ModRevision),
+				clientv3.Compare(clientv3.CreateRevision(newKey), "=", 0)).
+			Then(clientv3.OpPut(newKey, string(kv.Value), putOpts...), clientv3.OpDelete(string(kv.Key))).
+			Commit()
+		if err != nil {
+			return moved, fmt.Errorf("could not move %s: %v", kv.Key, err)
+		}
+		if txnResp.Succeeded {
+			moved++
+		} else {
+			log.Printf("Not moving %s: it has been modified, or %s already exists\n", kv.Key, newKey)
+		}
+	}
+	return moved, nil
+}
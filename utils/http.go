@@ -11,7 +11,20 @@ import (
 )
 
 func PostJson(url string, body []byte) (*http.Response, error) {
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	return PostJsonWithHeaders(url, body, nil)
+}
+
+// PostJsonWithHeaders is like PostJson, but sets additional headers on the request
+func PostJsonWithHeaders(url string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}